@@ -25,8 +25,12 @@ import (
 
 	"github.com/prometheus/client_golang/prometheus"
 	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
 
 	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/pkg/audit"
+	"sigs.k8s.io/external-dns/pkg/events"
 	"sigs.k8s.io/external-dns/plan"
 	"sigs.k8s.io/external-dns/provider"
 	"sigs.k8s.io/external-dns/registry"
@@ -34,61 +38,68 @@ import (
 )
 
 var (
-	registryErrorsTotal = prometheus.NewCounter(
+	registryErrorsTotal = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Namespace: "external_dns",
 			Subsystem: "registry",
 			Name:      "errors_total",
 			Help:      "Number of Registry errors.",
 		},
+		[]string{"pipeline"},
 	)
-	sourceErrorsTotal = prometheus.NewCounter(
+	sourceErrorsTotal = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Namespace: "external_dns",
 			Subsystem: "source",
 			Name:      "errors_total",
 			Help:      "Number of Source errors.",
 		},
+		[]string{"pipeline"},
 	)
-	sourceEndpointsTotal = prometheus.NewGauge(
+	sourceEndpointsTotal = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Namespace: "external_dns",
 			Subsystem: "source",
 			Name:      "endpoints_total",
 			Help:      "Number of Endpoints in all sources",
 		},
+		[]string{"pipeline"},
 	)
-	registryEndpointsTotal = prometheus.NewGauge(
+	registryEndpointsTotal = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Namespace: "external_dns",
 			Subsystem: "registry",
 			Name:      "endpoints_total",
 			Help:      "Number of Endpoints in the registry",
 		},
+		[]string{"pipeline"},
 	)
-	lastSyncTimestamp = prometheus.NewGauge(
+	lastSyncTimestamp = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Namespace: "external_dns",
 			Subsystem: "controller",
 			Name:      "last_sync_timestamp_seconds",
 			Help:      "Timestamp of last successful sync with the DNS provider",
 		},
+		[]string{"pipeline"},
 	)
-	lastReconcileTimestamp = prometheus.NewGauge(
+	lastReconcileTimestamp = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Namespace: "external_dns",
 			Subsystem: "controller",
 			Name:      "last_reconcile_timestamp_seconds",
 			Help:      "Timestamp of last attempted sync with the DNS provider",
 		},
+		[]string{"pipeline"},
 	)
-	controllerNoChangesTotal = prometheus.NewCounter(
+	controllerNoChangesTotal = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Namespace: "external_dns",
 			Subsystem: "controller",
 			Name:      "no_op_runs_total",
 			Help:      "Number of reconcile loops ending up with no changes on the DNS provider side.",
 		},
+		[]string{"pipeline"},
 	)
 	deprecatedRegistryErrors = prometheus.NewCounter(
 		prometheus.CounterOpts{
@@ -104,53 +115,133 @@ var (
 			Help:      "Number of Source errors.",
 		},
 	)
-	registryARecords = prometheus.NewGauge(
+	registryARecords = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Namespace: "external_dns",
 			Subsystem: "registry",
 			Name:      "a_records",
 			Help:      "Number of Registry A records.",
 		},
+		[]string{"pipeline"},
 	)
-	registryAAAARecords = prometheus.NewGauge(
+	registryAAAARecords = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Namespace: "external_dns",
 			Subsystem: "registry",
 			Name:      "aaaa_records",
 			Help:      "Number of Registry AAAA records.",
 		},
+		[]string{"pipeline"},
 	)
-	sourceARecords = prometheus.NewGauge(
+	sourceARecords = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Namespace: "external_dns",
 			Subsystem: "source",
 			Name:      "a_records",
 			Help:      "Number of Source A records.",
 		},
+		[]string{"pipeline"},
 	)
-	sourceAAAARecords = prometheus.NewGauge(
+	sourceAAAARecords = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Namespace: "external_dns",
 			Subsystem: "source",
 			Name:      "aaaa_records",
 			Help:      "Number of Source AAAA records.",
 		},
+		[]string{"pipeline"},
 	)
-	verifiedARecords = prometheus.NewGauge(
+	verifiedARecords = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Namespace: "external_dns",
 			Subsystem: "controller",
 			Name:      "verified_a_records",
 			Help:      "Number of DNS A-records that exists both in source and registry.",
 		},
+		[]string{"pipeline"},
 	)
-	verifiedAAAARecords = prometheus.NewGauge(
+	consecutiveFailures = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "external_dns",
+			Subsystem: "controller",
+			Name:      "consecutive_failures",
+			Help:      "Number of consecutive failed reconcile loops.",
+		},
+		[]string{"pipeline"},
+	)
+	quarantinedEndpoints = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "external_dns",
+			Subsystem: "controller",
+			Name:      "quarantined_endpoints",
+			Help:      "Number of endpoints whose changes failed and are not retried before their backoff.",
+		},
+		[]string{"pipeline"},
+	)
+	heldDeletes = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "external_dns",
+			Subsystem: "controller",
+			Name:      "held_deletes",
+			Help:      "Number of deletions held by the delete guard in the last synchronization.",
+		},
+		[]string{"pipeline"},
+	)
+	phaseDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "external_dns",
+			Subsystem: "controller",
+			Name:      "phase_duration_seconds",
+			Help:      "Duration of the phases of a synchronization: registry_read, source_read, plan and apply.",
+			Buckets:   prometheus.ExponentialBuckets(0.01, 2, 14),
+		},
+		[]string{"pipeline", "phase"},
+	)
+	plannedChangesTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "external_dns",
+			Subsystem: "controller",
+			Name:      "planned_changes_total",
+			Help:      "Number of planned changes of the DNS records.",
+		},
+		[]string{"pipeline", "action", "record_type", "provider"},
+	)
+	appliedChangesTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "external_dns",
+			Subsystem: "controller",
+			Name:      "applied_changes_total",
+			Help:      "Number of changes of the DNS records applied successfully.",
+		},
+		[]string{"pipeline", "action", "record_type", "provider"},
+	)
+	sourceTypeEndpoints = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "external_dns",
+			Subsystem: "source",
+			Name:      "type_endpoints",
+			Help:      "Number of Endpoints listed by each source type.",
+		},
+		[]string{"pipeline", "source_type"},
+	)
+	propagationDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "external_dns",
+			Subsystem: "controller",
+			Name:      "propagation_duration_seconds",
+			Help:      "Delay between the change of a resource and the successful change of its DNS records.",
+			Buckets:   prometheus.ExponentialBuckets(0.5, 2, 13),
+		},
+		[]string{"pipeline"},
+	)
+	verifiedAAAARecords = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Namespace: "external_dns",
 			Subsystem: "controller",
 			Name:      "verified_aaaa_records",
 			Help:      "Number of DNS AAAA-records that exists both in source and registry.",
 		},
+		[]string{"pipeline"},
 	)
 )
 
@@ -170,8 +261,35 @@ func init() {
 	prometheus.MustRegister(sourceAAAARecords)
 	prometheus.MustRegister(verifiedARecords)
 	prometheus.MustRegister(verifiedAAAARecords)
+	prometheus.MustRegister(consecutiveFailures)
+	prometheus.MustRegister(quarantinedEndpoints)
+	prometheus.MustRegister(heldDeletes)
+	prometheus.MustRegister(phaseDuration)
+	prometheus.MustRegister(plannedChangesTotal)
+	prometheus.MustRegister(appliedChangesTotal)
+	prometheus.MustRegister(sourceTypeEndpoints)
+	prometheus.MustRegister(propagationDuration)
 }
 
+// Phases of a synchronization
+const (
+	phaseRegistryRead = "registry_read"
+	phaseSourceRead   = "source_read"
+	phasePlan         = "plan"
+	phaseApply        = "apply"
+)
+
+// Actions of the changes in the metrics
+const (
+	metricActionCreate = "create"
+	metricActionUpdate = "update"
+	metricActionDelete = "delete"
+)
+
+// backoffJitter is the maximum fraction of the backoff added to it, so that controllers failing
+// together do not retry together
+const backoffJitter = 0.2
+
 // Controller is responsible for orchestrating the different components.
 // It works in the following way:
 // * Ask the DNS provider for current list of endpoints.
@@ -183,13 +301,25 @@ type Controller struct {
 	Registry registry.Registry
 	// The policy that defines which changes to DNS records are allowed
 	Policy plan.Policy
+	// Guard holds the deletions exceeding its limits, if set
+	Guard *plan.GuardPolicy
+	// ConflictResolver picks the desired record among the ones of the resources claiming the same DNS name
+	ConflictResolver plan.ConflictResolver
+	// SharedOwnership merges the targets of the owners sharing the records, see plan.Plan
+	SharedOwnership bool
+	// SyncedAtAnnotator annotates the resources with the time their records were applied, if set
+	SyncedAtAnnotator events.Annotator
+	// AuditSink receives the records of the applied changes, if set
+	AuditSink audit.Sink
+	// EventEmitter records events on the resources, e.g. on the ones rejected by the ConflictResolver
+	EventEmitter events.Emitter
 	// The interval between individual synchronizations
 	Interval time.Duration
 	// The DomainFilter defines which DNS records to keep or exclude
 	DomainFilter endpoint.DomainFilter
 	// The nextRunAt used for throttling and batching reconciliation
 	nextRunAt time.Time
-	// The nextRunAtMux is for atomic updating of nextRunAt
+	// The nextRunAtMux is for atomic updating of nextRunAt and of the failures
 	nextRunAtMux sync.Mutex
 	// MangedRecordTypes are DNS record types that will be considered for management.
 	ManagedRecordTypes []string
@@ -197,71 +327,305 @@ type Controller struct {
 	ExcludeRecordTypes []string
 	// MinEventSyncInterval is used as window for batching events
 	MinEventSyncInterval time.Duration
+	// ProviderName labels the metrics of the changes
+	ProviderName string
+	// SourceEndpointCounts holds the number of endpoints of each source type, if the source counts them
+	SourceEndpointCounts *source.EndpointCounts
+	// Name labels the metrics and logs of the controller, when several controllers run in the same process
+	Name string
+	// ContinueOnError stops the loop instead of exiting the process when the authentication to the
+	// provider fails, so that the other controllers of the process are not stopped
+	ContinueOnError bool
+	// Backoff is the delay before retrying a failed run, doubled after each consecutive failure.
+	// Failed runs are retried at the next interval when it is not set.
+	Backoff time.Duration
+	// MaxBackoff caps the delay before retrying a failed run, the interval if it is not set
+	MaxBackoff time.Duration
+	// CircuitBreakerThreshold is the number of consecutive failed runs after which the controller
+	// reports itself unhealthy, until a run succeeds. 0 disables the circuit breaker.
+	CircuitBreakerThreshold int
+	// The failures is the number of consecutive failed runs
+	failures int
+	// The backoffUntil is the time before which the events do not trigger a run, after a failed run
+	backoffUntil time.Time
+	// The stopErr is the error that stopped the loop
+	stopErr error
+	// The propagation holds when the changes of the resources were observed, until their records are applied
+	propagation propagation
+	// The quarantine holds the endpoints whose changes failed, when the registry reports the result of each change
+	quarantine quarantine
 }
 
 // RunOnce runs a single iteration of a reconciliation loop.
 func (c *Controller) RunOnce(ctx context.Context) error {
-	lastReconcileTimestamp.SetToCurrentTime()
+	lastReconcileTimestamp.WithLabelValues(c.Name).SetToCurrentTime()
+	runStart := time.Now()
+
+	ctx, plan, err := c.calculatePlan(ctx)
+	if err != nil {
+		return err
+	}
+	c.emitConflicts(plan.Conflicts)
+	heldDeletes.WithLabelValues(c.Name).Set(float64(len(plan.Held)))
+	if len(plan.Held) > 0 {
+		c.logger().Warnf("Holding the deletion of %d records, exceeding the limits of the delete guard, until it is confirmed", len(plan.Held))
+	}
+
+	c.countChanges(plannedChangesTotal, plan.Changes)
+
+	if plan.Changes.HasChanges() {
+		start := time.Now()
+		applied, err := c.applyChanges(ctx, start, plan.Changes)
+		c.observePhase(phaseApply, start)
+		if err != nil {
+			registryErrorsTotal.WithLabelValues(c.Name).Inc()
+			deprecatedRegistryErrors.Inc()
+			return err
+		}
+		c.settle(ctx, runStart, time.Now(), plan.Changes, applied)
+	} else {
+		controllerNoChangesTotal.WithLabelValues(c.Name).Inc()
+		c.logger().Info("All records are already up to date")
+		c.settle(ctx, runStart, time.Now(), plan.Changes, nil)
+	}
 
+	lastSyncTimestamp.WithLabelValues(c.Name).SetToCurrentTime()
+
+	return nil
+}
+
+// Plan returns the changes a single iteration of the reconciliation loop would apply, without applying them.
+func (c *Controller) Plan(ctx context.Context) (*plan.Changes, error) {
+	_, plan, err := c.calculatePlan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return plan.Changes, nil
+}
+
+// calculatePlan calculates the plan from the records of the registry and the endpoints of the source.
+// It returns the context to apply the plan with, which holds the records.
+func (c *Controller) calculatePlan(ctx context.Context) (context.Context, *plan.Plan, error) {
+	start := time.Now()
 	records, err := c.Registry.Records(ctx)
+	c.observePhase(phaseRegistryRead, start)
 	if err != nil {
-		registryErrorsTotal.Inc()
+		registryErrorsTotal.WithLabelValues(c.Name).Inc()
 		deprecatedRegistryErrors.Inc()
-		return err
+		return ctx, nil, err
 	}
 
-	registryEndpointsTotal.Set(float64(len(records)))
+	registryEndpointsTotal.WithLabelValues(c.Name).Set(float64(len(records)))
 	regARecords, regAAAARecords := countAddressRecords(records)
-	registryARecords.Set(float64(regARecords))
-	registryAAAARecords.Set(float64(regAAAARecords))
+	registryARecords.WithLabelValues(c.Name).Set(float64(regARecords))
+	registryAAAARecords.WithLabelValues(c.Name).Set(float64(regAAAARecords))
 	ctx = context.WithValue(ctx, provider.RecordsContextKey, records)
 
+	start = time.Now()
 	endpoints, err := c.Source.Endpoints(ctx)
+	c.observePhase(phaseSourceRead, start)
 	if err != nil {
-		sourceErrorsTotal.Inc()
+		sourceErrorsTotal.WithLabelValues(c.Name).Inc()
 		deprecatedSourceErrors.Inc()
-		return err
+		return ctx, nil, err
+	}
+	sourceEndpointsTotal.WithLabelValues(c.Name).Set(float64(len(endpoints)))
+	if c.SourceEndpointCounts != nil {
+		for sourceType, count := range c.SourceEndpointCounts.Counts() {
+			sourceTypeEndpoints.WithLabelValues(c.Name, sourceType).Set(float64(count))
+		}
 	}
-	sourceEndpointsTotal.Set(float64(len(endpoints)))
 	srcARecords, srcAAAARecords := countAddressRecords(endpoints)
-	sourceARecords.Set(float64(srcARecords))
-	sourceAAAARecords.Set(float64(srcAAAARecords))
+	sourceARecords.WithLabelValues(c.Name).Set(float64(srcARecords))
+	sourceAAAARecords.WithLabelValues(c.Name).Set(float64(srcAAAARecords))
 	vARecords, vAAAARecords := countMatchingAddressRecords(endpoints, records)
-	verifiedARecords.Set(float64(vARecords))
-	verifiedAAAARecords.Set(float64(vAAAARecords))
+	verifiedARecords.WithLabelValues(c.Name).Set(float64(vARecords))
+	verifiedAAAARecords.WithLabelValues(c.Name).Set(float64(vAAAARecords))
 	endpoints, err = c.Registry.AdjustEndpoints(endpoints)
 	if err != nil {
-		return fmt.Errorf("adjusting endpoints: %w", err)
+		return ctx, nil, fmt.Errorf("adjusting endpoints: %w", err)
 	}
 	registryFilter := c.Registry.GetDomainFilter()
 
+	policies := []plan.Policy{c.Policy}
+	if c.Guard != nil {
+		policies = append(policies, c.Guard)
+	}
 	plan := &plan.Plan{
-		Policies:       []plan.Policy{c.Policy},
-		Current:        records,
-		Desired:        endpoints,
-		DomainFilter:   endpoint.MatchAllDomainFilters{&c.DomainFilter, &registryFilter},
-		ManagedRecords: c.ManagedRecordTypes,
-		ExcludeRecords: c.ExcludeRecordTypes,
-		OwnerID:        c.Registry.OwnerID(),
+		Policies:        policies,
+		Current:         records,
+		Desired:         endpoints,
+		DomainFilter:    endpoint.MatchAllDomainFilters{&c.DomainFilter, &registryFilter},
+		ManagedRecords:  c.ManagedRecordTypes,
+		ExcludeRecords:  c.ExcludeRecordTypes,
+		OwnerID:         c.Registry.OwnerID(),
+		SharedOwnership: c.SharedOwnership,
+		Resolver:        c.ConflictResolver,
+	}
+	if collector, ok := c.Registry.(registry.OrphanCollector); ok {
+		plan.Orphans = collector.Orphans()
 	}
 
+	start = time.Now()
 	plan = plan.Calculate()
+	c.observePhase(phasePlan, start)
+	return ctx, plan, nil
+}
 
-	if plan.Changes.HasChanges() {
-		err = c.Registry.ApplyChanges(ctx, plan.Changes)
+// observePhase observes the duration of a phase of the synchronization started at start
+func (c *Controller) observePhase(phase string, start time.Time) {
+	phaseDuration.WithLabelValues(c.Name, phase).Observe(time.Since(start).Seconds())
+}
+
+// countChanges adds the changes to the counter, by action and record type
+func (c *Controller) countChanges(counter *prometheus.CounterVec, changes *plan.Changes) {
+	for action, endpoints := range map[string][]*endpoint.Endpoint{
+		metricActionCreate: changes.Create,
+		metricActionUpdate: changes.UpdateNew,
+		metricActionDelete: changes.Delete,
+	} {
+		for _, ep := range endpoints {
+			counter.WithLabelValues(c.Name, action, ep.RecordType, c.ProviderName).Inc()
+		}
+	}
+}
+
+// emitConflicts records an event on each resource which lost a DNS name to another resource
+func (c *Controller) emitConflicts(conflicts []plan.Conflict) {
+	if c.EventEmitter == nil {
+		return
+	}
+	emitted := map[[2]string]bool{}
+	for _, conflict := range conflicts {
+		resource := conflict.Rejected.Labels[endpoint.ResourceLabelKey]
+		key := [2]string{resource, conflict.Rejected.DNSName}
+		if emitted[key] {
+			continue
+		}
+		emitted[key] = true
+		c.EventEmitter.Emit(events.Event{
+			Resource: resource,
+			Type:     corev1.EventTypeWarning,
+			Reason:   events.ReasonConflictRejected,
+			Message:  fmt.Sprintf("%s is acquired by %s", conflict.Rejected.DNSName, conflict.Winner.Labels[endpoint.ResourceLabelKey]),
+		})
+	}
+}
+
+// applyChanges applies the changes of the endpoints which are not quarantined. When the registry
+// reports the result of each change, the endpoints whose changes failed are quarantined for a
+// backoff instead of failing the run, so that they do not block the other changes.
+func (c *Controller) applyChanges(ctx context.Context, now time.Time, changes *plan.Changes) (*plan.Changes, error) {
+	if c.quarantine == nil {
+		c.quarantine = quarantine{}
+	}
+	defer func() {
+		quarantinedEndpoints.WithLabelValues(c.Name).Set(float64(len(c.quarantine)))
+	}()
+
+	changes = c.quarantine.filter(now, changes)
+	if !changes.HasChanges() {
+		c.logger().Info("All the changes are quarantined")
+		return changes, nil
+	}
+
+	applier, ok := c.Registry.(provider.ResultsApplier)
+	if !ok {
+		err := c.Registry.ApplyChanges(ctx, changes)
+		c.audit(now, changes, nil, err)
 		if err != nil {
-			registryErrorsTotal.Inc()
-			deprecatedRegistryErrors.Inc()
-			return err
+			return nil, err
 		}
-	} else {
-		controllerNoChangesTotal.Inc()
-		log.Info("All records are already up to date")
+		c.countChanges(appliedChangesTotal, changes)
+		return changes, nil
+	}
+	results, err := applier.ApplyChangesWithResults(ctx, changes)
+	c.audit(now, changes, results, err)
+	if err != nil {
+		return nil, err
+	}
+	applied := succeeded(changes, results)
+	c.countChanges(appliedChangesTotal, applied)
+	base := c.Backoff
+	if base <= 0 {
+		base = c.Interval
 	}
+	for _, result := range results {
+		if result.Err == nil {
+			c.quarantine.release(result.Endpoint)
+			continue
+		}
+		delay := c.quarantine.add(result.Endpoint, now, func(failures int) time.Duration {
+			return c.backoff(base, failures)
+		})
+		c.logger().Errorf("Failed to apply the change of %s %s, quarantined for %s: %v", result.Endpoint.DNSName, result.Endpoint.RecordType, delay.Round(time.Second), result.Err)
+	}
+	return applied, nil
+}
+
+// succeeded returns the changes whose results succeeded, all the changes if the results are unknown
+func succeeded(changes *plan.Changes, results []provider.ChangeResult) *plan.Changes {
+	if results == nil {
+		return changes
+	}
+	failed := make(map[*endpoint.Endpoint]bool, len(results))
+	for _, result := range results {
+		failed[result.Endpoint] = result.Err != nil
+	}
+	filter := func(endpoints []*endpoint.Endpoint) []*endpoint.Endpoint {
+		var kept []*endpoint.Endpoint
+		for _, ep := range endpoints {
+			if !failed[ep] {
+				kept = append(kept, ep)
+			}
+		}
+		return kept
+	}
+	applied := &plan.Changes{Create: filter(changes.Create), Delete: filter(changes.Delete)}
+	// the results are those of UpdateNew, the old endpoint at the same index is kept along with it
+	for i, ep := range changes.UpdateNew {
+		if failed[ep] {
+			continue
+		}
+		applied.UpdateNew = append(applied.UpdateNew, ep)
+		if i < len(changes.UpdateOld) {
+			applied.UpdateOld = append(applied.UpdateOld, changes.UpdateOld[i])
+		}
+	}
+	return applied
+}
 
-	lastSyncTimestamp.SetToCurrentTime()
+// ObserveChange records that the resource, by its resource label, changed, so that the delay until
+// its records are applied is measured
+func (c *Controller) ObserveChange(resource string) {
+	c.propagation.observe(resource, time.Now())
+}
 
-	return nil
+// settle measures the propagation delays of the changes of the resources applied by the run started
+// at start, and annotates the resources whose records were applied with the time they were
+func (c *Controller) settle(ctx context.Context, start, now time.Time, planned, applied *plan.Changes) {
+	for _, delay := range c.propagation.settle(start, now, planned, applied) {
+		propagationDuration.WithLabelValues(c.Name).Observe(delay.Seconds())
+	}
+	if c.SyncedAtAnnotator == nil {
+		return
+	}
+	syncedAt := map[string]string{source.SyncedAtAnnotationKey: now.UTC().Format(time.RFC3339)}
+	for resource := range changedResources(applied) {
+		if err := c.SyncedAtAnnotator.Annotate(ctx, resource, syncedAt); err != nil {
+			c.logger().Warnf("Failed to annotate %s with the time its records were applied: %v", resource, err)
+		}
+	}
+}
+
+// audit writes the records of the applied changes to the audit sink, if any
+func (c *Controller) audit(now time.Time, changes *plan.Changes, results []provider.ChangeResult, err error) {
+	if c.AuditSink == nil {
+		return
+	}
+	if err := c.AuditSink.Write(audit.NewRecords(now, c.Name, c.Registry.OwnerID(), changes, results, err)); err != nil {
+		c.logger().Errorf("Failed to write the audit records: %v", err)
+	}
 }
 
 // Counts the intersections of A and AAAA records in endpoint and registry.
@@ -308,6 +672,10 @@ func countAddressRecords(endpoints []*endpoint.Endpoint) (int, int) {
 func (c *Controller) ScheduleRunOnce(now time.Time) {
 	c.nextRunAtMux.Lock()
 	defer c.nextRunAtMux.Unlock()
+	// do not retry a failed run before its backoff
+	if now.Before(c.backoffUntil) {
+		return
+	}
 	// schedule only if a reconciliation is not already planned
 	// to happen in the following c.MinEventSyncInterval
 	if !c.nextRunAt.Before(now.Add(c.MinEventSyncInterval)) {
@@ -331,19 +699,111 @@ func (c *Controller) Run(ctx context.Context) {
 	defer ticker.Stop()
 	for {
 		if c.ShouldRunOnce(time.Now()) {
-			if err := c.RunOnce(ctx); err != nil {
-				if errors.Is(err, provider.SoftError) {
-					log.Errorf("Failed to do run once: %v", err)
-				} else {
-					log.Fatalf("Failed to do run once: %v", err)
-				}
+			if !c.handleRunResult(time.Now(), c.RunOnce(ctx)) {
+				return
 			}
 		}
 		select {
 		case <-ticker.C:
 		case <-ctx.Done():
-			log.Info("Terminating main controller loop")
+			c.logger().Info("Terminating main controller loop")
 			return
 		}
 	}
 }
+
+// handleRunResult schedules the retry of a failed run, or resets the failures after a successful run.
+// It returns false when the loop must stop.
+func (c *Controller) handleRunResult(now time.Time, err error) bool {
+	switch {
+	case err == nil:
+		c.nextRunAtMux.Lock()
+		c.failures = 0
+		c.backoffUntil = time.Time{}
+		c.nextRunAtMux.Unlock()
+		consecutiveFailures.WithLabelValues(c.Name).Set(0)
+	case errors.Is(err, provider.AuthFailed):
+		// retrying does not help, restarting may pick up new credentials
+		if !c.ContinueOnError {
+			c.logger().Fatalf("Failed to do run once: %v", err)
+		}
+		c.logger().Errorf("Stopping the controller loop: %v", err)
+		c.nextRunAtMux.Lock()
+		c.stopErr = err
+		c.nextRunAtMux.Unlock()
+		return false
+	case errors.Is(err, provider.InvalidRecord):
+		// retrying sooner would fail the same way, the next interval is already scheduled
+		c.logger().Errorf("Failed to do run once: %v", err)
+	default:
+		if delay := c.failed(now, err); delay > 0 {
+			c.logger().Errorf("Failed to do run once, retrying in %s: %v", delay.Round(time.Second), err)
+		} else {
+			c.logger().Errorf("Failed to do run once: %v", err)
+		}
+	}
+	return true
+}
+
+// failed counts a failed run and schedules its retry after the backoff, returning the backoff.
+// The retry stays at the next interval when there is no backoff.
+func (c *Controller) failed(now time.Time, err error) time.Duration {
+	c.nextRunAtMux.Lock()
+	defer c.nextRunAtMux.Unlock()
+	c.failures++
+	consecutiveFailures.WithLabelValues(c.Name).Set(float64(c.failures))
+
+	delay := c.backoff(c.Backoff, c.failures)
+	if retryAfter, ok := provider.RetryAfter(err); ok && retryAfter > delay {
+		delay = retryAfter
+	}
+	if delay > 0 {
+		c.nextRunAt = now.Add(delay)
+		c.backoffUntil = c.nextRunAt
+	}
+	return delay
+}
+
+// backoff returns the given base delay doubled for each failure after the first one and jittered, capped by
+// MaxBackoff, or by the interval when it is not set
+func (c *Controller) backoff(base time.Duration, failures int) time.Duration {
+	if base <= 0 {
+		return 0
+	}
+	maxBackoff := c.MaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = c.Interval
+	}
+	delay := base
+	for i := 1; i < failures && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+	// jittered before capping, so that the delay never exceeds MaxBackoff
+	delay = wait.Jitter(delay, backoffJitter)
+	if maxBackoff > 0 && delay > maxBackoff {
+		delay = maxBackoff
+	}
+	return delay
+}
+
+// Healthy returns an error when the circuit breaker of the controller is open, i.e. when its last
+// CircuitBreakerThreshold runs failed, or when its loop stopped.
+func (c *Controller) Healthy() error {
+	c.nextRunAtMux.Lock()
+	defer c.nextRunAtMux.Unlock()
+	if c.stopErr != nil {
+		return fmt.Errorf("the controller loop stopped: %w", c.stopErr)
+	}
+	if c.CircuitBreakerThreshold > 0 && c.failures >= c.CircuitBreakerThreshold {
+		return fmt.Errorf("the last %d runs failed", c.failures)
+	}
+	return nil
+}
+
+// logger returns the logger of the controller, with the name of the controller if it has one.
+func (c *Controller) logger() log.FieldLogger {
+	if c.Name == "" {
+		return log.StandardLogger()
+	}
+	return log.WithField("pipeline", c.Name)
+}
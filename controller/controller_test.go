@@ -19,6 +19,7 @@ package controller
 import (
 	"context"
 	"errors"
+	"fmt"
 	"math"
 	"reflect"
 	"sort"
@@ -26,13 +27,18 @@ import (
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 
 	"sigs.k8s.io/external-dns/endpoint"
 	"sigs.k8s.io/external-dns/internal/testutils"
 	"sigs.k8s.io/external-dns/pkg/apis/externaldns"
+	"sigs.k8s.io/external-dns/pkg/audit"
+	"sigs.k8s.io/external-dns/pkg/events"
 	"sigs.k8s.io/external-dns/plan"
 	"sigs.k8s.io/external-dns/provider"
+	"sigs.k8s.io/external-dns/provider/inmemory"
 	"sigs.k8s.io/external-dns/registry"
+	"sigs.k8s.io/external-dns/source"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -234,8 +240,233 @@ func TestRunOnce(t *testing.T) {
 	// Validate that the mock source was called.
 	source.AssertExpectations(t)
 	// check the verified records
-	assert.Equal(t, math.Float64bits(1), valueFromMetric(verifiedARecords))
-	assert.Equal(t, math.Float64bits(1), valueFromMetric(verifiedAAAARecords))
+	assert.Equal(t, math.Float64bits(1), valueFromMetric(verifiedARecords.WithLabelValues("")))
+	assert.Equal(t, math.Float64bits(1), valueFromMetric(verifiedAAAARecords.WithLabelValues("")))
+}
+
+// TestPlan tests that Plan returns the changes of RunOnce without applying them.
+func TestPlan(t *testing.T) {
+	cfg := getTestConfig()
+	p := &filteredMockProvider{
+		RecordsStore: []*endpoint.Endpoint{
+			endpoint.NewEndpoint("update-record", endpoint.RecordTypeA, "8.8.8.8"),
+			endpoint.NewEndpoint("update-aaaa-record", endpoint.RecordTypeAAAA, "2001:DB8::3"),
+		},
+	}
+	r, err := registry.NewNoopRegistry(p)
+	require.NoError(t, err)
+
+	ctrl := &Controller{
+		Source:             getTestSource(),
+		Registry:           r,
+		Policy:             &plan.SyncPolicy{},
+		ManagedRecordTypes: cfg.ManagedDNSRecordTypes,
+	}
+	changes, err := ctrl.Plan(context.Background())
+	require.NoError(t, err)
+
+	assert.Len(t, changes.Create, 2)
+	assert.Len(t, changes.UpdateOld, 2)
+	assert.Len(t, changes.UpdateNew, 2)
+	assert.Empty(t, changes.Delete)
+	assert.Empty(t, p.ApplyChangesCalls)
+}
+
+// recordingEmitter records the emitted events.
+type recordingEmitter struct {
+	events []events.Event
+}
+
+func (e *recordingEmitter) Emit(event events.Event) {
+	e.events = append(e.events, event)
+}
+
+// TestRunOnceConflictEvents tests that the resources losing a DNS name get an event.
+func TestRunOnceConflictEvents(t *testing.T) {
+	resourceEndpoint := func(target, resource, created string) *endpoint.Endpoint {
+		ep := endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeA, target)
+		ep.Labels[endpoint.ResourceLabelKey] = resource
+		ep.Labels[endpoint.ResourceCreatedLabelKey] = created
+		return ep
+	}
+	source := new(testutils.MockSource)
+	source.On("Endpoints").Return([]*endpoint.Endpoint{
+		resourceEndpoint("1.1.1.1", "ingress/default/new", "2024-01-01T00:00:00Z"),
+		resourceEndpoint("2.2.2.2", "ingress/default/old", "2023-01-01T00:00:00Z"),
+	}, nil)
+	p := &filteredMockProvider{}
+	r, err := registry.NewNoopRegistry(p)
+	require.NoError(t, err)
+	resolver, err := plan.NewConflictResolver(plan.ConflictResolutionOldest, nil)
+	require.NoError(t, err)
+	emitter := &recordingEmitter{}
+
+	ctrl := &Controller{
+		Source:             source,
+		Registry:           r,
+		Policy:             &plan.SyncPolicy{},
+		ManagedRecordTypes: []string{endpoint.RecordTypeA},
+		ConflictResolver:   resolver,
+		EventEmitter:       emitter,
+	}
+	require.NoError(t, ctrl.RunOnce(context.Background()))
+
+	require.Len(t, p.ApplyChangesCalls, 1)
+	assert.Equal(t, endpoint.Targets{"2.2.2.2"}, p.ApplyChangesCalls[0].Create[0].Targets)
+	assert.Equal(t, []events.Event{{
+		Resource: "ingress/default/new",
+		Type:     "Warning",
+		Reason:   events.ReasonConflictRejected,
+		Message:  "foo.example.org is acquired by ingress/default/old",
+	}}, emitter.events)
+}
+
+func TestRunOnceGuard(t *testing.T) {
+	source := new(testutils.MockSource)
+	source.On("Endpoints").Return([]*endpoint.Endpoint{}, nil)
+	p := &filteredMockProvider{RecordsStore: []*endpoint.Endpoint{
+		endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeA, "1.1.1.1"),
+		endpoint.NewEndpoint("bar.example.org", endpoint.RecordTypeA, "1.1.1.1"),
+	}}
+	r, err := registry.NewNoopRegistry(p)
+	require.NoError(t, err)
+	confirmed := false
+
+	ctrl := &Controller{
+		Source:             source,
+		Registry:           r,
+		Policy:             &plan.SyncPolicy{},
+		ManagedRecordTypes: []string{endpoint.RecordTypeA},
+		Guard:              &plan.GuardPolicy{MaxDeletesPercent: 50, Override: func() bool { return confirmed }},
+		Name:               "guard",
+	}
+	require.NoError(t, ctrl.RunOnce(context.Background()))
+	assert.Empty(t, p.ApplyChangesCalls)
+	assert.Equal(t, 2.0, testutil.ToFloat64(heldDeletes.WithLabelValues("guard")))
+
+	confirmed = true
+	require.NoError(t, ctrl.RunOnce(context.Background()))
+	require.Len(t, p.ApplyChangesCalls, 1)
+	assert.Len(t, p.ApplyChangesCalls[0].Delete, 2)
+	assert.Equal(t, 0.0, testutil.ToFloat64(heldDeletes.WithLabelValues("guard")))
+}
+
+func TestRunOnceOrphans(t *testing.T) {
+	ctx := context.Background()
+	source := new(testutils.MockSource)
+	desired := endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeA, "1.1.1.1")
+	desired.Labels[endpoint.ResourceLabelKey] = "ingress/default/foo"
+	source.On("Endpoints").Return([]*endpoint.Endpoint{desired}, nil)
+	// the record was deleted by hand, its TXT records are left
+	p := inmemory.NewInMemoryProvider()
+	require.NoError(t, p.CreateZone("example.org"))
+	require.NoError(t, p.ApplyChanges(ctx, &plan.Changes{Create: []*endpoint.Endpoint{
+		endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeTXT, "\"heritage=external-dns,external-dns/owner=owner,external-dns/resource=ingress/default/gone\""),
+		endpoint.NewEndpoint("a-foo.example.org", endpoint.RecordTypeTXT, "\"heritage=external-dns,external-dns/owner=owner,external-dns/resource=ingress/default/gone\""),
+	}}))
+	r, err := registry.NewTXTRegistry(p, "", "", "owner", 0, "", []string{endpoint.RecordTypeA}, nil, false, nil)
+	require.NoError(t, err)
+	r.CollectOrphans()
+
+	ctrl := &Controller{
+		Source:             source,
+		Registry:           r,
+		Policy:             &plan.SyncPolicy{},
+		ManagedRecordTypes: []string{endpoint.RecordTypeA},
+	}
+	// the TXT records are replaced by the TXT records of the record created again
+	require.NoError(t, ctrl.RunOnce(ctx))
+	records, err := r.Records(ctx)
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, "owner", records[0].Labels[endpoint.OwnerLabelKey])
+	assert.Equal(t, "ingress/default/foo", records[0].Labels[endpoint.ResourceLabelKey])
+	assert.Empty(t, ctrl.quarantine)
+}
+
+type recordingSink struct {
+	records []audit.Record
+}
+
+func (s *recordingSink) Write(records []audit.Record) error {
+	s.records = append(s.records, records...)
+	return nil
+}
+
+func TestRunOnceAudit(t *testing.T) {
+	source := new(testutils.MockSource)
+	source.On("Endpoints").Return([]*endpoint.Endpoint{endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeA, "1.1.1.1")}, nil)
+	noop, err := registry.NewNoopRegistry(&filteredMockProvider{})
+	require.NoError(t, err)
+	r := &resultsMockRegistry{Registry: noop, failing: map[string]bool{"foo.example.org": true}}
+	sink := &recordingSink{}
+
+	ctrl := &Controller{
+		Source:             source,
+		Registry:           r,
+		Policy:             &plan.SyncPolicy{},
+		ManagedRecordTypes: []string{endpoint.RecordTypeA},
+		AuditSink:          sink,
+		Backoff:            time.Minute,
+		Name:               "audit",
+	}
+	require.NoError(t, ctrl.RunOnce(context.Background()))
+
+	require.Len(t, sink.records, 1)
+	record := sink.records[0]
+	assert.Equal(t, "audit", record.Pipeline)
+	assert.Equal(t, audit.ActionCreate, record.Action)
+	assert.Equal(t, "foo.example.org", record.DNSName)
+	assert.Equal(t, []string{"1.1.1.1"}, record.NewTargets)
+	assert.Equal(t, audit.ResultFailed, record.Result)
+	assert.Contains(t, record.Error, "invalid")
+
+	// the quarantined endpoint is neither applied nor audited
+	require.NoError(t, ctrl.RunOnce(context.Background()))
+	assert.Len(t, sink.records, 1)
+}
+
+func TestRunOnceMetrics(t *testing.T) {
+	services := new(testutils.MockSource)
+	services.On("Endpoints").Return([]*endpoint.Endpoint{
+		endpoint.NewEndpoint("new.example.org", endpoint.RecordTypeA, "1.1.1.1"),
+		endpoint.NewEndpoint("bad.example.org", endpoint.RecordTypeA, "1.1.1.1"),
+		endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeCNAME, "bar.example.org"),
+	}, nil)
+	counts := &source.EndpointCounts{}
+	noop, err := registry.NewNoopRegistry(&filteredMockProvider{RecordsStore: []*endpoint.Endpoint{
+		endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeCNAME, "baz.example.org"),
+		endpoint.NewEndpoint("old.example.org", endpoint.RecordTypeA, "1.1.1.1"),
+	}})
+	require.NoError(t, err)
+	r := &resultsMockRegistry{Registry: noop, failing: map[string]bool{"bad.example.org": true}}
+	phases := testutil.CollectAndCount(phaseDuration)
+
+	ctrl := &Controller{
+		Source:               source.NewCountingSource("service", services, counts),
+		Registry:             r,
+		Policy:               &plan.SyncPolicy{},
+		ManagedRecordTypes:   []string{endpoint.RecordTypeA, endpoint.RecordTypeCNAME},
+		ProviderName:         "inmemory",
+		SourceEndpointCounts: counts,
+		Backoff:              time.Minute,
+		Name:                 "metrics",
+	}
+	require.NoError(t, ctrl.RunOnce(context.Background()))
+
+	assert.Equal(t, phases+4, testutil.CollectAndCount(phaseDuration))
+	assert.Equal(t, 3.0, testutil.ToFloat64(sourceTypeEndpoints.WithLabelValues("metrics", "service")))
+	for _, tc := range []struct {
+		action, recordType string
+		planned, applied   float64
+	}{
+		{"create", endpoint.RecordTypeA, 2, 1},
+		{"update", endpoint.RecordTypeCNAME, 1, 1},
+		{"delete", endpoint.RecordTypeA, 1, 1},
+	} {
+		assert.Equal(t, tc.planned, testutil.ToFloat64(plannedChangesTotal.WithLabelValues("metrics", tc.action, tc.recordType, "inmemory")), tc.action)
+		assert.Equal(t, tc.applied, testutil.ToFloat64(appliedChangesTotal.WithLabelValues("metrics", tc.action, tc.recordType, "inmemory")), tc.action)
+	}
 }
 
 // TestRun tests that Run correctly starts and stops
@@ -268,8 +499,67 @@ func TestRun(t *testing.T) {
 	// Validate that the mock source was called.
 	source.AssertExpectations(t)
 	// check the verified records
-	assert.Equal(t, math.Float64bits(1), valueFromMetric(verifiedARecords))
-	assert.Equal(t, math.Float64bits(1), valueFromMetric(verifiedAAAARecords))
+	assert.Equal(t, math.Float64bits(1), valueFromMetric(verifiedARecords.WithLabelValues("")))
+	assert.Equal(t, math.Float64bits(1), valueFromMetric(verifiedAAAARecords.WithLabelValues("")))
+}
+
+// TestRunOnceNamed tests that the metrics of named controllers are labeled with their names.
+func TestRunOnceNamed(t *testing.T) {
+	cfg := getTestConfig()
+
+	r, err := registry.NewNoopRegistry(getTestProvider())
+	require.NoError(t, err)
+	public := &Controller{
+		Name:               "public",
+		Source:             getTestSource(),
+		Registry:           r,
+		Policy:             &plan.SyncPolicy{},
+		ManagedRecordTypes: cfg.ManagedDNSRecordTypes,
+	}
+	assert.NoError(t, public.RunOnce(context.Background()))
+
+	r, err = registry.NewNoopRegistry(&errorMockProvider{})
+	require.NoError(t, err)
+	private := &Controller{
+		Name:               "private",
+		Source:             getTestSource(),
+		Registry:           r,
+		Policy:             &plan.SyncPolicy{},
+		ManagedRecordTypes: cfg.ManagedDNSRecordTypes,
+	}
+	assert.Error(t, private.RunOnce(context.Background()))
+
+	assert.Equal(t, math.Float64bits(1), valueFromMetric(verifiedARecords.WithLabelValues("public")))
+	assert.Equal(t, math.Float64bits(0), valueFromMetric(verifiedARecords.WithLabelValues("private")))
+	assert.Equal(t, float64(0), testutil.ToFloat64(registryErrorsTotal.WithLabelValues("public")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(registryErrorsTotal.WithLabelValues("private")))
+}
+
+// TestRunContinueOnError tests that Run keeps running after an error when ContinueOnError is set.
+func TestRunContinueOnError(t *testing.T) {
+	r, err := registry.NewNoopRegistry(&errorMockProvider{})
+	require.NoError(t, err)
+
+	ctrl := &Controller{
+		Name:            "failing",
+		Source:          getTestSource(),
+		Registry:        r,
+		Policy:          &plan.SyncPolicy{},
+		Interval:        time.Millisecond,
+		ContinueOnError: true,
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		ctrl.Run(ctx)
+		close(stopped)
+	}()
+	time.Sleep(1500 * time.Millisecond)
+	cancel()
+	<-stopped
+
+	// the loop went on after the first failed run
+	assert.GreaterOrEqual(t, testutil.ToFloat64(registryErrorsTotal.WithLabelValues("failing")), float64(2))
 }
 
 func valueFromMetric(metric prometheus.Gauge) uint64 {
@@ -327,6 +617,247 @@ func TestShouldRunOnce(t *testing.T) {
 	assert.True(t, ctrl.ShouldRunOnce(now))
 }
 
+func TestBackoff(t *testing.T) {
+	ctrl := &Controller{Name: "backoff", Interval: 10 * time.Minute, MinEventSyncInterval: 5 * time.Second, Backoff: 10 * time.Second, MaxBackoff: time.Minute}
+	err := errors.New("provider error")
+
+	now := time.Now()
+	for _, backoff := range []time.Duration{10 * time.Second, 20 * time.Second, 40 * time.Second, time.Minute, time.Minute} {
+		assert.True(t, ctrl.ShouldRunOnce(now))
+		assert.True(t, ctrl.handleRunResult(now, err))
+
+		// the retry happens after the backoff and its jitter
+		assert.False(t, ctrl.ShouldRunOnce(now.Add(backoff-time.Millisecond)))
+		now = now.Add(backoff + time.Duration(backoffJitter*float64(backoff)))
+	}
+	assert.Equal(t, float64(5), testutil.ToFloat64(consecutiveFailures.WithLabelValues("backoff")))
+
+	// the events do not trigger a retry during the backoff
+	assert.True(t, ctrl.ShouldRunOnce(now))
+	assert.True(t, ctrl.handleRunResult(now, provider.NewSoftError(err)))
+	ctrl.ScheduleRunOnce(now)
+	assert.False(t, ctrl.ShouldRunOnce(now.Add(ctrl.MinEventSyncInterval)))
+
+	// a successful run resets the backoff
+	now = now.Add(2 * time.Minute)
+	assert.True(t, ctrl.ShouldRunOnce(now))
+	assert.True(t, ctrl.handleRunResult(now, nil))
+	assert.Equal(t, float64(0), testutil.ToFloat64(consecutiveFailures.WithLabelValues("backoff")))
+	ctrl.ScheduleRunOnce(now)
+	assert.True(t, ctrl.ShouldRunOnce(now.Add(ctrl.MinEventSyncInterval)))
+	assert.True(t, ctrl.handleRunResult(now, err))
+	assert.True(t, ctrl.ShouldRunOnce(now.Add(10*time.Second+time.Duration(backoffJitter*float64(10*time.Second)))))
+}
+
+func TestBackoffCap(t *testing.T) {
+	// the jitter does not exceed the maximum backoff, nor the interval when it is not set
+	for _, ctrl := range []*Controller{
+		{Interval: 10 * time.Minute, MaxBackoff: time.Minute},
+		{Interval: 10 * time.Minute},
+	} {
+		maxBackoff := ctrl.MaxBackoff
+		if maxBackoff == 0 {
+			maxBackoff = ctrl.Interval
+		}
+		for i := 0; i < 100; i++ {
+			assert.LessOrEqual(t, ctrl.backoff(10*time.Second, 20), maxBackoff)
+		}
+		assert.GreaterOrEqual(t, ctrl.backoff(10*time.Second, 1), 10*time.Second)
+	}
+}
+
+func TestBackoffErrors(t *testing.T) {
+	err := errors.New("provider error")
+	now := time.Now()
+
+	// without backoff, the failed runs are retried at the next interval
+	ctrl := &Controller{Interval: 10 * time.Minute}
+	assert.True(t, ctrl.ShouldRunOnce(now))
+	assert.True(t, ctrl.handleRunResult(now, err))
+	assert.False(t, ctrl.ShouldRunOnce(now.Add(10*time.Minute-time.Second)))
+	assert.True(t, ctrl.ShouldRunOnce(now.Add(10*time.Minute)))
+
+	// the rate limits are retried after their retry-after hint
+	ctrl = &Controller{Interval: 10 * time.Minute, Backoff: 10 * time.Second, MaxBackoff: time.Minute}
+	assert.True(t, ctrl.ShouldRunOnce(now))
+	assert.True(t, ctrl.handleRunResult(now, provider.NewRateLimitedError(err, 5*time.Minute)))
+	assert.False(t, ctrl.ShouldRunOnce(now.Add(5*time.Minute-time.Second)))
+	assert.True(t, ctrl.ShouldRunOnce(now.Add(5*time.Minute)))
+
+	// the invalid records are retried at the next interval and are not counted
+	ctrl = &Controller{Interval: 10 * time.Minute, Backoff: 10 * time.Second, CircuitBreakerThreshold: 1}
+	assert.True(t, ctrl.ShouldRunOnce(now))
+	assert.True(t, ctrl.handleRunResult(now, fmt.Errorf("applying changes: %w", provider.NewInvalidRecordError(err))))
+	assert.False(t, ctrl.ShouldRunOnce(now.Add(time.Minute)))
+	assert.NoError(t, ctrl.Healthy())
+
+	// the authentication failures stop the loop
+	ctrl = &Controller{Interval: 10 * time.Minute, ContinueOnError: true}
+	assert.False(t, ctrl.handleRunResult(now, provider.NewAuthFailedError(err)))
+	assert.ErrorIs(t, ctrl.Healthy(), provider.AuthFailed)
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	ctrl := &Controller{Interval: time.Minute, Backoff: time.Second, CircuitBreakerThreshold: 2}
+	err := errors.New("provider error")
+
+	now := time.Now()
+	assert.True(t, ctrl.handleRunResult(now, err))
+	assert.NoError(t, ctrl.Healthy())
+	assert.True(t, ctrl.handleRunResult(now, err))
+	assert.EqualError(t, ctrl.Healthy(), "the last 2 runs failed")
+	assert.True(t, ctrl.handleRunResult(now, nil))
+	assert.NoError(t, ctrl.Healthy())
+
+	// disabled
+	ctrl.CircuitBreakerThreshold = 0
+	for i := 0; i < 10; i++ {
+		assert.True(t, ctrl.handleRunResult(now, err))
+	}
+	assert.NoError(t, ctrl.Healthy())
+}
+
+// resultsMockRegistry records the applied changes and fails the changes of the failing names.
+type resultsMockRegistry struct {
+	registry.Registry
+	failing map[string]bool
+	applied []*plan.Changes
+}
+
+func (r *resultsMockRegistry) ApplyChangesWithResults(ctx context.Context, changes *plan.Changes) ([]provider.ChangeResult, error) {
+	r.applied = append(r.applied, changes)
+	var results []provider.ChangeResult
+	for _, endpoints := range [][]*endpoint.Endpoint{changes.Create, changes.UpdateNew, changes.Delete} {
+		for _, ep := range endpoints {
+			result := provider.ChangeResult{Endpoint: ep}
+			if r.failing[ep.DNSName] {
+				result.Err = provider.NewInvalidRecordError(errors.New("invalid"))
+			}
+			results = append(results, result)
+		}
+	}
+	return results, nil
+}
+
+func TestQuarantine(t *testing.T) {
+	r := &resultsMockRegistry{failing: map[string]bool{"bad.org": true}}
+	ctrl := &Controller{Name: "quarantine", Registry: r, Interval: time.Minute, Backoff: 10 * time.Second, MaxBackoff: time.Minute}
+	good := endpoint.NewEndpoint("good.org", endpoint.RecordTypeA, "1.1.1.1")
+	bad := endpoint.NewEndpoint("bad.org", endpoint.RecordTypeA, "1.1.1.1")
+	badOld := endpoint.NewEndpoint("bad.org", endpoint.RecordTypeA, "2.2.2.2")
+	changes := &plan.Changes{
+		Create:    []*endpoint.Endpoint{good},
+		UpdateOld: []*endpoint.Endpoint{badOld},
+		UpdateNew: []*endpoint.Endpoint{bad},
+	}
+
+	// the failed change does not fail the run
+	now := time.Now()
+	applied, err := ctrl.applyChanges(context.Background(), now, changes)
+	require.NoError(t, err)
+	assert.Equal(t, changes, r.applied[0])
+	assert.Equal(t, &plan.Changes{Create: []*endpoint.Endpoint{good}}, applied)
+	assert.Equal(t, float64(1), testutil.ToFloat64(quarantinedEndpoints.WithLabelValues("quarantine")))
+
+	// the quarantined endpoint is skipped during its backoff
+	now = now.Add(5 * time.Second)
+	_, err = ctrl.applyChanges(context.Background(), now, changes)
+	require.NoError(t, err)
+	assert.Equal(t, &plan.Changes{Create: []*endpoint.Endpoint{good}}, r.applied[1])
+
+	// and retried after it, with a doubled backoff when it fails again
+	now = now.Add(10 * time.Second)
+	_, err = ctrl.applyChanges(context.Background(), now, changes)
+	require.NoError(t, err)
+	assert.Equal(t, changes, r.applied[2])
+	_, err = ctrl.applyChanges(context.Background(), now.Add(15*time.Second), changes)
+	require.NoError(t, err)
+	assert.Equal(t, &plan.Changes{Create: []*endpoint.Endpoint{good}}, r.applied[3])
+
+	// a new desired state releases the endpoint
+	fixed := endpoint.NewEndpoint("bad.org", endpoint.RecordTypeA, "3.3.3.3")
+	changes.UpdateNew = []*endpoint.Endpoint{fixed}
+	r.failing = map[string]bool{}
+	applied, err = ctrl.applyChanges(context.Background(), now.Add(15*time.Second), changes)
+	require.NoError(t, err)
+	assert.Equal(t, changes, r.applied[4])
+	// the applied update keeps its old endpoint
+	assert.Equal(t, changes, applied)
+	assert.Equal(t, float64(0), testutil.ToFloat64(quarantinedEndpoints.WithLabelValues("quarantine")))
+
+	// an endpoint whose change is no longer planned is released
+	r.failing = map[string]bool{"bad.org": true}
+	_, err = ctrl.applyChanges(context.Background(), now, changes)
+	require.NoError(t, err)
+	assert.Equal(t, float64(1), testutil.ToFloat64(quarantinedEndpoints.WithLabelValues("quarantine")))
+	_, err = ctrl.applyChanges(context.Background(), now, &plan.Changes{Create: []*endpoint.Endpoint{good}})
+	require.NoError(t, err)
+	assert.Equal(t, float64(0), testutil.ToFloat64(quarantinedEndpoints.WithLabelValues("quarantine")))
+}
+
+func TestPropagationSettle(t *testing.T) {
+	resourceEndpoint := func(name, resource string) *endpoint.Endpoint {
+		ep := endpoint.NewEndpoint(name, endpoint.RecordTypeA, "1.1.1.1")
+		ep.Labels[endpoint.ResourceLabelKey] = resource
+		return ep
+	}
+	applied := resourceEndpoint("applied.org", "ingress/default/applied")
+	failed := resourceEndpoint("failed.org", "ingress/default/failed")
+	start := time.Now()
+	p := &propagation{}
+	p.observe("ingress/default/applied", start.Add(-10*time.Second))
+	p.observe("ingress/default/applied", start.Add(-5*time.Second))
+	p.observe("ingress/default/failed", start.Add(-5*time.Second))
+	p.observe("ingress/default/unchanged", start.Add(-5*time.Second))
+	p.observe("ingress/default/later", start.Add(time.Second))
+
+	delays := p.settle(start, start.Add(2*time.Second), &plan.Changes{Create: []*endpoint.Endpoint{applied, failed}}, &plan.Changes{Create: []*endpoint.Endpoint{applied}})
+
+	// the delay is measured from the first change
+	assert.Equal(t, map[string]time.Duration{"ingress/default/applied": 12 * time.Second}, delays)
+	assert.Equal(t, map[string]time.Time{
+		"ingress/default/failed": start.Add(-5 * time.Second),
+		"ingress/default/later":  start.Add(time.Second),
+	}, p.observed)
+}
+
+type recordingAnnotator struct {
+	annotations map[string]map[string]string
+}
+
+func (a *recordingAnnotator) Annotate(ctx context.Context, resource string, annotations map[string]string) error {
+	a.annotations[resource] = annotations
+	return nil
+}
+
+func TestRunOncePropagation(t *testing.T) {
+	ep := endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeA, "1.1.1.1")
+	ep.Labels[endpoint.ResourceLabelKey] = "ingress/default/foo"
+	mockSource := new(testutils.MockSource)
+	mockSource.On("Endpoints").Return([]*endpoint.Endpoint{ep}, nil)
+	r, err := registry.NewNoopRegistry(&filteredMockProvider{})
+	require.NoError(t, err)
+	annotator := &recordingAnnotator{annotations: map[string]map[string]string{}}
+	series := testutil.CollectAndCount(propagationDuration)
+
+	ctrl := &Controller{
+		Source:             mockSource,
+		Registry:           r,
+		Policy:             &plan.SyncPolicy{},
+		ManagedRecordTypes: []string{endpoint.RecordTypeA},
+		SyncedAtAnnotator:  annotator,
+		Name:               "propagation",
+	}
+	ctrl.ObserveChange("ingress/default/foo")
+	require.NoError(t, ctrl.RunOnce(context.Background()))
+
+	assert.Equal(t, series+1, testutil.CollectAndCount(propagationDuration))
+	assert.Empty(t, ctrl.propagation.observed)
+	require.Contains(t, annotator.annotations, "ingress/default/foo")
+	_, err = time.Parse(time.RFC3339, annotator.annotations["ingress/default/foo"][source.SyncedAtAnnotationKey])
+	assert.NoError(t, err)
+}
+
 func testControllerFiltersDomains(t *testing.T, configuredEndpoints []*endpoint.Endpoint, domainFilter endpoint.DomainFilter, providerEndpoints []*endpoint.Endpoint, expectedChanges []*plan.Changes) {
 	t.Helper()
 	cfg := externaldns.NewConfig()
@@ -513,7 +1044,7 @@ func TestVerifyARecords(t *testing.T) {
 		},
 		[]*plan.Changes{},
 	)
-	assert.Equal(t, math.Float64bits(2), valueFromMetric(verifiedARecords))
+	assert.Equal(t, math.Float64bits(2), valueFromMetric(verifiedARecords.WithLabelValues("")))
 
 	testControllerFiltersDomains(
 		t,
@@ -557,8 +1088,8 @@ func TestVerifyARecords(t *testing.T) {
 			},
 		}},
 	)
-	assert.Equal(t, math.Float64bits(2), valueFromMetric(verifiedARecords))
-	assert.Equal(t, math.Float64bits(0), valueFromMetric(verifiedAAAARecords))
+	assert.Equal(t, math.Float64bits(2), valueFromMetric(verifiedARecords.WithLabelValues("")))
+	assert.Equal(t, math.Float64bits(0), valueFromMetric(verifiedAAAARecords.WithLabelValues("")))
 }
 
 func TestVerifyAAAARecords(t *testing.T) {
@@ -591,7 +1122,7 @@ func TestVerifyAAAARecords(t *testing.T) {
 		},
 		[]*plan.Changes{},
 	)
-	assert.Equal(t, math.Float64bits(2), valueFromMetric(verifiedAAAARecords))
+	assert.Equal(t, math.Float64bits(2), valueFromMetric(verifiedAAAARecords.WithLabelValues("")))
 
 	testControllerFiltersDomains(
 		t,
@@ -635,8 +1166,8 @@ func TestVerifyAAAARecords(t *testing.T) {
 			},
 		}},
 	)
-	assert.Equal(t, math.Float64bits(0), valueFromMetric(verifiedARecords))
-	assert.Equal(t, math.Float64bits(2), valueFromMetric(verifiedAAAARecords))
+	assert.Equal(t, math.Float64bits(0), valueFromMetric(verifiedARecords.WithLabelValues("")))
+	assert.Equal(t, math.Float64bits(2), valueFromMetric(verifiedAAAARecords.WithLabelValues("")))
 }
 
 func TestARecords(t *testing.T) {
@@ -682,8 +1213,8 @@ func TestARecords(t *testing.T) {
 			},
 		}},
 	)
-	assert.Equal(t, math.Float64bits(2), valueFromMetric(sourceARecords))
-	assert.Equal(t, math.Float64bits(1), valueFromMetric(registryARecords))
+	assert.Equal(t, math.Float64bits(2), valueFromMetric(sourceARecords.WithLabelValues("")))
+	assert.Equal(t, math.Float64bits(1), valueFromMetric(registryARecords.WithLabelValues("")))
 }
 
 func TestAAAARecords(t *testing.T) {
@@ -729,6 +1260,6 @@ func TestAAAARecords(t *testing.T) {
 			},
 		}},
 	)
-	assert.Equal(t, math.Float64bits(2), valueFromMetric(sourceAAAARecords))
-	assert.Equal(t, math.Float64bits(1), valueFromMetric(registryAAAARecords))
+	assert.Equal(t, math.Float64bits(2), valueFromMetric(sourceAAAARecords.WithLabelValues("")))
+	assert.Equal(t, math.Float64bits(1), valueFromMetric(registryAAAARecords.WithLabelValues("")))
 }
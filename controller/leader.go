@@ -0,0 +1,179 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+// Default timings of the leader election, the ones of the Kubernetes controllers
+const (
+	defaultLeaseDuration = 15 * time.Second
+	defaultRenewDeadline = 10 * time.Second
+	defaultRetryPeriod   = 2 * time.Second
+)
+
+// LeaderElection elects the replica running the controllers of the process with a Lease. The
+// controllers of the other replicas wait, with their sources kept in sync, until they acquire it.
+type LeaderElection struct {
+	// Client creates and renews the Lease
+	Client kubernetes.Interface
+	// LeaseName and LeaseNamespace locate the Lease
+	LeaseName      string
+	LeaseNamespace string
+	// Identity is the holder identity of the replica in the Lease
+	Identity string
+	// LeaseDuration, RenewDeadline and RetryPeriod are the timings of the election, the defaults when zero
+	LeaseDuration time.Duration
+	RenewDeadline time.Duration
+	RetryPeriod   time.Duration
+
+	mu sync.Mutex
+	// leading is done when the leadership is lost, nil before it is first acquired
+	leading context.Context
+	// acquired is closed when the leadership is acquired
+	acquired chan struct{}
+}
+
+// Run takes part in the election until ctx is done, running again after each loss of the leadership.
+// The Lease is released when ctx is done, so that another replica takes over without waiting for it
+// to expire.
+func (le *LeaderElection) Run(ctx context.Context) error {
+	lock := &resourcelock.LeaseLock{
+		LeaseMeta: metav1.ObjectMeta{
+			Name:      le.LeaseName,
+			Namespace: le.LeaseNamespace,
+		},
+		Client:     le.Client.CoordinationV1(),
+		LockConfig: resourcelock.ResourceLockConfig{Identity: le.Identity},
+	}
+	logger := log.WithField("lease", le.LeaseNamespace+"/"+le.LeaseName)
+	for ctx.Err() == nil {
+		elector, err := leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+			Lock:            lock,
+			Name:            le.LeaseName,
+			LeaseDuration:   durationOrDefault(le.LeaseDuration, defaultLeaseDuration),
+			RenewDeadline:   durationOrDefault(le.RenewDeadline, defaultRenewDeadline),
+			RetryPeriod:     durationOrDefault(le.RetryPeriod, defaultRetryPeriod),
+			ReleaseOnCancel: true,
+			Callbacks: leaderelection.LeaderCallbacks{
+				OnStartedLeading: func(leading context.Context) {
+					logger.Infof("Acquired the leadership as %s", le.Identity)
+					le.lead(leading)
+				},
+				OnStoppedLeading: func() {
+					logger.Infof("Not leading as %s", le.Identity)
+				},
+				OnNewLeader: func(identity string) {
+					if identity != le.Identity {
+						logger.Infof("The leader is %s", identity)
+					}
+				},
+			},
+		})
+		if err != nil {
+			return err
+		}
+		elector.Run(ctx)
+	}
+	return nil
+}
+
+// lead records the acquired leadership, which lasts until leading is done, and releases the
+// controllers waiting for it
+func (le *LeaderElection) lead(leading context.Context) {
+	le.mu.Lock()
+	defer le.mu.Unlock()
+	le.leading = leading
+	if le.acquired != nil {
+		close(le.acquired)
+		le.acquired = nil
+	}
+}
+
+// leadership waits for the leadership and returns the context which is done when it is lost, or
+// false when ctx is done first.
+func (le *LeaderElection) leadership(ctx context.Context) (context.Context, bool) {
+	for {
+		le.mu.Lock()
+		leading := le.leading
+		if leading != nil && leading.Err() == nil {
+			le.mu.Unlock()
+			return leading, true
+		}
+		if le.acquired == nil {
+			le.acquired = make(chan struct{})
+		}
+		acquired := le.acquired
+		le.mu.Unlock()
+
+		select {
+		case <-acquired:
+		case <-ctx.Done():
+			return nil, false
+		}
+	}
+}
+
+// RunLeading runs the controller while the process holds the leadership of the election, until ctx is
+// done. A run is scheduled right after each acquisition, so that the records are synchronized as soon
+// as the controller takes over from a failed leader. Without an election, it is the same as Run.
+func (c *Controller) RunLeading(ctx context.Context, election *LeaderElection) {
+	if election == nil {
+		c.ScheduleRunOnce(time.Now())
+		c.Run(ctx)
+		return
+	}
+	for {
+		leading, ok := election.leadership(ctx)
+		if !ok {
+			return
+		}
+		c.takeOver(time.Now())
+		c.Run(leading)
+		if leading.Err() == nil {
+			// the loop stopped on its own, see handleRunResult
+			return
+		}
+	}
+}
+
+// takeOver schedules a run right away after acquiring the leadership. The backoff of the runs which
+// failed while leading before is reset, as the failure may have been the reason the leadership was lost.
+func (c *Controller) takeOver(now time.Time) {
+	c.nextRunAtMux.Lock()
+	defer c.nextRunAtMux.Unlock()
+	c.failures = 0
+	c.backoffUntil = time.Time{}
+	c.nextRunAt = now
+	consecutiveFailures.WithLabelValues(c.Name).Set(0)
+}
+
+func durationOrDefault(d, def time.Duration) time.Duration {
+	if d <= 0 {
+		return def
+	}
+	return d
+}
@@ -0,0 +1,212 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/plan"
+	"sigs.k8s.io/external-dns/registry"
+)
+
+func newTestElection(client kubernetes.Interface, identity string) *LeaderElection {
+	return &LeaderElection{
+		Client:         client,
+		LeaseName:      "external-dns",
+		LeaseNamespace: "default",
+		Identity:       identity,
+		LeaseDuration:  time.Second,
+		RenewDeadline:  500 * time.Millisecond,
+		RetryPeriod:    100 * time.Millisecond,
+	}
+}
+
+// waitLeadership waits for the leadership for at most timeout
+func waitLeadership(election *LeaderElection, timeout time.Duration) (context.Context, bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return election.leadership(ctx)
+}
+
+func leaseHolder(t *testing.T, client kubernetes.Interface) string {
+	lease, err := client.CoordinationV1().Leases("default").Get(context.Background(), "external-dns", metav1.GetOptions{})
+	require.NoError(t, err)
+	if lease.Spec.HolderIdentity == nil {
+		return ""
+	}
+	return *lease.Spec.HolderIdentity
+}
+
+func TestLeaderElection(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	first := newTestElection(client, "first")
+	second := newTestElection(client, "second")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	firstCtx, stopFirst := context.WithCancel(ctx)
+	firstStopped := make(chan struct{})
+	go func() {
+		assert.NoError(t, first.Run(firstCtx))
+		close(firstStopped)
+	}()
+	firstLeading, ok := waitLeadership(first, 5*time.Second)
+	require.True(t, ok)
+	assert.Equal(t, "first", leaseHolder(t, client))
+
+	// the second replica waits while the first one renews the lease
+	go func() {
+		assert.NoError(t, second.Run(ctx))
+	}()
+	_, ok = waitLeadership(second, 1500*time.Millisecond)
+	assert.False(t, ok)
+
+	// the second replica takes over the released lease
+	stopFirst()
+	<-firstStopped
+	assert.Error(t, firstLeading.Err())
+	_, ok = waitLeadership(second, 5*time.Second)
+	require.True(t, ok)
+	assert.Equal(t, "second", leaseHolder(t, client))
+}
+
+// countingSource counts the reads of its endpoints, which fail with err when set
+type countingSource struct {
+	reads atomic.Int32
+	err   error
+}
+
+func (s *countingSource) Endpoints(context.Context) ([]*endpoint.Endpoint, error) {
+	s.reads.Add(1)
+	return []*endpoint.Endpoint{}, s.err
+}
+
+func (s *countingSource) AddEventHandler(context.Context, func()) {}
+
+func TestRunLeading(t *testing.T) {
+	source := &countingSource{}
+	r, err := registry.NewNoopRegistry(&filteredMockProvider{})
+	require.NoError(t, err)
+	ctrl := &Controller{
+		Source:             source,
+		Registry:           r,
+		Policy:             &plan.SyncPolicy{},
+		ManagedRecordTypes: []string{endpoint.RecordTypeA},
+		Interval:           time.Hour,
+	}
+	election := &LeaderElection{}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		ctrl.RunLeading(ctx, election)
+		close(stopped)
+	}()
+
+	// the controller does not run before the leadership is acquired
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, int32(0), source.reads.Load())
+
+	// it runs as soon as the leadership is acquired, and stops when it is lost
+	leading, lose := context.WithCancel(ctx)
+	election.lead(leading)
+	require.Eventually(t, func() bool { return source.reads.Load() == 1 }, 5*time.Second, 10*time.Millisecond)
+	lose()
+
+	// it runs again right after taking over, without waiting for the interval
+	election.lead(ctx)
+	require.Eventually(t, func() bool { return source.reads.Load() == 2 }, 5*time.Second, 10*time.Millisecond)
+
+	cancel()
+	<-stopped
+}
+
+func TestRunLeadingBackoff(t *testing.T) {
+	source := &countingSource{err: errors.New("source failure")}
+	r, err := registry.NewNoopRegistry(&filteredMockProvider{})
+	require.NoError(t, err)
+	ctrl := &Controller{
+		Source:             source,
+		Registry:           r,
+		Policy:             &plan.SyncPolicy{},
+		ManagedRecordTypes: []string{endpoint.RecordTypeA},
+		Interval:           time.Hour,
+		Backoff:            time.Hour,
+		MaxBackoff:         time.Hour,
+	}
+	election := &LeaderElection{}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		ctrl.RunLeading(ctx, election)
+		close(stopped)
+	}()
+
+	// the run fails and is retried after the backoff
+	leading, lose := context.WithCancel(ctx)
+	election.lead(leading)
+	require.Eventually(t, func() bool { return source.reads.Load() == 1 }, 5*time.Second, 10*time.Millisecond)
+	require.Eventually(t, func() bool {
+		ctrl.nextRunAtMux.Lock()
+		defer ctrl.nextRunAtMux.Unlock()
+		return ctrl.failures == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	lose()
+
+	// taking over again runs right away, without waiting for the backoff
+	election.lead(ctx)
+	require.Eventually(t, func() bool { return source.reads.Load() == 2 }, 5*time.Second, 10*time.Millisecond)
+
+	cancel()
+	<-stopped
+}
+
+func TestRunLeadingWithoutElection(t *testing.T) {
+	source := &countingSource{}
+	r, err := registry.NewNoopRegistry(&filteredMockProvider{})
+	require.NoError(t, err)
+	ctrl := &Controller{
+		Source:             source,
+		Registry:           r,
+		Policy:             &plan.SyncPolicy{},
+		ManagedRecordTypes: []string{endpoint.RecordTypeA},
+		Interval:           time.Hour,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		ctrl.RunLeading(ctx, nil)
+		close(stopped)
+	}()
+	require.Eventually(t, func() bool { return source.reads.Load() == 1 }, 5*time.Second, 10*time.Millisecond)
+
+	cancel()
+	<-stopped
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"sync"
+	"time"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/plan"
+)
+
+// propagation holds when the change of each resource was first observed, until its records are applied
+type propagation struct {
+	mu       sync.Mutex
+	observed map[string]time.Time
+}
+
+// observe records the change of the resource, unless an earlier change is not applied yet
+func (p *propagation) observe(resource string, now time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.observed == nil {
+		p.observed = map[string]time.Time{}
+	}
+	if _, ok := p.observed[resource]; !ok {
+		p.observed[resource] = now
+	}
+}
+
+// settle returns the propagation delays of the resources whose changes, observed before the run
+// started at start, are applied. The changes of the resources without planned changes are forgotten,
+// since they did not change any record, while the ones of the failed changes are kept for the next run.
+func (p *propagation) settle(start, now time.Time, planned, applied *plan.Changes) map[string]time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	plannedResources := changedResources(planned)
+	appliedResources := changedResources(applied)
+	delays := map[string]time.Duration{}
+	for resource, observed := range p.observed {
+		if observed.After(start) {
+			continue
+		}
+		if appliedResources[resource] {
+			delays[resource] = now.Sub(observed)
+			delete(p.observed, resource)
+		} else if !plannedResources[resource] {
+			delete(p.observed, resource)
+		}
+	}
+	return delays
+}
+
+// changedResources returns the resources of the changed endpoints
+func changedResources(changes *plan.Changes) map[string]bool {
+	resources := map[string]bool{}
+	if changes == nil {
+		return resources
+	}
+	for _, endpoints := range [][]*endpoint.Endpoint{changes.Create, changes.UpdateNew, changes.Delete} {
+		for _, ep := range endpoints {
+			if resource := ep.Labels[endpoint.ResourceLabelKey]; resource != "" {
+				resources[resource] = true
+			}
+		}
+	}
+	return resources
+}
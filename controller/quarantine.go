@@ -0,0 +1,103 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"time"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/plan"
+)
+
+// quarantinedEndpoint is an endpoint whose change failed
+type quarantinedEndpoint struct {
+	endpoint *endpoint.Endpoint
+	failures int
+	until    time.Time
+}
+
+// quarantine holds the endpoints whose changes failed, so that they are not retried before their
+// backoff while the changes of the other endpoints are applied
+type quarantine map[endpoint.EndpointKey]*quarantinedEndpoint
+
+// add quarantines the endpoint after a failed change, for the backoff of its consecutive failures,
+// and returns the backoff
+func (q quarantine) add(ep *endpoint.Endpoint, now time.Time, backoff func(failures int) time.Duration) time.Duration {
+	entry, ok := q[ep.Key()]
+	if !ok {
+		entry = &quarantinedEndpoint{}
+		q[ep.Key()] = entry
+	}
+	entry.endpoint = ep
+	entry.failures++
+	delay := backoff(entry.failures)
+	entry.until = now.Add(delay)
+	return delay
+}
+
+// release removes the endpoint from the quarantine
+func (q quarantine) release(ep *endpoint.Endpoint) {
+	delete(q, ep.Key())
+}
+
+// filter returns the changes without the changes of the quarantined endpoints. An endpoint is
+// released when its change is no longer planned, or when its desired state changed since the
+// change failed, since the new change may succeed.
+func (q quarantine) filter(now time.Time, changes *plan.Changes) *plan.Changes {
+	planned := map[endpoint.EndpointKey]bool{}
+	held := func(ep *endpoint.Endpoint) bool {
+		key := ep.Key()
+		planned[key] = true
+		entry, ok := q[key]
+		if !ok {
+			return false
+		}
+		if !entry.endpoint.Targets.Same(ep.Targets) || entry.endpoint.RecordTTL != ep.RecordTTL {
+			delete(q, key)
+			return false
+		}
+		return now.Before(entry.until)
+	}
+
+	filtered := &plan.Changes{}
+	for _, ep := range changes.Create {
+		if !held(ep) {
+			filtered.Create = append(filtered.Create, ep)
+		}
+	}
+	for i, ep := range changes.UpdateNew {
+		if held(ep) {
+			continue
+		}
+		filtered.UpdateNew = append(filtered.UpdateNew, ep)
+		if i < len(changes.UpdateOld) {
+			filtered.UpdateOld = append(filtered.UpdateOld, changes.UpdateOld[i])
+		}
+	}
+	for _, ep := range changes.Delete {
+		if !held(ep) {
+			filtered.Delete = append(filtered.Delete, ep)
+		}
+	}
+
+	for key := range q {
+		if !planned[key] {
+			delete(q, key)
+		}
+	}
+	return filtered
+}
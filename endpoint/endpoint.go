@@ -316,6 +316,23 @@ func (e *Endpoint) IsOwnedBy(ownerID string) bool {
 	return ok && endpointOwner == ownerID
 }
 
+// IsSharedBy returns true if the given ownerID is one of the owners sharing the endpoint, false otherwise
+func (e *Endpoint) IsSharedBy(ownerID string) bool {
+	_, ok := e.SharedOwners()[ownerID]
+	return ok
+}
+
+// IsShared returns true if the endpoint is shared by several owners, see SharedOwnersLabelKey
+func (e *Endpoint) IsShared() bool {
+	_, ok := e.Labels[SharedOwnersLabelKey]
+	return ok
+}
+
+// SharedOwners returns the owners sharing the endpoint and their targets, empty if it is not shared
+func (e *Endpoint) SharedOwners() SharedOwners {
+	return NewSharedOwnersFromString(e.Labels[SharedOwnersLabelKey])
+}
+
 func (e *Endpoint) String() string {
 	return fmt.Sprintf("%s %d IN %s %s %s %s", e.DNSName, e.RecordTTL, e.RecordType, e.SetIdentifier, e.Targets, e.ProviderSpecific)
 }
@@ -335,6 +352,21 @@ func FilterEndpointsByOwnerID(ownerID string, eps []*Endpoint) []*Endpoint {
 	return filtered
 }
 
+// FilterEndpointsBySharedOwnerID is FilterEndpointsByOwnerID which also keeps the shared endpoints of
+// other owners, that the given ownerID may join or leave.
+func FilterEndpointsBySharedOwnerID(ownerID string, eps []*Endpoint) []*Endpoint {
+	filtered := []*Endpoint{}
+	for _, ep := range eps {
+		if !ep.IsOwnedBy(ownerID) && !ep.IsShared() {
+			log.Debugf(`Skipping endpoint %v because owner id does not match and it is not shared, found: "%s", required: "%s"`, ep, ep.Labels[OwnerLabelKey], ownerID)
+		} else {
+			filtered = append(filtered, ep)
+		}
+	}
+
+	return filtered
+}
+
 // DNSEndpointSpec defines the desired state of DNSEndpoint
 type DNSEndpointSpec struct {
 	Endpoints []*Endpoint `json:"endpoints,omitempty"`
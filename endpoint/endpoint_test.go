@@ -296,6 +296,12 @@ func TestIsOwnedBy(t *testing.T) {
 			args:   args{ownerID: "foo"},
 			want:   true,
 		},
+		{
+			name:   "shared owners match",
+			fields: fields{Labels: Labels{OwnerLabelKey: "bar", SharedOwnersLabelKey: SharedOwners{"bar": {"1.1.1.1"}, "foo": {"2.2.2.2"}}.String()}},
+			args:   args{ownerID: "foo"},
+			want:   false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -44,6 +44,14 @@ const (
 	// DualstackLabelKey is the name of the label that identifies dualstack endpoints
 	DualstackLabelKey = "dualstack"
 
+	// ResourceCreatedLabelKey is the name of the label that holds the creation timestamp of the k8s resource, in RFC 3339 format
+	ResourceCreatedLabelKey = "resource-created"
+	// ResourcePriorityLabelKey is the name of the label that holds the priority of the k8s resource to acquire the DNS name
+	ResourcePriorityLabelKey = "resource-priority"
+
+	// SharedOwnersLabelKey is the name of the label that holds the owners sharing a record and their targets
+	SharedOwnersLabelKey = "shared-owners"
+
 	// txtEncryptionNonce label for keep same nonce for same txt records, for prevent different result of encryption for same txt record, it can cause issues for some providers
 	txtEncryptionNonce = "txt-encryption-nonce"
 )
@@ -92,11 +100,11 @@ func NewLabelsFromStringPlain(labelText string) (Labels, error) {
 
 func NewLabelsFromString(labelText string, aesKey []byte) (Labels, error) {
 	if len(aesKey) != 0 {
-		decryptedText, encryptionNonce, err := DecryptText(strings.Trim(labelText, "\""), aesKey)
+		decryptedText, encryptionNonce, err := DecryptText(joinTXTStrings(labelText), aesKey)
 		//in case if we have decryption error, just try process original text
 		//decryption errors should be ignored here, because we can already have plain-text labels in registry
 		if err == nil {
-			labels, err := NewLabelsFromStringPlain(decryptedText)
+			labels, err := decodeLabels(decryptedText)
 			if err == nil {
 				labels[txtEncryptionNonce] = encryptionNonce
 			}
@@ -104,7 +112,7 @@ func NewLabelsFromString(labelText string, aesKey []byte) (Labels, error) {
 			return labels, err
 		}
 	}
-	return NewLabelsFromStringPlain(labelText)
+	return decodeLabels(labelText)
 }
 
 // SerializePlain transforms endpoints labels into a external-dns recognizable format string
@@ -119,7 +127,7 @@ func (l Labels) SerializePlain(withQuotes bool) string {
 	sort.Strings(keys) // sort for consistency
 
 	for _, key := range keys {
-		if key == txtEncryptionNonce {
+		if !IsStoredLabel(key) {
 			continue
 		}
 		tokens = append(tokens, fmt.Sprintf("%s/%s=%s", heritage, key, l[key]))
@@ -130,12 +138,43 @@ func (l Labels) SerializePlain(withQuotes bool) string {
 	return strings.Join(tokens, ",")
 }
 
+// IsStoredLabel returns false for the labels which are not stored in the registries
+func IsStoredLabel(key string) bool {
+	switch key {
+	case txtEncryptionNonce, labelsFormat:
+		return false
+	case ResourceCreatedLabelKey, ResourcePriorityLabelKey:
+		// they only rank the candidates of the DNS name
+		return false
+	}
+	return true
+}
+
 // Serialize same to SerializePlain, but encrypt data, if encryption enabled
 func (l Labels) Serialize(withQuotes bool, txtEncryptEnabled bool, aesKey []byte) string {
 	if !txtEncryptEnabled {
 		return l.SerializePlain(withQuotes)
 	}
 
+	text := l.encrypt(l.SerializePlain(false), aesKey)
+	if withQuotes {
+		text = fmt.Sprintf("\"%s\"", text)
+	}
+	log.Debugf("Serialized text after encryption is %#v.", text)
+	return text
+}
+
+// SerializeWith returns the quoted TXT strings of the labels encoded with the codec, encrypted if encryption enabled
+func (l Labels) SerializeWith(codec LabelsCodec, txtEncryptEnabled bool, aesKey []byte) string {
+	text := codec.Encode(l)
+	if txtEncryptEnabled {
+		text = l.encrypt(text, aesKey)
+	}
+	return codec.Quote(text)
+}
+
+// encrypt encrypts the text with the nonce of the labels, which is generated if they have none
+func (l Labels) encrypt(text string, aesKey []byte) string {
 	var encryptionNonce []byte
 	if extractedNonce, nonceExists := l[txtEncryptionNonce]; nonceExists {
 		encryptionNonce = []byte(extractedNonce)
@@ -148,18 +187,10 @@ func (l Labels) Serialize(withQuotes bool, txtEncryptEnabled bool, aesKey []byte
 		l[txtEncryptionNonce] = string(encryptionNonce)
 	}
 
-	text := l.SerializePlain(false)
 	log.Debugf("Encrypt the serialized text %#v before returning it.", text)
-	var err error
-	text, err = EncryptText(text, aesKey, encryptionNonce)
-
+	encrypted, err := EncryptText(text, aesKey, encryptionNonce)
 	if err != nil {
 		log.Fatalf("Failed to encrypt the text %#v using the encryption key %#v. Got error %#v.", text, aesKey, err)
 	}
-
-	if withQuotes {
-		text = fmt.Sprintf("\"%s\"", text)
-	}
-	log.Debugf("Serialized text after encryption is %#v.", text)
-	return text
+	return encrypted
 }
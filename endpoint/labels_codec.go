@@ -0,0 +1,162 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package endpoint
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Formats of the labels in the TXT records
+const (
+	// LabelsFormatV1 is the comma separated key=value format: heritage=external-dns,external-dns/owner=...
+	LabelsFormatV1 = "v1"
+	// LabelsFormatV2 is the base64 encoded JSON format: external-dns/v2:eyJvd25lciI6Ii4uLiJ9, split across
+	// several TXT strings when longer than one
+	LabelsFormatV2 = "v2"
+)
+
+const (
+	// labelsFormat is the label holding the format the labels were read in, when it is not LabelsFormatV1
+	labelsFormat = "labels-format"
+	// labelsV2Prefix identifies the labels in the LabelsFormatV2 format
+	labelsV2Prefix = heritage + "/" + LabelsFormatV2 + ":"
+	// txtStringMaxLength is the maximum length of a TXT string, see RFC 1035 3.3.14
+	txtStringMaxLength = 255
+)
+
+// LabelsCodec encodes the labels into the text of the TXT records, and decodes them back
+type LabelsCodec interface {
+	// Format identifies the codec, e.g. LabelsFormatV1
+	Format() string
+	// Encode returns the text of the labels, without the labels which are not stored
+	Encode(labels Labels) string
+	// Decode returns the labels of the text, ErrInvalidHeritage if it is not in the format of the codec
+	Decode(text string) (Labels, error)
+	// Quote returns the quoted TXT strings of the text, which may be encrypted
+	Quote(text string) string
+}
+
+// NewLabelsCodec returns the codec of the given format
+func NewLabelsCodec(format string) (LabelsCodec, error) {
+	switch format {
+	case LabelsFormatV1:
+		return labelsCodecV1{}, nil
+	case LabelsFormatV2:
+		return labelsCodecV2{}, nil
+	}
+	return nil, fmt.Errorf("unknown labels format %q", format)
+}
+
+// labelsCodecs are the codecs the labels are decoded with, in order
+var labelsCodecs = []LabelsCodec{labelsCodecV2{}, labelsCodecV1{}}
+
+// decodeLabels decodes the labels with the first codec of their format
+func decodeLabels(text string) (Labels, error) {
+	for _, codec := range labelsCodecs {
+		labels, err := codec.Decode(text)
+		if err == ErrInvalidHeritage {
+			continue
+		}
+		if err == nil && codec.Format() != LabelsFormatV1 {
+			labels[labelsFormat] = codec.Format()
+		}
+		return labels, err
+	}
+	return nil, ErrInvalidHeritage
+}
+
+// joinTXTStrings returns the text of the TXT strings, e.g. `"foo" "bar"` is foobar
+func joinTXTStrings(text string) string {
+	return strings.ReplaceAll(strings.Trim(text, "\""), `" "`, "")
+}
+
+// Format returns the format the labels were read in
+func (l Labels) Format() string {
+	if format, ok := l[labelsFormat]; ok {
+		return format
+	}
+	return LabelsFormatV1
+}
+
+type labelsCodecV1 struct{}
+
+func (labelsCodecV1) Format() string {
+	return LabelsFormatV1
+}
+
+func (labelsCodecV1) Encode(labels Labels) string {
+	return labels.SerializePlain(false)
+}
+
+func (labelsCodecV1) Decode(text string) (Labels, error) {
+	return NewLabelsFromStringPlain(text)
+}
+
+func (labelsCodecV1) Quote(text string) string {
+	return fmt.Sprintf("\"%s\"", text)
+}
+
+type labelsCodecV2 struct{}
+
+func (labelsCodecV2) Format() string {
+	return LabelsFormatV2
+}
+
+func (labelsCodecV2) Encode(labels Labels) string {
+	stored := map[string]string{}
+	for key, value := range labels {
+		if IsStoredLabel(key) {
+			stored[key] = value
+		}
+	}
+	// the keys of a map are sorted, for consistency
+	data, err := json.Marshal(stored)
+	if err != nil {
+		// a map of strings is always encoded
+		panic(err)
+	}
+	return labelsV2Prefix + base64.StdEncoding.EncodeToString(data)
+}
+
+func (labelsCodecV2) Decode(text string) (Labels, error) {
+	encoded, ok := strings.CutPrefix(joinTXTStrings(text), labelsV2Prefix)
+	if !ok {
+		return nil, ErrInvalidHeritage
+	}
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode the %s labels: %w", LabelsFormatV2, err)
+	}
+	labels := NewLabels()
+	if err := json.Unmarshal(data, &labels); err != nil {
+		return nil, fmt.Errorf("failed to decode the %s labels: %w", LabelsFormatV2, err)
+	}
+	return labels, nil
+}
+
+func (labelsCodecV2) Quote(text string) string {
+	var tokens []string
+	for len(text) > txtStringMaxLength {
+		tokens = append(tokens, fmt.Sprintf("\"%s\"", text[:txtStringMaxLength]))
+		text = text[txtStringMaxLength:]
+	}
+	tokens = append(tokens, fmt.Sprintf("\"%s\"", text))
+	return strings.Join(tokens, " ")
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package endpoint
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLabelsCodec(t *testing.T) {
+	labels := Labels{
+		OwnerLabelKey:           "owner,with=separators",
+		ResourceLabelKey:        "ingress/default/" + strings.Repeat("a", 300),
+		ResourceCreatedLabelKey: "2024-01-01T00:00:00Z",
+	}
+
+	v1, err := NewLabelsCodec(LabelsFormatV1)
+	require.NoError(t, err)
+	assert.Equal(t, labels.Serialize(true, false, nil), labels.SerializeWith(v1, false, nil))
+
+	v2, err := NewLabelsCodec(LabelsFormatV2)
+	require.NoError(t, err)
+	text := labels.SerializeWith(v2, false, nil)
+	assert.True(t, strings.HasPrefix(text, `"external-dns/v2:`), text)
+	for _, txt := range strings.Split(text, " ") {
+		// the quoted TXT strings
+		assert.LessOrEqual(t, len(txt), 255+2)
+	}
+
+	decoded, err := NewLabelsFromString(text, nil)
+	require.NoError(t, err)
+	assert.Equal(t, Labels{
+		OwnerLabelKey:    "owner,with=separators",
+		ResourceLabelKey: labels[ResourceLabelKey],
+		labelsFormat:     LabelsFormatV2,
+	}, decoded)
+	assert.Equal(t, LabelsFormatV2, decoded.Format())
+	// the labels are written again as they were read
+	assert.Equal(t, text, decoded.SerializeWith(v2, false, nil))
+
+	// the v1 labels are still read
+	decoded, err = NewLabelsFromString(`"heritage=external-dns,external-dns/owner=foo"`, nil)
+	require.NoError(t, err)
+	assert.Equal(t, Labels{OwnerLabelKey: "foo"}, decoded)
+	assert.Equal(t, LabelsFormatV1, decoded.Format())
+
+	_, err = NewLabelsFromString(`"external-dns/v2:not base64"`, nil)
+	assert.Error(t, err)
+	_, err = NewLabelsFromString(`"external-dns/v1:foo"`, nil)
+	assert.Equal(t, ErrInvalidHeritage, err)
+	_, err = NewLabelsCodec("v3")
+	assert.EqualError(t, err, `unknown labels format "v3"`)
+}
+
+func TestLabelsCodecEncrypted(t *testing.T) {
+	aesKey := []byte(")K_Fy|?Z.64#UuHm`}[d!GC%WJM_fs{_")
+	// the encrypted text is compressed, the resource is made of distinct names so that it stays long
+	var names []string
+	for i := 0; i < 100; i++ {
+		names = append(names, fmt.Sprintf("name-%d", i*7919))
+	}
+	labels := Labels{OwnerLabelKey: "foo", ResourceLabelKey: "ingress/default/" + strings.Join(names, "-")}
+
+	v2, err := NewLabelsCodec(LabelsFormatV2)
+	require.NoError(t, err)
+	text := labels.SerializeWith(v2, true, aesKey)
+	assert.Greater(t, strings.Count(text, `" "`), 0, text)
+
+	decoded, err := NewLabelsFromString(text, aesKey)
+	require.NoError(t, err)
+	assert.Equal(t, labels[ResourceLabelKey], decoded[ResourceLabelKey])
+	assert.Equal(t, LabelsFormatV2, decoded.Format())
+	assert.Equal(t, labels[txtEncryptionNonce], decoded[txtEncryptionNonce])
+}
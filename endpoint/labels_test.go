@@ -72,6 +72,14 @@ func (suite *LabelsSuite) TestSerialize() {
 	suite.NotEqual(suite.fooAsTextWithQuotes, suite.foo.Serialize(true, true, suite.aesKey), "should serializeLabel and encrypt")
 }
 
+func (suite *LabelsSuite) TestSerializeSkipsResourceRanks() {
+	ranked := Labels{ResourceCreatedLabelKey: "2024-01-01T00:00:00Z", ResourcePriorityLabelKey: "10"}
+	for k, v := range suite.foo {
+		ranked[k] = v
+	}
+	suite.Equal(suite.fooAsText, ranked.SerializePlain(false), "should not serialize the ranks of the resource")
+}
+
 func (suite *LabelsSuite) TestEncryptionNonceReUsage() {
 	foo, err := NewLabelsFromString(suite.fooAsTextEncrypted, suite.aesKey)
 	suite.NoError(err, "should succeed for valid label text")
@@ -0,0 +1,101 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package endpoint
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// SharedOwners maps the owners sharing a record to the targets each of them adds to it
+//
+// It is stored in the SharedOwnersLabelKey label as base64 encoded JSON, since the owner IDs and the targets
+// may contain any character, while the labels are serialized as comma separated key=value pairs.
+type SharedOwners map[string]Targets
+
+// NewSharedOwnersFromString parses the value of the SharedOwnersLabelKey label, empty if it is malformed
+func NewSharedOwnersFromString(value string) SharedOwners {
+	owners := SharedOwners{}
+	if value == "" {
+		return owners
+	}
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err == nil {
+		err = json.Unmarshal(data, &owners)
+	}
+	if err != nil {
+		log.Debugf("Ignoring the malformed %s label %q: %v", SharedOwnersLabelKey, value, err)
+		return SharedOwners{}
+	}
+	for owner, targets := range owners {
+		if owner == "" {
+			delete(owners, owner)
+		} else if targets == nil {
+			owners[owner] = Targets{}
+		}
+	}
+	return owners
+}
+
+// String serializes the owners into the value of the SharedOwnersLabelKey label, sorted for consistency
+func (s SharedOwners) String() string {
+	if len(s) == 0 {
+		return ""
+	}
+	sorted := make(map[string]Targets, len(s))
+	for owner, targets := range s {
+		sorted[owner] = append(Targets{}, targets...)
+		sort.Sort(sorted[owner])
+	}
+	// the keys of a map are sorted
+	data, err := json.Marshal(sorted)
+	if err != nil {
+		// a map of strings is always encoded
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// Owners returns the sorted owners
+func (s SharedOwners) Owners() []string {
+	owners := make([]string, 0, len(s))
+	for owner := range s {
+		owners = append(owners, owner)
+	}
+	sort.Strings(owners)
+	return owners
+}
+
+// Targets returns the sorted targets of all the owners, without duplicates
+func (s SharedOwners) Targets() Targets {
+	seen := map[string]bool{}
+	targets := Targets{}
+	for _, owner := range s.Owners() {
+		for _, target := range s[owner] {
+			if !seen[strings.ToLower(target)] {
+				seen[strings.ToLower(target)] = true
+				targets = append(targets, target)
+			}
+		}
+	}
+	sort.Sort(targets)
+	return targets
+}
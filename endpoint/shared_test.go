@@ -0,0 +1,68 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package endpoint
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSharedOwners(t *testing.T) {
+	// the owner IDs and the targets may contain the separators of the labels
+	owners := SharedOwners{
+		"a":         {"1.1.1.1"},
+		"b:cluster": {"2001:db8::1", "1.1.1.1"},
+		"c|d":       {"\"v=spf1 include:a.example.org,b.example.org +all\""},
+	}
+	value := owners.String()
+	assert.NotContains(t, value, ",")
+	assert.NotContains(t, value, "=")
+	assert.Equal(t, SharedOwners{
+		"a":         {"1.1.1.1"},
+		"b:cluster": {"1.1.1.1", "2001:db8::1"},
+		"c|d":       {"\"v=spf1 include:a.example.org,b.example.org +all\""},
+	}, NewSharedOwnersFromString(value))
+	assert.Equal(t, value, NewSharedOwnersFromString(value).String())
+	assert.Equal(t, []string{"a", "b:cluster", "c|d"}, owners.Owners())
+	assert.Equal(t, Targets{"\"v=spf1 include:a.example.org,b.example.org +all\"", "1.1.1.1", "2001:db8::1"}, owners.Targets())
+
+	// the labels survive their serialization
+	labels, err := NewLabelsFromStringPlain(Labels{SharedOwnersLabelKey: value}.SerializePlain(false))
+	assert.NoError(t, err)
+	assert.Equal(t, value, labels[SharedOwnersLabelKey])
+
+	assert.Equal(t, SharedOwners{"c": {}}, NewSharedOwnersFromString(SharedOwners{"c": nil, "": {"1.1.1.1"}}.String()))
+	assert.Empty(t, NewSharedOwnersFromString("malformed"))
+	assert.Empty(t, NewSharedOwnersFromString(""))
+	assert.Equal(t, "", SharedOwners{}.String())
+}
+
+func TestFilterEndpointsBySharedOwnerID(t *testing.T) {
+	owned := NewEndpoint("owned.example.org", RecordTypeA, "1.1.1.1")
+	owned.Labels[OwnerLabelKey] = "foo"
+	shared := NewEndpoint("shared.example.org", RecordTypeA, "1.1.1.1")
+	shared.Labels[OwnerLabelKey] = "bar"
+	shared.Labels[SharedOwnersLabelKey] = SharedOwners{"bar": {"1.1.1.1"}}.String()
+	other := NewEndpoint("other.example.org", RecordTypeA, "1.1.1.1")
+	other.Labels[OwnerLabelKey] = "bar"
+
+	assert.Equal(t, []*Endpoint{owned, shared}, FilterEndpointsBySharedOwnerID("foo", []*Endpoint{owned, shared, other}))
+	assert.Equal(t, []*Endpoint{owned}, FilterEndpointsByOwnerID("foo", []*Endpoint{owned, shared, other}))
+	assert.False(t, shared.IsSharedBy("foo"))
+	assert.True(t, shared.IsSharedBy("bar"))
+}
@@ -0,0 +1,77 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"sigs.k8s.io/external-dns/pkg/apis/externaldns"
+	"sigs.k8s.io/external-dns/plan"
+	"sigs.k8s.io/external-dns/source"
+)
+
+// deleteGuardOverrideAnnotationKey confirms the held deletions when set to "true" on the pod of ExternalDNS
+const deleteGuardOverrideAnnotationKey = "external-dns.alpha.kubernetes.io/delete-guard-override"
+
+// buildGuard returns the delete guard of the config, nil if its limits are disabled
+func buildGuard(ctx context.Context, cfg *externaldns.Config, clientGenerator source.ClientGenerator) *plan.GuardPolicy {
+	if cfg.MaxDeletes <= 0 && cfg.MaxDeletesPercent <= 0 {
+		return nil
+	}
+	return &plan.GuardPolicy{
+		MaxDeletes:        cfg.MaxDeletes,
+		MaxDeletesPercent: cfg.MaxDeletesPercent,
+		Override: func() bool {
+			return cfg.DeleteGuardOverride || podOverridesGuard(ctx, clientGenerator)
+		},
+	}
+}
+
+// podOverridesGuard tells whether the pod of ExternalDNS is annotated to confirm the held deletions.
+// The pod is named after the hostname, and only found when running in a cluster.
+func podOverridesGuard(ctx context.Context, clientGenerator source.ClientGenerator) bool {
+	namespace, err := os.ReadFile(namespaceFile)
+	if err != nil {
+		log.Debugf("Not reading the %s annotation outside of a cluster: %v", deleteGuardOverrideAnnotationKey, err)
+		return false
+	}
+	name, err := os.Hostname()
+	if err != nil {
+		log.Warnf("Failed to read the %s annotation: %v", deleteGuardOverrideAnnotationKey, err)
+		return false
+	}
+	kubeClient, err := clientGenerator.KubeClient()
+	if err != nil {
+		log.Warnf("Failed to read the %s annotation: %v", deleteGuardOverrideAnnotationKey, err)
+		return false
+	}
+	pod, err := kubeClient.CoreV1().Pods(strings.TrimSpace(string(namespace))).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		log.Warnf("Failed to read the %s annotation: %v", deleteGuardOverrideAnnotationKey, err)
+		return false
+	}
+	if pod.Annotations[deleteGuardOverrideAnnotationKey] != "true" {
+		return false
+	}
+	log.Infof("The held deletions are confirmed by the %s annotation of pod %s/%s", deleteGuardOverrideAnnotationKey, pod.Namespace, pod.Name)
+	return true
+}
@@ -0,0 +1,60 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/external-dns/controller"
+	"sigs.k8s.io/external-dns/pkg/apis/externaldns"
+	"sigs.k8s.io/external-dns/source"
+)
+
+// startLeaderElection starts the leader election of the config in the background, and returns nil
+// when it is disabled. The Lease is in the namespace of the pod by default.
+func startLeaderElection(ctx context.Context, cfg *externaldns.Config, clientGenerator source.ClientGenerator) (*controller.LeaderElection, error) {
+	if !cfg.LeaderElect {
+		return nil, nil
+	}
+	kubeClient, err := clientGenerator.KubeClient()
+	if err != nil {
+		return nil, err
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the identity of the leader election: %w", err)
+	}
+
+	election := &controller.LeaderElection{
+		Client:         kubeClient,
+		LeaseName:      cfg.LeaderElectionLeaseName,
+		LeaseNamespace: namespaceOrPod(cfg.LeaderElectionNamespace),
+		// the hostname identifies the pod, the UUID tells apart the processes of a host
+		Identity: hostname + "_" + uuid.NewString(),
+	}
+	go func() {
+		if err := election.Run(ctx); err != nil {
+			log.Fatalf("failed to run the leader election: %v", err)
+		}
+	}()
+	return election, nil
+}
@@ -22,6 +22,7 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 
@@ -40,6 +41,8 @@ import (
 	"sigs.k8s.io/external-dns/endpoint"
 	"sigs.k8s.io/external-dns/pkg/apis/externaldns"
 	"sigs.k8s.io/external-dns/pkg/apis/externaldns/validation"
+	"sigs.k8s.io/external-dns/pkg/audit"
+	"sigs.k8s.io/external-dns/pkg/events"
 	"sigs.k8s.io/external-dns/plan"
 	"sigs.k8s.io/external-dns/provider"
 	"sigs.k8s.io/external-dns/provider/akamai"
@@ -116,9 +119,80 @@ func main() {
 
 	ctx, cancel := context.WithCancel(context.Background())
 
-	go serveMetrics(cfg.MetricsAddress)
+	health := &healthCheck{}
+	go serveMetrics(cfg.MetricsAddress, health)
 	go handleSigterm(cancel)
 
+	if cfg.PipelinesConfig != "" {
+		runPipelines(ctx, cfg, health)
+		return
+	}
+
+	clientGenerator := &source.SingletonClientGenerator{
+		KubeConfig:   cfg.KubeConfig,
+		APIServerURL: cfg.APIServerURL,
+		// If update events are enabled, disable timeout.
+		RequestTimeout: func() time.Duration {
+			if cfg.UpdateEvents {
+				return 0
+			}
+			return cfg.RequestTimeout
+		}(),
+	}
+
+	endpointsSource, sourceCounts, err := buildSource(ctx, cfg, clientGenerator)
+	if err != nil {
+		log.Fatal(err)
+	}
+	domainFilter := newDomainFilter(cfg)
+
+	p, err := buildProvider(ctx, cfg, clientGenerator, endpointsSource, domainFilter)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if cfg.WebhookServer {
+		webhookapi.StartHTTPApi(p, nil, cfg.WebhookProviderReadTimeout, cfg.WebhookProviderWriteTimeout, "127.0.0.1:8888")
+		os.Exit(0)
+	}
+
+	ctrl, err := buildController(ctx, cfg, clientGenerator, endpointsSource, domainFilter, p)
+	if err != nil {
+		log.Fatal(err)
+	}
+	ctrl.SourceEndpointCounts = sourceCounts
+	health.add(ctrl)
+
+	if cfg.Once {
+		if cfg.Output != "" {
+			os.Exit(previewPlans(ctx, os.Stdout, cfg.Output, []*controller.Controller{ctrl}))
+		}
+
+		err := ctrl.RunOnce(ctx)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		os.Exit(0)
+	}
+
+	if cfg.UpdateEvents {
+		// Add RunOnce as the handler function that will be called when ingress/service sources have changed.
+		// Note that k8s Informers will perform an initial list operation, which results in the handler
+		// function initially being called for every Service/Ingress that exists
+		source.AddObservedEventHandler(ctx, ctrl.Source, func() { ctrl.ScheduleRunOnce(time.Now()) }, ctrl.ObserveChange)
+	}
+
+	election, err := startLeaderElection(ctx, cfg, clientGenerator)
+	if err != nil {
+		log.Fatal(err)
+	}
+	ctrl.RunLeading(ctx, election)
+}
+
+// buildSource creates the deduplicated and filtered source of the endpoints from the config, and the
+// counts of the endpoints of each of its source types.
+func buildSource(ctx context.Context, cfg *externaldns.Config, clientGenerator source.ClientGenerator) (source.Source, *source.EndpointCounts, error) {
 	// error is explicitly ignored because the filter is already validated in validation.ValidateConfig
 	labelSelector, _ := labels.Parse(cfg.LabelFilter)
 
@@ -159,23 +233,16 @@ func main() {
 		TraefikDisableNew:              cfg.TraefikDisableNew,
 	}
 
-
-	clientGenerator := &source.SingletonClientGenerator{
-		KubeConfig:   cfg.KubeConfig,
-		APIServerURL: cfg.APIServerURL,
-		// If update events are enabled, disable timeout.
-		RequestTimeout: func() time.Duration {
-			if cfg.UpdateEvents {
-				return 0
-			}
-			return cfg.RequestTimeout
-		}(),
-	}
-	
 	// Lookup all the selected sources by names and pass them the desired configuration.
 	sources, err := source.ByNames(ctx, clientGenerator, cfg.Sources, sourceCfg)
 	if err != nil {
-		log.Fatal(err)
+		return nil, nil, err
+	}
+
+	// Count the endpoints of each source type for the metrics.
+	counts := &source.EndpointCounts{}
+	for i := range sources {
+		sources[i] = source.NewCountingSource(cfg.Sources[i], sources[i], counts)
 	}
 
 	// Filter targets
@@ -183,21 +250,29 @@ func main() {
 
 	// Combine multiple sources into a single, deduplicated source.
 	endpointsSource := source.NewDedupSource(source.NewMultiSource(sources, sourceCfg.DefaultTargets))
-	endpointsSource = source.NewTargetFilterSource(endpointsSource, targetFilter)
+	return source.NewTargetFilterSource(endpointsSource, targetFilter), counts, nil
+}
 
+// newDomainFilter creates the domain filter of the provider and controller from the config.
+func newDomainFilter(cfg *externaldns.Config) endpoint.DomainFilter {
 	// RegexDomainFilter overrides DomainFilter
-	var domainFilter endpoint.DomainFilter
 	if cfg.RegexDomainFilter.String() != "" {
-		domainFilter = endpoint.NewRegexDomainFilter(cfg.RegexDomainFilter, cfg.RegexDomainExclusion)
-	} else {
-		domainFilter = endpoint.NewDomainFilterWithExclusions(cfg.DomainFilter, cfg.ExcludeDomains)
+		return endpoint.NewRegexDomainFilter(cfg.RegexDomainFilter, cfg.RegexDomainExclusion)
 	}
+	return endpoint.NewDomainFilterWithExclusions(cfg.DomainFilter, cfg.ExcludeDomains)
+}
+
+// buildProvider creates the DNS provider selected by the config.
+func buildProvider(ctx context.Context, cfg *externaldns.Config, clientGenerator source.ClientGenerator, endpointsSource source.Source, domainFilter endpoint.DomainFilter) (provider.Provider, error) {
 	zoneNameFilter := endpoint.NewDomainFilter(cfg.ZoneNameFilter)
 	zoneIDFilter := provider.NewZoneIDFilter(cfg.ZoneIDFilter)
 	zoneTypeFilter := provider.NewZoneTypeFilter(cfg.AWSZoneType)
 	zoneTagFilter := provider.NewZoneTagFilter(cfg.AWSZoneTagFilter)
 
-	var p provider.Provider
+	var (
+		p   provider.Provider
+		err error
+	)
 	switch cfg.Provider {
 	case "akamai":
 		p, err = akamai.NewAkamaiProvider(
@@ -295,6 +370,23 @@ func main() {
 				CoreDNSDeployment: cfg.CoreDNSDeployment,
 				CoreDNSConfigMap: cfg.CoreDNSConfigMap,
 				CoreDNSNamespace: cfg.CoreDNSNamespace,
+				Mode: cfg.CoreDNSK8sMode,
+				ServerBlock: cfg.CoreDNSK8sServerBlock,
+				Zones: cfg.CoreDNSK8sZones,
+				SOAMname: cfg.CoreDNSK8sSOAMname,
+				SOARname: cfg.CoreDNSK8sSOARname,
+				SOARefresh: cfg.CoreDNSK8sSOARefresh,
+				SOARetry: cfg.CoreDNSK8sSOARetry,
+				SOAExpire: cfg.CoreDNSK8sSOAExpire,
+				SOAMinimum: cfg.CoreDNSK8sSOAMinimum,
+				Nameservers: cfg.CoreDNSK8sNameservers,
+				SerialFormat: cfg.CoreDNSK8sSerialFormat,
+				ImportConfigMap: cfg.CoreDNSK8sImportConfigMap,
+				ImportDir: cfg.CoreDNSK8sImportDir,
+				VerifyReload: cfg.CoreDNSK8sVerifyReload,
+				VerifyServer: cfg.CoreDNSK8sVerifyServer,
+				VerifyTimeout: cfg.CoreDNSK8sVerifyTimeout,
+				VerifyInterval: cfg.CoreDNSK8sVerifyInterval,
 			}, cfg.DryRun)
 	case "rdns":
 		p, err = rdns.NewRDNSProvider(
@@ -401,18 +493,18 @@ func main() {
 	case "webhook":
 		p, err = webhook.NewWebhookProvider(cfg.WebhookProviderURL)
 	default:
-		log.Fatalf("unknown dns provider: %s", cfg.Provider)
-	}
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	if cfg.WebhookServer {
-		webhookapi.StartHTTPApi(p, nil, cfg.WebhookProviderReadTimeout, cfg.WebhookProviderWriteTimeout, "127.0.0.1:8888")
-		os.Exit(0)
+		err = fmt.Errorf("unknown dns provider: %s", cfg.Provider)
 	}
+	return p, err
+}
 
-	var r registry.Registry
+// buildController creates the registry selected by the config on top of the provider, and the
+// controller synchronizing it with the source.
+func buildController(ctx context.Context, cfg *externaldns.Config, clientGenerator source.ClientGenerator, endpointsSource source.Source, domainFilter endpoint.DomainFilter, p provider.Provider) (*controller.Controller, error) {
+	var (
+		r   registry.Registry
+		err error
+	)
 	switch cfg.Registry {
 	case "dynamodb":
 		config := awsSDK.NewConfig()
@@ -420,54 +512,126 @@ func main() {
 			config = config.WithRegion(cfg.AWSDynamoDBRegion)
 		}
 		r, err = registry.NewDynamoDBRegistry(p, cfg.TXTOwnerID, dynamodb.New(aws.CreateDefaultSession(cfg), config), cfg.AWSDynamoDBTable, cfg.TXTPrefix, cfg.TXTSuffix, cfg.TXTWildcardReplacement, cfg.ManagedDNSRecordTypes, cfg.ExcludeDNSRecordTypes, []byte(cfg.TXTEncryptAESKey), cfg.TXTCacheInterval)
+	case "configmap":
+		kubeClient, kubeErr := clientGenerator.KubeClient()
+		if kubeErr != nil {
+			return nil, kubeErr
+		}
+		r, err = registry.NewConfigMapRegistry(p, cfg.TXTOwnerID, kubeClient, namespaceOrPod(cfg.ConfigMapRegistryNamespace), cfg.ConfigMapRegistryName, cfg.ConfigMapRegistryShards, cfg.TXTPrefix, cfg.TXTSuffix, cfg.TXTWildcardReplacement, cfg.ManagedDNSRecordTypes, cfg.ExcludeDNSRecordTypes, []byte(cfg.TXTEncryptAESKey), cfg.TXTCacheInterval)
 	case "noop":
 		r, err = registry.NewNoopRegistry(p)
 	case "txt":
-		r, err = registry.NewTXTRegistry(p, cfg.TXTPrefix, cfg.TXTSuffix, cfg.TXTOwnerID, cfg.TXTCacheInterval, cfg.TXTWildcardReplacement, cfg.ManagedDNSRecordTypes, cfg.ExcludeDNSRecordTypes, cfg.TXTEncryptEnabled, []byte(cfg.TXTEncryptAESKey))
+		var txtRegistry *registry.TXTRegistry
+		txtRegistry, err = registry.NewTXTRegistry(p, cfg.TXTPrefix, cfg.TXTSuffix, cfg.TXTOwnerID, cfg.TXTCacheInterval, cfg.TXTWildcardReplacement, cfg.ManagedDNSRecordTypes, cfg.ExcludeDNSRecordTypes, cfg.TXTEncryptEnabled, []byte(cfg.TXTEncryptAESKey))
+		if err == nil && cfg.TXTOwnerMigrateFrom != "" {
+			txtRegistry.MigrateOwnerFrom(cfg.TXTOwnerMigrateFrom)
+		}
+		if err == nil && cfg.TXTSharedOwnership {
+			txtRegistry.ShareOwnership()
+		}
+		if err == nil && cfg.TXTDeleteOrphans {
+			txtRegistry.CollectOrphans()
+		}
+		if err == nil {
+			err = txtRegistry.UseLabelsFormat(cfg.TXTLabelsFormat)
+		}
+		r = txtRegistry
 	case "aws-sd":
-		r, err = registry.NewAWSSDRegistry(p.(*awssd.AWSSDProvider), cfg.TXTOwnerID)
+		awsSDProvider, ok := p.(*awssd.AWSSDProvider)
+		if !ok {
+			return nil, fmt.Errorf("registry %q cannot be used with provider %q", cfg.Registry, cfg.Provider)
+		}
+		r, err = registry.NewAWSSDRegistry(awsSDProvider, cfg.TXTOwnerID)
 	default:
-		log.Fatalf("unknown registry: %s", cfg.Registry)
+		err = fmt.Errorf("unknown registry: %s", cfg.Registry)
 	}
-
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 
 	policy, exists := plan.Policies[cfg.Policy]
 	if !exists {
-		log.Fatalf("unknown policy: %s", cfg.Policy)
+		return nil, fmt.Errorf("unknown policy: %s", cfg.Policy)
 	}
 
-	ctrl := controller.Controller{
-		Source:               endpointsSource,
-		Registry:             r,
-		Policy:               policy,
-		Interval:             cfg.Interval,
-		DomainFilter:         domainFilter,
-		ManagedRecordTypes:   cfg.ManagedDNSRecordTypes,
-		ExcludeRecordTypes:   cfg.ExcludeDNSRecordTypes,
-		MinEventSyncInterval: cfg.MinEventSyncInterval,
+	resolver, err := plan.NewConflictResolver(cfg.ConflictResolution, cfg.ConflictResolutionNamespaces)
+	if err != nil {
+		return nil, err
 	}
 
-	if cfg.Once {
-		err := ctrl.RunOnce(ctx)
+	var emitter events.Emitter
+	if cfg.EmitEvents {
+		kubeClient, err := clientGenerator.KubeClient()
 		if err != nil {
-			log.Fatal(err)
+			return nil, err
 		}
+		emitter = events.NewKubeEmitter(ctx, kubeClient)
+	}
 
-		os.Exit(0)
+	auditSink, err := buildAuditSink(cfg, emitter)
+	if err != nil {
+		return nil, err
 	}
 
-	if cfg.UpdateEvents {
-		// Add RunOnce as the handler function that will be called when ingress/service sources have changed.
-		// Note that k8s Informers will perform an initial list operation, which results in the handler
-		// function initially being called for every Service/Ingress that exists
-		ctrl.Source.AddEventHandler(ctx, func() { ctrl.ScheduleRunOnce(time.Now()) })
+	// the resources are not annotated in dry-run mode, since their records are not applied
+	var annotator events.Annotator
+	if cfg.AnnotateSyncedAt && !cfg.DryRun {
+		dynamicClient, err := clientGenerator.DynamicKubernetesClient()
+		if err != nil {
+			return nil, err
+		}
+		annotator = events.NewKubeAnnotator(dynamicClient)
 	}
 
-	ctrl.ScheduleRunOnce(time.Now())
-	ctrl.Run(ctx)
+	return &controller.Controller{
+		Source:                  endpointsSource,
+		Registry:                r,
+		Policy:                  policy,
+		Interval:                cfg.Interval,
+		DomainFilter:            domainFilter,
+		ManagedRecordTypes:      cfg.ManagedDNSRecordTypes,
+		ExcludeRecordTypes:      cfg.ExcludeDNSRecordTypes,
+		MinEventSyncInterval:    cfg.MinEventSyncInterval,
+		Backoff:                 cfg.Backoff,
+		MaxBackoff:              cfg.MaxBackoff,
+		CircuitBreakerThreshold: cfg.CircuitBreakerThreshold,
+		ProviderName:            cfg.Provider,
+		Guard:                   buildGuard(ctx, cfg, clientGenerator),
+		ConflictResolver:        resolver,
+		SharedOwnership:         cfg.TXTSharedOwnership,
+		AuditSink:               auditSink,
+		SyncedAtAnnotator:       annotator,
+		EventEmitter:            emitter,
+	}, nil
+}
+
+// buildAuditSink creates the audit sinks selected by the config, nil if there are none. The changes
+// are not audited in dry-run mode, since they are not applied.
+func buildAuditSink(cfg *externaldns.Config, emitter events.Emitter) (audit.Sink, error) {
+	if cfg.DryRun {
+		return nil, nil
+	}
+	var sinks audit.MultiSink
+	for _, name := range cfg.AuditSinks {
+		switch name {
+		case "file":
+			sink, err := audit.NewFileSink(cfg.AuditLogFile)
+			if err != nil {
+				return nil, err
+			}
+			sinks = append(sinks, sink)
+		case "stdout":
+			sinks = append(sinks, audit.NewWriterSink(os.Stdout))
+		case "events":
+			sinks = append(sinks, &audit.EventSink{Emitter: emitter})
+		default:
+			return nil, fmt.Errorf("unknown audit sink: %s", name)
+		}
+	}
+	if len(sinks) == 0 {
+		return nil, nil
+	}
+	return sinks, nil
 }
 
 func handleSigterm(cancel func()) {
@@ -478,11 +642,37 @@ func handleSigterm(cancel func()) {
 	cancel()
 }
 
-func serveMetrics(address string) {
-	http.HandleFunc("/healthz", func(w http.ResponseWriter, _ *http.Request) {
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte("OK"))
-	})
+// healthCheck serves the health of the controllers of the process, failing while the circuit breaker
+// of any of them is open.
+type healthCheck struct {
+	mux         sync.Mutex
+	controllers []*controller.Controller
+}
+
+func (h *healthCheck) add(ctrl *controller.Controller) {
+	h.mux.Lock()
+	defer h.mux.Unlock()
+	h.controllers = append(h.controllers, ctrl)
+}
+
+func (h *healthCheck) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	h.mux.Lock()
+	defer h.mux.Unlock()
+	for _, ctrl := range h.controllers {
+		if err := ctrl.Healthy(); err != nil {
+			if ctrl.Name != "" {
+				err = fmt.Errorf("pipeline %s: %w", ctrl.Name, err)
+			}
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("OK"))
+}
+
+func serveMetrics(address string, health http.Handler) {
+	http.Handle("/healthz", health)
 
 	http.Handle("/metrics", promhttp.Handler())
 
@@ -0,0 +1,37 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"os"
+	"strings"
+)
+
+// namespaceFile holds the namespace of the pod, when running in a cluster
+const namespaceFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+
+// namespaceOrPod returns the namespace, or the one of the pod of ExternalDNS when empty, which is default
+// outside of a cluster.
+func namespaceOrPod(namespace string) string {
+	if namespace != "" {
+		return namespace
+	}
+	if contents, err := os.ReadFile(namespaceFile); err == nil {
+		return strings.TrimSpace(string(contents))
+	}
+	return "default"
+}
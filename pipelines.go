@@ -0,0 +1,190 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"os"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/external-dns/controller"
+	"sigs.k8s.io/external-dns/pkg/apis/externaldns"
+	"sigs.k8s.io/external-dns/pkg/apis/externaldns/validation"
+	"sigs.k8s.io/external-dns/source"
+)
+
+// runPipelines runs a controller for each of the pipelines declared in the --pipelines-config file.
+// The pipelines share the Kubernetes clients and informers, and a pipeline that cannot be built or
+// whose runs fail does not stop the others.
+func runPipelines(ctx context.Context, cfg *externaldns.Config, health *healthCheck) {
+	pipelines, err := cfg.LoadPipelines()
+	if err != nil {
+		log.Fatalf("failed to load the pipelines: %v", err)
+	}
+	if err := validation.ValidatePipelines(pipelines); err != nil {
+		log.Fatalf("config validation failed: %v", err)
+	}
+
+	updateEvents := false
+	for _, pipeline := range pipelines {
+		log.Infof("pipeline %s config: %s", pipeline.Name, pipeline.Config)
+		updateEvents = updateEvents || pipeline.Config.UpdateEvents
+	}
+
+	clientGenerator := &source.SingletonClientGenerator{
+		KubeConfig:   cfg.KubeConfig,
+		APIServerURL: cfg.APIServerURL,
+		// If update events are enabled for any of the pipelines, disable timeout.
+		RequestTimeout: func() time.Duration {
+			if updateEvents {
+				return 0
+			}
+			return cfg.RequestTimeout
+		}(),
+		// the sources of the pipelines watching the same objects share their informers
+		InformerFactories: source.NewKubeInformerFactories(),
+	}
+
+	if cfg.Once {
+		if cfg.Output != "" {
+			os.Exit(previewPipelines(ctx, cfg.Output, pipelines, clientGenerator))
+		}
+		if !runPipelinesOnce(ctx, pipelines, clientGenerator) {
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	// the pipelines are built while waiting for the leadership, to keep their informers in sync
+	election, err := startLeaderElection(ctx, cfg, clientGenerator)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for _, pipeline := range pipelines {
+		wg.Add(1)
+		go func(pipeline *externaldns.Pipeline) {
+			defer wg.Done()
+			runPipeline(ctx, pipeline, clientGenerator, health, election)
+		}(pipeline)
+	}
+	wg.Wait()
+}
+
+// runPipelinesOnce runs a single iteration of each of the pipelines and reports whether they all
+// succeeded.
+func runPipelinesOnce(ctx context.Context, pipelines []*externaldns.Pipeline, clientGenerator source.ClientGenerator) bool {
+	var (
+		wg        sync.WaitGroup
+		failedMux sync.Mutex
+		failed    bool
+	)
+	for _, pipeline := range pipelines {
+		wg.Add(1)
+		go func(pipeline *externaldns.Pipeline) {
+			defer wg.Done()
+			ctrl, err := buildPipeline(ctx, pipeline, clientGenerator)
+			if err == nil {
+				err = ctrl.RunOnce(ctx)
+			}
+			if err != nil {
+				log.WithField("pipeline", pipeline.Name).Error(err)
+				failedMux.Lock()
+				failed = true
+				failedMux.Unlock()
+			}
+		}(pipeline)
+	}
+	wg.Wait()
+	return !failed
+}
+
+// previewPipelines prints the changes of each of the pipelines, see previewPlans, and returns the exit code.
+func previewPipelines(ctx context.Context, output string, pipelines []*externaldns.Pipeline, clientGenerator source.ClientGenerator) int {
+	ctrls := make([]*controller.Controller, 0, len(pipelines))
+	for _, pipeline := range pipelines {
+		ctrl, err := buildPipeline(ctx, pipeline, clientGenerator)
+		if err != nil {
+			log.WithField("pipeline", pipeline.Name).Error(err)
+			return exitFailed
+		}
+		ctrls = append(ctrls, ctrl)
+	}
+	return previewPlans(ctx, os.Stdout, output, ctrls)
+}
+
+// runPipeline runs the controller of the pipeline until the context is done, while leading the election
+// if any. Building the controller is retried at the interval of the pipeline, e.g. when its provider
+// cannot be reached yet.
+func runPipeline(ctx context.Context, pipeline *externaldns.Pipeline, clientGenerator source.ClientGenerator, health *healthCheck, election *controller.LeaderElection) {
+	logger := log.WithField("pipeline", pipeline.Name)
+	var ctrl *controller.Controller
+	for {
+		var err error
+		ctrl, err = buildPipeline(ctx, pipeline, clientGenerator)
+		if err == nil {
+			break
+		}
+		logger.Errorf("failed to build the pipeline: %v", err)
+		select {
+		case <-time.After(pipeline.Config.Interval):
+		case <-ctx.Done():
+			return
+		}
+	}
+	health.add(ctrl)
+
+	if pipeline.Config.UpdateEvents {
+		// Add RunOnce as the handler function that will be called when ingress/service sources have changed.
+		source.AddObservedEventHandler(ctx, ctrl.Source, func() { ctrl.ScheduleRunOnce(time.Now()) }, ctrl.ObserveChange)
+	}
+
+	ctrl.RunLeading(ctx, election)
+}
+
+// buildPipeline creates the controller of the pipeline, named after the pipeline and not exiting the
+// process when its runs fail.
+func buildPipeline(ctx context.Context, pipeline *externaldns.Pipeline, clientGenerator source.ClientGenerator) (*controller.Controller, error) {
+	cfg := pipeline.Config
+	if cfg.DryRun {
+		log.WithField("pipeline", pipeline.Name).Info("running in dry-run mode. No changes to DNS records will be made.")
+	}
+
+	endpointsSource, sourceCounts, err := buildSource(ctx, cfg, clientGenerator)
+	if err != nil {
+		return nil, err
+	}
+	domainFilter := newDomainFilter(cfg)
+
+	p, err := buildProvider(ctx, cfg, clientGenerator, endpointsSource, domainFilter)
+	if err != nil {
+		return nil, err
+	}
+
+	ctrl, err := buildController(ctx, cfg, clientGenerator, endpointsSource, domainFilter, p)
+	if err != nil {
+		return nil, err
+	}
+	ctrl.Name = pipeline.Name
+	ctrl.SourceEndpointCounts = sourceCounts
+	ctrl.ContinueOnError = true
+	return ctrl, nil
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externaldns
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Pipeline is a named pipeline of sources, filters, provider, registry and policy run by its own
+// controller, next to the other pipelines of the process.
+type Pipeline struct {
+	// Name identifies the pipeline in the metrics and logs
+	Name string `yaml:"name"`
+	// Args are the flags of the pipeline, e.g. --source, --provider or --txt-owner-id
+	Args []string `yaml:"args"`
+	// Config is the configuration parsed from the args
+	Config *Config `yaml:"-"`
+}
+
+type pipelinesConfig struct {
+	Pipelines []*Pipeline `yaml:"pipelines"`
+}
+
+// LoadPipelines reads the pipelines declared in the --pipelines-config file, e.g.
+//
+//	pipelines:
+//	- name: public
+//	  args: ["--source=ingress", "--provider=aws", "--txt-owner-id=public"]
+//	- name: private
+//	  args: ["--source=service", "--provider=corednsk8s", "--txt-owner-id=private"]
+//
+// The args of each pipeline are parsed like the command line, including the environment variables.
+// The settings of the process, i.e. the Kubernetes connection, logging, metrics address and --once,
+// are taken from cfg.
+func (cfg *Config) LoadPipelines() ([]*Pipeline, error) {
+	contents, err := os.ReadFile(cfg.PipelinesConfig)
+	if err != nil {
+		return nil, fmt.Errorf("reading pipelines config file %q: %w", cfg.PipelinesConfig, err)
+	}
+
+	pipelinesCfg := pipelinesConfig{}
+	if err := yaml.UnmarshalStrict(contents, &pipelinesCfg); err != nil {
+		return nil, fmt.Errorf("parsing pipelines config file %q: %w", cfg.PipelinesConfig, err)
+	}
+	if len(pipelinesCfg.Pipelines) == 0 {
+		return nil, fmt.Errorf("no pipelines declared in %q", cfg.PipelinesConfig)
+	}
+
+	names := make(map[string]bool)
+	for _, pipeline := range pipelinesCfg.Pipelines {
+		if pipeline.Name == "" {
+			return nil, fmt.Errorf("pipeline without a name in %q", cfg.PipelinesConfig)
+		}
+		if names[pipeline.Name] {
+			return nil, fmt.Errorf("duplicate pipeline %q in %q", pipeline.Name, cfg.PipelinesConfig)
+		}
+		names[pipeline.Name] = true
+
+		pipeline.Config = NewConfig()
+		if err := pipeline.Config.ParseFlags(pipeline.Args); err != nil {
+			return nil, fmt.Errorf("parsing the args of pipeline %q: %w", pipeline.Name, err)
+		}
+		pipeline.Config.APIServerURL = cfg.APIServerURL
+		pipeline.Config.KubeConfig = cfg.KubeConfig
+		pipeline.Config.RequestTimeout = cfg.RequestTimeout
+		pipeline.Config.LogFormat = cfg.LogFormat
+		pipeline.Config.LogLevel = cfg.LogLevel
+		pipeline.Config.MetricsAddress = cfg.MetricsAddress
+		pipeline.Config.Once = cfg.Once
+	}
+
+	return pipelinesCfg.Pipelines, nil
+}
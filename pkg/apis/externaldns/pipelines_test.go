@@ -0,0 +1,120 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externaldns
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writePipelinesConfig(t *testing.T, contents string) string {
+	path := filepath.Join(t.TempDir(), "pipelines.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(contents), 0o600))
+	return path
+}
+
+func TestLoadPipelines(t *testing.T) {
+	cfg := NewConfig()
+	require.NoError(t, cfg.ParseFlags([]string{"--kubeconfig=/etc/kubeconfig", "--request-timeout=10s", "--log-level=debug", "--once"}))
+	cfg.PipelinesConfig = writePipelinesConfig(t, `
+pipelines:
+- name: public
+  args: ["--source=ingress", "--provider=aws", "--txt-owner-id=public", "--domain-filter=example.org"]
+- name: private
+  args:
+  - --source=service
+  - --source=pod
+  - --provider=inmemory
+  - --registry=noop
+  - --policy=upsert-only
+  - --kubeconfig=/ignored
+`)
+
+	pipelines, err := cfg.LoadPipelines()
+	require.NoError(t, err)
+	require.Len(t, pipelines, 2)
+
+	assert.Equal(t, "public", pipelines[0].Name)
+	assert.Equal(t, []string{"ingress"}, pipelines[0].Config.Sources)
+	assert.Equal(t, "aws", pipelines[0].Config.Provider)
+	assert.Equal(t, "public", pipelines[0].Config.TXTOwnerID)
+	assert.Equal(t, []string{"example.org"}, pipelines[0].Config.DomainFilter)
+	assert.Equal(t, "txt", pipelines[0].Config.Registry)
+
+	assert.Equal(t, "private", pipelines[1].Name)
+	assert.Equal(t, []string{"service", "pod"}, pipelines[1].Config.Sources)
+	assert.Equal(t, "inmemory", pipelines[1].Config.Provider)
+	assert.Equal(t, "noop", pipelines[1].Config.Registry)
+	assert.Equal(t, "upsert-only", pipelines[1].Config.Policy)
+	assert.Equal(t, "default", pipelines[1].Config.TXTOwnerID)
+
+	for _, pipeline := range pipelines {
+		assert.Equal(t, "/etc/kubeconfig", pipeline.Config.KubeConfig)
+		assert.Equal(t, 10*time.Second, pipeline.Config.RequestTimeout)
+		assert.Equal(t, "debug", pipeline.Config.LogLevel)
+		assert.True(t, pipeline.Config.Once)
+	}
+}
+
+func TestLoadPipelinesErrors(t *testing.T) {
+	for _, tt := range []struct {
+		title    string
+		contents string
+		err      string
+	}{
+		{
+			title:    "no pipelines",
+			contents: "pipelines: []",
+			err:      "no pipelines declared",
+		},
+		{
+			title:    "unknown field",
+			contents: "pipelines:\n- name: public\n  flags: [--source=ingress]",
+			err:      "parsing pipelines config file",
+		},
+		{
+			title:    "no name",
+			contents: "pipelines:\n- args: [--source=ingress, --provider=aws]",
+			err:      "pipeline without a name",
+		},
+		{
+			title:    "duplicate name",
+			contents: "pipelines:\n- name: public\n  args: [--source=ingress, --provider=aws]\n- name: public\n  args: [--source=service, --provider=aws]",
+			err:      `duplicate pipeline "public"`,
+		},
+		{
+			title:    "invalid args",
+			contents: "pipelines:\n- name: public\n  args: [--source=unknown, --provider=aws]",
+			err:      `parsing the args of pipeline "public"`,
+		},
+	} {
+		t.Run(tt.title, func(t *testing.T) {
+			cfg := &Config{PipelinesConfig: writePipelinesConfig(t, tt.contents)}
+			_, err := cfg.LoadPipelines()
+			assert.ErrorContains(t, err, tt.err)
+		})
+	}
+
+	cfg := &Config{PipelinesConfig: filepath.Join(t.TempDir(), "missing.yaml")}
+	_, err := cfg.LoadPipelines()
+	assert.ErrorContains(t, err, "reading pipelines config file")
+}
@@ -98,6 +98,9 @@ type Config struct {
 	AWSZoneMatchParent                 bool
 	AWSDynamoDBRegion                  string
 	AWSDynamoDBTable                   string
+	ConfigMapRegistryNamespace         string
+	ConfigMapRegistryName              string
+	ConfigMapRegistryShards            int
 	AzureConfigFile                    string
 	AzureResourceGroup                 string
 	AzureSubscriptionID                string
@@ -117,6 +120,23 @@ type Config struct {
 	CoreDNSDeployment                  string
 	CoreDNSConfigMap                   string
 	CoreDNSNamespace                   string
+	CoreDNSK8sMode                     string
+	CoreDNSK8sServerBlock              string
+	CoreDNSK8sZones                    []string
+	CoreDNSK8sSOAMname                 string
+	CoreDNSK8sSOARname                 string
+	CoreDNSK8sSOARefresh               time.Duration
+	CoreDNSK8sSOARetry                 time.Duration
+	CoreDNSK8sSOAExpire                time.Duration
+	CoreDNSK8sSOAMinimum               time.Duration
+	CoreDNSK8sNameservers              []string
+	CoreDNSK8sSerialFormat             string
+	CoreDNSK8sImportConfigMap          string
+	CoreDNSK8sImportDir                string
+	CoreDNSK8sVerifyReload             bool
+	CoreDNSK8sVerifyServer             string
+	CoreDNSK8sVerifyTimeout            time.Duration
+	CoreDNSK8sVerifyInterval           time.Duration
 	RcodezeroTXTEncrypt                bool
 	AkamaiServiceConsumerDomain        string
 	AkamaiClientToken                  string
@@ -143,16 +163,33 @@ type Config struct {
 	TLSClientCert                      string
 	TLSClientCertKey                   string
 	Policy                             string
+	MaxDeletes                         int
+	MaxDeletesPercent                  int
+	DeleteGuardOverride                bool
+	ConflictResolution                 string
+	ConflictResolutionNamespaces       []string
+	EmitEvents                         bool
+	AuditSinks                         []string
+	AuditLogFile                       string
+	AnnotateSyncedAt                   bool
 	Registry                           string
 	TXTOwnerID                         string
+	TXTOwnerMigrateFrom                string
+	TXTSharedOwnership                 bool
+	TXTLabelsFormat                    string
+	TXTDeleteOrphans                   bool
 	TXTPrefix                          string
 	TXTSuffix                          string
 	TXTEncryptEnabled                  bool
 	TXTEncryptAESKey                   string `secure:"yes"`
 	Interval                           time.Duration
 	MinEventSyncInterval               time.Duration
+	Backoff                            time.Duration
+	MaxBackoff                         time.Duration
+	CircuitBreakerThreshold            int
 	Once                               bool
 	DryRun                             bool
+	Output                             string
 	UpdateEvents                       bool
 	LogFormat                          string
 	MetricsAddress                     string
@@ -214,6 +251,10 @@ type Config struct {
 	WebhookProviderReadTimeout         time.Duration
 	WebhookProviderWriteTimeout        time.Duration
 	WebhookServer                      bool
+	PipelinesConfig                    string
+	LeaderElect                        bool
+	LeaderElectionLeaseName            string
+	LeaderElectionNamespace            string
 	TraefikDisableLegacy               bool
 	TraefikDisableNew                  bool
 }
@@ -270,6 +311,9 @@ var defaultConfig = &Config{
 	AWSSDServiceCleanup:         false,
 	AWSDynamoDBRegion:           "",
 	AWSDynamoDBTable:            "external-dns",
+	ConfigMapRegistryNamespace:  "",
+	ConfigMapRegistryName:       "external-dns-ownership",
+	ConfigMapRegistryShards:     8,
 	AzureConfigFile:             "/etc/kubernetes/azure.json",
 	AzureResourceGroup:          "",
 	AzureSubscriptionID:         "",
@@ -281,6 +325,23 @@ var defaultConfig = &Config{
 	CoreDNSDeployment:           "coredns",
 	CoreDNSConfigMap:            "coredns",
 	CoreDNSNamespace:            "kube-system",
+	CoreDNSK8sMode:              "hosts",
+	CoreDNSK8sServerBlock:       ".:53",
+	CoreDNSK8sZones:             []string{},
+	CoreDNSK8sSOAMname:          "",
+	CoreDNSK8sSOARname:          "",
+	CoreDNSK8sSOARefresh:        time.Hour,
+	CoreDNSK8sSOARetry:          15 * time.Minute,
+	CoreDNSK8sSOAExpire:         14 * 24 * time.Hour,
+	CoreDNSK8sSOAMinimum:        time.Hour,
+	CoreDNSK8sNameservers:       []string{},
+	CoreDNSK8sSerialFormat:      "date",
+	CoreDNSK8sImportConfigMap:   "",
+	CoreDNSK8sImportDir:         "/etc/coredns/custom",
+	CoreDNSK8sVerifyReload:      false,
+	CoreDNSK8sVerifyServer:      "",
+	CoreDNSK8sVerifyTimeout:     2 * time.Minute,
+	CoreDNSK8sVerifyInterval:    5 * time.Second,
 	RcodezeroTXTEncrypt:         false,
 	AkamaiServiceConsumerDomain: "",
 	AkamaiClientToken:           "",
@@ -301,18 +362,34 @@ var defaultConfig = &Config{
 	TLSClientCert:               "",
 	TLSClientCertKey:            "",
 	Policy:                      "sync",
+	MaxDeletes:                  0,
+	MaxDeletesPercent:           0,
+	DeleteGuardOverride:         false,
+	ConflictResolution:          "per-resource",
+	EmitEvents:                  false,
+	AuditSinks:                  nil,
+	AuditLogFile:                "",
+	AnnotateSyncedAt:            false,
 	Registry:                    "txt",
 	TXTOwnerID:                  "default",
+	TXTOwnerMigrateFrom:         "",
+	TXTSharedOwnership:          false,
+	TXTLabelsFormat:             "v1",
+	TXTDeleteOrphans:            false,
 	TXTPrefix:                   "",
 	TXTSuffix:                   "",
 	TXTCacheInterval:            0,
 	TXTWildcardReplacement:      "",
 	MinEventSyncInterval:        5 * time.Second,
+	Backoff:                     10 * time.Second,
+	MaxBackoff:                  5 * time.Minute,
+	CircuitBreakerThreshold:     5,
 	TXTEncryptEnabled:           false,
 	TXTEncryptAESKey:            "",
 	Interval:                    time.Minute,
 	Once:                        false,
 	DryRun:                      false,
+	Output:                      "",
 	UpdateEvents:                false,
 	LogFormat:                   "text",
 	MetricsAddress:              ":7979",
@@ -367,6 +444,10 @@ var defaultConfig = &Config{
 	WebhookProviderReadTimeout:  5 * time.Second,
 	WebhookProviderWriteTimeout: 10 * time.Second,
 	WebhookServer:               false,
+	PipelinesConfig:             "",
+	LeaderElect:                 false,
+	LeaderElectionLeaseName:     "external-dns",
+	LeaderElectionNamespace:     "",
 	TraefikDisableLegacy:        false,
 	TraefikDisableNew:           false,
 }
@@ -430,7 +511,7 @@ func (cfg *Config) ParseFlags(args []string) error {
 	app.Flag("skipper-routegroup-groupversion", "The resource version for skipper routegroup").Default(source.DefaultRoutegroupVersion).StringVar(&cfg.SkipperRouteGroupVersion)
 
 	// Flags related to processing source
-	app.Flag("source", "The resource types that are queried for endpoints; specify multiple times for multiple sources (required, options: service, ingress, node, pod, fake, connector, gateway-httproute, gateway-grpcroute, gateway-tlsroute, gateway-tcproute, gateway-udproute, istio-gateway, istio-virtualservice, cloudfoundry, contour-httpproxy, gloo-proxy, crd, empty, skipper-routegroup, openshift-route, ambassador-host, kong-tcpingress, f5-virtualserver, traefik-proxy)").Required().PlaceHolder("source").EnumsVar(&cfg.Sources, "service", "ingress", "node", "pod", "gateway-httproute", "gateway-grpcroute", "gateway-tlsroute", "gateway-tcproute", "gateway-udproute", "istio-gateway", "istio-virtualservice", "cloudfoundry", "contour-httpproxy", "gloo-proxy", "fake", "connector", "crd", "empty", "skipper-routegroup", "openshift-route", "ambassador-host", "kong-tcpingress", "f5-virtualserver", "traefik-proxy")
+	app.Flag("source", "The resource types that are queried for endpoints; specify multiple times for multiple sources (required unless --pipelines-config is set, options: service, ingress, node, pod, fake, connector, gateway-httproute, gateway-grpcroute, gateway-tlsroute, gateway-tcproute, gateway-udproute, istio-gateway, istio-virtualservice, cloudfoundry, contour-httpproxy, gloo-proxy, crd, empty, skipper-routegroup, openshift-route, ambassador-host, kong-tcpingress, f5-virtualserver, traefik-proxy)").PlaceHolder("source").EnumsVar(&cfg.Sources, "service", "ingress", "node", "pod", "gateway-httproute", "gateway-grpcroute", "gateway-tlsroute", "gateway-tcproute", "gateway-udproute", "istio-gateway", "istio-virtualservice", "cloudfoundry", "contour-httpproxy", "gloo-proxy", "fake", "connector", "crd", "empty", "skipper-routegroup", "openshift-route", "ambassador-host", "kong-tcpingress", "f5-virtualserver", "traefik-proxy")
 	app.Flag("openshift-router-name", "if source is openshift-route then you can pass the ingress controller name. Based on this name external-dns will select the respective router from the route status and map that routerCanonicalHostname to the route host while creating a CNAME record.").StringVar(&cfg.OCPRouterName)
 	app.Flag("namespace", "Limit resources queried for endpoints to a specific namespace (default: all namespaces)").Default(defaultConfig.Namespace).StringVar(&cfg.Namespace)
 	app.Flag("annotation-filter", "Filter resources queried for endpoints by annotation, using label selector semantics").Default(defaultConfig.AnnotationFilter).StringVar(&cfg.AnnotationFilter)
@@ -461,7 +542,7 @@ func (cfg *Config) ParseFlags(args []string) error {
 
 	// Flags related to providers
 	providers := []string{"akamai", "alibabacloud", "aws", "aws-sd", "azure", "azure-dns", "azure-private-dns", "bluecat", "civo", "cloudflare", "coredns", "corednsk8s", "designate", "digitalocean", "dnsimple", "dyn", "exoscale", "gandi", "godaddy", "google", "ibmcloud", "inmemory", "linode", "ns1", "oci", "ovh", "pdns", "pihole", "plural", "rcodezero", "rdns", "rfc2136", "safedns", "scaleway", "skydns", "tencentcloud", "transip", "ultradns", "vinyldns", "vultr", "webhook"}
-	app.Flag("provider", "The DNS provider where the DNS records will be created (required, options: "+strings.Join(providers, ", ")+")").Required().PlaceHolder("provider").EnumVar(&cfg.Provider, providers...)
+	app.Flag("provider", "The DNS provider where the DNS records will be created (required unless --pipelines-config is set, options: "+strings.Join(providers, ", ")+")").PlaceHolder("provider").EnumVar(&cfg.Provider, providers...)
 	app.Flag("domain-filter", "Limit possible target zones by a domain suffix; specify multiple times for multiple domains (optional)").Default("").StringsVar(&cfg.DomainFilter)
 	app.Flag("exclude-domains", "Exclude subdomains (optional)").Default("").StringsVar(&cfg.ExcludeDomains)
 	app.Flag("regex-domain-filter", "Limit possible domains and target zones by a Regex filter; Overrides domain-filter (optional)").Default(defaultConfig.RegexDomainFilter.String()).RegexpVar(&cfg.RegexDomainFilter)
@@ -512,6 +593,23 @@ func (cfg *Config) ParseFlags(args []string) error {
 	app.Flag("coredns-deployment", "When using the CoreDNSK8s provider, specify the deployment name of coredns").Default(defaultConfig.CoreDNSDeployment).StringVar(&cfg.CoreDNSDeployment)
 	app.Flag("coredns-configmap", "When using the CoreDNSK8s provider, specify the configmap where the Corefile exists").Default(defaultConfig.CoreDNSConfigMap).StringVar(&cfg.CoreDNSConfigMap)
 	app.Flag("coredns-namespace", "When using the CoreDNSK8s provider, specify the namespace of coredns deployment").Default(defaultConfig.CoreDNSNamespace).StringVar(&cfg.CoreDNSNamespace)
+	app.Flag("coredns-k8s-mode", "When using the CoreDNSK8s provider, specify how the records are served: through the hosts plugin or through zone files (optional, options: hosts, zonefile)").Default(defaultConfig.CoreDNSK8sMode).EnumVar(&cfg.CoreDNSK8sMode, "hosts", "zonefile")
+	app.Flag("coredns-k8s-server-block", "When using the CoreDNSK8s provider, specify the key of the Corefile server block in which the records are served (default: .:53)").Default(defaultConfig.CoreDNSK8sServerBlock).StringVar(&cfg.CoreDNSK8sServerBlock)
+	app.Flag("coredns-k8s-zone", "When using the CoreDNSK8s provider in zonefile mode, provide the zones the zone files are authoritative for, defaults to the domain filter; specify multiple times for multiple zones (optional)").Default("").StringsVar(&cfg.CoreDNSK8sZones)
+	app.Flag("coredns-k8s-soa-mname", "When using the CoreDNSK8s provider in zonefile mode, set the primary nameserver of the SOA records (default: ns.<zone>)").Default(defaultConfig.CoreDNSK8sSOAMname).StringVar(&cfg.CoreDNSK8sSOAMname)
+	app.Flag("coredns-k8s-soa-rname", "When using the CoreDNSK8s provider in zonefile mode, set the administrator mailbox of the SOA records (default: hostmaster.<zone>)").Default(defaultConfig.CoreDNSK8sSOARname).StringVar(&cfg.CoreDNSK8sSOARname)
+	app.Flag("coredns-k8s-soa-refresh", "When using the CoreDNSK8s provider in zonefile mode, set the refresh interval of the SOA records").Default(defaultConfig.CoreDNSK8sSOARefresh.String()).DurationVar(&cfg.CoreDNSK8sSOARefresh)
+	app.Flag("coredns-k8s-soa-retry", "When using the CoreDNSK8s provider in zonefile mode, set the retry interval of the SOA records").Default(defaultConfig.CoreDNSK8sSOARetry.String()).DurationVar(&cfg.CoreDNSK8sSOARetry)
+	app.Flag("coredns-k8s-soa-expire", "When using the CoreDNSK8s provider in zonefile mode, set the expire time of the SOA records").Default(defaultConfig.CoreDNSK8sSOAExpire.String()).DurationVar(&cfg.CoreDNSK8sSOAExpire)
+	app.Flag("coredns-k8s-soa-minimum", "When using the CoreDNSK8s provider in zonefile mode, set the negative caching TTL of the SOA records").Default(defaultConfig.CoreDNSK8sSOAMinimum.String()).DurationVar(&cfg.CoreDNSK8sSOAMinimum)
+	app.Flag("coredns-k8s-nameserver", "When using the CoreDNSK8s provider in zonefile mode, provide the apex NS records of the zones; specify multiple times for multiple nameservers (optional)").Default("").StringsVar(&cfg.CoreDNSK8sNameservers)
+	app.Flag("coredns-k8s-serial-format", "When using the CoreDNSK8s provider in zonefile mode, set the format of the SOA serials (optional, options: date, unixtime)").Default(defaultConfig.CoreDNSK8sSerialFormat).EnumVar(&cfg.CoreDNSK8sSerialFormat, "date", "unixtime")
+	app.Flag("coredns-k8s-import-configmap", "When using the CoreDNSK8s provider in zonefile mode, store the zone files in this ConfigMap, created and owned by external-dns, instead of mounting them in the CoreDNS Deployment; the Corefile server block has to import the external-dns.override file of its mount directory (optional)").Default(defaultConfig.CoreDNSK8sImportConfigMap).StringVar(&cfg.CoreDNSK8sImportConfigMap)
+	app.Flag("coredns-k8s-import-dir", "When using the CoreDNSK8s provider with --coredns-k8s-import-configmap, the directory the ConfigMap is mounted at in the CoreDNS container").Default(defaultConfig.CoreDNSK8sImportDir).StringVar(&cfg.CoreDNSK8sImportDir)
+	app.Flag("coredns-k8s-verify-reload", "When using the CoreDNSK8s provider, verify in the background that CoreDNS serves the changes after applying them; changes not served before the timeout are reported in the logs and metrics (default: disabled)").BoolVar(&cfg.CoreDNSK8sVerifyReload)
+	app.Flag("coredns-k8s-verify-server", "When using the CoreDNSK8s provider with --coredns-k8s-verify-reload, the address of the CoreDNS server to query (default: the kube-dns Service in the CoreDNS namespace)").Default(defaultConfig.CoreDNSK8sVerifyServer).StringVar(&cfg.CoreDNSK8sVerifyServer)
+	app.Flag("coredns-k8s-verify-timeout", "When using the CoreDNSK8s provider with --coredns-k8s-verify-reload, the time to wait for CoreDNS to serve the changes").Default(defaultConfig.CoreDNSK8sVerifyTimeout.String()).DurationVar(&cfg.CoreDNSK8sVerifyTimeout)
+	app.Flag("coredns-k8s-verify-interval", "When using the CoreDNSK8s provider with --coredns-k8s-verify-reload, the interval between the queries to CoreDNS").Default(defaultConfig.CoreDNSK8sVerifyInterval.String()).DurationVar(&cfg.CoreDNSK8sVerifyInterval)
 	app.Flag("akamai-serviceconsumerdomain", "When using the Akamai provider, specify the base URL (required when --provider=akamai and edgerc-path not specified)").Default(defaultConfig.AkamaiServiceConsumerDomain).StringVar(&cfg.AkamaiServiceConsumerDomain)
 	app.Flag("akamai-client-token", "When using the Akamai provider, specify the client token (required when --provider=akamai and edgerc-path not specified)").Default(defaultConfig.AkamaiClientToken).StringVar(&cfg.AkamaiClientToken)
 	app.Flag("akamai-client-secret", "When using the Akamai provider, specify the client secret (required when --provider=akamai and edgerc-path not specified)").Default(defaultConfig.AkamaiClientSecret).StringVar(&cfg.AkamaiClientSecret)
@@ -591,10 +689,23 @@ func (cfg *Config) ParseFlags(args []string) error {
 
 	// Flags related to policies
 	app.Flag("policy", "Modify how DNS records are synchronized between sources and providers (default: sync, options: sync, upsert-only, create-only)").Default(defaultConfig.Policy).EnumVar(&cfg.Policy, "sync", "upsert-only", "create-only")
+	app.Flag("max-deletes", "Holds all the deletions of a synchronization when there are more than this number, until they are confirmed; 0 disables it (default: 0)").Default(strconv.Itoa(defaultConfig.MaxDeletes)).IntVar(&cfg.MaxDeletes)
+	app.Flag("max-deletes-percent", "Holds all the deletions of a synchronization when they are more than this percentage of the owned records, until they are confirmed; 0 disables it (default: 0)").Default(strconv.Itoa(defaultConfig.MaxDeletesPercent)).IntVar(&cfg.MaxDeletesPercent)
+	app.Flag("delete-guard-override", "When enabled, confirms the deletions held by --max-deletes and --max-deletes-percent (default: disabled)").BoolVar(&cfg.DeleteGuardOverride)
+	app.Flag("conflict-resolution", "How to choose between the resources claiming the same DNS name: per-resource keeps the current owner resource, oldest prefers the oldest resource, priority prefers the highest external-dns.alpha.kubernetes.io/priority annotation, namespace prefers the earliest namespace of --conflict-resolution-namespace (default: per-resource, options: per-resource, oldest, priority, namespace)").Default(defaultConfig.ConflictResolution).EnumVar(&cfg.ConflictResolution, "per-resource", "oldest", "priority", "namespace")
+	app.Flag("conflict-resolution-namespace", "When using --conflict-resolution=namespace, a namespace allowed to claim the DNS names, in order of preference; specify multiple times for multiple namespaces").StringsVar(&cfg.ConflictResolutionNamespaces)
+	app.Flag("emit-events", "When enabled, records Kubernetes events on the resources, e.g. when they lose a DNS name to another resource; requires the permission to create events (default: disabled)").BoolVar(&cfg.EmitEvents)
+	app.Flag("audit-sink", "Records every change applied to the DNS records to this sink: file appends JSON lines to --audit-log-file, stdout prints JSON lines, events records events on the resources and requires --emit-events; specify multiple times for multiple sinks (default: disabled, options: file, stdout, events)").EnumsVar(&cfg.AuditSinks, "file", "stdout", "events")
+	app.Flag("audit-log-file", "When using --audit-sink=file, the file the records are appended to").Default(defaultConfig.AuditLogFile).StringVar(&cfg.AuditLogFile)
+	app.Flag("annotate-synced-at", "When enabled, annotates the resources with the time their DNS records were applied, in external-dns.alpha.kubernetes.io/synced-at; requires the permission to patch the resources (default: disabled)").BoolVar(&cfg.AnnotateSyncedAt)
 
 	// Flags related to the registry
-	app.Flag("registry", "The registry implementation to use to keep track of DNS record ownership (default: txt, options: txt, noop, dynamodb, aws-sd)").Default(defaultConfig.Registry).EnumVar(&cfg.Registry, "txt", "noop", "dynamodb", "aws-sd")
-	app.Flag("txt-owner-id", "When using the TXT or DynamoDB registry, a name that identifies this instance of ExternalDNS (default: default)").Default(defaultConfig.TXTOwnerID).StringVar(&cfg.TXTOwnerID)
+	app.Flag("registry", "The registry implementation to use to keep track of DNS record ownership (default: txt, options: txt, noop, dynamodb, aws-sd, configmap)").Default(defaultConfig.Registry).EnumVar(&cfg.Registry, "txt", "noop", "dynamodb", "aws-sd", "configmap")
+	app.Flag("txt-owner-id", "When using the TXT, DynamoDB or ConfigMap registry, a name that identifies this instance of ExternalDNS (default: default)").Default(defaultConfig.TXTOwnerID).StringVar(&cfg.TXTOwnerID)
+	app.Flag("txt-owner-migrate-from", "When using the TXT registry, takes over the records of this former owner ID and rewrites their TXT records with --txt-owner-id, e.g. after renaming the owner ID (optional)").Default(defaultConfig.TXTOwnerMigrateFrom).StringVar(&cfg.TXTOwnerMigrateFrom)
+	app.Flag("txt-shared-ownership", "When using the TXT registry, shares the records with the other owners which enable it as well, e.g. clusters serving the same DNS name: the targets of the owners are merged, and each owner only removes its own targets (default: disabled)").BoolVar(&cfg.TXTSharedOwnership)
+	app.Flag("txt-labels-format", "When using the TXT registry, the format the labels are written in: v1 as comma separated key=value pairs, or v2 as base64 encoded JSON split across several TXT strings. Both are read, and the TXT records of this instance are rewritten in this format (default: v1, options: v1, v2)").Default(defaultConfig.TXTLabelsFormat).EnumVar(&cfg.TXTLabelsFormat, "v1", "v2")
+	app.Flag("txt-delete-orphans", "When using the TXT registry, deletes the TXT records of this instance whose record does not exist, e.g. because it was deleted by hand or rejected by the provider (default: disabled)").BoolVar(&cfg.TXTDeleteOrphans)
 	app.Flag("txt-prefix", "When using the TXT registry, a custom string that's prefixed to each ownership DNS record (optional). Could contain record type template like '%{record_type}-prefix-'. Mutual exclusive with txt-suffix!").Default(defaultConfig.TXTPrefix).StringVar(&cfg.TXTPrefix)
 	app.Flag("txt-suffix", "When using the TXT registry, a custom string that's suffixed to the host portion of each ownership DNS record (optional). Could contain record type template like '-%{record_type}-suffix'. Mutual exclusive with txt-prefix!").Default(defaultConfig.TXTSuffix).StringVar(&cfg.TXTSuffix)
 	app.Flag("txt-wildcard-replacement", "When using the TXT registry, a custom string that's used instead of an asterisk for TXT records corresponding to wildcard DNS records (optional)").Default(defaultConfig.TXTWildcardReplacement).StringVar(&cfg.TXTWildcardReplacement)
@@ -602,19 +713,30 @@ func (cfg *Config) ParseFlags(args []string) error {
 	app.Flag("txt-encrypt-aes-key", "When using the TXT registry, set TXT record decryption and encryption 32 byte aes key (required when --txt-encrypt=true)").Default(defaultConfig.TXTEncryptAESKey).StringVar(&cfg.TXTEncryptAESKey)
 	app.Flag("dynamodb-region", "When using the DynamoDB registry, the AWS region of the DynamoDB table (optional)").Default(cfg.AWSDynamoDBRegion).StringVar(&cfg.AWSDynamoDBRegion)
 	app.Flag("dynamodb-table", "When using the DynamoDB registry, the name of the DynamoDB table (default: \"external-dns\")").Default(defaultConfig.AWSDynamoDBTable).StringVar(&cfg.AWSDynamoDBTable)
+	app.Flag("configmap-registry-namespace", "When using the ConfigMap registry, the namespace of the ConfigMaps (default: the namespace of the pod, or default outside of a cluster)").Default(defaultConfig.ConfigMapRegistryNamespace).StringVar(&cfg.ConfigMapRegistryNamespace)
+	app.Flag("configmap-registry-name", "When using the ConfigMap registry, the name prefix of the ConfigMaps, which may be shared by several owners (default: external-dns-ownership)").Default(defaultConfig.ConfigMapRegistryName).StringVar(&cfg.ConfigMapRegistryName)
+	app.Flag("configmap-registry-shards", "When using the ConfigMap registry, the number of ConfigMaps the ownership records are spread across (default: 8)").Default(strconv.Itoa(defaultConfig.ConfigMapRegistryShards)).IntVar(&cfg.ConfigMapRegistryShards)
 
 	// Flags related to the main control loop
 	app.Flag("txt-cache-interval", "The interval between cache synchronizations in duration format (default: disabled)").Default(defaultConfig.TXTCacheInterval.String()).DurationVar(&cfg.TXTCacheInterval)
 	app.Flag("interval", "The interval between two consecutive synchronizations in duration format (default: 1m)").Default(defaultConfig.Interval.String()).DurationVar(&cfg.Interval)
 	app.Flag("min-event-sync-interval", "The minimum interval between two consecutive synchronizations triggered from kubernetes events in duration format (default: 5s)").Default(defaultConfig.MinEventSyncInterval.String()).DurationVar(&cfg.MinEventSyncInterval)
+	app.Flag("backoff", "The delay before retrying a failed synchronization in duration format, doubled after each consecutive failure; 0s retries at the next interval (default: 10s)").Default(defaultConfig.Backoff.String()).DurationVar(&cfg.Backoff)
+	app.Flag("max-backoff", "The maximum delay before retrying a failed synchronization in duration format (default: 5m)").Default(defaultConfig.MaxBackoff.String()).DurationVar(&cfg.MaxBackoff)
+	app.Flag("circuit-breaker-threshold", "The number of consecutive failed synchronizations after which the health check fails, until a synchronization succeeds; 0 disables it (default: 5)").Default(strconv.Itoa(defaultConfig.CircuitBreakerThreshold)).IntVar(&cfg.CircuitBreakerThreshold)
 	app.Flag("once", "When enabled, exits the synchronization loop after the first iteration (default: disabled)").BoolVar(&cfg.Once)
 	app.Flag("dry-run", "When enabled, prints DNS record changes rather than actually performing them (default: disabled)").BoolVar(&cfg.DryRun)
+	app.Flag("output", "When set with --once, prints the planned changes in this format instead of applying them, and exits with 2 when changes are pending (default: disabled, options: json, table)").Default(defaultConfig.Output).EnumVar(&cfg.Output, "", "json", "table")
 	app.Flag("events", "When enabled, in addition to running every interval, the reconciliation loop will get triggered when supported sources change (default: disabled)").BoolVar(&cfg.UpdateEvents)
 
 	// Miscellaneous flags
 	app.Flag("log-format", "The format in which log messages are printed (default: text, options: text, json)").Default(defaultConfig.LogFormat).EnumVar(&cfg.LogFormat, "text", "json")
 	app.Flag("metrics-address", "Specify where to serve the metrics and health check endpoint (default: :7979)").Default(defaultConfig.MetricsAddress).StringVar(&cfg.MetricsAddress)
 	app.Flag("log-level", "Set the level of logging. (default: info, options: panic, debug, info, warning, error, fatal)").Default(defaultConfig.LogLevel).EnumVar(&cfg.LogLevel, allLogLevelsAsStrings()...)
+	app.Flag("pipelines-config", "When set, runs a controller for each of the named pipelines declared in this YAML file instead of a single one configured by the flags; the pipelines share the Kubernetes clients and informers (default: disabled)").Default(defaultConfig.PipelinesConfig).StringVar(&cfg.PipelinesConfig)
+	app.Flag("leader-elect", "When enabled, only the replica holding the leader election Lease changes the DNS records, while the others keep their informers in sync to take over; requires the permission to manage leases (default: disabled)").BoolVar(&cfg.LeaderElect)
+	app.Flag("leader-election-lease-name", "The name of the Lease of the leader election (default: external-dns)").Default(defaultConfig.LeaderElectionLeaseName).StringVar(&cfg.LeaderElectionLeaseName)
+	app.Flag("leader-election-namespace", "The namespace of the Lease of the leader election (default: the namespace of the pod, or default outside of a cluster)").Default(defaultConfig.LeaderElectionNamespace).StringVar(&cfg.LeaderElectionNamespace)
 
 	// Webhook provider
 	app.Flag("webhook-provider-url", "The URL of the remote endpoint to call for the webhook provider (default: http://localhost:8888)").Default(defaultConfig.WebhookProviderURL).StringVar(&cfg.WebhookProviderURL)
@@ -69,6 +69,8 @@ var (
 		AWSZoneCacheDuration:        0 * time.Second,
 		AWSSDServiceCleanup:         false,
 		AWSDynamoDBTable:            "external-dns",
+		ConfigMapRegistryName:       "external-dns-ownership",
+		ConfigMapRegistryShards:     8,
 		AzureConfigFile:             "/etc/kubernetes/azure.json",
 		AzureResourceGroup:          "",
 		AzureSubscriptionID:         "",
@@ -86,6 +88,19 @@ var (
 		CoreDNSDeployment:           "coredns",
 		CoreDNSConfigMap:            "coredns",
 		CoreDNSNamespace:            "kube-system",
+		CoreDNSK8sMode:              "hosts",
+		CoreDNSK8sServerBlock:       ".:53",
+		CoreDNSK8sZones:             []string{""},
+		CoreDNSK8sSOARefresh:        time.Hour,
+		CoreDNSK8sSOARetry:          15 * time.Minute,
+		CoreDNSK8sSOAExpire:         14 * 24 * time.Hour,
+		CoreDNSK8sSOAMinimum:        time.Hour,
+		CoreDNSK8sNameservers:       []string{""},
+		CoreDNSK8sSerialFormat:      "date",
+		CoreDNSK8sImportConfigMap:   "",
+		CoreDNSK8sImportDir:         "/etc/coredns/custom",
+		CoreDNSK8sVerifyTimeout:     2 * time.Minute,
+		CoreDNSK8sVerifyInterval:    5 * time.Second,
 		AkamaiServiceConsumerDomain: "",
 		AkamaiClientToken:           "",
 		AkamaiClientSecret:          "",
@@ -101,18 +116,27 @@ var (
 		PDNSServer:                  "http://localhost:8081",
 		PDNSAPIKey:                  "",
 		Policy:                      "sync",
+		MaxDeletes:                  0,
+		MaxDeletesPercent:           0,
+		ConflictResolution:          "per-resource",
 		Registry:                    "txt",
 		TXTOwnerID:                  "default",
+		TXTLabelsFormat:             "v1",
 		TXTPrefix:                   "",
 		TXTCacheInterval:            0,
 		Interval:                    time.Minute,
 		MinEventSyncInterval:        5 * time.Second,
+		Backoff:                     10 * time.Second,
+		MaxBackoff:                  5 * time.Minute,
+		CircuitBreakerThreshold:     5,
 		Once:                        false,
 		DryRun:                      false,
+		Output:                      "",
 		UpdateEvents:                false,
 		LogFormat:                   "text",
 		MetricsAddress:              ":7979",
 		LogLevel:                    logrus.InfoLevel.String(),
+		LeaderElectionLeaseName:     "external-dns",
 		ConnectorSourceServer:       "localhost:8080",
 		ExoscaleAPIEnvironment:      "api",
 		ExoscaleAPIZone:             "ch-gva-2",
@@ -179,6 +203,9 @@ var (
 		AWSZoneCacheDuration:        10 * time.Second,
 		AWSSDServiceCleanup:         true,
 		AWSDynamoDBTable:            "custom-table",
+		ConfigMapRegistryNamespace:  "dns",
+		ConfigMapRegistryName:       "ownership",
+		ConfigMapRegistryShards:     4,
 		AzureConfigFile:             "azure.json",
 		AzureResourceGroup:          "arg",
 		AzureSubscriptionID:         "arg",
@@ -196,6 +223,23 @@ var (
 		CoreDNSDeployment:           "coredns",
 		CoreDNSConfigMap:            "coredns",
 		CoreDNSNamespace:            "kube-system",
+		CoreDNSK8sMode:              "zonefile",
+		CoreDNSK8sServerBlock:       "cluster.local:53",
+		CoreDNSK8sZones:             []string{"example.org", "company.com"},
+		CoreDNSK8sSOAMname:          "ns1.example.org",
+		CoreDNSK8sSOARname:          "hostmaster@example.org",
+		CoreDNSK8sSOARefresh:        2 * time.Hour,
+		CoreDNSK8sSOARetry:          30 * time.Minute,
+		CoreDNSK8sSOAExpire:         28 * 24 * time.Hour,
+		CoreDNSK8sSOAMinimum:        5 * time.Minute,
+		CoreDNSK8sNameservers:       []string{"ns1.example.org", "ns2.example.org"},
+		CoreDNSK8sSerialFormat:      "unixtime",
+		CoreDNSK8sImportConfigMap:   "coredns-custom",
+		CoreDNSK8sImportDir:         "/etc/coredns/extra",
+		CoreDNSK8sVerifyReload:      true,
+		CoreDNSK8sVerifyServer:      "10.96.0.10:53",
+		CoreDNSK8sVerifyTimeout:     5 * time.Minute,
+		CoreDNSK8sVerifyInterval:    10 * time.Second,
 		AkamaiServiceConsumerDomain: "oooo-xxxxxxxxxxxxxxxx-xxxxxxxxxxxxxxxx.luna.akamaiapis.net",
 		AkamaiClientToken:           "o184671d5307a388180fbf7f11dbdf46",
 		AkamaiClientSecret:          "o184671d5307a388180fbf7f11dbdf46",
@@ -215,18 +259,39 @@ var (
 		TLSClientCert:               "/path/to/cert.pem",
 		TLSClientCertKey:            "/path/to/key.pem",
 		Policy:                      "upsert-only",
+		MaxDeletes:                  10,
+		MaxDeletesPercent:           20,
+		DeleteGuardOverride:         true,
+		ConflictResolution:          "namespace",
+		ConflictResolutionNamespaces: []string{"prod", "staging"},
+		EmitEvents:                  true,
+		AuditSinks:                  []string{"file", "events"},
+		AuditLogFile:                "/var/log/external-dns/audit.log",
+		AnnotateSyncedAt:            true,
 		Registry:                    "noop",
 		TXTOwnerID:                  "owner-1",
+		TXTOwnerMigrateFrom:         "owner-0",
+		TXTSharedOwnership:          true,
+		TXTLabelsFormat:             "v2",
+		TXTDeleteOrphans:            true,
 		TXTPrefix:                   "associated-txt-record",
 		TXTCacheInterval:            12 * time.Hour,
 		Interval:                    10 * time.Minute,
 		MinEventSyncInterval:        50 * time.Second,
+		Backoff:                     30 * time.Second,
+		MaxBackoff:                  10 * time.Minute,
+		CircuitBreakerThreshold:     3,
 		Once:                        true,
 		DryRun:                      true,
+		Output:                      "json",
 		UpdateEvents:                true,
 		LogFormat:                   "json",
 		MetricsAddress:              "127.0.0.1:9099",
 		LogLevel:                    logrus.DebugLevel.String(),
+		PipelinesConfig:             "pipelines.yaml",
+		LeaderElect:                 true,
+		LeaderElectionLeaseName:     "dns",
+		LeaderElectionNamespace:     "kube-system",
 		ConnectorSourceServer:       "localhost:8081",
 		ExoscaleAPIEnvironment:      "api1",
 		ExoscaleAPIZone:             "zone1",
@@ -309,6 +374,25 @@ func TestParseFlags(t *testing.T) {
 				"--coredns-deployment=coredns",
 				"--coredns-configmap=coredns",
 				"--coredns-namespace=kube-system",
+				"--coredns-k8s-mode=zonefile",
+				"--coredns-k8s-server-block=cluster.local:53",
+				"--coredns-k8s-zone=example.org",
+				"--coredns-k8s-zone=company.com",
+				"--coredns-k8s-soa-mname=ns1.example.org",
+				"--coredns-k8s-soa-rname=hostmaster@example.org",
+				"--coredns-k8s-soa-refresh=2h",
+				"--coredns-k8s-soa-retry=30m",
+				"--coredns-k8s-soa-expire=672h",
+				"--coredns-k8s-soa-minimum=5m",
+				"--coredns-k8s-nameserver=ns1.example.org",
+				"--coredns-k8s-nameserver=ns2.example.org",
+				"--coredns-k8s-serial-format=unixtime",
+				"--coredns-k8s-import-configmap=coredns-custom",
+				"--coredns-k8s-import-dir=/etc/coredns/extra",
+				"--coredns-k8s-verify-reload",
+				"--coredns-k8s-verify-server=10.96.0.10:53",
+				"--coredns-k8s-verify-timeout=5m",
+				"--coredns-k8s-verify-interval=10s",
 				"--akamai-serviceconsumerdomain=oooo-xxxxxxxxxxxxxxxx-xxxxxxxxxxxxxxxx.luna.akamaiapis.net",
 				"--akamai-client-token=o184671d5307a388180fbf7f11dbdf46",
 				"--akamai-client-secret=o184671d5307a388180fbf7f11dbdf46",
@@ -359,19 +443,45 @@ func TestParseFlags(t *testing.T) {
 				"--aws-sd-service-cleanup",
 				"--no-aws-evaluate-target-health",
 				"--policy=upsert-only",
+				"--max-deletes=10",
+				"--max-deletes-percent=20",
+				"--delete-guard-override",
+				"--conflict-resolution=namespace",
+				"--conflict-resolution-namespace=prod",
+				"--conflict-resolution-namespace=staging",
+				"--emit-events",
+				"--audit-sink=file",
+				"--audit-sink=events",
+				"--audit-log-file=/var/log/external-dns/audit.log",
+				"--annotate-synced-at",
 				"--registry=noop",
 				"--txt-owner-id=owner-1",
+				"--txt-owner-migrate-from=owner-0",
+				"--txt-shared-ownership",
+				"--txt-labels-format=v2",
+				"--txt-delete-orphans",
 				"--txt-prefix=associated-txt-record",
 				"--txt-cache-interval=12h",
 				"--dynamodb-table=custom-table",
+				"--configmap-registry-namespace=dns",
+				"--configmap-registry-name=ownership",
+				"--configmap-registry-shards=4",
 				"--interval=10m",
 				"--min-event-sync-interval=50s",
+				"--backoff=30s",
+				"--max-backoff=10m",
+				"--circuit-breaker-threshold=3",
 				"--once",
 				"--dry-run",
+				"--output=json",
 				"--events",
 				"--log-format=json",
 				"--metrics-address=127.0.0.1:9099",
 				"--log-level=debug",
+				"--pipelines-config=pipelines.yaml",
+				"--leader-elect",
+				"--leader-election-lease-name=dns",
+				"--leader-election-namespace=kube-system",
 				"--connector-source-server=localhost:8081",
 				"--exoscale-apienv=api1",
 				"--exoscale-apizone=zone1",
@@ -437,6 +547,23 @@ func TestParseFlags(t *testing.T) {
 				"EXTERNAL_DNS_COREDNS_DEPLOYMENT":               "coredns",
 				"EXTERNAL_DNS_COREDNS_CONFIGIMAP":               "coredns",
 				"EXTERNAL_DNS_COREDNS_NAMESPACE":                "kube-system",
+				"EXTERNAL_DNS_COREDNS_K8S_MODE":                 "zonefile",
+				"EXTERNAL_DNS_COREDNS_K8S_SERVER_BLOCK":         "cluster.local:53",
+				"EXTERNAL_DNS_COREDNS_K8S_ZONE":                 "example.org\ncompany.com",
+				"EXTERNAL_DNS_COREDNS_K8S_SOA_MNAME":            "ns1.example.org",
+				"EXTERNAL_DNS_COREDNS_K8S_SOA_RNAME":            "hostmaster@example.org",
+				"EXTERNAL_DNS_COREDNS_K8S_SOA_REFRESH":          "2h",
+				"EXTERNAL_DNS_COREDNS_K8S_SOA_RETRY":            "30m",
+				"EXTERNAL_DNS_COREDNS_K8S_SOA_EXPIRE":           "672h",
+				"EXTERNAL_DNS_COREDNS_K8S_SOA_MINIMUM":          "5m",
+				"EXTERNAL_DNS_COREDNS_K8S_NAMESERVER":           "ns1.example.org\nns2.example.org",
+				"EXTERNAL_DNS_COREDNS_K8S_SERIAL_FORMAT":        "unixtime",
+				"EXTERNAL_DNS_COREDNS_K8S_IMPORT_CONFIGMAP":     "coredns-custom",
+				"EXTERNAL_DNS_COREDNS_K8S_IMPORT_DIR":           "/etc/coredns/extra",
+				"EXTERNAL_DNS_COREDNS_K8S_VERIFY_RELOAD":        "1",
+				"EXTERNAL_DNS_COREDNS_K8S_VERIFY_SERVER":        "10.96.0.10:53",
+				"EXTERNAL_DNS_COREDNS_K8S_VERIFY_TIMEOUT":       "5m",
+				"EXTERNAL_DNS_COREDNS_K8S_VERIFY_INTERVAL":      "10s",
 				"EXTERNAL_DNS_AKAMAI_SERVICECONSUMERDOMAIN":    "oooo-xxxxxxxxxxxxxxxx-xxxxxxxxxxxxxxxx.luna.akamaiapis.net",
 				"EXTERNAL_DNS_AKAMAI_CLIENT_TOKEN":             "o184671d5307a388180fbf7f11dbdf46",
 				"EXTERNAL_DNS_AKAMAI_CLIENT_SECRET":            "o184671d5307a388180fbf7f11dbdf46",
@@ -480,19 +607,43 @@ func TestParseFlags(t *testing.T) {
 				"EXTERNAL_DNS_AWS_ZONES_CACHE_DURATION":        "10s",
 				"EXTERNAL_DNS_AWS_SD_SERVICE_CLEANUP":          "true",
 				"EXTERNAL_DNS_DYNAMODB_TABLE":                  "custom-table",
+				"EXTERNAL_DNS_CONFIGMAP_REGISTRY_NAMESPACE":    "dns",
+				"EXTERNAL_DNS_CONFIGMAP_REGISTRY_NAME":         "ownership",
+				"EXTERNAL_DNS_CONFIGMAP_REGISTRY_SHARDS":       "4",
 				"EXTERNAL_DNS_POLICY":                          "upsert-only",
+				"EXTERNAL_DNS_MAX_DELETES":                     "10",
+				"EXTERNAL_DNS_MAX_DELETES_PERCENT":             "20",
+				"EXTERNAL_DNS_DELETE_GUARD_OVERRIDE":           "1",
+				"EXTERNAL_DNS_CONFLICT_RESOLUTION":             "namespace",
+				"EXTERNAL_DNS_CONFLICT_RESOLUTION_NAMESPACE":   "prod\nstaging",
+				"EXTERNAL_DNS_EMIT_EVENTS":                     "1",
+				"EXTERNAL_DNS_AUDIT_SINK":                      "file\nevents",
+				"EXTERNAL_DNS_AUDIT_LOG_FILE":                  "/var/log/external-dns/audit.log",
+				"EXTERNAL_DNS_ANNOTATE_SYNCED_AT":              "1",
 				"EXTERNAL_DNS_REGISTRY":                        "noop",
 				"EXTERNAL_DNS_TXT_OWNER_ID":                    "owner-1",
+				"EXTERNAL_DNS_TXT_OWNER_MIGRATE_FROM":          "owner-0",
+				"EXTERNAL_DNS_TXT_SHARED_OWNERSHIP":            "1",
+				"EXTERNAL_DNS_TXT_LABELS_FORMAT":               "v2",
+				"EXTERNAL_DNS_TXT_DELETE_ORPHANS":              "1",
 				"EXTERNAL_DNS_TXT_PREFIX":                      "associated-txt-record",
 				"EXTERNAL_DNS_TXT_CACHE_INTERVAL":              "12h",
 				"EXTERNAL_DNS_INTERVAL":                        "10m",
 				"EXTERNAL_DNS_MIN_EVENT_SYNC_INTERVAL":         "50s",
+				"EXTERNAL_DNS_BACKOFF":                         "30s",
+				"EXTERNAL_DNS_MAX_BACKOFF":                     "10m",
+				"EXTERNAL_DNS_CIRCUIT_BREAKER_THRESHOLD":       "3",
 				"EXTERNAL_DNS_ONCE":                            "1",
 				"EXTERNAL_DNS_DRY_RUN":                         "1",
+				"EXTERNAL_DNS_OUTPUT":                          "json",
 				"EXTERNAL_DNS_EVENTS":                          "1",
 				"EXTERNAL_DNS_LOG_FORMAT":                      "json",
 				"EXTERNAL_DNS_METRICS_ADDRESS":                 "127.0.0.1:9099",
 				"EXTERNAL_DNS_LOG_LEVEL":                       "debug",
+				"EXTERNAL_DNS_PIPELINES_CONFIG":                "pipelines.yaml",
+				"EXTERNAL_DNS_LEADER_ELECT":                    "1",
+				"EXTERNAL_DNS_LEADER_ELECTION_LEASE_NAME":      "dns",
+				"EXTERNAL_DNS_LEADER_ELECTION_NAMESPACE":       "kube-system",
 				"EXTERNAL_DNS_CONNECTOR_SOURCE_SERVER":         "localhost:8081",
 				"EXTERNAL_DNS_EXOSCALE_APIENV":                 "api1",
 				"EXTERNAL_DNS_EXOSCALE_APIZONE":                "zone1",
@@ -25,12 +25,80 @@ import (
 	"sigs.k8s.io/external-dns/pkg/apis/externaldns"
 )
 
+// ValidatePipelines performs validation on the Config object of each pipeline
+func ValidatePipelines(pipelines []*externaldns.Pipeline) error {
+	for _, pipeline := range pipelines {
+		if pipeline.Config.PipelinesConfig != "" || pipeline.Config.WebhookServer {
+			return fmt.Errorf("pipeline %s: pipelines only run controllers", pipeline.Name)
+		}
+		if pipeline.Config.LeaderElect {
+			return fmt.Errorf("pipeline %s: the leader election is shared by the pipelines, set --leader-elect on the process", pipeline.Name)
+		}
+		if err := ValidateConfig(pipeline.Config); err != nil {
+			return fmt.Errorf("pipeline %s: %w", pipeline.Name, err)
+		}
+	}
+	return nil
+}
+
 // ValidateConfig performs validation on the Config object
 func ValidateConfig(cfg *externaldns.Config) error {
 	// TODO: Should probably return field.ErrorList
 	if cfg.LogFormat != "text" && cfg.LogFormat != "json" {
 		return fmt.Errorf("unsupported log format: %s", cfg.LogFormat)
 	}
+	if cfg.Output != "" && !cfg.Once {
+		return errors.New("--output requires --once")
+	}
+	if cfg.MaxDeletes < 0 {
+		return errors.New("--max-deletes must not be negative")
+	}
+	if cfg.MaxDeletesPercent < 0 || cfg.MaxDeletesPercent > 100 {
+		return errors.New("--max-deletes-percent must be between 0 and 100")
+	}
+	for _, sink := range cfg.AuditSinks {
+		if sink == "file" && cfg.AuditLogFile == "" {
+			return errors.New("--audit-sink=file requires --audit-log-file")
+		}
+		if sink == "events" && !cfg.EmitEvents {
+			return errors.New("--audit-sink=events requires --emit-events")
+		}
+	}
+	if cfg.ConflictResolution == "namespace" && len(cfg.ConflictResolutionNamespaces) == 0 {
+		return errors.New("--conflict-resolution=namespace requires --conflict-resolution-namespace")
+	}
+	if cfg.TXTOwnerMigrateFrom != "" {
+		if cfg.Registry != "txt" {
+			return errors.New("--txt-owner-migrate-from requires --registry=txt")
+		}
+		if cfg.TXTOwnerMigrateFrom == cfg.TXTOwnerID {
+			return errors.New("--txt-owner-migrate-from must differ from --txt-owner-id")
+		}
+	}
+	if cfg.TXTSharedOwnership && cfg.Registry != "txt" {
+		return errors.New("--txt-shared-ownership requires --registry=txt")
+	}
+	if cfg.TXTLabelsFormat == "v2" && cfg.Registry != "txt" {
+		return errors.New("--txt-labels-format=v2 requires --registry=txt")
+	}
+	if cfg.TXTDeleteOrphans && cfg.Registry != "txt" {
+		return errors.New("--txt-delete-orphans requires --registry=txt")
+	}
+	if cfg.LeaderElect {
+		if cfg.Once {
+			return errors.New("--leader-elect cannot be used with --once")
+		}
+		if cfg.LeaderElectionLeaseName == "" {
+			return errors.New("--leader-elect requires --leader-election-lease-name")
+		}
+	}
+	if cfg.PipelinesConfig != "" {
+		// the sources and provider are configured by the pipelines, see ValidatePipelines
+		if cfg.WebhookServer {
+			return errors.New("the webhook server cannot run pipelines")
+		}
+		return nil
+	}
 	if len(cfg.Sources) == 0 {
 		return errors.New("no sources specified")
 	}
@@ -94,6 +162,20 @@ func ValidateConfig(cfg *externaldns.Config) error {
 		}
 	}
 
+	if cfg.Provider == "corednsk8s" {
+		if cfg.CoreDNSK8sMode == "hosts" && cfg.Registry == "txt" {
+			return errors.New("--registry=txt cannot be used with --coredns-k8s-mode=hosts, the hosts plugin only serves A and AAAA records")
+		}
+		if cfg.CoreDNSK8sVerifyReload {
+			if cfg.CoreDNSK8sVerifyTimeout <= 0 {
+				return errors.New("--coredns-k8s-verify-timeout must be positive")
+			}
+			if cfg.CoreDNSK8sVerifyInterval <= 0 || cfg.CoreDNSK8sVerifyInterval > cfg.CoreDNSK8sVerifyTimeout {
+				return errors.New("--coredns-k8s-verify-interval must be positive and not longer than --coredns-k8s-verify-timeout")
+			}
+		}
+	}
+
 	if cfg.IgnoreHostnameAnnotation && cfg.FQDNTemplate == "" {
 		return errors.New("FQDN Template must be set if ignoring annotations")
 	}
@@ -102,6 +184,14 @@ func ValidateConfig(cfg *externaldns.Config) error {
 		return errors.New("txt-prefix and txt-suffix are mutual exclusive")
 	}
 
+	if cfg.Backoff < 0 || cfg.MaxBackoff < 0 {
+		return errors.New("--backoff and --max-backoff cannot be negative")
+	}
+
+	if cfg.CircuitBreakerThreshold < 0 {
+		return errors.New("--circuit-breaker-threshold cannot be negative")
+	}
+
 	_, err := labels.Parse(cfg.LabelFilter)
 	if err != nil {
 		return errors.New("--label-filter does not specify a valid label selector")
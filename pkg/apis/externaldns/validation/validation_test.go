@@ -18,6 +18,7 @@ package validation
 
 import (
 	"testing"
+	"time"
 
 	"sigs.k8s.io/external-dns/pkg/apis/externaldns"
 
@@ -50,6 +51,134 @@ func TestValidateFlags(t *testing.T) {
 	cfg = newValidConfig(t)
 	cfg.Provider = ""
 	assert.Error(t, ValidateConfig(cfg))
+
+	cfg = newValidConfig(t)
+	cfg.Backoff = -time.Second
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg = newValidConfig(t)
+	cfg.CircuitBreakerThreshold = -1
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg = newValidConfig(t)
+	cfg.Output = "json"
+	assert.EqualError(t, ValidateConfig(cfg), "--output requires --once")
+	cfg.Once = true
+	assert.NoError(t, ValidateConfig(cfg))
+
+	cfg = newValidConfig(t)
+	cfg.MaxDeletes = -1
+	assert.EqualError(t, ValidateConfig(cfg), "--max-deletes must not be negative")
+
+	cfg = newValidConfig(t)
+	cfg.MaxDeletesPercent = 101
+	assert.EqualError(t, ValidateConfig(cfg), "--max-deletes-percent must be between 0 and 100")
+
+	cfg = newValidConfig(t)
+	cfg.AuditSinks = []string{"stdout", "file"}
+	assert.EqualError(t, ValidateConfig(cfg), "--audit-sink=file requires --audit-log-file")
+	cfg.AuditLogFile = "audit.log"
+	assert.NoError(t, ValidateConfig(cfg))
+	cfg.AuditSinks = []string{"events"}
+	assert.EqualError(t, ValidateConfig(cfg), "--audit-sink=events requires --emit-events")
+	cfg.EmitEvents = true
+	assert.NoError(t, ValidateConfig(cfg))
+
+	cfg = newValidConfig(t)
+	cfg.ConflictResolution = "namespace"
+	assert.EqualError(t, ValidateConfig(cfg), "--conflict-resolution=namespace requires --conflict-resolution-namespace")
+	cfg.ConflictResolutionNamespaces = []string{"prod"}
+	assert.NoError(t, ValidateConfig(cfg))
+
+	cfg = newValidConfig(t)
+	cfg.Registry = "txt"
+	cfg.TXTOwnerMigrateFrom = "old"
+	assert.NoError(t, ValidateConfig(cfg))
+	cfg.TXTOwnerID = "old"
+	assert.EqualError(t, ValidateConfig(cfg), "--txt-owner-migrate-from must differ from --txt-owner-id")
+	cfg.Registry = "dynamodb"
+	assert.EqualError(t, ValidateConfig(cfg), "--txt-owner-migrate-from requires --registry=txt")
+
+	cfg = newValidConfig(t)
+	cfg.Registry = "txt"
+	cfg.TXTSharedOwnership = true
+	assert.NoError(t, ValidateConfig(cfg))
+	cfg.Registry = "configmap"
+	assert.EqualError(t, ValidateConfig(cfg), "--txt-shared-ownership requires --registry=txt")
+
+	cfg = newValidConfig(t)
+	cfg.Registry = "txt"
+	cfg.TXTLabelsFormat = "v2"
+	assert.NoError(t, ValidateConfig(cfg))
+	cfg.Registry = "dynamodb"
+	assert.EqualError(t, ValidateConfig(cfg), "--txt-labels-format=v2 requires --registry=txt")
+
+	cfg = newValidConfig(t)
+	cfg.Registry = "txt"
+	cfg.TXTDeleteOrphans = true
+	assert.NoError(t, ValidateConfig(cfg))
+	cfg.Registry = "noop"
+	assert.EqualError(t, ValidateConfig(cfg), "--txt-delete-orphans requires --registry=txt")
+
+	cfg = newValidConfig(t)
+	cfg.LeaderElect = true
+	cfg.LeaderElectionLeaseName = "external-dns"
+	assert.NoError(t, ValidateConfig(cfg))
+	cfg.Once = true
+	assert.EqualError(t, ValidateConfig(cfg), "--leader-elect cannot be used with --once")
+	cfg.Once = false
+	cfg.LeaderElectionLeaseName = ""
+	assert.EqualError(t, ValidateConfig(cfg), "--leader-elect requires --leader-election-lease-name")
+
+	cfg = newValidConfig(t)
+	cfg.Provider = "corednsk8s"
+	cfg.CoreDNSK8sMode = "hosts"
+	cfg.Registry = "noop"
+	assert.NoError(t, ValidateConfig(cfg))
+	cfg.Registry = "txt"
+	assert.EqualError(t, ValidateConfig(cfg), "--registry=txt cannot be used with --coredns-k8s-mode=hosts, the hosts plugin only serves A and AAAA records")
+	cfg.CoreDNSK8sMode = "zonefile"
+	assert.NoError(t, ValidateConfig(cfg))
+
+	cfg = newValidConfig(t)
+	cfg.Provider = "corednsk8s"
+	cfg.CoreDNSK8sVerifyReload = true
+	cfg.CoreDNSK8sVerifyTimeout = time.Minute
+	cfg.CoreDNSK8sVerifyInterval = 5 * time.Second
+	assert.NoError(t, ValidateConfig(cfg))
+	cfg.CoreDNSK8sVerifyInterval = 0
+	assert.EqualError(t, ValidateConfig(cfg), "--coredns-k8s-verify-interval must be positive and not longer than --coredns-k8s-verify-timeout")
+	cfg.CoreDNSK8sVerifyInterval = 2 * time.Minute
+	assert.EqualError(t, ValidateConfig(cfg), "--coredns-k8s-verify-interval must be positive and not longer than --coredns-k8s-verify-timeout")
+	cfg.CoreDNSK8sVerifyTimeout = 0
+	assert.EqualError(t, ValidateConfig(cfg), "--coredns-k8s-verify-timeout must be positive")
+}
+
+func TestValidatePipelinesConfig(t *testing.T) {
+	cfg := externaldns.NewConfig()
+	cfg.LogFormat = "text"
+	cfg.PipelinesConfig = "pipelines.yaml"
+	assert.NoError(t, ValidateConfig(cfg))
+
+	cfg.WebhookServer = true
+	assert.Error(t, ValidateConfig(cfg))
+
+	pipelines := []*externaldns.Pipeline{
+		{Name: "public", Config: newValidConfig(t)},
+		{Name: "private", Config: newValidConfig(t)},
+	}
+	assert.NoError(t, ValidatePipelines(pipelines))
+
+	pipelines[1].Config.Provider = ""
+	assert.EqualError(t, ValidatePipelines(pipelines), "pipeline private: no provider specified")
+
+	pipelines[1].Config = newValidConfig(t)
+	pipelines[1].Config.LeaderElect = true
+	assert.EqualError(t, ValidatePipelines(pipelines), "pipeline private: the leader election is shared by the pipelines, set --leader-elect on the process")
+
+	pipelines[1].Config = newValidConfig(t)
+	pipelines[1].Config.PipelinesConfig = "pipelines.yaml"
+	assert.Error(t, ValidatePipelines(pipelines))
 }
 
 func newValidConfig(t *testing.T) *externaldns.Config {
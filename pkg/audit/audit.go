@@ -0,0 +1,210 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package audit
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/pkg/events"
+	"sigs.k8s.io/external-dns/plan"
+	"sigs.k8s.io/external-dns/provider"
+)
+
+// Actions of the audited changes
+const (
+	ActionCreate = plan.ActionCreate
+	ActionUpdate = "update"
+	ActionDelete = plan.ActionDelete
+)
+
+// Results of the audited changes
+const (
+	ResultSucceeded = "succeeded"
+	ResultFailed    = "failed"
+)
+
+// Record is a change applied to a DNS record
+type Record struct {
+	Time          time.Time `json:"time"`
+	Pipeline      string    `json:"pipeline,omitempty"`
+	Action        string    `json:"action"`
+	DNSName       string    `json:"dnsName"`
+	RecordType    string    `json:"recordType"`
+	SetIdentifier string    `json:"setIdentifier,omitempty"`
+	Owner         string    `json:"owner,omitempty"`
+	Resource      string    `json:"resource,omitempty"`
+	OldTargets    []string  `json:"oldTargets,omitempty"`
+	NewTargets    []string  `json:"newTargets,omitempty"`
+	OldTTL        int64     `json:"oldTTL,omitempty"`
+	NewTTL        int64     `json:"newTTL,omitempty"`
+	Result        string    `json:"result"`
+	Error         string    `json:"error,omitempty"`
+}
+
+// Sink receives the records of the changes applied by a synchronization
+type Sink interface {
+	Write(records []Record) error
+}
+
+// NewRecords returns the records of the applied changes. When results are given, the result of each
+// change is the one of its endpoint, otherwise every change has the result of err.
+func NewRecords(now time.Time, pipeline, ownerID string, changes *plan.Changes, results []provider.ChangeResult, err error) []Record {
+	errs := make(map[*endpoint.Endpoint]error, len(results))
+	for _, result := range results {
+		errs[result.Endpoint] = result.Err
+	}
+	newRecord := func(action string, oldEp, newEp *endpoint.Endpoint) Record {
+		ep := newEp
+		if ep == nil {
+			ep = oldEp
+		}
+		record := Record{
+			Time:          now,
+			Pipeline:      pipeline,
+			Action:        action,
+			DNSName:       ep.DNSName,
+			RecordType:    ep.RecordType,
+			SetIdentifier: ep.SetIdentifier,
+			Owner:         ep.Labels[endpoint.OwnerLabelKey],
+			Resource:      ep.Labels[endpoint.ResourceLabelKey],
+			Result:        ResultSucceeded,
+		}
+		if record.Owner == "" {
+			record.Owner = ownerID
+		}
+		if oldEp != nil {
+			record.OldTargets = append([]string{}, oldEp.Targets...)
+			record.OldTTL = int64(oldEp.RecordTTL)
+		}
+		if newEp != nil {
+			record.NewTargets = append([]string{}, newEp.Targets...)
+			record.NewTTL = int64(newEp.RecordTTL)
+		}
+		changeErr := err
+		if results != nil {
+			changeErr = errs[ep]
+		}
+		if changeErr != nil {
+			record.Result = ResultFailed
+			record.Error = changeErr.Error()
+		}
+		return record
+	}
+
+	records := make([]Record, 0, len(changes.Create)+len(changes.UpdateNew)+len(changes.Delete))
+	for _, ep := range changes.Create {
+		records = append(records, newRecord(ActionCreate, nil, ep))
+	}
+	for i, ep := range changes.UpdateNew {
+		var old *endpoint.Endpoint
+		if i < len(changes.UpdateOld) {
+			old = changes.UpdateOld[i]
+		}
+		records = append(records, newRecord(ActionUpdate, old, ep))
+	}
+	for _, ep := range changes.Delete {
+		records = append(records, newRecord(ActionDelete, ep, nil))
+	}
+	return records
+}
+
+// WriterSink writes the records as JSON lines
+type WriterSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewWriterSink returns a sink writing the records as JSON lines to w
+func NewWriterSink(w io.Writer) *WriterSink {
+	return &WriterSink{w: w}
+}
+
+// NewFileSink returns a sink appending the records as JSON lines to the file at path
+func NewFileSink(path string) (*WriterSink, error) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("opening the audit log: %w", err)
+	}
+	return NewWriterSink(f), nil
+}
+
+// Write writes each record as a line of JSON
+func (s *WriterSink) Write(records []Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	enc := json.NewEncoder(s.w)
+	for _, record := range records {
+		if err := enc.Encode(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// EventSink records the records as events on the resources of the changed endpoints
+type EventSink struct {
+	Emitter events.Emitter
+}
+
+// Write emits an event per record with a resource
+func (s *EventSink) Write(records []Record) error {
+	for _, record := range records {
+		if record.Resource == "" {
+			continue
+		}
+		event := events.Event{
+			Resource: record.Resource,
+			Type:     corev1.EventTypeNormal,
+			Reason:   events.ReasonRecordChanged,
+			Message:  fmt.Sprintf("%s %s record %s", record.Action, record.RecordType, record.DNSName),
+		}
+		if record.NewTargets != nil {
+			event.Message += " to " + strings.Join(record.NewTargets, ",")
+		}
+		if record.Result == ResultFailed {
+			event.Type = corev1.EventTypeWarning
+			event.Reason = events.ReasonRecordChangeFailed
+			event.Message += " failed: " + record.Error
+		}
+		s.Emitter.Emit(event)
+	}
+	return nil
+}
+
+// MultiSink writes the records to each of its sinks
+type MultiSink []Sink
+
+// Write writes the records to each sink, and returns the errors of the sinks
+func (s MultiSink) Write(records []Record) error {
+	var errs []error
+	for _, sink := range s {
+		if err := sink.Write(records); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
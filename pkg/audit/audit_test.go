@@ -0,0 +1,149 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package audit
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/pkg/events"
+	"sigs.k8s.io/external-dns/plan"
+	"sigs.k8s.io/external-dns/provider"
+)
+
+var now = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func testChanges() *plan.Changes {
+	create := endpoint.NewEndpointWithTTL("new.example.org", endpoint.RecordTypeA, 300, "1.1.1.1")
+	create.Labels[endpoint.ResourceLabelKey] = "ingress/default/new"
+	oldEp := endpoint.NewEndpointWithTTL("foo.example.org", endpoint.RecordTypeA, 300, "2.2.2.2")
+	oldEp.Labels[endpoint.OwnerLabelKey] = "me"
+	newEp := endpoint.NewEndpointWithTTL("foo.example.org", endpoint.RecordTypeA, 60, "3.3.3.3")
+	newEp.Labels[endpoint.OwnerLabelKey] = "me"
+	newEp.Labels[endpoint.ResourceLabelKey] = "service/default/foo"
+	return &plan.Changes{
+		Create:    []*endpoint.Endpoint{create},
+		UpdateOld: []*endpoint.Endpoint{oldEp},
+		UpdateNew: []*endpoint.Endpoint{newEp},
+		Delete:    []*endpoint.Endpoint{endpoint.NewEndpoint("old.example.org", endpoint.RecordTypeCNAME, "bar.example.org")},
+	}
+}
+
+func TestNewRecords(t *testing.T) {
+	changes := testChanges()
+
+	records := NewRecords(now, "public", "me", changes, nil, nil)
+	assert.Equal(t, []Record{
+		{Time: now, Pipeline: "public", Action: ActionCreate, DNSName: "new.example.org", RecordType: "A", Owner: "me", Resource: "ingress/default/new", NewTargets: []string{"1.1.1.1"}, NewTTL: 300, Result: ResultSucceeded},
+		{Time: now, Pipeline: "public", Action: ActionUpdate, DNSName: "foo.example.org", RecordType: "A", Owner: "me", Resource: "service/default/foo", OldTargets: []string{"2.2.2.2"}, OldTTL: 300, NewTargets: []string{"3.3.3.3"}, NewTTL: 60, Result: ResultSucceeded},
+		{Time: now, Pipeline: "public", Action: ActionDelete, DNSName: "old.example.org", RecordType: "CNAME", Owner: "me", OldTargets: []string{"bar.example.org"}, Result: ResultSucceeded},
+	}, records)
+
+	// every change failed with the error
+	records = NewRecords(now, "", "me", changes, nil, errors.New("boom"))
+	for _, record := range records {
+		assert.Equal(t, ResultFailed, record.Result)
+		assert.Equal(t, "boom", record.Error)
+	}
+
+	// each change has the result of its endpoint
+	records = NewRecords(now, "", "me", changes, []provider.ChangeResult{
+		{Endpoint: changes.Create[0]},
+		{Endpoint: changes.UpdateNew[0], Err: errors.New("invalid")},
+		{Endpoint: changes.Delete[0]},
+	}, nil)
+	assert.Equal(t, ResultSucceeded, records[0].Result)
+	assert.Equal(t, ResultFailed, records[1].Result)
+	assert.Equal(t, "invalid", records[1].Error)
+	assert.Equal(t, ResultSucceeded, records[2].Result)
+}
+
+func TestWriterSink(t *testing.T) {
+	var buf bytes.Buffer
+	sink := NewWriterSink(&buf)
+	require.NoError(t, sink.Write(NewRecords(now, "", "me", &plan.Changes{Delete: testChanges().Delete}, nil, nil)))
+
+	assert.Equal(t, `{"time":"2024-01-01T00:00:00Z","action":"delete","dnsName":"old.example.org","recordType":"CNAME","owner":"me","oldTargets":["bar.example.org"],"result":"succeeded"}`+"\n", buf.String())
+}
+
+func TestFileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	records := NewRecords(now, "", "me", testChanges(), nil, nil)
+
+	sink, err := NewFileSink(path)
+	require.NoError(t, err)
+	require.NoError(t, sink.Write(records[:1]))
+	// the records are appended to the existing ones
+	sink, err = NewFileSink(path)
+	require.NoError(t, err)
+	require.NoError(t, sink.Write(records[1:]))
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Len(t, bytes.Split(bytes.TrimSpace(content), []byte("\n")), 3)
+
+	_, err = NewFileSink(filepath.Join(t.TempDir(), "missing", "audit.log"))
+	assert.Error(t, err)
+}
+
+type recordingEmitter struct {
+	events []events.Event
+}
+
+func (e *recordingEmitter) Emit(event events.Event) {
+	e.events = append(e.events, event)
+}
+
+func TestEventSink(t *testing.T) {
+	changes := testChanges()
+	emitter := &recordingEmitter{}
+	sink := &EventSink{Emitter: emitter}
+	require.NoError(t, sink.Write(NewRecords(now, "", "me", changes, []provider.ChangeResult{
+		{Endpoint: changes.Create[0]},
+		{Endpoint: changes.UpdateNew[0], Err: errors.New("invalid")},
+		{Endpoint: changes.Delete[0]},
+	}, nil)))
+
+	// the deleted record has no resource
+	assert.Equal(t, []events.Event{
+		{Resource: "ingress/default/new", Type: corev1.EventTypeNormal, Reason: events.ReasonRecordChanged, Message: "create A record new.example.org to 1.1.1.1"},
+		{Resource: "service/default/foo", Type: corev1.EventTypeWarning, Reason: events.ReasonRecordChangeFailed, Message: "update A record foo.example.org to 3.3.3.3 failed: invalid"},
+	}, emitter.events)
+}
+
+type errorSink struct{}
+
+func (errorSink) Write([]Record) error {
+	return errors.New("full")
+}
+
+func TestMultiSink(t *testing.T) {
+	var buf bytes.Buffer
+	sink := MultiSink{errorSink{}, NewWriterSink(&buf)}
+
+	assert.EqualError(t, sink.Write(NewRecords(now, "", "me", testChanges(), nil, nil)), "full")
+	assert.NotEmpty(t, buf.String())
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package events
+
+import (
+	"context"
+	"encoding/json"
+
+	log "github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+)
+
+// Annotator annotates the Kubernetes resources of the endpoints
+type Annotator interface {
+	Annotate(ctx context.Context, resource string, annotations map[string]string) error
+}
+
+// KubeAnnotator annotates the resources with the Kubernetes API
+type KubeAnnotator struct {
+	client dynamic.Interface
+}
+
+// NewKubeAnnotator returns an annotator patching the resources with the given client
+func NewKubeAnnotator(client dynamic.Interface) *KubeAnnotator {
+	return &KubeAnnotator{client: client}
+}
+
+// Annotate sets the annotations of the resource of the given resource label, unless its kind is
+// unknown or it does not exist anymore
+func (a *KubeAnnotator) Annotate(ctx context.Context, resource string, annotations map[string]string) error {
+	kind, namespace, name, ok := parseResource(resource)
+	if !ok {
+		log.Debugf("Skipping the annotations of %q: unknown resource kind", resource)
+		return nil
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{"annotations": annotations},
+	})
+	if err != nil {
+		return err
+	}
+	gvr := kind.gvk.GroupVersion().WithResource(kind.resource)
+	_, err = a.client.Resource(gvr).Namespace(namespace).Patch(ctx, name, types.MergePatchType, patch, metav1.PatchOptions{})
+	if apierrors.IsNotFound(err) {
+		log.Debugf("Skipping the annotations of %q: not found", resource)
+		return nil
+	}
+	return err
+}
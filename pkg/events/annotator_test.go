@@ -0,0 +1,55 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package events
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakedynamic "k8s.io/client-go/dynamic/fake"
+)
+
+func TestKubeAnnotator(t *testing.T) {
+	ingress := &unstructured.Unstructured{}
+	ingress.SetAPIVersion("networking.k8s.io/v1")
+	ingress.SetKind("Ingress")
+	ingress.SetNamespace("default")
+	ingress.SetName("foo")
+	ingress.SetAnnotations(map[string]string{"kubernetes.io/ingress.class": "nginx"})
+	gvr := schema.GroupVersionResource{Group: "networking.k8s.io", Version: "v1", Resource: "ingresses"}
+	client := fakedynamic.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{gvr: "IngressList"}, ingress)
+	annotator := NewKubeAnnotator(client)
+	ctx := context.Background()
+
+	require.NoError(t, annotator.Annotate(ctx, "ingress/default/foo", map[string]string{"external-dns.alpha.kubernetes.io/synced-at": "2024-01-01T00:00:00Z"}))
+	annotated, err := client.Resource(gvr).Namespace("default").Get(ctx, "foo", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"kubernetes.io/ingress.class":                "nginx",
+		"external-dns.alpha.kubernetes.io/synced-at": "2024-01-01T00:00:00Z",
+	}, annotated.GetAnnotations())
+
+	// the deleted resources and the unknown kinds are skipped
+	assert.NoError(t, annotator.Annotate(ctx, "ingress/default/deleted", map[string]string{"foo": "bar"}))
+	assert.NoError(t, annotator.Annotate(ctx, "unknown/default/foo", map[string]string{"foo": "bar"}))
+}
@@ -0,0 +1,130 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package events
+
+import (
+	"context"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+// Reasons of the events
+const (
+	// ReasonConflictRejected is the reason of the events on the resources which lost a DNS name to another resource
+	ReasonConflictRejected = "ConflictRejected"
+	// ReasonRecordChanged is the reason of the events on the resources whose DNS records were changed
+	ReasonRecordChanged = "RecordChanged"
+	// ReasonRecordChangeFailed is the reason of the events on the resources whose DNS records failed to change
+	ReasonRecordChangeFailed = "RecordChangeFailed"
+)
+
+// Event is an event on the Kubernetes resource of an endpoint
+type Event struct {
+	// Resource is the resource label of the endpoint, i.e. kind/namespace/name
+	Resource string
+	// Type is corev1.EventTypeNormal or corev1.EventTypeWarning
+	Type    string
+	Reason  string
+	Message string
+}
+
+// Emitter records events on the Kubernetes resources of the endpoints
+type Emitter interface {
+	Emit(event Event)
+}
+
+// resourceKind is the kind of a resource, and the name of the resource of the kind in the API
+type resourceKind struct {
+	gvk      schema.GroupVersionKind
+	resource string
+}
+
+// resourceKinds are the kinds of the resources referenced by the resource labels of the sources
+var resourceKinds = map[string]resourceKind{
+	"service":        {schema.GroupVersionKind{Version: "v1", Kind: "Service"}, "services"},
+	"ingress":        {schema.GroupVersionKind{Group: "networking.k8s.io", Version: "v1", Kind: "Ingress"}, "ingresses"},
+	"crd":            {schema.GroupVersionKind{Group: "externaldns.k8s.io", Version: "v1alpha1", Kind: "DNSEndpoint"}, "dnsendpoints"},
+	"gateway":        {schema.GroupVersionKind{Group: "networking.istio.io", Version: "v1alpha3", Kind: "Gateway"}, "gateways"},
+	"virtualservice": {schema.GroupVersionKind{Group: "networking.istio.io", Version: "v1alpha3", Kind: "VirtualService"}, "virtualservices"},
+	"httproute":      {schema.GroupVersionKind{Group: "gateway.networking.k8s.io", Version: "v1", Kind: "HTTPRoute"}, "httproutes"},
+	"grpcroute":      {schema.GroupVersionKind{Group: "gateway.networking.k8s.io", Version: "v1alpha2", Kind: "GRPCRoute"}, "grpcroutes"},
+	"tlsroute":       {schema.GroupVersionKind{Group: "gateway.networking.k8s.io", Version: "v1alpha2", Kind: "TLSRoute"}, "tlsroutes"},
+	"tcproute":       {schema.GroupVersionKind{Group: "gateway.networking.k8s.io", Version: "v1alpha2", Kind: "TCPRoute"}, "tcproutes"},
+	"udproute":       {schema.GroupVersionKind{Group: "gateway.networking.k8s.io", Version: "v1alpha2", Kind: "UDPRoute"}, "udproutes"},
+	"route":          {schema.GroupVersionKind{Group: "route.openshift.io", Version: "v1", Kind: "Route"}, "routes"},
+	"HTTPProxy":      {schema.GroupVersionKind{Group: "projectcontour.io", Version: "v1", Kind: "HTTPProxy"}, "httpproxies"},
+}
+
+// parseResource returns the kind, namespace and name of the resource of the given resource label,
+// if its kind is known
+func parseResource(resource string) (resourceKind, string, string, bool) {
+	parts := strings.Split(resource, "/")
+	if len(parts) != 3 {
+		return resourceKind{}, "", "", false
+	}
+	kind, ok := resourceKinds[parts[0]]
+	return kind, parts[1], parts[2], ok
+}
+
+// objectReference returns the reference of the resource of the given resource label, if its kind is known
+func objectReference(resource string) (*corev1.ObjectReference, bool) {
+	kind, namespace, name, ok := parseResource(resource)
+	if !ok {
+		return nil, false
+	}
+	return &corev1.ObjectReference{
+		APIVersion: kind.gvk.GroupVersion().String(),
+		Kind:       kind.gvk.Kind,
+		Namespace:  namespace,
+		Name:       name,
+	}, true
+}
+
+// KubeEmitter records the events with the Kubernetes API
+type KubeEmitter struct {
+	recorder record.EventRecorder
+}
+
+// NewKubeEmitter returns an emitter recording the events with the given client until the context is done
+func NewKubeEmitter(ctx context.Context, client kubernetes.Interface) *KubeEmitter {
+	broadcaster := record.NewBroadcaster(record.WithContext(ctx))
+	broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: client.CoreV1().Events("")})
+	go func() {
+		<-ctx.Done()
+		broadcaster.Shutdown()
+	}()
+	return &KubeEmitter{
+		recorder: broadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: "external-dns"}),
+	}
+}
+
+// Emit records the event on its resource, unless the kind of the resource is unknown
+func (e *KubeEmitter) Emit(event Event) {
+	ref, ok := objectReference(event.Resource)
+	if !ok {
+		log.Debugf("Skipping the event %s on %q: unknown resource kind", event.Reason, event.Resource)
+		return
+	}
+	e.recorder.Event(ref, event.Type, event.Reason, event.Message)
+}
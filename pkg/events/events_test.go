@@ -0,0 +1,69 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package events
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestObjectReference(t *testing.T) {
+	ref, ok := objectReference("ingress/default/foo")
+	require.True(t, ok)
+	assert.Equal(t, &corev1.ObjectReference{APIVersion: "networking.k8s.io/v1", Kind: "Ingress", Namespace: "default", Name: "foo"}, ref)
+
+	ref, ok = objectReference("service/default/foo")
+	require.True(t, ok)
+	assert.Equal(t, "v1", ref.APIVersion)
+
+	for _, resource := range []string{"", "ingress/foo", "unknown/default/foo"} {
+		_, ok = objectReference(resource)
+		assert.False(t, ok, resource)
+	}
+}
+
+func TestKubeEmitter(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	client := fake.NewSimpleClientset()
+	emitter := NewKubeEmitter(ctx, client)
+
+	emitter.Emit(Event{Resource: "unknown/default/foo", Type: corev1.EventTypeWarning, Reason: ReasonConflictRejected, Message: "skipped"})
+	emitter.Emit(Event{Resource: "ingress/default/foo", Type: corev1.EventTypeWarning, Reason: ReasonConflictRejected, Message: "rejected"})
+
+	var events *corev1.EventList
+	require.Eventually(t, func() bool {
+		var err error
+		events, err = client.CoreV1().Events("default").List(ctx, metav1.ListOptions{})
+		return err == nil && len(events.Items) > 0
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Len(t, events.Items, 1)
+	event := events.Items[0]
+	assert.Equal(t, "Ingress", event.InvolvedObject.Kind)
+	assert.Equal(t, "foo", event.InvolvedObject.Name)
+	assert.Equal(t, corev1.EventTypeWarning, event.Type)
+	assert.Equal(t, ReasonConflictRejected, event.Reason)
+	assert.Equal(t, "rejected", event.Message)
+	assert.Equal(t, "external-dns", event.Source.Component)
+}
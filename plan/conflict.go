@@ -17,7 +17,11 @@ limitations under the License.
 package plan
 
 import (
+	"fmt"
 	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 
@@ -122,4 +126,136 @@ func (s PerResource) less(x, y *endpoint.Endpoint) bool {
 	return x.Targets.IsLess(y.Targets)
 }
 
-// TODO: with cross-resource/cross-cluster setup alternative variations of ConflictResolver can be used
+// Names of the conflict resolution strategies
+const (
+	ConflictResolutionPerResource = "per-resource"
+	ConflictResolutionOldest      = "oldest"
+	ConflictResolutionPriority    = "priority"
+	ConflictResolutionNamespace   = "namespace"
+)
+
+// NewConflictResolver returns the resolver of the named strategy. The namespaces rank the resources
+// of the namespace strategy.
+func NewConflictResolver(name string, namespaces []string) (ConflictResolver, error) {
+	switch name {
+	case "", ConflictResolutionPerResource:
+		return PerResource{}, nil
+	case ConflictResolutionOldest:
+		return RankedResolver{Compare: compareCreation}, nil
+	case ConflictResolutionPriority:
+		return RankedResolver{Compare: comparePriority}, nil
+	case ConflictResolutionNamespace:
+		if len(namespaces) == 0 {
+			return nil, fmt.Errorf("the %s conflict resolution requires namespaces", name)
+		}
+		return RankedResolver{Compare: namespaceComparator(namespaces)}, nil
+	default:
+		return nil, fmt.Errorf("unknown conflict resolution %q", name)
+	}
+}
+
+// RankedResolver allows only one resource to own a given dns name like PerResource, but picks the
+// resource ranked first rather than the one with the "minimal" targets. A resource ranked before the
+// one which already acquired the dns name takes it over. Resources ranked equally are resolved like
+// with PerResource.
+type RankedResolver struct {
+	PerResource
+	// Compare returns a negative number when x is ranked before y, a positive one when x is ranked
+	// after y, and 0 when they are ranked equally
+	Compare func(x, y *endpoint.Endpoint) int
+}
+
+// ResolveCreate takes the endpoint of the resource ranked first to acquire the DNS record
+func (s RankedResolver) ResolveCreate(candidates []*endpoint.Endpoint) *endpoint.Endpoint {
+	var min *endpoint.Endpoint
+	for _, ep := range candidates {
+		if min == nil || s.less(ep, min) {
+			min = ep
+		}
+	}
+	return min
+}
+
+// ResolveUpdate keeps the "current" resource when no other resource is ranked before it
+func (s RankedResolver) ResolveUpdate(current *endpoint.Endpoint, candidates []*endpoint.Endpoint) *endpoint.Endpoint {
+	currentResource := current.Labels[endpoint.ResourceLabelKey]
+	first := s.ResolveCreate(candidates)
+	// the endpoint of the current resource ranked first, for consistency when it has several
+	var kept *endpoint.Endpoint
+	for _, ep := range candidates {
+		if ep.Labels[endpoint.ResourceLabelKey] != currentResource || s.Compare(ep, first) != 0 {
+			continue
+		}
+		if kept == nil || s.less(ep, kept) {
+			kept = ep
+		}
+	}
+	if kept != nil {
+		return kept
+	}
+	return first
+}
+
+// less returns true if endpoint x is ranked before y, or if they are ranked equally and x is less than y
+func (s RankedResolver) less(x, y *endpoint.Endpoint) bool {
+	if c := s.Compare(x, y); c != 0 {
+		return c < 0
+	}
+	return s.PerResource.less(x, y)
+}
+
+// compareCreation ranks the oldest resource first, and the resources without creation timestamp last
+func compareCreation(x, y *endpoint.Endpoint) int {
+	created := func(ep *endpoint.Endpoint) (time.Time, bool) {
+		t, err := time.Parse(time.RFC3339, ep.Labels[endpoint.ResourceCreatedLabelKey])
+		return t, err == nil
+	}
+	xCreated, xOk := created(x)
+	yCreated, yOk := created(y)
+	switch {
+	case xOk && yOk:
+		return xCreated.Compare(yCreated)
+	case xOk:
+		return -1
+	case yOk:
+		return 1
+	}
+	return 0
+}
+
+// comparePriority ranks the resource with the highest priority first, the resources without priority
+// have the priority 0
+func comparePriority(x, y *endpoint.Endpoint) int {
+	priority := func(ep *endpoint.Endpoint) int {
+		p, _ := strconv.Atoi(ep.Labels[endpoint.ResourcePriorityLabelKey])
+		return p
+	}
+	xPriority, yPriority := priority(x), priority(y)
+	switch {
+	case xPriority > yPriority:
+		return -1
+	case xPriority < yPriority:
+		return 1
+	}
+	return 0
+}
+
+// namespaceComparator ranks the resources in the order of their namespaces in the list, and the
+// resources of the other namespaces last
+func namespaceComparator(namespaces []string) func(x, y *endpoint.Endpoint) int {
+	rank := func(ep *endpoint.Endpoint) int {
+		// the resource label is kind/namespace/name
+		parts := strings.Split(ep.Labels[endpoint.ResourceLabelKey], "/")
+		if len(parts) == 3 {
+			for i, namespace := range namespaces {
+				if parts[1] == namespace {
+					return i
+				}
+			}
+		}
+		return len(namespaces)
+	}
+	return func(x, y *endpoint.Endpoint) int {
+		return rank(x) - rank(y)
+	}
+}
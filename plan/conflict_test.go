@@ -20,6 +20,8 @@ import (
 	"reflect"
 	"testing"
 
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 	"sigs.k8s.io/external-dns/endpoint"
 )
@@ -295,3 +297,66 @@ func (suite *ResolverSuite) TestPerResource_ResolveRecordTypes() {
 func TestConflictResolver(t *testing.T) {
 	suite.Run(t, new(ResolverSuite))
 }
+
+func rankedEndpoint(target, resource string, labels map[string]string) *endpoint.Endpoint {
+	ep := endpoint.NewEndpoint("foo", endpoint.RecordTypeA, target)
+	ep.Labels[endpoint.ResourceLabelKey] = resource
+	for k, v := range labels {
+		ep.Labels[k] = v
+	}
+	return ep
+}
+
+func TestNewConflictResolver(t *testing.T) {
+	for _, name := range []string{"", ConflictResolutionPerResource} {
+		resolver, err := NewConflictResolver(name, nil)
+		require.NoError(t, err)
+		assert.Equal(t, PerResource{}, resolver)
+	}
+	for _, name := range []string{ConflictResolutionOldest, ConflictResolutionPriority} {
+		resolver, err := NewConflictResolver(name, nil)
+		require.NoError(t, err)
+		assert.IsType(t, RankedResolver{}, resolver)
+	}
+	_, err := NewConflictResolver(ConflictResolutionNamespace, nil)
+	assert.Error(t, err)
+	_, err = NewConflictResolver("random", nil)
+	assert.Error(t, err)
+}
+
+func TestRankedResolver(t *testing.T) {
+	older := rankedEndpoint("2.2.2.2", "ingress/team-b/old", map[string]string{endpoint.ResourceCreatedLabelKey: "2023-01-01T00:00:00Z"})
+	newer := rankedEndpoint("1.1.1.1", "ingress/team-a/new", map[string]string{endpoint.ResourceCreatedLabelKey: "2024-01-01T00:00:00Z"})
+	unknown := rankedEndpoint("0.0.0.0", "ingress/team-c/unknown", nil)
+	prioritized := rankedEndpoint("3.3.3.3", "ingress/team-c/prioritized", map[string]string{endpoint.ResourcePriorityLabelKey: "10"})
+	deprioritized := rankedEndpoint("0.0.0.1", "ingress/team-a/deprioritized", map[string]string{endpoint.ResourcePriorityLabelKey: "-1"})
+
+	oldest, err := NewConflictResolver(ConflictResolutionOldest, nil)
+	require.NoError(t, err)
+	assert.Equal(t, older, oldest.ResolveCreate([]*endpoint.Endpoint{unknown, newer, older}))
+	// the current resource is kept only while no resource is older
+	assert.Equal(t, older, oldest.ResolveUpdate(newer, []*endpoint.Endpoint{newer, older}))
+	assert.Equal(t, newer, oldest.ResolveUpdate(newer, []*endpoint.Endpoint{newer, unknown}))
+	// the resources without creation timestamp are resolved like PerResource
+	otherUnknown := rankedEndpoint("0.0.0.1", "ingress/team-c/other", nil)
+	assert.Equal(t, unknown, oldest.ResolveCreate([]*endpoint.Endpoint{otherUnknown, unknown}))
+	assert.Equal(t, otherUnknown, oldest.ResolveUpdate(otherUnknown, []*endpoint.Endpoint{unknown, otherUnknown}))
+
+	priority, err := NewConflictResolver(ConflictResolutionPriority, nil)
+	require.NoError(t, err)
+	assert.Equal(t, prioritized, priority.ResolveCreate([]*endpoint.Endpoint{unknown, prioritized, deprioritized}))
+	assert.Equal(t, unknown, priority.ResolveCreate([]*endpoint.Endpoint{deprioritized, unknown}))
+	assert.Equal(t, prioritized, priority.ResolveUpdate(unknown, []*endpoint.Endpoint{unknown, prioritized}))
+
+	namespace, err := NewConflictResolver(ConflictResolutionNamespace, []string{"team-b", "team-a"})
+	require.NoError(t, err)
+	assert.Equal(t, older, namespace.ResolveCreate([]*endpoint.Endpoint{unknown, newer, older}))
+	assert.Equal(t, newer, namespace.ResolveCreate([]*endpoint.Endpoint{unknown, newer}))
+	assert.Equal(t, deprioritized, namespace.ResolveUpdate(deprioritized, []*endpoint.Endpoint{newer, deprioritized, unknown}))
+
+	// the candidates are not reordered, and the least endpoint of the current resource is kept
+	sameResource := rankedEndpoint("1.1.1.0", "ingress/team-a/new", map[string]string{endpoint.ResourceCreatedLabelKey: "2024-01-01T00:00:00Z"})
+	candidates := []*endpoint.Endpoint{unknown, newer, sameResource}
+	assert.Equal(t, sameResource, oldest.ResolveUpdate(newer, candidates))
+	assert.Equal(t, []*endpoint.Endpoint{unknown, newer, sameResource}, candidates)
+}
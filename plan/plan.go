@@ -49,6 +49,27 @@ type Plan struct {
 	ExcludeRecords []string
 	// OwnerID of records to manage
 	OwnerID string
+	// SharedOwnership lets the owners share records: the targets of the owners are merged, and each owner
+	// only removes its own targets. See endpoint.SharedOwnersLabelKey
+	SharedOwnership bool
+	// Resolver picks the desired record among the candidates of a DNS name, PerResource if nil
+	Resolver ConflictResolver
+	// Conflicts are the candidates which were not picked by the resolver, because a record of
+	// another resource was. Populated after calling Calculate()
+	Conflicts []Conflict
+	// Held are the deletions held by a GuardPolicy. Populated after calling Calculate()
+	Held []*endpoint.Endpoint
+	// Orphans are the ownership records of the registry left without their record, deleted along with the
+	// changes under the same policies. See registry.OrphanCollector
+	Orphans []*endpoint.Endpoint
+}
+
+// Conflict is a desired record rejected in favor of the record of another resource
+type Conflict struct {
+	// Rejected is the rejected record
+	Rejected *endpoint.Endpoint
+	// Winner is the record picked instead
+	Winner *endpoint.Endpoint
 }
 
 // Changes holds lists of actions to be executed by dns providers
@@ -92,8 +113,11 @@ type planTable struct {
 	resolver ConflictResolver
 }
 
-func newPlanTable() planTable { // TODO: make resolver configurable
-	return planTable{map[planKey]*planTableRow{}, PerResource{}}
+func newPlanTable(resolver ConflictResolver) planTable {
+	if resolver == nil {
+		resolver = PerResource{}
+	}
+	return planTable{map[planKey]*planTableRow{}, resolver}
 }
 
 // planTableRow represents a set of current and desired domain resource records.
@@ -165,7 +189,7 @@ func (c *Changes) HasChanges() bool {
 // state. It then passes those changes to the current policy for further
 // processing. It returns a copy of Plan with the changes populated.
 func (p *Plan) Calculate() *Plan {
-	t := newPlanTable()
+	t := newPlanTable(p.Resolver)
 
 	if p.DomainFilter == nil {
 		p.DomainFilter = endpoint.MatchAllDomainFilters(nil)
@@ -179,6 +203,11 @@ func (p *Plan) Calculate() *Plan {
 	}
 
 	changes := &Changes{}
+	var conflicts []Conflict
+	resolved := func(winner *endpoint.Endpoint, candidates []*endpoint.Endpoint) *endpoint.Endpoint {
+		conflicts = appendConflicts(conflicts, winner, candidates)
+		return winner
+	}
 
 	for key, row := range t.rows {
 		// dns name not taken
@@ -186,14 +215,20 @@ func (p *Plan) Calculate() *Plan {
 			recordsByType := t.resolver.ResolveRecordTypes(key, row)
 			for _, records := range recordsByType {
 				if len(records.candidates) > 0 {
-					changes.Create = append(changes.Create, t.resolver.ResolveCreate(records.candidates))
+					create := resolved(t.resolver.ResolveCreate(records.candidates), records.candidates)
+					if p.sharing() {
+						create = p.share(create, nil)
+					}
+					changes.Create = append(changes.Create, create)
 				}
 			}
 		}
 
 		// dns name released or possibly owned by a different external dns
 		if len(row.current) > 0 && len(row.candidates) == 0 {
-			changes.Delete = append(changes.Delete, row.current...)
+			for _, current := range row.current {
+				p.appendDelete(changes, current)
+			}
 		}
 
 		// dns name is taken
@@ -205,12 +240,15 @@ func (p *Plan) Calculate() *Plan {
 			for _, records := range recordsByType {
 				// record type not desired
 				if records.current != nil && len(records.candidates) == 0 {
-					changes.Delete = append(changes.Delete, records.current)
+					p.appendDelete(changes, records.current)
 				}
 
 				// new record type desired
 				if records.current == nil && len(records.candidates) > 0 {
-					update := t.resolver.ResolveCreate(records.candidates)
+					update := resolved(t.resolver.ResolveCreate(records.candidates), records.candidates)
+					if p.sharing() {
+						update = p.share(update, nil)
+					}
 					// creates are evaluated after all domain records have been processed to
 					// validate that this external dns has ownership claim on the domain before
 					// adding the records to planned changes.
@@ -219,9 +257,12 @@ func (p *Plan) Calculate() *Plan {
 
 				// update existing record
 				if records.current != nil && len(records.candidates) > 0 {
-					update := t.resolver.ResolveUpdate(records.current, records.candidates)
+					update := resolved(t.resolver.ResolveUpdate(records.current, records.candidates), records.candidates)
+					if p.sharing() && p.manages(records.current) {
+						update = p.share(update, records.current)
+					}
 
-					if shouldUpdateTTL(update, records.current) || targetChanged(update, records.current) || p.shouldUpdateProviderSpecific(update, records.current) {
+					if shouldUpdateTTL(update, records.current) || targetChanged(update, records.current) || p.shouldUpdateProviderSpecific(update, records.current) || (p.sharing() && sharedOwnersChanged(update, records.current)) {
 						inheritOwner(records.current, update)
 						changes.UpdateNew = append(changes.UpdateNew, update)
 						changes.UpdateOld = append(changes.UpdateOld, records.current)
@@ -233,7 +274,7 @@ func (p *Plan) Calculate() *Plan {
 				// only add creates if the external dns has ownership claim on the domain
 				ownersMatch := true
 				for _, current := range row.current {
-					if p.OwnerID != "" && !current.IsOwnedBy(p.OwnerID) {
+					if !p.manages(current) {
 						ownersMatch = false
 					}
 				}
@@ -245,16 +286,39 @@ func (p *Plan) Calculate() *Plan {
 		}
 	}
 
+	for _, orphan := range p.Orphans {
+		if p.DomainFilter.Match(orphan.DNSName) {
+			changes.Delete = append(changes.Delete, orphan)
+		}
+	}
+
 	for _, pol := range p.Policies {
+		if _, ok := pol.(*GuardPolicy); ok {
+			// guarded once the deletions not owned are filtered out, see below
+			continue
+		}
 		changes = pol.Apply(changes)
 	}
 
 	// filter out updates this external dns does not have ownership claim over
 	if p.OwnerID != "" {
-		changes.Delete = endpoint.FilterEndpointsByOwnerID(p.OwnerID, changes.Delete)
+		filter := endpoint.FilterEndpointsByOwnerID
+		if p.SharedOwnership {
+			filter = endpoint.FilterEndpointsBySharedOwnerID
+		}
+		changes.Delete = filter(p.OwnerID, changes.Delete)
 		changes.Delete = endpoint.RemoveDuplicates(changes.Delete)
-		changes.UpdateOld = endpoint.FilterEndpointsByOwnerID(p.OwnerID, changes.UpdateOld)
-		changes.UpdateNew = endpoint.FilterEndpointsByOwnerID(p.OwnerID, changes.UpdateNew)
+		changes.UpdateOld = filter(p.OwnerID, changes.UpdateOld)
+		changes.UpdateNew = filter(p.OwnerID, changes.UpdateNew)
+	}
+
+	var held []*endpoint.Endpoint
+	for _, pol := range p.Policies {
+		if guard, ok := pol.(*GuardPolicy); ok {
+			var guardHeld []*endpoint.Endpoint
+			changes, guardHeld = guard.Guard(changes, p.owned())
+			held = append(held, guardHeld...)
+		}
 	}
 
 	plan := &Plan{
@@ -262,11 +326,97 @@ func (p *Plan) Calculate() *Plan {
 		Desired:        p.Desired,
 		Changes:        changes,
 		ManagedRecords: []string{endpoint.RecordTypeA, endpoint.RecordTypeAAAA, endpoint.RecordTypeCNAME},
+		Conflicts:      conflicts,
+		Held:           held,
 	}
 
 	return plan
 }
 
+// owned returns the number of current records owned by the plan, including the orphaned ownership records
+func (p *Plan) owned() int {
+	if p.OwnerID == "" {
+		return len(p.Current) + len(p.Orphans)
+	}
+	owned := len(p.Orphans)
+	for _, current := range p.Current {
+		if current.IsOwnedBy(p.OwnerID) {
+			owned++
+		}
+	}
+	return owned
+}
+
+// manages returns true if the plan may change the current record: it owns it, or the record is shared
+// by other owners that the plan may join
+func (p *Plan) manages(current *endpoint.Endpoint) bool {
+	return p.OwnerID == "" || current.IsOwnedBy(p.OwnerID) || (p.SharedOwnership && current.IsShared())
+}
+
+// sharing returns true if the records are shared with the other owners
+func (p *Plan) sharing() bool {
+	return p.SharedOwnership && p.OwnerID != ""
+}
+
+// share returns a copy of the desired record with the targets of the other owners sharing the current one,
+// if any, and the desired targets as the ones of the owner of the plan
+func (p *Plan) share(desired, current *endpoint.Endpoint) *endpoint.Endpoint {
+	owners := endpoint.SharedOwners{}
+	if current != nil {
+		owners = current.SharedOwners()
+	}
+	owners[p.OwnerID] = desired.Targets
+
+	shared := desired.DeepCopy()
+	if shared.Labels == nil {
+		shared.Labels = endpoint.NewLabels()
+	}
+	shared.Targets = owners.Targets()
+	shared.Labels[endpoint.SharedOwnersLabelKey] = owners.String()
+	return shared
+}
+
+// appendDelete deletes the current record, unless it is shared with other owners: only the targets of the
+// owner of the plan are removed then, and the record is handed over to another owner if the plan owns it
+func (p *Plan) appendDelete(changes *Changes, current *endpoint.Endpoint) {
+	if !p.sharing() || !current.IsShared() {
+		changes.Delete = append(changes.Delete, current)
+		return
+	}
+	if !current.IsSharedBy(p.OwnerID) {
+		// left already, or never joined
+		return
+	}
+
+	owners := current.SharedOwners()
+	delete(owners, p.OwnerID)
+	if len(owners) == 0 {
+		changes.Delete = append(changes.Delete, current)
+		return
+	}
+
+	update := current.DeepCopy()
+	update.Targets = owners.Targets()
+	update.Labels[endpoint.SharedOwnersLabelKey] = owners.String()
+	if update.Labels[endpoint.OwnerLabelKey] == p.OwnerID {
+		update.Labels[endpoint.OwnerLabelKey] = owners.Owners()[0]
+	}
+	changes.UpdateOld = append(changes.UpdateOld, current)
+	changes.UpdateNew = append(changes.UpdateNew, update)
+}
+
+// appendConflicts appends the candidates of other resources than the one of the winner to the conflicts
+func appendConflicts(conflicts []Conflict, winner *endpoint.Endpoint, candidates []*endpoint.Endpoint) []Conflict {
+	winnerResource := winner.Labels[endpoint.ResourceLabelKey]
+	for _, candidate := range candidates {
+		resource := candidate.Labels[endpoint.ResourceLabelKey]
+		if resource != "" && resource != winnerResource {
+			conflicts = append(conflicts, Conflict{Rejected: candidate, Winner: winner})
+		}
+	}
+	return conflicts
+}
+
 func inheritOwner(from, to *endpoint.Endpoint) {
 	if to.Labels == nil {
 		to.Labels = map[string]string{}
@@ -281,6 +431,10 @@ func targetChanged(desired, current *endpoint.Endpoint) bool {
 	return !desired.Targets.Same(current.Targets)
 }
 
+func sharedOwnersChanged(desired, current *endpoint.Endpoint) bool {
+	return desired.Labels[endpoint.SharedOwnersLabelKey] != current.Labels[endpoint.SharedOwnersLabelKey]
+}
+
 func shouldUpdateTTL(desired, current *endpoint.Endpoint) bool {
 	if !desired.RecordTTL.IsConfigured() {
 		return false
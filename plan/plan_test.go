@@ -969,6 +969,250 @@ func validateEntries(t *testing.T, entries, expected []*endpoint.Endpoint) {
 	}
 }
 
+func TestPlanConflicts(t *testing.T) {
+	older := rankedEndpoint("2.2.2.2", "ingress/default/old", map[string]string{endpoint.ResourceCreatedLabelKey: "2023-01-01T00:00:00Z"})
+	newer := rankedEndpoint("1.1.1.1", "ingress/default/new", map[string]string{endpoint.ResourceCreatedLabelKey: "2024-01-01T00:00:00Z"})
+	sameResource := rankedEndpoint("3.3.3.3", "ingress/default/old", nil)
+
+	resolver, err := NewConflictResolver(ConflictResolutionOldest, nil)
+	assert.NoError(t, err)
+	p := &Plan{
+		Policies:       []Policy{&SyncPolicy{}},
+		Desired:        []*endpoint.Endpoint{newer, older, sameResource},
+		ManagedRecords: []string{endpoint.RecordTypeA},
+		Resolver:       resolver,
+	}
+	p = p.Calculate()
+
+	assert.Equal(t, []*endpoint.Endpoint{older}, p.Changes.Create)
+	assert.Equal(t, []Conflict{{Rejected: newer, Winner: older}}, p.Conflicts)
+
+	// without resolver, the targets decide
+	p = (&Plan{Policies: []Policy{&SyncPolicy{}}, Desired: []*endpoint.Endpoint{newer, older}, ManagedRecords: []string{endpoint.RecordTypeA}}).Calculate()
+	assert.Equal(t, []*endpoint.Endpoint{newer}, p.Changes.Create)
+	assert.Equal(t, []Conflict{{Rejected: older, Winner: newer}}, p.Conflicts)
+}
+
+func TestPlanGuard(t *testing.T) {
+	owned := func(name, owner string) *endpoint.Endpoint {
+		ep := endpoint.NewEndpoint(name, endpoint.RecordTypeA, "1.1.1.1")
+		ep.Labels[endpoint.OwnerLabelKey] = owner
+		return ep
+	}
+	current := []*endpoint.Endpoint{owned("a.example.org", "me"), owned("b.example.org", "me"), owned("c.example.org", "other"), owned("d.example.org", "other")}
+
+	p := (&Plan{
+		Policies:       []Policy{&SyncPolicy{}, &GuardPolicy{MaxDeletesPercent: 50}},
+		Current:        current,
+		Desired:        []*endpoint.Endpoint{endpoint.NewEndpoint("e.example.org", endpoint.RecordTypeA, "1.1.1.1")},
+		ManagedRecords: []string{endpoint.RecordTypeA},
+		OwnerID:        "me",
+	}).Calculate()
+
+	// the deletions of the records of other owners neither count nor are held
+	validateEntries(t, p.Changes.Create, []*endpoint.Endpoint{endpoint.NewEndpoint("e.example.org", endpoint.RecordTypeA, "1.1.1.1")})
+	validateEntries(t, p.Changes.Delete, []*endpoint.Endpoint{})
+	validateEntries(t, p.Held, current[:2])
+
+	p = (&Plan{
+		Policies:       []Policy{&SyncPolicy{}, &GuardPolicy{MaxDeletesPercent: 50}},
+		Current:        current,
+		Desired:        current[:1],
+		ManagedRecords: []string{endpoint.RecordTypeA},
+		OwnerID:        "me",
+	}).Calculate()
+	validateEntries(t, p.Changes.Delete, current[1:2])
+	assert.Empty(t, p.Held)
+}
+
+func TestPlanOrphans(t *testing.T) {
+	owned := func(name, recordType, target string) *endpoint.Endpoint {
+		ep := endpoint.NewEndpoint(name, recordType, target)
+		ep.Labels[endpoint.OwnerLabelKey] = "me"
+		return ep
+	}
+	current := []*endpoint.Endpoint{owned("a.example.org", endpoint.RecordTypeA, "1.1.1.1"), owned("b.example.org", endpoint.RecordTypeA, "1.1.1.1")}
+	orphans := []*endpoint.Endpoint{
+		owned("a-c.example.org", endpoint.RecordTypeTXT, "\"heritage=external-dns,external-dns/owner=me\""),
+		owned("a-c.example.com", endpoint.RecordTypeTXT, "\"heritage=external-dns,external-dns/owner=me\""),
+	}
+	domainFilter := endpoint.NewDomainFilter([]string{"example.org"})
+	calculate := func(desired []*endpoint.Endpoint, policies ...Policy) *Plan {
+		return (&Plan{
+			Policies:       policies,
+			Current:        current,
+			Desired:        desired,
+			DomainFilter:   endpoint.MatchAllDomainFilters{&domainFilter},
+			ManagedRecords: []string{endpoint.RecordTypeA},
+			OwnerID:        "me",
+			Orphans:        orphans,
+		}).Calculate()
+	}
+
+	// the orphans of the domains are deleted along with the changes
+	validateEntries(t, calculate(current, &SyncPolicy{}).Changes.Delete, orphans[:1])
+	validateEntries(t, calculate(current, &UpsertOnlyPolicy{}).Changes.Delete, []*endpoint.Endpoint{})
+
+	// the orphans are owned records for the guard
+	p := calculate(current, &SyncPolicy{}, &GuardPolicy{MaxDeletesPercent: 40})
+	validateEntries(t, p.Changes.Delete, orphans[:1])
+	assert.Empty(t, p.Held)
+	p = calculate(current[:1], &SyncPolicy{}, &GuardPolicy{MaxDeletes: 1})
+	validateEntries(t, p.Changes.Delete, []*endpoint.Endpoint{})
+	validateEntries(t, p.Held, []*endpoint.Endpoint{current[1], orphans[0]})
+}
+
+func TestPlanSharedOwnership(t *testing.T) {
+	shared := func(owner string, sharedOwners endpoint.SharedOwners, targets ...string) *endpoint.Endpoint {
+		ep := endpoint.NewEndpoint("shared.example.org", endpoint.RecordTypeA, targets...)
+		ep.Labels[endpoint.OwnerLabelKey] = owner
+		if sharedOwners != nil {
+			ep.Labels[endpoint.SharedOwnersLabelKey] = sharedOwners.String()
+		}
+		return ep
+	}
+	desired := func(targets ...string) []*endpoint.Endpoint {
+		if len(targets) == 0 {
+			return nil
+		}
+		return []*endpoint.Endpoint{endpoint.NewEndpoint("shared.example.org", endpoint.RecordTypeA, targets...)}
+	}
+	calculate := func(ownerID string, current *endpoint.Endpoint, desired []*endpoint.Endpoint) *Changes {
+		p := &Plan{
+			Policies:        []Policy{&SyncPolicy{}},
+			Desired:         desired,
+			ManagedRecords:  []string{endpoint.RecordTypeA},
+			OwnerID:         ownerID,
+			SharedOwnership: true,
+		}
+		if current != nil {
+			p.Current = []*endpoint.Endpoint{current}
+		}
+		return p.Calculate().Changes
+	}
+
+	for _, tc := range []struct {
+		name      string
+		ownerID   string
+		current   *endpoint.Endpoint
+		desired   []*endpoint.Endpoint
+		create    []*endpoint.Endpoint
+		updateOld []*endpoint.Endpoint
+		updateNew []*endpoint.Endpoint
+		delete    []*endpoint.Endpoint
+	}{
+		{
+			name:    "the first owner creates the record",
+			ownerID: "a",
+			desired: desired("1.1.1.1"),
+			create:  []*endpoint.Endpoint{shared("", endpoint.SharedOwners{"a": {"1.1.1.1"}}, "1.1.1.1")},
+		},
+		{
+			name:      "another owner adds its targets",
+			ownerID:   "b",
+			current:   shared("a", endpoint.SharedOwners{"a": {"1.1.1.1"}}, "1.1.1.1"),
+			desired:   desired("2.2.2.2"),
+			updateOld: []*endpoint.Endpoint{shared("a", endpoint.SharedOwners{"a": {"1.1.1.1"}}, "1.1.1.1")},
+			updateNew: []*endpoint.Endpoint{shared("a", endpoint.SharedOwners{"a": {"1.1.1.1"}, "b": {"2.2.2.2"}}, "1.1.1.1", "2.2.2.2")},
+		},
+		{
+			name:      "another owner with the same targets joins",
+			ownerID:   "b",
+			current:   shared("a", endpoint.SharedOwners{"a": {"1.1.1.1"}}, "1.1.1.1"),
+			desired:   desired("1.1.1.1"),
+			updateOld: []*endpoint.Endpoint{shared("a", endpoint.SharedOwners{"a": {"1.1.1.1"}}, "1.1.1.1")},
+			updateNew: []*endpoint.Endpoint{shared("a", endpoint.SharedOwners{"a": {"1.1.1.1"}, "b": {"1.1.1.1"}}, "1.1.1.1")},
+		},
+		{
+			name:    "the targets of the owner are unchanged",
+			ownerID: "b",
+			current: shared("a", endpoint.SharedOwners{"a": {"1.1.1.1"}, "b": {"2.2.2.2"}}, "1.1.1.1", "2.2.2.2"),
+			desired: desired("2.2.2.2"),
+		},
+		{
+			name:      "the owner changes its targets",
+			ownerID:   "b",
+			current:   shared("a", endpoint.SharedOwners{"a": {"1.1.1.1"}, "b": {"2.2.2.2"}}, "1.1.1.1", "2.2.2.2"),
+			desired:   desired("3.3.3.3"),
+			updateOld: []*endpoint.Endpoint{shared("a", endpoint.SharedOwners{"a": {"1.1.1.1"}, "b": {"2.2.2.2"}}, "1.1.1.1", "2.2.2.2")},
+			updateNew: []*endpoint.Endpoint{shared("a", endpoint.SharedOwners{"a": {"1.1.1.1"}, "b": {"3.3.3.3"}}, "1.1.1.1", "3.3.3.3")},
+		},
+		{
+			name:      "an owner removes its targets",
+			ownerID:   "b",
+			current:   shared("a", endpoint.SharedOwners{"a": {"1.1.1.1"}, "b": {"2.2.2.2"}}, "1.1.1.1", "2.2.2.2"),
+			updateOld: []*endpoint.Endpoint{shared("a", endpoint.SharedOwners{"a": {"1.1.1.1"}, "b": {"2.2.2.2"}}, "1.1.1.1", "2.2.2.2")},
+			updateNew: []*endpoint.Endpoint{shared("a", endpoint.SharedOwners{"a": {"1.1.1.1"}}, "1.1.1.1")},
+		},
+		{
+			name:      "the owner of the record hands it over when it removes its targets",
+			ownerID:   "a",
+			current:   shared("a", endpoint.SharedOwners{"a": {"1.1.1.1"}, "b": {"2.2.2.2"}}, "1.1.1.1", "2.2.2.2"),
+			updateOld: []*endpoint.Endpoint{shared("a", endpoint.SharedOwners{"a": {"1.1.1.1"}, "b": {"2.2.2.2"}}, "1.1.1.1", "2.2.2.2")},
+			updateNew: []*endpoint.Endpoint{shared("b", endpoint.SharedOwners{"b": {"2.2.2.2"}}, "2.2.2.2")},
+		},
+		{
+			name:    "the last owner deletes the record",
+			ownerID: "b",
+			current: shared("a", endpoint.SharedOwners{"b": {"2.2.2.2"}}, "2.2.2.2"),
+			delete:  []*endpoint.Endpoint{shared("a", endpoint.SharedOwners{"b": {"2.2.2.2"}}, "2.2.2.2")},
+		},
+		{
+			name:    "an owner which does not share the record leaves it",
+			ownerID: "c",
+			current: shared("a", endpoint.SharedOwners{"a": {"1.1.1.1"}, "b": {"2.2.2.2"}}, "1.1.1.1", "2.2.2.2"),
+		},
+		{
+			name:      "the record of the owner is shared",
+			ownerID:   "a",
+			current:   shared("a", nil, "1.1.1.1"),
+			desired:   desired("1.1.1.1"),
+			updateOld: []*endpoint.Endpoint{shared("a", nil, "1.1.1.1")},
+			updateNew: []*endpoint.Endpoint{shared("a", endpoint.SharedOwners{"a": {"1.1.1.1"}}, "1.1.1.1")},
+		},
+		{
+			name:      "the owner IDs and the targets may contain the separators of the labels",
+			ownerID:   "cluster:b",
+			current:   shared("a", endpoint.SharedOwners{"a": {"1.1.1.1"}}, "1.1.1.1"),
+			desired:   desired("2001:db8::1"),
+			updateOld: []*endpoint.Endpoint{shared("a", endpoint.SharedOwners{"a": {"1.1.1.1"}}, "1.1.1.1")},
+			updateNew: []*endpoint.Endpoint{shared("a", endpoint.SharedOwners{"a": {"1.1.1.1"}, "cluster:b": {"2001:db8::1"}}, "1.1.1.1", "2001:db8::1")},
+		},
+		{
+			name:    "the record of an owner which does not share it is not joined",
+			ownerID: "b",
+			current: shared("a", nil, "1.1.1.1"),
+			desired: desired("2.2.2.2"),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			changes := calculate(tc.ownerID, tc.current, tc.desired)
+			validateEntries(t, changes.Create, tc.create)
+			validateEntries(t, changes.UpdateOld, tc.updateOld)
+			validateEntries(t, changes.UpdateNew, tc.updateNew)
+			validateEntries(t, changes.Delete, tc.delete)
+			for _, r := range changes.UpdateNew {
+				assert.Equal(t, tc.updateNew[0].Labels, r.Labels)
+			}
+			for _, r := range changes.Create {
+				assert.Equal(t, tc.create[0].Labels[endpoint.SharedOwnersLabelKey], r.Labels[endpoint.SharedOwnersLabelKey])
+			}
+		})
+	}
+
+	// without the shared ownership, the record is left to its owner, even by the owners sharing it
+	for _, ownerID := range []string{"b", "c"} {
+		p := (&Plan{
+			Policies:       []Policy{&SyncPolicy{}},
+			Current:        []*endpoint.Endpoint{shared("a", endpoint.SharedOwners{"a": {"1.1.1.1"}, "b": {"2.2.2.2"}}, "1.1.1.1", "2.2.2.2")},
+			Desired:        desired("3.3.3.3"),
+			ManagedRecords: []string{endpoint.RecordTypeA},
+			OwnerID:        ownerID,
+		}).Calculate()
+		assert.False(t, p.Changes.HasChanges(), ownerID)
+	}
+}
+
 func TestNormalizeDNSName(t *testing.T) {
 	records := []struct {
 		dnsName string
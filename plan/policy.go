@@ -16,6 +16,10 @@ limitations under the License.
 
 package plan
 
+import (
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
 // Policy allows to apply different rules to a set of changes.
 type Policy interface {
 	Apply(changes *Changes) *Changes
@@ -57,3 +61,41 @@ func (p *CreateOnlyPolicy) Apply(changes *Changes) *Changes {
 		Create: changes.Create,
 	}
 }
+
+// GuardPolicy holds all the deletions of a synchronization when they exceed MaxDeletes records,
+// or MaxDeletesPercent percent of the records owned by the plan, so that a source listing no
+// endpoints by mistake does not delete every record. It is meant to be chained after the other
+// policies; a limit of 0 is disabled.
+type GuardPolicy struct {
+	MaxDeletes        int
+	MaxDeletesPercent int
+	// Override lets the deletions through when it returns true, e.g. once an operator confirmed them
+	Override func() bool
+}
+
+// Apply applies the guard policy with the MaxDeletes limit only, since the number of owned
+// records is unknown. Plan.Calculate uses Guard instead.
+func (p *GuardPolicy) Apply(changes *Changes) *Changes {
+	changes, _ = p.Guard(changes, -1)
+	return changes
+}
+
+// Guard returns the changes without the deletions when they exceed a limit for the given number
+// of owned records, a negative number disabling MaxDeletesPercent, and the held deletions.
+func (p *GuardPolicy) Guard(changes *Changes, owned int) (*Changes, []*endpoint.Endpoint) {
+	if !p.exceeded(len(changes.Delete), owned) || (p.Override != nil && p.Override()) {
+		return changes, nil
+	}
+	return &Changes{
+		Create:    changes.Create,
+		UpdateOld: changes.UpdateOld,
+		UpdateNew: changes.UpdateNew,
+	}, changes.Delete
+}
+
+func (p *GuardPolicy) exceeded(deletes, owned int) bool {
+	if p.MaxDeletes > 0 && deletes > p.MaxDeletes {
+		return true
+	}
+	return p.MaxDeletesPercent > 0 && owned >= 0 && deletes*100 > p.MaxDeletesPercent*owned
+}
@@ -70,6 +70,49 @@ func TestApply(t *testing.T) {
 	}
 }
 
+// TestGuardPolicy tests that the guard policy holds the deletions exceeding its limits.
+func TestGuardPolicy(t *testing.T) {
+	foo := &endpoint.Endpoint{DNSName: "foo", Targets: endpoint.Targets{"v1"}}
+	bar := &endpoint.Endpoint{DNSName: "bar", Targets: endpoint.Targets{"v1"}}
+	baz := &endpoint.Endpoint{DNSName: "baz", Targets: endpoint.Targets{"v1"}}
+	changes := &Changes{Create: []*endpoint.Endpoint{baz}, Delete: []*endpoint.Endpoint{foo, bar}}
+	override := false
+
+	for _, tc := range []struct {
+		name     string
+		policy   *GuardPolicy
+		owned    int
+		override bool
+		held     bool
+	}{
+		{"disabled", &GuardPolicy{}, 2, false, false},
+		{"below max deletes", &GuardPolicy{MaxDeletes: 2}, 2, false, false},
+		{"above max deletes", &GuardPolicy{MaxDeletes: 1}, 2, false, true},
+		{"below max percent", &GuardPolicy{MaxDeletesPercent: 50}, 4, false, false},
+		{"above max percent", &GuardPolicy{MaxDeletesPercent: 40}, 4, false, true},
+		{"unknown owned records", &GuardPolicy{MaxDeletesPercent: 40}, -1, false, false},
+		{"overridden", &GuardPolicy{MaxDeletes: 1, Override: func() bool { return override }}, 2, true, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			override = tc.override
+			guarded, held := tc.policy.Guard(changes, tc.owned)
+
+			validateEntries(t, guarded.Create, changes.Create)
+			if tc.held {
+				validateEntries(t, guarded.Delete, []*endpoint.Endpoint{})
+				validateEntries(t, held, changes.Delete)
+			} else {
+				validateEntries(t, guarded.Delete, changes.Delete)
+				validateEntries(t, held, []*endpoint.Endpoint{})
+			}
+		})
+	}
+
+	// without owned records, only the number of deletions is guarded
+	validateEntries(t, (&GuardPolicy{MaxDeletes: 1}).Apply(changes).Delete, []*endpoint.Endpoint{})
+	validateEntries(t, (&GuardPolicy{MaxDeletesPercent: 1}).Apply(changes).Delete, changes.Delete)
+}
+
 // TestPolicies tests that policies are correctly registered.
 func TestPolicies(t *testing.T) {
 	validatePolicy(t, Policies["sync"], &SyncPolicy{})
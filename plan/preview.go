@@ -0,0 +1,139 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plan
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+// Actions of the planned changes
+const (
+	ActionCreate    = "create"
+	ActionUpdateOld = "update-old"
+	ActionUpdateNew = "update-new"
+	ActionDelete    = "delete"
+)
+
+// Formats of the plan previews
+const (
+	PreviewFormatJSON  = "json"
+	PreviewFormatTable = "table"
+)
+
+// PlannedChange is a change of a plan, in the stable form of the plan previews
+type PlannedChange struct {
+	Pipeline      string   `json:"pipeline,omitempty"`
+	Action        string   `json:"action"`
+	DNSName       string   `json:"dnsName"`
+	RecordType    string   `json:"recordType"`
+	SetIdentifier string   `json:"setIdentifier,omitempty"`
+	Targets       []string `json:"targets"`
+	RecordTTL     int64    `json:"recordTTL,omitempty"`
+	Owner         string   `json:"owner,omitempty"`
+	Resource      string   `json:"resource,omitempty"`
+}
+
+// Preview returns the changes in the order of the actions, then of the names, record types and set
+// identifiers. The records to create are owned by the given owner, since the registry labels them
+// when it applies them.
+func (c *Changes) Preview(ownerID string) []PlannedChange {
+	var changes []PlannedChange
+	add := func(action string, endpoints []*endpoint.Endpoint) {
+		planned := make([]PlannedChange, 0, len(endpoints))
+		for _, ep := range endpoints {
+			owner := ep.Labels[endpoint.OwnerLabelKey]
+			if owner == "" && action == ActionCreate {
+				owner = ownerID
+			}
+			planned = append(planned, PlannedChange{
+				Action:        action,
+				DNSName:       ep.DNSName,
+				RecordType:    ep.RecordType,
+				SetIdentifier: ep.SetIdentifier,
+				Targets:       append([]string{}, ep.Targets...),
+				RecordTTL:     int64(ep.RecordTTL),
+				Owner:         owner,
+				Resource:      ep.Labels[endpoint.ResourceLabelKey],
+			})
+		}
+		sort.SliceStable(planned, func(i, j int) bool {
+			if planned[i].DNSName != planned[j].DNSName {
+				return planned[i].DNSName < planned[j].DNSName
+			}
+			if planned[i].RecordType != planned[j].RecordType {
+				return planned[i].RecordType < planned[j].RecordType
+			}
+			return planned[i].SetIdentifier < planned[j].SetIdentifier
+		})
+		changes = append(changes, planned...)
+	}
+	add(ActionCreate, c.Create)
+	add(ActionUpdateOld, c.UpdateOld)
+	add(ActionUpdateNew, c.UpdateNew)
+	add(ActionDelete, c.Delete)
+	return changes
+}
+
+// WritePreview writes the planned changes to w in the given format
+func WritePreview(w io.Writer, format string, changes []PlannedChange) error {
+	switch format {
+	case PreviewFormatJSON:
+		if changes == nil {
+			changes = []PlannedChange{}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(struct {
+			Changes []PlannedChange `json:"changes"`
+		}{changes})
+	case PreviewFormatTable:
+		// the pipeline column is only shown with --pipelines-config
+		withPipeline := false
+		for _, change := range changes {
+			withPipeline = withPipeline || change.Pipeline != ""
+		}
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		header := []string{"ACTION", "NAME", "TYPE", "SET-IDENTIFIER", "TTL", "TARGETS", "OWNER", "RESOURCE"}
+		if withPipeline {
+			header = append([]string{"PIPELINE"}, header...)
+		}
+		fmt.Fprintln(tw, strings.Join(header, "\t"))
+		for _, change := range changes {
+			ttl := ""
+			if change.RecordTTL > 0 {
+				ttl = strconv.FormatInt(change.RecordTTL, 10)
+			}
+			row := []string{change.Action, change.DNSName, change.RecordType, change.SetIdentifier, ttl,
+				strings.Join(change.Targets, ","), change.Owner, change.Resource}
+			if withPipeline {
+				row = append([]string{change.Pipeline}, row...)
+			}
+			fmt.Fprintln(tw, strings.Join(row, "\t"))
+		}
+		return tw.Flush()
+	default:
+		return fmt.Errorf("unknown preview format %q", format)
+	}
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plan
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+func previewChanges() *Changes {
+	labeled := func(ep *endpoint.Endpoint, owner, resource string) *endpoint.Endpoint {
+		ep.Labels = endpoint.Labels{endpoint.OwnerLabelKey: owner, endpoint.ResourceLabelKey: resource}
+		return ep
+	}
+	return &Changes{
+		Create: []*endpoint.Endpoint{
+			labeled(endpoint.NewEndpoint("b.example.org", endpoint.RecordTypeA, "1.1.1.1"), "", "service/default/b"),
+			labeled(endpoint.NewEndpointWithTTL("a.example.org", endpoint.RecordTypeA, 300, "1.1.1.2", "1.1.1.3"), "", "ingress/default/a"),
+		},
+		UpdateOld: []*endpoint.Endpoint{
+			labeled(endpoint.NewEndpoint("c.example.org", endpoint.RecordTypeCNAME, "old.example.org"), "owner", "service/default/c"),
+		},
+		UpdateNew: []*endpoint.Endpoint{
+			labeled(endpoint.NewEndpoint("c.example.org", endpoint.RecordTypeCNAME, "new.example.org"), "owner", "service/default/c"),
+		},
+		Delete: []*endpoint.Endpoint{
+			labeled(endpoint.NewEndpoint("d.example.org", endpoint.RecordTypeA, "2.2.2.2").WithSetIdentifier("eu"), "owner", ""),
+		},
+	}
+}
+
+func TestPreview(t *testing.T) {
+	assert.Equal(t, []PlannedChange{
+		{Action: ActionCreate, DNSName: "a.example.org", RecordType: "A", Targets: []string{"1.1.1.2", "1.1.1.3"}, RecordTTL: 300, Owner: "owner", Resource: "ingress/default/a"},
+		{Action: ActionCreate, DNSName: "b.example.org", RecordType: "A", Targets: []string{"1.1.1.1"}, Owner: "owner", Resource: "service/default/b"},
+		{Action: ActionUpdateOld, DNSName: "c.example.org", RecordType: "CNAME", Targets: []string{"old.example.org"}, Owner: "owner", Resource: "service/default/c"},
+		{Action: ActionUpdateNew, DNSName: "c.example.org", RecordType: "CNAME", Targets: []string{"new.example.org"}, Owner: "owner", Resource: "service/default/c"},
+		{Action: ActionDelete, DNSName: "d.example.org", RecordType: "A", SetIdentifier: "eu", Targets: []string{"2.2.2.2"}, Owner: "owner"},
+	}, previewChanges().Preview("owner"))
+
+	assert.Nil(t, (&Changes{}).Preview("owner"))
+}
+
+func TestWritePreview(t *testing.T) {
+	changes := previewChanges().Preview("owner")
+
+	var buf bytes.Buffer
+	require.NoError(t, WritePreview(&buf, PreviewFormatTable, changes[:2]))
+	assert.Equal(t, `ACTION  NAME           TYPE  SET-IDENTIFIER  TTL  TARGETS          OWNER  RESOURCE
+create  a.example.org  A                     300  1.1.1.2,1.1.1.3  owner  ingress/default/a
+create  b.example.org  A                          1.1.1.1          owner  service/default/b
+`, buf.String())
+
+	buf.Reset()
+	changes[3].Pipeline = "private"
+	require.NoError(t, WritePreview(&buf, PreviewFormatTable, changes[3:4]))
+	assert.Equal(t, `PIPELINE  ACTION      NAME           TYPE   SET-IDENTIFIER  TTL  TARGETS          OWNER  RESOURCE
+private   update-new  c.example.org  CNAME                       new.example.org  owner  service/default/c
+`, buf.String())
+
+	buf.Reset()
+	require.NoError(t, WritePreview(&buf, PreviewFormatJSON, changes[4:]))
+	assert.JSONEq(t, `{"changes": [{"action": "delete", "dnsName": "d.example.org", "recordType": "A", "setIdentifier": "eu", "targets": ["2.2.2.2"], "owner": "owner"}]}`, buf.String())
+
+	buf.Reset()
+	require.NoError(t, WritePreview(&buf, PreviewFormatJSON, nil))
+	assert.JSONEq(t, `{"changes": []}`, buf.String())
+
+	assert.Error(t, WritePreview(&buf, "yaml", nil))
+}
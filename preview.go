@@ -0,0 +1,70 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"io"
+
+	log "github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/external-dns/controller"
+	"sigs.k8s.io/external-dns/plan"
+)
+
+// Exit codes of --once --output
+const (
+	exitNoChanges      = 0
+	exitFailed         = 1
+	exitPendingChanges = 2
+)
+
+// previewPlans writes the changes the controllers would apply to w in the given format, without
+// applying them, and returns the exit code telling whether changes are pending.
+func previewPlans(ctx context.Context, w io.Writer, output string, ctrls []*controller.Controller) int {
+	var changes []plan.PlannedChange
+	failed := false
+	for _, ctrl := range ctrls {
+		ctrlChanges, err := ctrl.Plan(ctx)
+		if err != nil {
+			if ctrl.Name != "" {
+				log.WithField("pipeline", ctrl.Name).Error(err)
+			} else {
+				log.Error(err)
+			}
+			failed = true
+			continue
+		}
+		planned := ctrlChanges.Preview(ctrl.Registry.OwnerID())
+		for i := range planned {
+			planned[i].Pipeline = ctrl.Name
+		}
+		changes = append(changes, planned...)
+	}
+	if failed {
+		return exitFailed
+	}
+
+	if err := plan.WritePreview(w, output, changes); err != nil {
+		log.Error(err)
+		return exitFailed
+	}
+	if len(changes) > 0 {
+		return exitPendingChanges
+	}
+	return exitNoChanges
+}
@@ -2,32 +2,82 @@ package corednsk8s
 
 import (
 	"context"
+	"fmt"
+	"sync"
+	"time"
 
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/client-go/kubernetes"
 	"sigs.k8s.io/external-dns/endpoint"
 	"sigs.k8s.io/external-dns/plan"
 	"sigs.k8s.io/external-dns/provider"
 	"sigs.k8s.io/external-dns/provider/corednsk8s/editor"
-	"sigs.k8s.io/external-dns/provider/corednsk8s/k8s"
 	"sigs.k8s.io/external-dns/provider/corednsk8s/manager"
+	"sigs.k8s.io/external-dns/provider/corednsk8s/verify"
 	"sigs.k8s.io/external-dns/source"
 )
 
+const (
+	// ModeHosts serves the records through the hosts plugin of the Corefile
+	ModeHosts = "hosts"
+	// ModeZoneFile serves the records through zone files mounted in the CoreDNS Deployment, or imported
+	// from a separate ConfigMap
+	ModeZoneFile = "zonefile"
+	// DefaultImportDir is the default directory the import ConfigMap is mounted at in the CoreDNS container
+	DefaultImportDir = "/etc/coredns/custom"
+)
+
 type coreDNSk8sProvider struct {
 	provider.BaseProvider
-	dryRun            bool
-	client            kubernetes.Interface
-	zoneEditor        *editor.ZoneEditor
-	coreDNSConfig     *editor.CoreDNSConfigEditor
-	coreDNSConfigMap  *k8s.CoreDNSConfigMap
-	coreDNSDeployment *k8s.CoreDNSDeployment
-	manager           CoreDNSManager
+	dryRun       bool
+	client       kubernetes.Interface
+	domainFilter endpoint.DomainFilter
+	manager      CoreDNSManager
+	configMap    string
+	namespace    string
+	serverBlock  string
+	// verifier checks that CoreDNS serves the applied changes, nil if the verification is disabled
+	verifier *verify.Verifier
+	// verifyMu guards cancelVerify, which cancels the verification running in the background
+	verifyMu     sync.Mutex
+	cancelVerify context.CancelFunc
+	// verifications are the verifications running in the background
+	verifications sync.WaitGroup
 }
 
 type CoreDNSConfig struct {
 	CoreDNSDeployment string
 	CoreDNSConfigMap  string
 	CoreDNSNamespace  string
+	Mode              string
+	// ServerBlock is the key of the Corefile server block to edit, defaults to ".:53"
+	ServerBlock string
+	// Zones are the zones the zone files are authoritative for, defaults to the domain filter
+	Zones []string
+	// SOAMname and SOARname are the primary nameserver and the administrator mailbox of the zones
+	SOAMname string
+	SOARname string
+	// SOARefresh, SOARetry, SOAExpire and SOAMinimum are the timers of the SOA records
+	SOARefresh time.Duration
+	SOARetry   time.Duration
+	SOAExpire  time.Duration
+	SOAMinimum time.Duration
+	// Nameservers are the apex NS records of the zones
+	Nameservers []string
+	// SerialFormat is the format of the SOA serials, either date or unixtime
+	SerialFormat string
+	// ImportConfigMap is the ConfigMap owned by external-dns and imported in the server block, holding
+	// the zone files instead of the CoreDNS ConfigMap so the CoreDNS Deployment is not modified
+	ImportConfigMap string
+	// ImportDir is the directory the import ConfigMap is mounted at in the CoreDNS container
+	ImportDir string
+	// VerifyReload enables the verification that CoreDNS serves the changes after applying them
+	VerifyReload bool
+	// VerifyServer is the address of the CoreDNS server to query, defaults to the kube-dns Service
+	VerifyServer string
+	// VerifyTimeout and VerifyInterval are the timeout of the verification and the interval between the queries
+	VerifyTimeout  time.Duration
+	VerifyInterval time.Duration
 }
 
 // NewCoreDNSProvider creates a new CoreDNS provider.
@@ -37,27 +87,107 @@ func NewCoreDNSProvider(domainFilter endpoint.DomainFilter, c source.ClientGener
 		return nil, err
 	}
 	p := &coreDNSk8sProvider{
-		dryRun:     dryRun,
-		client:     client,
-		zoneEditor: editor.NewZoneEditor(),
-	}
-	//if mgr, err := manager.NewRFC1035Manager(client, cfg.CoreDNSDeployment, cfg.CoreDNSConfigMap, cfg.CoreDNSNamespace); err == nil {
-	//	p.manager = mgr
-	//} else {
-	//	return nil, err
-	//}
-	mgr, err := manager.NewHostsManager(client, cfg.CoreDNSConfigMap, cfg.CoreDNSNamespace)
+		dryRun:       dryRun,
+		client:       client,
+		domainFilter: domainFilter,
+		configMap:    cfg.CoreDNSConfigMap,
+		namespace:    cfg.CoreDNSNamespace,
+		serverBlock:  cfg.ServerBlock,
+	}
+	switch cfg.Mode {
+	case ModeHosts, "":
+		p.manager, err = manager.NewHostsManager(client, cfg.CoreDNSConfigMap, cfg.CoreDNSNamespace, cfg.ServerBlock)
+	case ModeZoneFile:
+		zones := cfg.Zones
+		if len(zones) == 0 {
+			zones = domainFilter.Filters
+		}
+		if cfg.ImportConfigMap != "" {
+			importDir := cfg.ImportDir
+			if importDir == "" {
+				importDir = DefaultImportDir
+			}
+			p.manager, err = manager.NewRFC1035ImportManager(client, cfg.CoreDNSConfigMap, cfg.ImportConfigMap, cfg.CoreDNSNamespace, cfg.ServerBlock, importDir, zones, cfg.zoneConfig())
+		} else {
+			p.manager, err = manager.NewRFC1035Manager(client, cfg.CoreDNSDeployment, cfg.CoreDNSConfigMap, cfg.CoreDNSNamespace, cfg.ServerBlock, zones, cfg.zoneConfig())
+		}
+	default:
+		err = fmt.Errorf("unknown CoreDNS mode %q", cfg.Mode)
+	}
 	if err != nil {
 		return nil, err
 	}
-	p.manager = mgr
+	if cfg.VerifyReload {
+		if p.verifier, err = newVerifier(context.Background(), client, cfg); err != nil {
+			return nil, err
+		}
+	}
 	return p, nil
 }
 
+// zoneConfig returns the configuration of the generated zones, falling back to the defaults for the unset values
+func (cfg CoreDNSConfig) zoneConfig() editor.ZoneConfig {
+	zoneConfig := editor.DefaultZoneConfig()
+	zoneConfig.Mname = cfg.SOAMname
+	zoneConfig.Rname = cfg.SOARname
+	for _, ns := range cfg.Nameservers {
+		if ns != "" {
+			zoneConfig.Nameservers = append(zoneConfig.Nameservers, ns)
+		}
+	}
+	if cfg.SOARefresh > 0 {
+		zoneConfig.Refresh = cfg.SOARefresh
+	}
+	if cfg.SOARetry > 0 {
+		zoneConfig.Retry = cfg.SOARetry
+	}
+	if cfg.SOAExpire > 0 {
+		zoneConfig.Expire = cfg.SOAExpire
+	}
+	if cfg.SOAMinimum > 0 {
+		zoneConfig.Minimum = cfg.SOAMinimum
+	}
+	if cfg.SerialFormat != "" {
+		zoneConfig.SerialFormat = cfg.SerialFormat
+	}
+	return zoneConfig
+}
+
 func (c *coreDNSk8sProvider) Records(ctx context.Context) ([]*endpoint.Endpoint, error) {
-	return c.manager.Records(ctx)
+	records, err := c.manager.Records(ctx)
+	return records, classifyError(err)
 }
 
 func (c *coreDNSk8sProvider) ApplyChanges(ctx context.Context, changes *plan.Changes) error {
-	return c.manager.ApplyChanges(ctx, changes)
+	if err := c.manager.ApplyChanges(ctx, changes); err != nil {
+		return classifyError(err)
+	}
+	if c.verifier != nil && changes.HasChanges() {
+		c.startVerification(changes)
+	}
+	return nil
+}
+
+func (c *coreDNSk8sProvider) AdjustEndpoints(endpoints []*endpoint.Endpoint) ([]*endpoint.Endpoint, error) {
+	return c.manager.AdjustEndpoints(endpoints)
+}
+
+func (c *coreDNSk8sProvider) GetDomainFilter() endpoint.DomainFilter {
+	return c.domainFilter
+}
+
+// classifyError tells the controller how to retry the errors of the Kubernetes API
+func classifyError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case apierrors.IsUnauthorized(err) || apierrors.IsForbidden(err):
+		return provider.NewAuthFailedError(err)
+	case apierrors.IsTooManyRequests(err):
+		retryAfter, _ := apierrors.SuggestsClientDelay(err)
+		return provider.NewRateLimitedError(err, time.Duration(retryAfter)*time.Second)
+	case apierrors.IsConflict(err) || apierrors.IsServerTimeout(err) || apierrors.IsTimeout(err) || apierrors.IsServiceUnavailable(err) || apierrors.IsInternalError(err):
+		return provider.NewSoftError(err)
+	}
+	return err
 }
@@ -1,14 +1,31 @@
 package corednsk8s
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/suite"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
 	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/client-go/util/homedir"
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/plan"
+	"sigs.k8s.io/external-dns/provider"
+	"sigs.k8s.io/external-dns/provider/corednsk8s/k8s"
+	"sigs.k8s.io/external-dns/provider/corednsk8s/manager"
+	"sigs.k8s.io/external-dns/source"
 )
 
 func createRealKubernetesClient() kubernetes.Interface {
@@ -33,6 +50,25 @@ func createRealKubernetesClient() kubernetes.Interface {
 	return clientset
 }
 
+// fakeClientGenerator returns a fake clientset with the CoreDNS ConfigMap
+type fakeClientGenerator struct {
+	source.ClientGenerator
+	client kubernetes.Interface
+}
+
+func newFakeClientGenerator() *fakeClientGenerator {
+	return &fakeClientGenerator{
+		client: fake.NewSimpleClientset(&v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "coredns", Namespace: "kube-system"},
+			Data:       map[string]string{k8s.CorefileKey: ".:53 {\n    forward . /etc/resolv.conf\n}\n"},
+		}),
+	}
+}
+
+func (f *fakeClientGenerator) KubeClient() (kubernetes.Interface, error) {
+	return f.client, nil
+}
+
 type CoreDNSk8sProviderTestSuite struct {
 	suite.Suite
 	provider *coreDNSk8sProvider
@@ -49,7 +85,199 @@ func (s *CoreDNSk8sProviderTestSuite) TestInitialize_Success() {
 	// TODO: Implement this test
 }
 
-// Add more tests here
+func (s *CoreDNSk8sProviderTestSuite) TestNewCoreDNSProvider_Modes() {
+	for _, tc := range []struct {
+		mode     string
+		expected CoreDNSManager
+	}{
+		{mode: "", expected: &manager.HostsManager{}},
+		{mode: ModeHosts, expected: &manager.HostsManager{}},
+		{mode: ModeZoneFile, expected: &manager.RFC1035Manager{}},
+	} {
+		p, err := NewCoreDNSProvider(endpoint.DomainFilter{}, newFakeClientGenerator(), CoreDNSConfig{
+			CoreDNSDeployment: "coredns",
+			CoreDNSConfigMap:  "coredns",
+			CoreDNSNamespace:  "kube-system",
+			Mode:              tc.mode,
+			Zones:             []string{"example.com"},
+		}, true)
+		s.Require().NoError(err, "mode %q", tc.mode)
+		s.IsType(tc.expected, p.manager, "mode %q", tc.mode)
+	}
+}
+
+func (s *CoreDNSk8sProviderTestSuite) TestNewCoreDNSProvider_ZonesFromDomainFilter() {
+	cfg := CoreDNSConfig{
+		CoreDNSDeployment: "coredns",
+		CoreDNSConfigMap:  "coredns",
+		CoreDNSNamespace:  "kube-system",
+		Mode:              ModeZoneFile,
+	}
+	_, err := NewCoreDNSProvider(endpoint.DomainFilter{}, newFakeClientGenerator(), cfg, true)
+	s.Require().Error(err, "zonefile mode should require zones")
+
+	p, err := NewCoreDNSProvider(endpoint.NewDomainFilter([]string{"example.com"}), newFakeClientGenerator(), cfg, true)
+	s.Require().NoError(err, "zones should default to the domain filter")
+	s.Equal(endpoint.NewDomainFilter([]string{"example.com"}), p.GetDomainFilter())
+}
+
+func (s *CoreDNSk8sProviderTestSuite) TestNewCoreDNSProvider_UnknownMode() {
+	_, err := NewCoreDNSProvider(endpoint.DomainFilter{}, newFakeClientGenerator(), CoreDNSConfig{
+		CoreDNSConfigMap: "coredns",
+		CoreDNSNamespace: "kube-system",
+		Mode:             "etcd",
+	}, true)
+	s.Require().Error(err)
+	s.Contains(err.Error(), "unknown CoreDNS mode")
+}
+
+func (s *CoreDNSk8sProviderTestSuite) TestNewCoreDNSProvider_VerifyKubeDNSService() {
+	generator := newFakeClientGenerator()
+	cfg := CoreDNSConfig{
+		CoreDNSConfigMap: "coredns",
+		CoreDNSNamespace: "kube-system",
+		VerifyReload:     true,
+		VerifyTimeout:    time.Second,
+	}
+	_, err := NewCoreDNSProvider(endpoint.DomainFilter{}, generator, cfg, false)
+	s.Require().Error(err, "the kube-dns Service should be required without a verify server")
+
+	_, err = generator.client.CoreV1().Services("kube-system").Create(context.Background(), &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "kube-dns", Namespace: "kube-system"},
+		Spec:       v1.ServiceSpec{ClusterIP: "10.96.0.10"},
+	}, metav1.CreateOptions{})
+	s.Require().NoError(err)
+	p, err := NewCoreDNSProvider(endpoint.DomainFilter{}, generator, cfg, false)
+	s.Require().NoError(err)
+	s.NotNil(p.verifier)
+}
+
+func (s *CoreDNSk8sProviderTestSuite) TestApplyChanges_VerifyReload() {
+	changes := &plan.Changes{
+		Create: []*endpoint.Endpoint{endpoint.NewEndpoint("www.example.com", endpoint.RecordTypeA, "192.0.2.1")},
+	}
+	for _, tc := range []struct {
+		name     string
+		corefile string
+		expected string
+	}{
+		{
+			name:     "no reload plugin",
+			corefile: ".:53 {\n    forward . /etc/resolv.conf\n}\n",
+			expected: verifyResultNoReloadPlugin,
+		},
+		{
+			name:     "not served",
+			corefile: ".:53 {\n    reload\n    forward . /etc/resolv.conf\n}\n",
+			expected: verifyResultTimeout,
+		},
+	} {
+		generator := newFakeClientGenerator()
+		_, err := generator.client.CoreV1().ConfigMaps("kube-system").Update(context.Background(), &v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "coredns", Namespace: "kube-system"},
+			Data:       map[string]string{k8s.CorefileKey: tc.corefile},
+		}, metav1.UpdateOptions{})
+		s.Require().NoError(err)
+		p, err := NewCoreDNSProvider(endpoint.DomainFilter{}, generator, CoreDNSConfig{
+			CoreDNSConfigMap: "coredns",
+			CoreDNSNamespace: "kube-system",
+			VerifyReload:     true,
+			// Nothing listens on the discard port, so the changes are never served
+			VerifyServer:   "127.0.0.1:9",
+			VerifyTimeout:  100 * time.Millisecond,
+			VerifyInterval: 10 * time.Millisecond,
+		}, false)
+		s.Require().NoError(err, tc.name)
+
+		// the verification failure does not fail the applied changes, it is reported in the metrics
+		verifications := testutil.ToFloat64(reloadVerificationsTotal.WithLabelValues(tc.expected))
+		err = p.ApplyChanges(context.Background(), changes)
+		s.Require().NoError(err, tc.name)
+		p.verifications.Wait()
+		s.Equal(verifications+1, testutil.ToFloat64(reloadVerificationsTotal.WithLabelValues(tc.expected)), tc.name)
+
+		records, err := p.Records(context.Background())
+		s.Require().NoError(err, tc.name)
+		s.Len(records, 1, "%s: the changes should be applied", tc.name)
+	}
+}
+
+func (s *CoreDNSk8sProviderTestSuite) TestApplyChanges_VerifyReloadSuperseded() {
+	generator := newFakeClientGenerator()
+	_, err := generator.client.CoreV1().ConfigMaps("kube-system").Update(context.Background(), &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "coredns", Namespace: "kube-system"},
+		Data:       map[string]string{k8s.CorefileKey: ".:53 {\n    reload\n    forward . /etc/resolv.conf\n}\n"},
+	}, metav1.UpdateOptions{})
+	s.Require().NoError(err)
+	p, err := NewCoreDNSProvider(endpoint.DomainFilter{}, generator, CoreDNSConfig{
+		CoreDNSConfigMap: "coredns",
+		CoreDNSNamespace: "kube-system",
+		VerifyReload:     true,
+		VerifyServer:     "127.0.0.1:9",
+		VerifyTimeout:    time.Minute,
+		VerifyInterval:   10 * time.Millisecond,
+	}, false)
+	s.Require().NoError(err)
+
+	// the verification of the first changes is canceled by the second ones, and not reported
+	timeouts := testutil.ToFloat64(reloadVerificationsTotal.WithLabelValues(verifyResultTimeout))
+	s.Require().NoError(p.ApplyChanges(context.Background(), &plan.Changes{
+		Create: []*endpoint.Endpoint{endpoint.NewEndpoint("www.example.com", endpoint.RecordTypeA, "192.0.2.1")},
+	}))
+	s.Require().NoError(p.ApplyChanges(context.Background(), &plan.Changes{
+		Create: []*endpoint.Endpoint{endpoint.NewEndpoint("api.example.com", endpoint.RecordTypeA, "192.0.2.2")},
+	}))
+	p.verifyMu.Lock()
+	p.cancelVerify()
+	p.verifyMu.Unlock()
+	p.verifications.Wait()
+	s.Equal(timeouts, testutil.ToFloat64(reloadVerificationsTotal.WithLabelValues(verifyResultTimeout)))
+}
+
+func (s *CoreDNSk8sProviderTestSuite) TestApplyChanges_ClassifyErrors() {
+	changes := &plan.Changes{
+		Create: []*endpoint.Endpoint{endpoint.NewEndpoint("www.example.com", endpoint.RecordTypeA, "192.0.2.1")},
+	}
+	gr := schema.GroupResource{Resource: "configmaps"}
+	for _, tc := range []struct {
+		name       string
+		err        error
+		expected   error
+		retryAfter time.Duration
+	}{
+		{name: "forbidden", err: apierrors.NewForbidden(gr, "coredns", errors.New("denied")), expected: provider.AuthFailed},
+		{name: "unauthorized", err: apierrors.NewUnauthorized("expired token"), expected: provider.AuthFailed},
+		{name: "too many requests", err: apierrors.NewTooManyRequests("slow down", 30), expected: provider.RateLimited, retryAfter: 30 * time.Second},
+		{name: "conflict", err: apierrors.NewConflict(gr, "coredns", errors.New("modified")), expected: provider.SoftError},
+		{name: "internal error", err: apierrors.NewInternalError(errors.New("etcd")), expected: provider.SoftError},
+	} {
+		generator := newFakeClientGenerator()
+		generator.client.(*fake.Clientset).PrependReactor("update", "configmaps", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			return true, nil, tc.err
+		})
+		p, err := NewCoreDNSProvider(endpoint.DomainFilter{}, generator, CoreDNSConfig{
+			CoreDNSConfigMap: "coredns",
+			CoreDNSNamespace: "kube-system",
+		}, false)
+		s.Require().NoError(err, tc.name)
+
+		err = p.ApplyChanges(context.Background(), changes)
+		s.ErrorIs(err, tc.expected, tc.name)
+		retryAfter, _ := provider.RetryAfter(err)
+		s.Equal(tc.retryAfter, retryAfter, tc.name)
+	}
+
+	p, err := NewCoreDNSProvider(endpoint.DomainFilter{}, newFakeClientGenerator(), CoreDNSConfig{
+		CoreDNSConfigMap: "coredns",
+		CoreDNSNamespace: "kube-system",
+	}, false)
+	s.Require().NoError(err)
+	err = p.ApplyChanges(context.Background(), &plan.Changes{
+		Create: []*endpoint.Endpoint{endpoint.NewEndpoint("www.example.com", endpoint.RecordTypeA, "not-an-ip")},
+	})
+	s.ErrorIs(err, provider.InvalidRecord, "an invalid target should be an invalid record")
+}
+
 func TestCoreDNSk8sProviderTestSuite(t *testing.T) {
 	suite.Run(t, new(CoreDNSk8sProviderTestSuite))
 }
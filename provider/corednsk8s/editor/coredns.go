@@ -2,46 +2,87 @@ package editor
 
 import (
 	"fmt"
-	"math"
 	"net"
+	"path"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/coredns/caddy/caddyfile"
 	"github.com/miekg/dns"
 )
 
-const ZoneFilePath = "/etc/coredns/Zone"
+const (
+	// ZoneFileDir is the default directory the zone files are mounted in the CoreDNS container
+	ZoneFileDir = "/etc/coredns"
+	// ZoneFilePrefix is the prefix of the zone file names, followed by the zone name
+	ZoneFilePrefix = "db."
+	// DefaultServerBlock is the server block managed by default
+	DefaultServerBlock = ".:53"
+	// SectionBegin and SectionEnd are the comments delimiting the section of the server block owned by external-dns
+	SectionBegin = "# BEGIN external-dns managed section, do not edit"
+	SectionEnd   = "# END external-dns managed section"
+)
 
+// CoreDNSConfigEditor edits the hosts and file plugins of a server block of the Corefile
+//
+// The plugins are written in a section of the server block delimited by the SectionBegin and
+// SectionEnd comments, the rest of the Corefile is left untouched.
 type CoreDNSConfigEditor struct {
-	serverBlocks []caddyfile.ServerBlock
-	text         string
+	corefile    *Corefile
+	serverBlock string
+	zoneFileDir string
 }
 
 // NewCoreDNSConfigEditor Creates a new CoreDNSConfigEditor
 func NewCoreDNSConfigEditor() *CoreDNSConfigEditor {
-	return &CoreDNSConfigEditor{}
+	return &CoreDNSConfigEditor{
+		corefile:    &Corefile{},
+		serverBlock: DefaultServerBlock,
+		zoneFileDir: ZoneFileDir,
+	}
+}
+
+// SetServerBlock sets the key of the server block to edit (e.g. ".:53" or "example.com:53")
+func (c *CoreDNSConfigEditor) SetServerBlock(key string) {
+	c.serverBlock = key
+}
+
+// SetZoneFileDir sets the directory the zone files are mounted in the CoreDNS container
+func (c *CoreDNSConfigEditor) SetZoneFileDir(dir string) {
+	c.zoneFileDir = dir
 }
 
 // SetZones sets the zones in the file plugin in the Corefile
+//
+// Every zone is served from its own zone file, since the file plugin expects a single origin per file.
 func (c *CoreDNSConfigEditor) SetZones(zones []string) error {
-	// Remove the file entry
-	err := c.removeFileEntry()
+	block, err := c.getServerBlock()
 	if err != nil {
 		return err
 	}
-	// If there are no zones, return
-	if len(zones) == 0 {
-		return nil
-	}
 	// Cleanup the zone names
 	cleanUpZones := make([]string, len(zones))
 	for i, z := range zones {
 		cleanUpZones[i] = removeTrailingDot(z)
 	}
+	sort.Strings(cleanUpZones)
+	// Remove the file entries written outside the section by the previous versions
+	block.Children = filterNodes(block.Children, func(node *Node) bool {
+		return !c.isZoneFileEntry(node)
+	})
 	// Update the file configuration
-	appendToLine := c.getLineToAppend()
-	entry := fmt.Sprintf("    file %s %s", ZoneFilePath, strings.Join(cleanUpZones, " "))
-	return c.addInLine(appendToLine, entry)
+	indent := block.childIndent()
+	updateSection(block, func(section []*Node) []*Node {
+		section = filterNodes(section, func(node *Node) bool {
+			return !c.isZoneFileEntry(node)
+		})
+		for _, z := range cleanUpZones {
+			section = append(section, newNode(fmt.Sprintf("%sfile %s %s", indent, c.zoneFilePath(z), z)))
+		}
+		return section
+	})
+	return nil
 }
 
 // AddZone adds a new zone in the file plugin
@@ -76,76 +117,163 @@ func (c *CoreDNSConfigEditor) RemoveZone(zone string) error {
 	if !found {
 		return nil
 	}
-	// Remove the file entry
-	err := c.removeFileEntry()
-	if err != nil {
-		return err
-	}
-	// If there are no more zones, remove the file entry
-	if len(zones) == 0 {
-		return nil
-	}
-	// Update the file configuration
-	appendToLine := c.getLineToAppend()
-	entry := fmt.Sprintf("    file %s %s", ZoneFilePath, strings.Join(zones, " "))
-	return c.addInLine(appendToLine, entry)
+	return c.SetZones(zones)
 }
 
 // GetZones returns the zones from the file plugin in the Corefile
+//
+// Only the file entries pointing to one of our zone files are taken into account.
 func (c *CoreDNSConfigEditor) GetZones() []string {
-	block := c.get53Block()
 	zones := make([]string, 0)
-	if tokens, ok := block.Tokens["file"]; ok {
-		ourFileEntryFound := false
-		for _, line := range tokens {
-			// We only want the entries in our file plugin
-			if strings.Contains(line.Text, ZoneFilePath) {
-				ourFileEntryFound = true
-				continue
-			} else if !ourFileEntryFound {
-				continue
+	block := c.corefile.ServerBlock(c.serverBlock)
+	if block == nil {
+		return zones
+	}
+	// The file entries are read from the whole server block, to also find the ones written by the
+	// previous versions outside the section
+	for _, node := range block.Children {
+		if c.isZoneFileEntry(node) {
+			// The zones of the entry are the arguments following the zone file
+			zones = append(zones, node.Args()[1:]...)
+		}
+	}
+	return zones
+}
+
+// RenderZones renders the file entries serving the zones from their zone files, one per line
+//
+// The entries are meant to be imported in the server block through the import plugin, instead of being
+// written in the Corefile.
+func (c *CoreDNSConfigEditor) RenderZones(zones []string) string {
+	cleanUpZones := make([]string, len(zones))
+	for i, z := range zones {
+		cleanUpZones[i] = removeTrailingDot(z)
+	}
+	sort.Strings(cleanUpZones)
+	var sb strings.Builder
+	for _, z := range cleanUpZones {
+		sb.WriteString(fmt.Sprintf("file %s %s\n", c.zoneFilePath(z), z))
+	}
+	return sb.String()
+}
+
+// Imports returns true if the server block imports the file through the import plugin
+//
+// The arguments of the import directives may be glob patterns (e.g. "custom/*.override"), relative
+// paths are resolved from the root directory of the Corefile.
+func (c *CoreDNSConfigEditor) Imports(filePath, root string) bool {
+	block := c.corefile.ServerBlock(c.serverBlock)
+	if block == nil {
+		return false
+	}
+	for _, node := range block.Children {
+		if node.Directive() != "import" {
+			continue
+		}
+		for _, pattern := range node.Args() {
+			if !path.IsAbs(pattern) {
+				pattern = path.Join(root, pattern)
 			}
-			// If a new file is found means that a new file plugin is configured, break
-			if strings.Contains(line.Text, "file") {
-				break
+			if matched, err := path.Match(path.Clean(pattern), path.Clean(filePath)); err == nil && matched {
+				return true
 			}
-			zones = append(zones, strings.TrimSpace(line.Text))
 		}
 	}
-	return zones
+	return false
+}
+
+// ZoneFileName returns the name of the zone file for the zone (e.g. "example.com." -> "db.example.com")
+func ZoneFileName(zone string) string {
+	return ZoneFilePrefix + removeTrailingDot(zone)
+}
+
+// zoneFilePath returns the path of the zone file for the zone in the CoreDNS container
+func (c *CoreDNSConfigEditor) zoneFilePath(zone string) string {
+	return path.Join(c.zoneFileDir, ZoneFileName(zone))
+}
+
+// isZoneFileEntry returns true if the line is a file entry serving one of our zone files
+func (c *CoreDNSConfigEditor) isZoneFileEntry(node *Node) bool {
+	args := node.Args()
+	return node.Directive() == "file" && !node.IsBlock() && len(args) >= 1 && c.isZoneFilePath(args[0])
 }
 
-// SetHosts sets the hosts in the Corefile
-func (c *CoreDNSConfigEditor) SetHosts(hosts []dns.A) error {
-	// Remove the hosts
-	err := c.removeHosts()
+// isZoneFilePath returns true if the path points to one of our zone files
+func (c *CoreDNSConfigEditor) isZoneFilePath(filePath string) bool {
+	return path.Dir(filePath) == path.Clean(c.zoneFileDir) && strings.HasPrefix(path.Base(filePath), ZoneFilePrefix)
+}
+
+// SetHosts sets the hosts in the Corefile, keeping the TTL of the hosts plugin
+//
+// Only A and AAAA records are accepted, since these are the only types the hosts plugin can serve.
+// Until the section exists, the hosts plugin of the server block is considered as written by the
+// previous versions and is replaced by the one of the section.
+func (c *CoreDNSConfigEditor) SetHosts(hosts []dns.RR) error {
+	return c.setHosts(hosts, c.GetHostsTTL())
+}
+
+// SetHostsTTL sets the TTL of the hosts plugin, which applies to every host
+//
+// A zero TTL removes the ttl option, so the hosts are served with the default TTL of the plugin. The TTL
+// is written in the hosts plugin, so it has no effect until there are hosts.
+func (c *CoreDNSConfigEditor) SetHostsTTL(ttl uint32) error {
+	return c.setHosts(c.GetHosts(), ttl)
+}
+
+// setHosts writes the hosts plugin with the hosts and the TTL
+func (c *CoreDNSConfigEditor) setHosts(hosts []dns.RR, ttl uint32) error {
+	// Validate the hosts before touching the Corefile
+	for _, h := range hosts {
+		if hostIP(h) == nil {
+			return fmt.Errorf("record %s of type %s cannot be served by the hosts plugin", h.Header().Name, dns.TypeToString[h.Header().Rrtype])
+		}
+	}
+	block, err := c.getServerBlock()
 	if err != nil {
 		return err
 	}
-	// If there are no hosts, return
-	if len(hosts) == 0 {
-		return nil
+	if hasSection(block) {
+		// The hosts plugin can only be used once per server block
+		for _, node := range outsideSection(block) {
+			if node.Directive() == "hosts" && len(hosts) > 0 {
+				return fmt.Errorf("the hosts plugin is already configured outside of the external-dns section of the server block %s", c.serverBlock)
+			}
+		}
+	} else {
+		// Take over the hosts plugin managed by the previous versions without the section
+		block.Children = filterNodes(block.Children, func(node *Node) bool {
+			return node.Directive() != "hosts"
+		})
 	}
 	// Update the hosts configuration
-	sb := strings.Builder{}
-	sb.WriteString("  hosts {\n")
-	for _, h := range hosts {
-		sb.WriteString(fmt.Sprintf("    %s %s\n", h.A.String(), removeTrailingDot(h.Hdr.Name)))
-	}
-	sb.WriteString("    fallthrough\n")
-	sb.WriteString("  }")
-
-	appendToLine := c.getLineToAppendHosts()
-	return c.addInLine(appendToLine, sb.String())
+	indent := block.childIndent()
+	updateSection(block, func(section []*Node) []*Node {
+		section = filterNodes(section, func(node *Node) bool {
+			return node.Directive() != "hosts"
+		})
+		if len(hosts) == 0 {
+			return section
+		}
+		entries := make([]*Node, 0, len(hosts)+2)
+		for _, h := range hosts {
+			entries = append(entries, newNode(fmt.Sprintf("%s%s%s %s", indent, indent, hostIP(h).String(), removeTrailingDot(h.Header().Name))))
+		}
+		if ttl > 0 {
+			entries = append(entries, newNode(fmt.Sprintf("%s%sttl %d", indent, indent, ttl)))
+		}
+		entries = append(entries, newNode(indent+indent+"fallthrough"))
+		return append([]*Node{newBlockNode(indent+"hosts {", indent, entries)}, section...)
+	})
+	return nil
 }
 
 // AddHost adds a new host in the hosts plugin
-func (c *CoreDNSConfigEditor) AddHost(host dns.A) error {
+func (c *CoreDNSConfigEditor) AddHost(host dns.RR) error {
 	// Get existing hosts
 	hosts := c.GetHosts()
 	// Check if the host already exists
 	for _, h := range hosts {
-		if h.A.Equal(host.A) && h.Hdr.Name == host.Hdr.Name {
+		if sameHost(h, host) {
 			return nil
 		}
 	}
@@ -154,13 +282,13 @@ func (c *CoreDNSConfigEditor) AddHost(host dns.A) error {
 }
 
 // UpdateHost updates a host in the hosts plugin
-func (c *CoreDNSConfigEditor) UpdateHost(oldHost, newHost dns.A) error {
+func (c *CoreDNSConfigEditor) UpdateHost(oldHost, newHost dns.RR) error {
 	// Get existing hosts
 	hosts := c.GetHosts()
 	// Update the host
-	newHosts := make([]dns.A, 0)
+	newHosts := make([]dns.RR, 0)
 	for _, h := range hosts {
-		if h.A.Equal(oldHost.A) && h.Hdr.Name == oldHost.Hdr.Name {
+		if sameHost(h, oldHost) {
 			newHosts = append(newHosts, newHost)
 		} else {
 			newHosts = append(newHosts, h)
@@ -170,13 +298,13 @@ func (c *CoreDNSConfigEditor) UpdateHost(oldHost, newHost dns.A) error {
 }
 
 // RemoveHost removes a host from the hosts plugin
-func (c *CoreDNSConfigEditor) RemoveHost(host dns.A) error {
+func (c *CoreDNSConfigEditor) RemoveHost(host dns.RR) error {
 	// Get existing hosts
 	hosts := c.GetHosts()
 	// Remove the host
-	newHosts := make([]dns.A, 0)
+	newHosts := make([]dns.RR, 0)
 	for _, h := range hosts {
-		if h.A.Equal(host.A) && h.Hdr.Name == host.Hdr.Name {
+		if sameHost(h, host) {
 			continue
 		}
 		newHosts = append(newHosts, h)
@@ -185,180 +313,196 @@ func (c *CoreDNSConfigEditor) RemoveHost(host dns.A) error {
 }
 
 // GetHosts returns the hosts from the hosts plugin in the Corefile
-func (c *CoreDNSConfigEditor) GetHosts() []dns.A {
-	block := c.get53Block()
-	hosts := make([]dns.A, 0)
-	if tokens, ok := block.Tokens["hosts"]; ok {
-		ourHostsEntryFound := false
-		for i := 0; i < len(tokens); i += 1 {
-			line := tokens[i]
-			// We only want the entries in our hosts plugin
-			if strings.Contains(line.Text, "hosts") {
-				ourHostsEntryFound = true
-				continue
-			} else if strings.Contains(line.Text, "{") {
-				continue
-			} else if strings.Contains(line.Text, "fallthrough") {
-				continue
-			} else if !ourHostsEntryFound {
-				continue
-			}
-			// If a new hosts is found means that a new hosts plugin is configured, break
-			if strings.Contains(line.Text, "}") {
-				break
+//
+// IPv4 entries are returned as *dns.A and IPv6 entries as *dns.AAAA, with the TTL of the hosts plugin.
+func (c *CoreDNSConfigEditor) GetHosts() []dns.RR {
+	hosts := make([]dns.RR, 0)
+	ttl := c.GetHostsTTL()
+	for _, entry := range c.hostsEntries() {
+		// An entry is an IP followed by one or more names, the other lines are options
+		ip := net.ParseIP(entry.Directive())
+		if ip == nil {
+			continue
+		}
+		for _, name := range entry.Tokens[1:] {
+			if host := newHost(addTrailingDot(name), ip); host != nil {
+				host.Header().Ttl = ttl
+				hosts = append(hosts, host)
 			}
-			// Parse the line
-			ip := tokens[i].Text
-			i += 1
-			name := tokens[i].Text
-			hosts = append(hosts, dns.A{Hdr: dns.RR_Header{Name: addTrailingDot(name)}, A: net.ParseIP(ip)})
 		}
 	}
 	return hosts
 }
 
-// GetConfig returns the Corefile as a string
-func (c *CoreDNSConfigEditor) GetConfig() string {
-	return c.text
-}
-
-// getMaxLine returns the maximum line number in the Corefile
-func (c *CoreDNSConfigEditor) getMaxLine() int {
-	maxLine := 0
-	block := c.get53Block()
-	for _, token := range block.Tokens {
-		for _, line := range token {
-			if line.Line > maxLine {
-				maxLine = line.Line
-			}
+// GetHostsTTL returns the TTL set by the ttl option of the hosts plugin, or 0 if it is not set
+func (c *CoreDNSConfigEditor) GetHostsTTL() uint32 {
+	for _, entry := range c.hostsEntries() {
+		if entry.Directive() != "ttl" || len(entry.Tokens) != 2 {
+			continue
+		}
+		if ttl, err := strconv.ParseUint(entry.Tokens[1], 10, 32); err == nil {
+			return uint32(ttl)
 		}
 	}
-	return maxLine
+	return 0
 }
 
-// getMinLine returns the minimum line number in the Corefile
-func (c *CoreDNSConfigEditor) getMinLine() int {
-	minLine := math.MaxInt
-	block := c.get53Block()
-	for _, token := range block.Tokens {
-		for _, line := range token {
-			if line.Line < minLine {
-				minLine = line.Line
-			}
+// hostsEntries returns the lines of the hosts plugin of the server block
+func (c *CoreDNSConfigEditor) hostsEntries() []*Node {
+	entries := make([]*Node, 0)
+	block := c.corefile.ServerBlock(c.serverBlock)
+	if block == nil {
+		return entries
+	}
+	// Without the section, the hosts plugin was managed by the previous versions
+	nodes := block.Children
+	if hasSection(block) {
+		nodes = getSection(block)
+	}
+	for _, node := range nodes {
+		if node.Directive() == "hosts" && node.IsBlock() {
+			entries = append(entries, node.Children...)
 		}
 	}
-	return minLine
+	return entries
 }
 
-// get53Block returns the .:53 block from the Corefile
-func (c *CoreDNSConfigEditor) get53Block() *caddyfile.ServerBlock {
-	for _, block := range c.serverBlocks {
-		if block.Keys[0] == ".:53" {
-			return &block
+// HasPlugin returns true if the plugin is configured in the server block
+func (c *CoreDNSConfigEditor) HasPlugin(plugin string) bool {
+	block := c.corefile.ServerBlock(c.serverBlock)
+	if block == nil {
+		return false
+	}
+	for _, node := range block.Children {
+		if node.Directive() == plugin {
+			return true
 		}
 	}
-	return nil
+	return false
+}
+
+// GetConfig returns the Corefile as a string
+func (c *CoreDNSConfigEditor) GetConfig() string {
+	return c.corefile.String()
 }
 
 // LoadCorefile Loads the Corefile from string
 func (c *CoreDNSConfigEditor) LoadCorefile(corefile string) error {
-	// Load the Corefile
-	parsed, err := caddyfile.Parse(
-		"config",
-		strings.NewReader(corefile),
-		nil,
-	)
+	// Validate the Corefile as CoreDNS does
+	if _, err := caddyfile.Parse("config", strings.NewReader(withoutImports(corefile)), nil); err != nil {
+		return err
+	}
+	parsed, err := ParseCorefile(corefile)
 	if err != nil {
 		return err
 	}
-	c.serverBlocks = parsed
-	c.text = corefile
+	c.corefile = parsed
 	return nil
 }
 
-// addInLine adds a new line in the Corefile at the specified line number
-func (c *CoreDNSConfigEditor) addInLine(line int, text string) error {
-	lines := strings.Split(c.text, "\n")
-	lines = append(lines[:line-1], append([]string{text}, lines[line-1:]...)...)
-	newText := strings.Join(lines, "\n")
-	return c.LoadCorefile(newText)
+// withoutImports blanks the import lines of the Corefile, since the imported files are only found in the
+// CoreDNS container
+func withoutImports(corefile string) string {
+	lines := strings.Split(corefile, "\n")
+	for i, line := range lines {
+		if tokens := tokenize(line); len(tokens) > 0 && tokens[0] == "import" {
+			lines[i] = ""
+		}
+	}
+	return strings.Join(lines, "\n")
 }
 
-// removeFileEntry removes all file entries from the Corefile
-func (c *CoreDNSConfigEditor) removeFileEntry() error {
-	if !strings.HasSuffix(c.text, "\n") {
-		c.text += "\n"
+// getServerBlock returns the server block to edit
+func (c *CoreDNSConfigEditor) getServerBlock() (*Node, error) {
+	block := c.corefile.ServerBlock(c.serverBlock)
+	if block == nil {
+		return nil, fmt.Errorf("server block %s not found in the Corefile", c.serverBlock)
 	}
+	return block, nil
+}
 
-	lines := strings.Split(c.text, "\n")
-	newLines := make([]string, len(lines))
-	toRemove := fmt.Sprintf("file %s", ZoneFilePath)
-	for _, line := range lines {
-		if !strings.Contains(line, toRemove) {
-			newLines = append(newLines, line)
+// findSection returns the indexes of the comments delimiting the section in the server block,
+// or -1 if the section is not found
+func findSection(block *Node) (int, int) {
+	begin, end := -1, -1
+	for i, node := range block.Children {
+		switch strings.TrimSpace(node.Text) {
+		case SectionBegin:
+			begin = i
+		case SectionEnd:
+			if begin >= 0 {
+				end = i
+			}
+		}
+		if end >= 0 {
+			return begin, end
 		}
 	}
+	return -1, -1
+}
 
-	c.text = strings.Join(newLines, "\n")
-	c.text = strings.TrimSpace(c.text)
-	c.text += "\n"
-	return c.LoadCorefile(c.text)
+// hasSection returns true if the server block contains the section
+func hasSection(block *Node) bool {
+	begin, _ := findSection(block)
+	return begin >= 0
 }
 
-// getLineToAppend returns the line number to append the new entry
-func (c *CoreDNSConfigEditor) getLineToAppend() int {
-	// TODO: Do something smarter (e.g. after a specific plugin)
-	return c.getMaxLine() - 1
+// getSection returns the lines of the section in the server block
+func getSection(block *Node) []*Node {
+	begin, end := findSection(block)
+	if begin < 0 {
+		return nil
+	}
+	return block.Children[begin+1 : end]
 }
 
-// getLineToAppendHosts returns the line number to append the new entry in the hosts plugin
+// outsideSection returns the lines of the server block which are not part of the section
+func outsideSection(block *Node) []*Node {
+	begin, end := findSection(block)
+	if begin < 0 {
+		return block.Children
+	}
+	return append(append([]*Node{}, block.Children[:begin]...), block.Children[end+1:]...)
+}
+
+// updateSection replaces the lines of the section in the server block
 //
-// The line number is calculated as follows:
-// 1. If the forward plugin is present, the line number is the line number of the forward plugin -1
-// 2. If the forward plugin is not present, the line number is the line number of the last line in the Corefile
-func (c *CoreDNSConfigEditor) getLineToAppendHosts() int {
-	// Initialize the line number to the maximum possible to ensure it's always after the current content
-	lineToAppend := c.getMaxLine()
-	// Iterate through each server block to find the 'forward' plugin
-	for _, block := range c.serverBlocks {
-		if tokens, ok := block.Tokens["forward"]; ok {
-			// If the 'forward' plugin is found, update the line number to the line before the 'forward' plugin starts
-			for _, token := range tokens {
-				if token.Line < lineToAppend {
-					lineToAppend = token.Line
-					break // Assuming only one 'forward' entry is relevant
-				}
-			}
+// The section is added at the end of the server block if it does not exist yet, and removed with
+// its comments when it becomes empty.
+func updateSection(block *Node, update func(section []*Node) []*Node) {
+	var before, section, after []*Node
+	if begin, end := findSection(block); begin >= 0 {
+		before, after = block.Children[:begin], block.Children[end+1:]
+		section = append(section, block.Children[begin+1:end]...)
+	} else {
+		// Append the section after the last line of the block which is not blank
+		at := len(block.Children)
+		for at > 0 && strings.TrimSpace(block.Children[at-1].Text) == "" {
+			at--
 		}
+		before, after = block.Children[:at], block.Children[at:]
 	}
-	return lineToAppend
-}
+	indent := block.childIndent()
+	section = update(section)
 
-func (c *CoreDNSConfigEditor) removeHosts() error {
-	if !strings.HasSuffix(c.text, "\n") {
-		c.text += "\n"
+	children := append([]*Node{}, before...)
+	if len(section) > 0 {
+		children = append(children, newNode(indent+SectionBegin))
+		children = append(children, section...)
+		children = append(children, newNode(indent+SectionEnd))
 	}
+	block.Children = append(children, after...)
+}
 
-	lines := strings.Split(c.text, "\n")
-	newLines := make([]string, len(lines))
-	found := false
-	for _, line := range lines {
-		if strings.Contains(line, "hosts {") {
-			found = true
-		}
-		if found && strings.Contains(line, "}") {
-			found = false
-			continue
-		}
-		if !found {
-			newLines = append(newLines, line)
+// filterNodes returns the nodes matching the filter
+func filterNodes(nodes []*Node, keep func(node *Node) bool) []*Node {
+	filtered := make([]*Node, 0, len(nodes))
+	for _, node := range nodes {
+		if keep(node) {
+			filtered = append(filtered, node)
 		}
 	}
-
-	c.text = strings.Join(newLines, "\n")
-	c.text = strings.TrimSpace(c.text)
-	c.text += "\n"
-	return c.LoadCorefile(c.text)
+	return filtered
 }
 
 // removeTrailingDot removes the trailing dot from the zone name
@@ -376,3 +520,31 @@ func addTrailingDot(zone string) string {
 	}
 	return zone
 }
+
+// newHost creates an A or AAAA record for the name, depending on the IP family
+func newHost(name string, ip net.IP) dns.RR {
+	switch {
+	case ip == nil:
+		return nil
+	case ip.To4() != nil:
+		return &dns.A{Hdr: dns.RR_Header{Name: name, Rrtype: dns.TypeA, Class: dns.ClassINET}, A: ip}
+	default:
+		return &dns.AAAA{Hdr: dns.RR_Header{Name: name, Rrtype: dns.TypeAAAA, Class: dns.ClassINET}, AAAA: ip}
+	}
+}
+
+// hostIP returns the IP of an A or AAAA record, or nil for any other record type
+func hostIP(rr dns.RR) net.IP {
+	switch r := rr.(type) {
+	case *dns.A:
+		return r.A
+	case *dns.AAAA:
+		return r.AAAA
+	}
+	return nil
+}
+
+// sameHost returns true if both records point the same name to the same IP
+func sameHost(a, b dns.RR) bool {
+	return dns.Fqdn(a.Header().Name) == dns.Fqdn(b.Header().Name) && hostIP(a).Equal(hostIP(b))
+}
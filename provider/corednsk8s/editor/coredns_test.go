@@ -51,7 +51,7 @@ func (s *CoreDNSConfigEditorTestSuite) TestNewCoreDNSConfig() {
 func (s *CoreDNSConfigEditorTestSuite) TestLoadCorefile_Success() {
 	err := s.config.LoadCorefile(s.testConfig)
 	assert.Nil(s.T(), err, "LoadCorefile should not return an error for valid input")
-	assert.NotNil(s.T(), s.config.serverBlocks, "LoadCorefile should populate the serverBlocks field on success")
+	assert.NotNil(s.T(), s.config.corefile.ServerBlock(".:53"), "LoadCorefile should parse the server blocks on success")
 }
 
 func (s *CoreDNSConfigEditorTestSuite) TestLoadCorefile_Error() {
@@ -103,7 +103,8 @@ func (s *CoreDNSConfigEditorTestSuite) TestGetZones() {
 	health {
 	   lameduck 5s
 	}
-	file /etc/coredns/Zone test1.com test2.com test3.com
+	file /etc/coredns/db.test1.com test1.com
+	file /etc/coredns/db.test2.com test2.com test3.com
     file /unrelated/file google.com
     file /root
 }
@@ -117,6 +118,30 @@ func (s *CoreDNSConfigEditorTestSuite) TestGetZones() {
 	s.Equal(zones, []string{"test1.com", "test2.com", "test3.com"}, "GetZones should return the correct zones")
 }
 
+func (s *CoreDNSConfigEditorTestSuite) TestSetZones_FileEntryPerZone() {
+	err := s.config.SetZones([]string{"test.com.", "example.com."})
+	s.Require().Nil(err, "SetZones should not return an error for valid zones")
+
+	configContent := s.config.GetConfig()
+	s.Contains(configContent, "file /etc/coredns/db.example.com example.com\n", "Every zone should be served from its own file")
+	s.Contains(configContent, "file /etc/coredns/db.test.com test.com\n", "Every zone should be served from its own file")
+	s.Equal([]string{"example.com", "test.com"}, s.config.GetZones())
+}
+
+func (s *CoreDNSConfigEditorTestSuite) TestSetZones_ZoneFileDir() {
+	s.config.SetZoneFileDir("/etc/coredns/zones")
+	err := s.config.SetZones([]string{"example.com."})
+	s.Require().Nil(err, "SetZones should not return an error for valid zones")
+
+	s.Contains(s.config.GetConfig(), "file /etc/coredns/zones/db.example.com example.com", "The zone file should be in the configured directory")
+	s.Equal([]string{"example.com"}, s.config.GetZones())
+}
+
+func (s *CoreDNSConfigEditorTestSuite) TestZoneFileName() {
+	s.Equal("db.example.com", ZoneFileName("example.com."))
+	s.Equal("db.example.com", ZoneFileName("example.com"))
+}
+
 func (s *CoreDNSConfigEditorTestSuite) TestAddZone_NewZone() {
 	// Step 1: Add a new zone that does not exist
 	err := s.config.LoadCorefile(`
@@ -148,7 +173,7 @@ func (s *CoreDNSConfigEditorTestSuite) TestAddZone_AdditionalZone() {
 	   lameduck 5s
 	}
 
-    file /etc/coredns/Zone test.com
+    file /etc/coredns/db.test.com test.com
     file /unrelated/file google.com
     file /root
 }
@@ -170,7 +195,7 @@ func (s *CoreDNSConfigEditorTestSuite) TestAddZone_ExistingZone() {
 	health {
 	   lameduck 5s
 	}
-    file /etc/coredns/Zone test.com
+    file /etc/coredns/db.test.com test.com
     file /unrelated/file google.com
     file /root
 }
@@ -198,7 +223,8 @@ func (s *CoreDNSConfigEditorTestSuite) TestRemoveZone_ExistingZone() {
 	health {
 	   lameduck 5s
 	}
-    file /etc/coredns/Zone test.com test2.com
+    file /etc/coredns/db.test.com test.com
+    file /etc/coredns/db.test2.com test2.com
     file /unrelated/file google.com
     file /root
 }
@@ -221,7 +247,7 @@ func (s *CoreDNSConfigEditorTestSuite) TestRemoveZone_LastZone() {
 	   lameduck 5s
 	}
 
-    file /etc/coredns/Zone test.com
+    file /etc/coredns/db.test.com test.com
     file /unrelated/file google.com
     file /root
 }
@@ -233,7 +259,8 @@ func (s *CoreDNSConfigEditorTestSuite) TestRemoveZone_LastZone() {
 	// Step 2: Assert the zone was removed
 	zones := s.config.GetZones()
 	s.NotContains(zones, "test.com", "Removed zone should not be present in the zones list")
-	s.NotContains(s.config.GetConfig(), "file /etc/coredns/Zone", "file plugin should not be present in the config")
+	s.NotContains(s.config.GetConfig(), "file /etc/coredns/db.", "file plugin should not be present in the config")
+	s.Contains(s.config.GetConfig(), "file /unrelated/file google.com", "Unrelated file plugins should be kept")
 }
 
 func (s *CoreDNSConfigEditorTestSuite) TestRemoveZone_NonExistingZone() {
@@ -244,7 +271,8 @@ func (s *CoreDNSConfigEditorTestSuite) TestRemoveZone_NonExistingZone() {
 	health {
 	   lameduck 5s
 	}
-    file /etc/coredns/Zone test.com test2.com
+    file /etc/coredns/db.test.com test.com
+    file /etc/coredns/db.test2.com test2.com
     file /unrelated/file google.com
     file /root
 }
@@ -269,7 +297,7 @@ func (s *CoreDNSConfigEditorTestSuite) TestSetHosts_MultipleHosts() {
 	   lameduck 5s
 	}
 
-    file /etc/coredns/Zone test.com
+    file /etc/coredns/db.test.com test.com
     file /unrelated/file google.com
     hosts {
        192.168.10.1 test.com
@@ -278,12 +306,12 @@ func (s *CoreDNSConfigEditorTestSuite) TestSetHosts_MultipleHosts() {
 }
 `)
 	s.Require().Nil(err, "LoadCorefile should not return an error")
-	hosts := []dns.A{
-		{
+	hosts := []dns.RR{
+		&dns.A{
 			Hdr: dns.RR_Header{Name: "example.com", Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 3600},
 			A:   net.ParseIP("192.0.2.1"),
 		},
-		{
+		&dns.A{
 			Hdr: dns.RR_Header{Name: "test.com", Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 3600},
 			A:   net.ParseIP("192.0.2.2"),
 		},
@@ -295,13 +323,14 @@ func (s *CoreDNSConfigEditorTestSuite) TestSetHosts_MultipleHosts() {
 	// Assert the hosts were added
 	configContent := s.config.GetConfig()
 	for _, host := range hosts {
-		s.Contains(configContent, host.A.String(), fmt.Sprintf("Host %s should be present in the config", host.A.String()))
-		s.Contains(configContent, host.Hdr.Name, fmt.Sprintf("Host name %s should be present in the config", host.Hdr.Name))
+		ip := hostIP(host).String()
+		s.Contains(configContent, ip, fmt.Sprintf("Host %s should be present in the config", ip))
+		s.Contains(configContent, host.Header().Name, fmt.Sprintf("Host name %s should be present in the config", host.Header().Name))
 	}
 }
 
 func (s *CoreDNSConfigEditorTestSuite) TestSetHosts_EmptyHosts() {
-	err := s.config.SetHosts([]dns.A{})
+	err := s.config.SetHosts([]dns.RR{})
 	s.Require().Nil(err, "SetHosts should not return an error for an empty hosts list")
 
 	// Assert no hosts are present
@@ -344,7 +373,7 @@ func (s *CoreDNSConfigEditorTestSuite) TestGetHosts_WithHosts() {
 	for _, expectedHost := range expectedHosts {
 		found := false
 		for _, host := range hosts {
-			if host.Hdr.Name == expectedHost.Hdr.Name && host.A.String() == expectedHost.A.String() {
+			if sameHost(host, &expectedHost) {
 				found = true
 				break
 			}
@@ -373,8 +402,57 @@ func (s *CoreDNSConfigEditorTestSuite) TestGetHosts_NoHosts() {
 	s.Len(hosts, 0, "No hosts should be present")
 }
 
+func (s *CoreDNSConfigEditorTestSuite) TestGetHosts_IPv6() {
+	// Load a Corefile with IPv4 and IPv6 hosts
+	err := s.config.LoadCorefile(`
+.:53 {
+    errors
+    hosts {
+       192.168.10.1 example.com
+       2001:db8::1 example.com other.com
+       fallthrough
+    }
+}
+`)
+	s.Require().Nil(err, "LoadCorefile should not return an error")
+
+	hosts := s.config.GetHosts()
+	s.Require().Len(hosts, 3, "Every name of every entry should be returned")
+	s.IsType(&dns.A{}, hosts[0], "IPv4 entries should be returned as A records")
+	s.IsType(&dns.AAAA{}, hosts[1], "IPv6 entries should be returned as AAAA records")
+	s.Equal("other.com.", hosts[2].Header().Name)
+	s.Equal("2001:db8::1", hostIP(hosts[2]).String())
+}
+
+func (s *CoreDNSConfigEditorTestSuite) TestSetHosts_IPv6() {
+	hosts := []dns.RR{
+		&dns.AAAA{
+			Hdr:  dns.RR_Header{Name: "example.com.", Rrtype: dns.TypeAAAA, Class: dns.ClassINET},
+			AAAA: net.ParseIP("2001:db8::1"),
+		},
+	}
+	err := s.config.SetHosts(hosts)
+	s.Require().Nil(err, "SetHosts should not return an error for AAAA hosts")
+
+	s.Contains(s.config.GetConfig(), "2001:db8::1 example.com", "AAAA host should be present in the config")
+	s.Require().Len(s.config.GetHosts(), 1)
+	s.True(sameHost(hosts[0], s.config.GetHosts()[0]), "AAAA host should be read back")
+}
+
+func (s *CoreDNSConfigEditorTestSuite) TestSetHosts_UnsupportedType() {
+	hosts := []dns.RR{
+		&dns.TXT{
+			Hdr: dns.RR_Header{Name: "example.com.", Rrtype: dns.TypeTXT, Class: dns.ClassINET},
+			Txt: []string{"heritage=external-dns"},
+		},
+	}
+	err := s.config.SetHosts(hosts)
+	s.Require().Error(err, "SetHosts should reject records the hosts plugin cannot serve")
+	s.Equal(s.testConfig, s.config.GetConfig(), "The Corefile should be left untouched")
+}
+
 func (s *CoreDNSConfigEditorTestSuite) TestAddHost_NewHost() {
-	host := dns.A{
+	host := &dns.A{
 		Hdr: dns.RR_Header{Name: "newhost.com.", Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 3600},
 		A:   net.ParseIP("192.0.2.3"),
 	}
@@ -387,7 +465,7 @@ func (s *CoreDNSConfigEditorTestSuite) TestAddHost_NewHost() {
 	hosts := s.config.GetHosts()
 	found := false
 	for _, h := range hosts {
-		if h.Hdr.Name == host.Hdr.Name && h.A.Equal(host.A) {
+		if sameHost(h, host) {
 			found = true
 			break
 		}
@@ -396,7 +474,7 @@ func (s *CoreDNSConfigEditorTestSuite) TestAddHost_NewHost() {
 }
 
 func (s *CoreDNSConfigEditorTestSuite) TestAddHost_ExistingHost() {
-	host := dns.A{
+	host := &dns.A{
 		Hdr: dns.RR_Header{Name: "existinghost.com.", Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 3600},
 		A:   net.ParseIP("192.0.2.4"),
 	}
@@ -412,7 +490,7 @@ func (s *CoreDNSConfigEditorTestSuite) TestAddHost_ExistingHost() {
 	hosts := s.config.GetHosts()
 	count := 0
 	for _, h := range hosts {
-		if h.Hdr.Name == host.Hdr.Name && h.A.Equal(host.A) {
+		if sameHost(h, host) {
 			count++
 		}
 	}
@@ -420,11 +498,11 @@ func (s *CoreDNSConfigEditorTestSuite) TestAddHost_ExistingHost() {
 }
 
 func (s *CoreDNSConfigEditorTestSuite) TestUpdateHost_ExistingHost() {
-	oldHost := dns.A{
+	oldHost := &dns.A{
 		Hdr: dns.RR_Header{Name: "oldhost.com.", Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 3600},
 		A:   net.ParseIP("192.0.2.3"),
 	}
-	newHost := dns.A{
+	newHost := &dns.A{
 		Hdr: dns.RR_Header{Name: "newhost.com.", Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 3600},
 		A:   net.ParseIP("192.0.2.4"),
 	}
@@ -439,10 +517,10 @@ func (s *CoreDNSConfigEditorTestSuite) TestUpdateHost_ExistingHost() {
 	foundNewHost := false
 	foundOldHost := false
 	for _, h := range hosts {
-		if h.Hdr.Name == newHost.Hdr.Name && h.A.Equal(newHost.A) {
+		if sameHost(h, newHost) {
 			foundNewHost = true
 		}
-		if h.Hdr.Name == oldHost.Hdr.Name && h.A.Equal(oldHost.A) {
+		if sameHost(h, oldHost) {
 			foundOldHost = true
 		}
 	}
@@ -451,11 +529,11 @@ func (s *CoreDNSConfigEditorTestSuite) TestUpdateHost_ExistingHost() {
 }
 
 func (s *CoreDNSConfigEditorTestSuite) TestUpdateHost_NonExistingHost() {
-	nonExistingHost := dns.A{
+	nonExistingHost := &dns.A{
 		Hdr: dns.RR_Header{Name: "nonexistinghost.com.", Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 3600},
 		A:   net.ParseIP("192.0.2.5"),
 	}
-	updatedHost := dns.A{
+	updatedHost := &dns.A{
 		Hdr: dns.RR_Header{Name: "updatedhost.com.", Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 3600},
 		A:   net.ParseIP("192.0.2.6"),
 	}
@@ -468,7 +546,7 @@ func (s *CoreDNSConfigEditorTestSuite) TestUpdateHost_NonExistingHost() {
 	hosts := s.config.GetHosts()
 	foundUpdatedHost := false
 	for _, h := range hosts {
-		if h.Hdr.Name == updatedHost.Hdr.Name && h.A.Equal(updatedHost.A) {
+		if sameHost(h, updatedHost) {
 			foundUpdatedHost = true
 			break
 		}
@@ -477,7 +555,7 @@ func (s *CoreDNSConfigEditorTestSuite) TestUpdateHost_NonExistingHost() {
 }
 
 func (s *CoreDNSConfigEditorTestSuite) TestRemoveHost_ExistingHost() {
-	host := dns.A{
+	host := &dns.A{
 		Hdr: dns.RR_Header{Name: "removehost.com.", Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 3600},
 		A:   net.ParseIP("192.0.2.5"),
 	}
@@ -493,7 +571,7 @@ func (s *CoreDNSConfigEditorTestSuite) TestRemoveHost_ExistingHost() {
 	hosts := s.config.GetHosts()
 	found := false
 	for _, h := range hosts {
-		if h.Hdr.Name == host.Hdr.Name && h.A.Equal(host.A) {
+		if sameHost(h, host) {
 			found = true
 			break
 		}
@@ -502,7 +580,7 @@ func (s *CoreDNSConfigEditorTestSuite) TestRemoveHost_ExistingHost() {
 }
 
 func (s *CoreDNSConfigEditorTestSuite) TestRemoveHost_NonExistingHost() {
-	host := dns.A{
+	host := &dns.A{
 		Hdr: dns.RR_Header{Name: "nonexistinghost.com.", Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 3600},
 		A:   net.ParseIP("192.0.2.6"),
 	}
@@ -531,6 +609,221 @@ func (s *CoreDNSConfigEditorTestSuite) TestGetConfig() {
 	s.Equal(s.testConfig, configContent, "GetConfig should return the correct Corefile content")
 }
 
+func (s *CoreDNSConfigEditorTestSuite) TestSection_PreservesCorefile() {
+	corefile := `# Managed by the cluster admins
+.:53 {
+    errors # keep errors first
+    # The admins comment
+    hosts /etc/custom-hosts internal.example.com {
+        fallthrough
+    }
+    forward . /etc/resolv.conf
+
+}
+
+example.org:53 {
+    whoami
+}
+`
+	s.Require().NoError(s.config.LoadCorefile(corefile))
+	s.Require().NoError(s.config.SetZones([]string{"example.com."}))
+
+	s.Equal(`# Managed by the cluster admins
+.:53 {
+    errors # keep errors first
+    # The admins comment
+    hosts /etc/custom-hosts internal.example.com {
+        fallthrough
+    }
+    forward . /etc/resolv.conf
+    `+SectionBegin+`
+    file /etc/coredns/db.example.com example.com
+    `+SectionEnd+`
+
+}
+
+example.org:53 {
+    whoami
+}
+`, s.config.GetConfig(), "Only the section should be added")
+
+	// Removing every zone should give back the original Corefile
+	s.Require().NoError(s.config.SetZones(nil))
+	s.Equal(corefile, s.config.GetConfig())
+}
+
+func (s *CoreDNSConfigEditorTestSuite) TestSection_HostsAndZones() {
+	host := &dns.A{
+		Hdr: dns.RR_Header{Name: "www.example.com.", Rrtype: dns.TypeA, Class: dns.ClassINET},
+		A:   net.ParseIP("192.0.2.1"),
+	}
+	s.Require().NoError(s.config.AddHost(host))
+	s.Require().NoError(s.config.SetZones([]string{"example.net."}))
+
+	s.Contains(s.config.GetConfig(), `	`+SectionBegin+`
+	hosts {
+		192.0.2.1 www.example.com
+		fallthrough
+	}
+	file /etc/coredns/db.example.net example.net
+	`+SectionEnd+`
+`, "The section should follow the indentation of the server block")
+
+	// The section should be parsed back
+	s.Require().NoError(s.config.LoadCorefile(s.config.GetConfig()))
+	s.Require().Len(s.config.GetHosts(), 1)
+	s.True(sameHost(host, s.config.GetHosts()[0]))
+	s.Equal([]string{"example.net"}, s.config.GetZones())
+
+	// Removing the hosts should keep the zones
+	s.Require().NoError(s.config.RemoveHost(host))
+	s.NotContains(s.config.GetConfig(), "hosts {")
+	s.Equal([]string{"example.net"}, s.config.GetZones())
+}
+
+func (s *CoreDNSConfigEditorTestSuite) TestSection_AdminHostsPlugin() {
+	err := s.config.LoadCorefile(`.:53 {
+    hosts /etc/custom-hosts {
+        fallthrough
+    }
+    ` + SectionBegin + `
+    file /etc/coredns/db.example.net example.net
+    ` + SectionEnd + `
+}
+`)
+	s.Require().NoError(err)
+	s.Empty(s.config.GetHosts(), "The hosts plugin outside the section should not be read")
+
+	err = s.config.AddHost(&dns.A{
+		Hdr: dns.RR_Header{Name: "www.example.com.", Rrtype: dns.TypeA, Class: dns.ClassINET},
+		A:   net.ParseIP("192.0.2.1"),
+	})
+	s.Require().Error(err, "The hosts plugin can only be used once per server block")
+}
+
+func (s *CoreDNSConfigEditorTestSuite) TestRenderZones() {
+	s.config.SetZoneFileDir("/etc/coredns/custom")
+	s.Equal("file /etc/coredns/custom/db.example.com example.com\nfile /etc/coredns/custom/db.example.net example.net\n",
+		s.config.RenderZones([]string{"example.net.", "example.com"}))
+	s.Empty(s.config.RenderZones(nil))
+}
+
+func (s *CoreDNSConfigEditorTestSuite) TestImports() {
+	s.Require().NoError(s.config.LoadCorefile(`.:53 {
+    errors
+    import custom/*.override
+    forward . /etc/resolv.conf
+}
+cluster.example:53 {
+    import /etc/coredns/extra/external-dns.override
+}
+import custom/*.server
+`))
+	s.True(s.config.Imports("/etc/coredns/custom/external-dns.override", "/etc/coredns"), "Relative globs should be resolved from the root")
+	s.False(s.config.Imports("/etc/coredns/custom/external-dns.server", "/etc/coredns"))
+	s.False(s.config.Imports("/etc/coredns/extra/external-dns.override", "/etc/coredns"), "Other server blocks should be ignored")
+
+	s.config.SetServerBlock("cluster.example")
+	s.True(s.config.Imports("/etc/coredns/extra/external-dns.override", "/etc/coredns"))
+}
+
+func (s *CoreDNSConfigEditorTestSuite) TestSetHostsTTL() {
+	s.Require().NoError(s.config.LoadCorefile(".:53 {\n    forward . /etc/resolv.conf\n}\n"))
+	s.Zero(s.config.GetHostsTTL())
+
+	s.Require().NoError(s.config.AddHost(&dns.A{
+		Hdr: dns.RR_Header{Name: "www.example.com.", Rrtype: dns.TypeA, Class: dns.ClassINET},
+		A:   net.ParseIP("192.0.2.1"),
+	}))
+	s.Require().NoError(s.config.SetHostsTTL(300))
+	s.Require().NoError(s.config.AddHost(&dns.A{
+		Hdr: dns.RR_Header{Name: "www.example.com.", Rrtype: dns.TypeA, Class: dns.ClassINET},
+		A:   net.ParseIP("192.0.2.2"),
+	}))
+	s.Contains(s.config.GetConfig(), "        192.0.2.2 www.example.com\n        ttl 300\n        fallthrough\n",
+		"The TTL should be kept when the hosts are set")
+	s.Equal(uint32(300), s.config.GetHostsTTL())
+	hosts := s.config.GetHosts()
+	s.Require().Len(hosts, 2)
+	s.Equal(uint32(300), hosts[0].Header().Ttl, "The hosts should be read with the TTL of the plugin")
+
+	s.Require().NoError(s.config.SetHostsTTL(0))
+	s.NotContains(s.config.GetConfig(), "ttl")
+}
+
+func (s *CoreDNSConfigEditorTestSuite) TestSection_LegacyEntries() {
+	// Corefile written by the previous versions, without the section
+	err := s.config.LoadCorefile(`.:53 {
+    errors
+    hosts {
+        192.0.2.1 www.example.com
+        fallthrough
+    }
+    file /etc/coredns/db.example.net example.net
+    forward . /etc/resolv.conf
+}
+`)
+	s.Require().NoError(err)
+	s.Len(s.config.GetHosts(), 1)
+	s.Equal([]string{"example.net"}, s.config.GetZones())
+
+	// The entries should be moved into the section on the first write
+	s.Require().NoError(s.config.SetHosts(s.config.GetHosts()))
+	s.Require().NoError(s.config.SetZones(s.config.GetZones()))
+	s.Equal(`.:53 {
+    errors
+    forward . /etc/resolv.conf
+    `+SectionBegin+`
+    hosts {
+        192.0.2.1 www.example.com
+        fallthrough
+    }
+    file /etc/coredns/db.example.net example.net
+    `+SectionEnd+`
+}
+`, s.config.GetConfig())
+}
+
+func (s *CoreDNSConfigEditorTestSuite) TestServerBlock() {
+	corefile := `.:53 {
+    errors
+}
+
+cluster.example:53 {
+    errors
+}
+`
+	s.Require().NoError(s.config.LoadCorefile(corefile))
+	s.config.SetServerBlock("cluster.example")
+	s.Require().NoError(s.config.SetZones([]string{"example.com."}))
+
+	s.Equal(`.:53 {
+    errors
+}
+
+cluster.example:53 {
+    errors
+    `+SectionBegin+`
+    file /etc/coredns/db.example.com example.com
+    `+SectionEnd+`
+}
+`, s.config.GetConfig(), "Only the selected server block should be edited")
+	s.Equal([]string{"example.com"}, s.config.GetZones())
+
+	s.config.SetServerBlock(".:53")
+	s.Empty(s.config.GetZones(), "The other server blocks should not be read")
+}
+
+func (s *CoreDNSConfigEditorTestSuite) TestServerBlock_NotFound() {
+	s.Require().NoError(s.config.LoadCorefile("example.org {\n    whoami\n}\n"))
+
+	s.Empty(s.config.GetZones())
+	s.Empty(s.config.GetHosts())
+	err := s.config.SetZones([]string{"example.com."})
+	s.Require().Error(err)
+	s.Contains(err.Error(), "server block .:53 not found")
+}
+
 func TestCoreDNSConfigEditorTestSuite(t *testing.T) {
 	suite.Run(t, new(CoreDNSConfigEditorTestSuite))
 }
@@ -0,0 +1,243 @@
+package editor
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Corefile is a lossless syntax tree of a Corefile
+//
+// Every node is a line of the Corefile and keeps its raw text, so rendering the tree gives back the
+// exact text it was parsed from, including the comments, blank lines and indentation. Only the
+// edited nodes are rendered from their tokens.
+type Corefile struct {
+	nodes []*Node
+	// finalNewline is true if the text ends with a newline
+	finalNewline bool
+}
+
+// Node is a line of the Corefile, with the lines of its block when it opens one
+type Node struct {
+	// Text is the raw text of the line
+	Text string
+	// Tokens are the tokens of the line, without the comment
+	Tokens []string
+	// Children are the lines of the block opened by the line
+	Children []*Node
+	// Closing is the raw text of the line closing the block
+	Closing string
+	block   bool
+}
+
+// ParseCorefile parses the Corefile text into a syntax tree
+//
+// Blocks are expected to be opened at the end of a line and closed on their own line, as done in
+// the Corefiles of Kubernetes clusters.
+func ParseCorefile(text string) (*Corefile, error) {
+	corefile := &Corefile{finalNewline: strings.HasSuffix(text, "\n")}
+	lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	if text == "" {
+		lines = nil
+	}
+
+	// stack holds the blocks opened by the previous lines, the innermost last
+	stack := make([]*Node, 0)
+	appendNode := func(node *Node) {
+		if len(stack) == 0 {
+			corefile.nodes = append(corefile.nodes, node)
+		} else {
+			parent := stack[len(stack)-1]
+			parent.Children = append(parent.Children, node)
+		}
+	}
+	for i, line := range lines {
+		node := newNode(line)
+		// balanced is false if a brace is closed before being opened in the line
+		opens, closes, balanced := 0, 0, true
+		for _, token := range node.Tokens {
+			switch token {
+			case "{":
+				opens++
+			case "}":
+				closes++
+				balanced = balanced && closes <= opens
+			}
+		}
+		switch {
+		case opens == closes && balanced:
+			appendNode(node)
+		case opens == 1 && closes == 0 && node.Tokens[len(node.Tokens)-1] == "{":
+			node.block = true
+			appendNode(node)
+			stack = append(stack, node)
+		case closes == 1 && opens == 0 && len(node.Tokens) == 1:
+			if len(stack) == 0 {
+				return nil, fmt.Errorf("line %d: unexpected '}'", i+1)
+			}
+			stack[len(stack)-1].Closing = line
+			stack = stack[:len(stack)-1]
+		default:
+			return nil, fmt.Errorf("line %d: unsupported block syntax %q", i+1, line)
+		}
+	}
+	if len(stack) > 0 {
+		return nil, fmt.Errorf("block %q is not closed", strings.TrimSpace(stack[len(stack)-1].Text))
+	}
+	return corefile, nil
+}
+
+// String renders the Corefile
+func (c *Corefile) String() string {
+	var sb strings.Builder
+	for _, node := range c.nodes {
+		node.render(&sb)
+	}
+	text := sb.String()
+	if !c.finalNewline {
+		text = strings.TrimSuffix(text, "\n")
+	}
+	return text
+}
+
+// ServerBlock returns the server block serving the key (e.g. ".:53"), or nil if there is none
+//
+// Keys without a port default to port 53, and the dns:// scheme is optional.
+func (c *Corefile) ServerBlock(key string) *Node {
+	key = normalizeServerKey(key)
+	for _, node := range c.nodes {
+		if !node.block {
+			continue
+		}
+		for _, k := range node.ServerKeys() {
+			if normalizeServerKey(k) == key {
+				return node
+			}
+		}
+	}
+	return nil
+}
+
+// newNode parses a line of the Corefile
+func newNode(text string) *Node {
+	return &Node{Text: text, Tokens: tokenize(text)}
+}
+
+// newBlockNode creates a line opening a block, closed at the given indentation
+func newBlockNode(text, closingIndent string, children []*Node) *Node {
+	node := newNode(text)
+	node.block = true
+	node.Children = children
+	node.Closing = closingIndent + "}"
+	return node
+}
+
+// Directive returns the name of the directive of the line, or an empty string for blank and comment lines
+func (n *Node) Directive() string {
+	if len(n.Tokens) == 0 {
+		return ""
+	}
+	return n.Tokens[0]
+}
+
+// Args returns the arguments of the directive of the line, without the opening brace of its block
+func (n *Node) Args() []string {
+	if len(n.Tokens) < 2 {
+		return nil
+	}
+	args := n.Tokens[1:]
+	if n.block {
+		args = args[:len(args)-1]
+	}
+	return args
+}
+
+// IsBlock returns true if the line opens a block
+func (n *Node) IsBlock() bool {
+	return n.block
+}
+
+// ServerKeys returns the keys of the server block opened by the line (e.g. ".:53")
+func (n *Node) ServerKeys() []string {
+	if !n.block {
+		return nil
+	}
+	keys := make([]string, 0)
+	for _, token := range n.Tokens[:len(n.Tokens)-1] {
+		for _, key := range strings.Split(token, ",") {
+			if key = strings.TrimSpace(key); key != "" {
+				keys = append(keys, key)
+			}
+		}
+	}
+	return keys
+}
+
+// Indent returns the indentation of the line
+func (n *Node) Indent() string {
+	return n.Text[:len(n.Text)-len(strings.TrimLeft(n.Text, " \t"))]
+}
+
+// childIndent returns the indentation of the lines of the block, from its first non blank line
+func (n *Node) childIndent() string {
+	for _, child := range n.Children {
+		if strings.TrimSpace(child.Text) != "" {
+			return child.Indent()
+		}
+	}
+	return n.Indent() + "    "
+}
+
+// render writes the line and the lines of its block
+func (n *Node) render(sb *strings.Builder) {
+	sb.WriteString(n.Text)
+	sb.WriteString("\n")
+	if !n.block {
+		return
+	}
+	for _, child := range n.Children {
+		child.render(sb)
+	}
+	sb.WriteString(n.Closing)
+	sb.WriteString("\n")
+}
+
+// tokenize splits a line of the Corefile into tokens, ignoring the comment
+//
+// Quoted tokens are kept with their quotes, so they are rendered back as they were written.
+func tokenize(line string) []string {
+	tokens := make([]string, 0)
+	var token strings.Builder
+	quoted, escaped := false, false
+	for _, ch := range line {
+		switch {
+		case escaped:
+			escaped = false
+		case quoted && ch == '\\':
+			escaped = true
+		case ch == '"':
+			quoted = !quoted
+		case !quoted && (ch == ' ' || ch == '\t' || ch == '\r'):
+			if token.Len() > 0 {
+				tokens = append(tokens, token.String())
+				token.Reset()
+			}
+			continue
+		case !quoted && ch == '#' && token.Len() == 0:
+			return tokens
+		}
+		token.WriteRune(ch)
+	}
+	if token.Len() > 0 {
+		tokens = append(tokens, token.String())
+	}
+	return tokens
+}
+
+// normalizeServerKey returns the server block key with its default scheme and port removed or added
+func normalizeServerKey(key string) string {
+	key = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(key), "dns://"))
+	if !strings.Contains(key, ":") {
+		key += ":53"
+	}
+	return key
+}
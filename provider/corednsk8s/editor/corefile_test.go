@@ -0,0 +1,105 @@
+package editor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type CorefileTestSuite struct {
+	suite.Suite
+}
+
+func (s *CorefileTestSuite) TestParseCorefile_RoundTrip() {
+	for _, corefile := range []string{
+		"",
+		".:53 {\n    errors\n}\n",
+		".:53 {\n    errors\n}",
+		`# Managed by kubeadm
+.:53 {
+	errors   # trailing comment
+	health {
+	   lameduck 5s
+	}
+
+    # Indented with spaces
+	kubernetes cluster.local in-addr.arpa ip6.arpa {
+	   pods insecure
+	   fallthrough in-addr.arpa ip6.arpa
+	}
+	template IN TXT example.com {
+	   answer "{{ .Name }} 60 IN TXT \"#not a comment\""
+	}
+	forward . /etc/resolv.conf
+}
+
+(snippet) {
+    log
+}
+
+example.com:5353 example.net:5353 {
+    import snippet
+}
+`,
+	} {
+		parsed, err := ParseCorefile(corefile)
+		s.Require().NoError(err, corefile)
+		s.Equal(corefile, parsed.String(), "The Corefile should be rendered as it was parsed")
+	}
+}
+
+func (s *CorefileTestSuite) TestParseCorefile_Errors() {
+	for _, corefile := range []string{
+		".:53 {\n    errors\n",
+		".:53 {\n    errors\n}\n}\n",
+		".:53 {\n    errors\n} .:54 {\n}\n",
+	} {
+		_, err := ParseCorefile(corefile)
+		s.Error(err, corefile)
+	}
+}
+
+func (s *CorefileTestSuite) TestParseCorefile_Tree() {
+	parsed, err := ParseCorefile(`.:53 {
+    errors # comment
+    hosts /etc/hosts example.com {
+        192.0.2.1 www.example.com
+        fallthrough
+    }
+}
+`)
+	s.Require().NoError(err)
+	block := parsed.ServerBlock(".:53")
+	s.Require().NotNil(block)
+	s.Require().Len(block.Children, 2)
+	s.Equal([]string{"errors"}, block.Children[0].Tokens, "Comments should not be tokenized")
+
+	hosts := block.Children[1]
+	s.True(hosts.IsBlock())
+	s.Equal("hosts", hosts.Directive())
+	s.Equal([]string{"/etc/hosts", "example.com"}, hosts.Args())
+	s.Require().Len(hosts.Children, 2)
+	s.Equal([]string{"192.0.2.1", "www.example.com"}, hosts.Children[0].Tokens)
+	s.Equal("    }", hosts.Closing)
+}
+
+func (s *CorefileTestSuite) TestServerBlock() {
+	parsed, err := ParseCorefile(`. {
+    errors
+}
+example.com:5353, dns://example.net {
+    errors
+}
+`)
+	s.Require().NoError(err)
+	s.NotNil(parsed.ServerBlock(".:53"), "The port should default to 53")
+	s.NotNil(parsed.ServerBlock("dns://.:53"), "The dns scheme should be optional")
+	s.NotNil(parsed.ServerBlock("example.com:5353"))
+	s.NotNil(parsed.ServerBlock("example.net"))
+	s.Nil(parsed.ServerBlock("example.com"))
+	s.Equal([]string{"example.com:5353", "dns://example.net"}, parsed.ServerBlock("example.net").ServerKeys())
+}
+
+func TestCorefileTestSuite(t *testing.T) {
+	suite.Run(t, new(CorefileTestSuite))
+}
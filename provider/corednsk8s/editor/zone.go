@@ -2,25 +2,103 @@ package editor
 
 import (
 	"fmt"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/miekg/dns"
 )
 
+const (
+	// SerialFormatDate uses date based serials (YYYYMMDDnn), as recommended by RFC 1912
+	SerialFormatDate = "date"
+	// SerialFormatUnixTime uses the unix time of the change as serial
+	SerialFormatUnixTime = "unixtime"
+)
+
+// ZoneConfig configures the SOA and apex NS records of the generated zones
+type ZoneConfig struct {
+	// Mname is the primary nameserver of the zones, defaults to ns.<zone>
+	Mname string
+	// Rname is the mailbox of the zones administrator, defaults to hostmaster.<zone>
+	Rname string
+	// TTL is the TTL of the SOA and apex NS records
+	TTL time.Duration
+	// Refresh, Retry and Expire are the timers of the secondary nameservers
+	Refresh time.Duration
+	Retry   time.Duration
+	Expire  time.Duration
+	// Minimum is the TTL of negative responses
+	Minimum time.Duration
+	// Nameservers are the apex NS records of the zones, none if empty
+	Nameservers []string
+	// SerialFormat is the format of the SOA serial, either date or unixtime
+	SerialFormat string
+}
+
+// DefaultZoneConfig returns the default configuration of the generated zones
+func DefaultZoneConfig() ZoneConfig {
+	return ZoneConfig{
+		TTL:          time.Hour,
+		Refresh:      time.Hour,
+		Retry:        15 * time.Minute,
+		Expire:       14 * 24 * time.Hour,
+		Minimum:      time.Hour,
+		SerialFormat: SerialFormatDate,
+	}
+}
+
 type ZoneEditor struct {
 	entriesByZone map[string][]dns.RR
+	config        ZoneConfig
+	// changedZones are the zones edited since the last serial update
+	changedZones map[string]struct{}
+	// serials are the last known serials of every zone, including the deleted ones
+	serials map[string]uint32
+	now     func() time.Time
 }
 
 // NewZoneEditor creates a new ZoneEditor
 func NewZoneEditor() *ZoneEditor {
+	return NewZoneEditorWithConfig(DefaultZoneConfig())
+}
+
+// NewZoneEditorWithConfig creates a new ZoneEditor generating zones with the given configuration
+func NewZoneEditorWithConfig(config ZoneConfig) *ZoneEditor {
 	return &ZoneEditor{
 		entriesByZone: make(map[string][]dns.RR),
+		config:        config,
+		changedZones:  make(map[string]struct{}),
+		serials:       make(map[string]uint32),
+		now:           time.Now,
 	}
 }
 
 // LoadZone loads a zone file
 func (z *ZoneEditor) LoadZone(zoneFile string) error {
+	return z.LoadZones([]string{zoneFile})
+}
+
+// LoadZones loads a set of zone files, replacing all the loaded zones
+func (z *ZoneEditor) LoadZones(zoneFiles []string) error {
 	z.entriesByZone = make(map[string][]dns.RR)
+	z.changedZones = make(map[string]struct{})
+	for _, zoneFile := range zoneFiles {
+		if err := z.loadZone(zoneFile); err != nil {
+			return err
+		}
+	}
+	// Remember the serials, so they never go backwards
+	for zone := range z.entriesByZone {
+		if soa := z.getSOA(zone); soa != nil && soa.Serial > z.serials[zone] {
+			z.serials[zone] = soa.Serial
+		}
+	}
+	return nil
+}
+
+// loadZone parses a zone file and adds its records to the loaded zones
+func (z *ZoneEditor) loadZone(zoneFile string) error {
 	parser := dns.NewZoneParser(strings.NewReader(zoneFile), "", "")
 	zone := ""
 	for rr, ok := parser.Next(); ok; rr, ok = parser.Next() {
@@ -43,12 +121,17 @@ func (z *ZoneEditor) LoadZone(zoneFile string) error {
 	return parser.Err()
 }
 
-// AddRecord adds a record to the zone
+// AddRecord adds a record to the zone, unless the zone already contains it
 func (z *ZoneEditor) AddRecord(zone string, record dns.RR) {
 	zoneRecords := z.GetOrCreateZone(zone)
+	for _, r := range zoneRecords {
+		if dns.IsDuplicate(r, record) {
+			return
+		}
+	}
 	zoneRecords = append(zoneRecords, record)
 	z.entriesByZone[zone] = zoneRecords
-	z.increaseZoneSerial(zone)
+	z.changedZones[zone] = struct{}{}
 }
 
 // UpdateRecord updates a record in the zone
@@ -63,33 +146,100 @@ func (z *ZoneEditor) UpdateRecord(zone string, oldRecord, newRecord dns.RR) {
 		}
 	}
 	z.entriesByZone[zone] = newRecords
-	z.increaseZoneSerial(zone)
+	z.changedZones[zone] = struct{}{}
+}
+
+// SetRecords replaces the records with the name and type of the given records in the zone
+//
+// The records are written in place of the replaced records, or at the end of the zone if there were none.
+func (z *ZoneEditor) SetRecords(zone string, records []dns.RR) {
+	if len(records) == 0 {
+		return
+	}
+	name, rrtype := records[0].Header().Name, records[0].Header().Rrtype
+	zoneRecords := z.GetOrCreateZone(zone)
+	newRecords := make([]dns.RR, 0, len(zoneRecords)+len(records))
+	replaced := false
+	for _, r := range zoneRecords {
+		if !strings.EqualFold(r.Header().Name, name) || r.Header().Rrtype != rrtype {
+			newRecords = append(newRecords, r)
+			continue
+		}
+		if !replaced {
+			newRecords = append(newRecords, records...)
+			replaced = true
+		}
+	}
+	if !replaced {
+		newRecords = append(newRecords, records...)
+	}
+	z.entriesByZone[zone] = newRecords
+	z.changedZones[zone] = struct{}{}
 }
 
 // DeleteRecord deletes a record from the zone
 func (z *ZoneEditor) DeleteRecord(zone string, record dns.RR) {
 	zoneRecords := z.GetOrCreateZone(zone)
 	newRecords := make([]dns.RR, 0)
+	generated := 0
 	for _, r := range zoneRecords {
-		if !(r.Header().Name == record.Header().Name && r.Header().Rrtype == record.Header().Rrtype) {
+		if !(strings.EqualFold(r.Header().Name, record.Header().Name) && r.Header().Rrtype == record.Header().Rrtype) {
 			newRecords = append(newRecords, r)
+			if z.IsGenerated(zone, r) {
+				generated++
+			}
 		}
 	}
-	// If only the SOA record is left, remove the zone
-	if len(newRecords) == 1 {
+	// If only the generated records are left, remove the zone
+	if len(newRecords) == generated {
 		delete(z.entriesByZone, zone)
+		delete(z.changedZones, zone)
 		return
 	}
 	z.entriesByZone[zone] = newRecords
-	z.increaseZoneSerial(zone)
+	z.changedZones[zone] = struct{}{}
+}
+
+// IsGenerated returns true if the record is generated for the zone itself from the configuration,
+// which are the SOA record and the apex NS records when nameservers are configured
+func (z *ZoneEditor) IsGenerated(zone string, record dns.RR) bool {
+	switch record.Header().Rrtype {
+	case dns.TypeSOA:
+		return true
+	case dns.TypeNS:
+		return len(z.config.Nameservers) > 0 && strings.EqualFold(record.Header().Name, zone)
+	}
+	return false
+}
+
+// UpdateSerials increases the serial of every zone edited since the last call
+//
+// This is required to notify the CoreDNS server that the zone has been updated, so it should be
+// called once before rendering the edited zones.
+func (z *ZoneEditor) UpdateSerials() {
+	for zone := range z.changedZones {
+		soa := z.getSOA(zone)
+		if soa == nil {
+			continue
+		}
+		z.applyConfig(zone, soa)
+		current := soa.Serial
+		if z.serials[zone] > current {
+			current = z.serials[zone]
+		}
+		soa.Serial = z.nextSerial(current)
+		z.serials[zone] = soa.Serial
+	}
+	z.changedZones = make(map[string]struct{})
 }
 
-// GetZones returns all zones
+// GetZones returns all zones, sorted by name
 func (z *ZoneEditor) GetZones() []string {
 	zones := make([]string, 0)
 	for zone := range z.entriesByZone {
 		zones = append(zones, zone)
 	}
+	sort.Strings(zones)
 	return zones
 }
 
@@ -102,58 +252,106 @@ func (z *ZoneEditor) GetAllRecords(zone string) []dns.RR {
 	return records
 }
 
-// RenderZone prints the zone file
-func (z *ZoneEditor) RenderZone() string {
+// RenderZone prints the zone file of a zone
+func (z *ZoneEditor) RenderZone(zone string) string {
 	var sb strings.Builder
 
-	for zone, records := range z.entriesByZone {
-		sb.WriteString(fmt.Sprintf("$ORIGIN %s\n", zone))
-		for _, record := range records {
-			sb.WriteString(record.String())
-			sb.WriteString("\n")
-		}
+	sb.WriteString(fmt.Sprintf("$ORIGIN %s\n", zone))
+	for _, record := range z.entriesByZone[zone] {
+		sb.WriteString(record.String())
+		sb.WriteString("\n")
 	}
 	return sb.String()
 }
 
 // GetOrCreateZone returns the records for a zone, creating it if it doesn't exist
+//
+// A new zone starts from the last known serial of the zone, so recreating a zone never decreases it.
 func (z *ZoneEditor) GetOrCreateZone(zone string) []dns.RR {
 	records, found := z.entriesByZone[zone]
 	if !found {
-		records = make([]dns.RR, 0)
-		// TODO: Make SOA configurable
-		soa := dns.SOA{
+		soa := &dns.SOA{
 			Hdr: dns.RR_Header{
 				Name:     zone,
 				Rrtype:   dns.TypeSOA,
 				Class:    dns.ClassINET,
-				Ttl:      3600,
 				Rdlength: 0,
 			},
-			Ns:      fmt.Sprintf("ns.%s", zone),
-			Mbox:    fmt.Sprintf("hostmaster.%s", zone),
-			Serial:  1,
-			Refresh: 3600,
-			Retry:   3600,
-			Expire:  3600,
-			Minttl:  3600,
+			Serial: z.serials[zone],
 		}
-		records = append(records, &soa)
-		z.entriesByZone[zone] = records
+		z.entriesByZone[zone] = []dns.RR{soa}
+		z.applyConfig(zone, soa)
+		z.changedZones[zone] = struct{}{}
+		records = z.entriesByZone[zone]
 	}
 	return records
 }
 
-// increaseZoneSerial increases the serial number of the SOA record for the zone
-//
-// This is required to notify the CoreDNS server that the zone has been updated.
-func (z *ZoneEditor) increaseZoneSerial(zone string) {
-	zoneRecords := z.GetOrCreateZone(zone)
-	for _, record := range zoneRecords {
+// getSOA returns the SOA record of the zone
+func (z *ZoneEditor) getSOA(zone string) *dns.SOA {
+	for _, record := range z.entriesByZone[zone] {
 		if soa, ok := record.(*dns.SOA); ok {
-			soa.Serial++
-			z.entriesByZone[zone] = zoneRecords
-			return
+			return soa
 		}
 	}
+	return nil
+}
+
+// applyConfig updates the SOA and apex NS records of the zone from the configuration
+func (z *ZoneEditor) applyConfig(zone string, soa *dns.SOA) {
+	ttl := uint32(z.config.TTL.Seconds())
+	soa.Hdr.Ttl = ttl
+	soa.Ns = fmt.Sprintf("ns.%s", zone)
+	if z.config.Mname != "" {
+		soa.Ns = dns.Fqdn(z.config.Mname)
+	}
+	soa.Mbox = fmt.Sprintf("hostmaster.%s", zone)
+	if z.config.Rname != "" {
+		// Accept the mailbox in email form (e.g. hostmaster@example.com)
+		soa.Mbox = dns.Fqdn(strings.Replace(z.config.Rname, "@", ".", 1))
+	}
+	soa.Refresh = uint32(z.config.Refresh.Seconds())
+	soa.Retry = uint32(z.config.Retry.Seconds())
+	soa.Expire = uint32(z.config.Expire.Seconds())
+	soa.Minttl = uint32(z.config.Minimum.Seconds())
+
+	if len(z.config.Nameservers) == 0 {
+		return
+	}
+	// Replace the apex NS records with the configured ones
+	records := make([]dns.RR, 0, len(z.entriesByZone[zone]))
+	for _, record := range z.entriesByZone[zone] {
+		if record.Header().Rrtype == dns.TypeNS && strings.EqualFold(record.Header().Name, zone) {
+			continue
+		}
+		records = append(records, record)
+	}
+	nameservers := make([]dns.RR, len(z.config.Nameservers))
+	for i, ns := range z.config.Nameservers {
+		nameservers[i] = &dns.NS{
+			Hdr: dns.RR_Header{Name: zone, Rrtype: dns.TypeNS, Class: dns.ClassINET, Ttl: ttl},
+			Ns:  dns.Fqdn(ns),
+		}
+	}
+	// Keep the SOA record first, followed by the apex NS records
+	z.entriesByZone[zone] = append(records[:1:1], append(nameservers, records[1:]...)...)
+}
+
+// nextSerial returns the serial following the current one in the configured format
+//
+// The serial is based on the current time, but never goes backwards.
+func (z *ZoneEditor) nextSerial(current uint32) uint32 {
+	now := z.now().UTC()
+	var serial uint32
+	switch z.config.SerialFormat {
+	case SerialFormatUnixTime:
+		serial = uint32(now.Unix())
+	default:
+		year, month, day := now.Date()
+		serial = uint32(year*1000000 + int(month)*10000 + day*100)
+	}
+	if serial <= current {
+		serial = current + 1
+	}
+	return serial
 }
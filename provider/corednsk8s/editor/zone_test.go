@@ -3,6 +3,7 @@ package editor
 import (
 	"net"
 	"testing"
+	"time"
 
 	"github.com/miekg/dns"
 	"github.com/stretchr/testify/suite"
@@ -67,6 +68,15 @@ func (s *ZoneEditorTestSuite) TestAddRecord_NewRecord() {
 	s.Require().Contains(records, record, "Newly added record should be present in the zone")
 }
 
+func (s *ZoneEditorTestSuite) TestAddRecord_ExistingRecord() {
+	zone := "example.com."
+	s.editor.AddRecord(zone, s.newARecord("www."+zone, "192.0.2.1"))
+	s.editor.AddRecord(zone, s.newARecord("www."+zone, "192.0.2.1"))
+	s.editor.AddRecord(zone, s.newARecord("www."+zone, "192.0.2.2"))
+
+	s.Len(s.editor.GetAllRecords(zone), 3, "Adding an existing record again should not duplicate it")
+}
+
 func (s *ZoneEditorTestSuite) TestUpdateRecord_ExistingRecord() {
 	zone := "example.com."
 	oldRecord := &dns.A{
@@ -144,10 +154,188 @@ ns.example.com.	3600	IN	A	192.0.2.1
 	err := s.editor.LoadZone(zoneFile)
 	s.Require().NoError(err, "LoadZone should not return an error")
 
-	renderedZone := s.editor.RenderZone()
+	renderedZone := s.editor.RenderZone("example.com.")
 	s.Require().Contains(expectedRender, renderedZone, "RenderZone output should match the expected render")
 }
 
+func (s *ZoneEditorTestSuite) TestLoadZones() {
+	zoneFiles := []string{
+		`$ORIGIN example.com.
+example.com.	3600	IN	SOA	ns.example.com. hostmaster.example.com. 1 3600 3600 3600 3600
+www.example.com.	3600	IN	A	192.0.2.1
+`,
+		`$ORIGIN example.net.
+example.net.	3600	IN	SOA	ns.example.net. hostmaster.example.net. 1 3600 3600 3600 3600
+www.example.net.	3600	IN	A	192.0.2.2
+`,
+	}
+
+	err := s.editor.LoadZones(zoneFiles)
+	s.Require().NoError(err, "LoadZones should not return an error")
+	s.Equal([]string{"example.com.", "example.net."}, s.editor.GetZones())
+
+	// Every zone should be rendered back in its own file
+	for i, zone := range s.editor.GetZones() {
+		s.Equal(zoneFiles[i], s.editor.RenderZone(zone))
+	}
+}
+
+func (s *ZoneEditorTestSuite) newARecord(name, ip string) *dns.A {
+	return &dns.A{
+		Hdr: dns.RR_Header{Name: name, Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 300},
+		A:   net.ParseIP(ip),
+	}
+}
+
+func (s *ZoneEditorTestSuite) TestUpdateSerials_Date() {
+	s.editor.now = func() time.Time { return time.Date(2024, time.March, 5, 12, 0, 0, 0, time.UTC) }
+	zone := "example.com."
+
+	s.editor.AddRecord(zone, s.newARecord("www."+zone, "192.0.2.1"))
+	s.editor.UpdateSerials()
+	s.Equal(uint32(2024030500), s.editor.getSOA(zone).Serial)
+
+	// Another change on the same day should increase the revision
+	s.editor.AddRecord(zone, s.newARecord("api."+zone, "192.0.2.2"))
+	s.editor.UpdateSerials()
+	s.Equal(uint32(2024030501), s.editor.getSOA(zone).Serial)
+
+	// Without changes the serial should not be updated
+	s.editor.UpdateSerials()
+	s.Equal(uint32(2024030501), s.editor.getSOA(zone).Serial)
+
+	s.editor.now = func() time.Time { return time.Date(2024, time.March, 6, 0, 0, 0, 0, time.UTC) }
+	s.editor.DeleteRecord(zone, s.newARecord("api."+zone, "192.0.2.2"))
+	s.editor.UpdateSerials()
+	s.Equal(uint32(2024030600), s.editor.getSOA(zone).Serial)
+}
+
+func (s *ZoneEditorTestSuite) TestUpdateSerials_UnixTime() {
+	config := DefaultZoneConfig()
+	config.SerialFormat = SerialFormatUnixTime
+	s.editor = NewZoneEditorWithConfig(config)
+	now := time.Unix(1700000000, 0)
+	s.editor.now = func() time.Time { return now }
+	zone := "example.com."
+
+	s.editor.AddRecord(zone, s.newARecord("www."+zone, "192.0.2.1"))
+	s.editor.UpdateSerials()
+	s.Equal(uint32(1700000000), s.editor.getSOA(zone).Serial)
+
+	// Changes within the same second should still increase the serial
+	s.editor.AddRecord(zone, s.newARecord("api."+zone, "192.0.2.2"))
+	s.editor.UpdateSerials()
+	s.Equal(uint32(1700000001), s.editor.getSOA(zone).Serial)
+}
+
+func (s *ZoneEditorTestSuite) TestUpdateSerials_NeverDecreases() {
+	s.editor.now = func() time.Time { return time.Date(2024, time.March, 5, 12, 0, 0, 0, time.UTC) }
+	zone := "example.com."
+
+	// A loaded serial ahead of the clock should keep increasing
+	err := s.editor.LoadZone(`$ORIGIN example.com.
+example.com.	3600	IN	SOA	ns.example.com. hostmaster.example.com. 2030010100 3600 3600 3600 3600
+www.example.com.	3600	IN	A	192.0.2.1
+`)
+	s.Require().NoError(err)
+	s.editor.AddRecord(zone, s.newARecord("api."+zone, "192.0.2.2"))
+	s.editor.UpdateSerials()
+	s.Equal(uint32(2030010101), s.editor.getSOA(zone).Serial)
+
+	// Deleting and recreating the zone should not reset the serial
+	s.editor.DeleteRecord(zone, s.newARecord("www."+zone, "192.0.2.1"))
+	s.editor.DeleteRecord(zone, s.newARecord("api."+zone, "192.0.2.2"))
+	s.Require().NotContains(s.editor.GetZones(), zone)
+	s.editor.AddRecord(zone, s.newARecord("www."+zone, "192.0.2.1"))
+	s.editor.UpdateSerials()
+	s.Equal(uint32(2030010102), s.editor.getSOA(zone).Serial)
+
+	// Reloading an older zone file should not reset the serial either
+	err = s.editor.LoadZone(`$ORIGIN example.com.
+example.com.	3600	IN	SOA	ns.example.com. hostmaster.example.com. 1 3600 3600 3600 3600
+www.example.com.	3600	IN	A	192.0.2.1
+`)
+	s.Require().NoError(err)
+	s.editor.AddRecord(zone, s.newARecord("api."+zone, "192.0.2.2"))
+	s.editor.UpdateSerials()
+	s.Equal(uint32(2030010103), s.editor.getSOA(zone).Serial)
+}
+
+func (s *ZoneEditorTestSuite) TestZoneConfig_SOA() {
+	config := DefaultZoneConfig()
+	config.Mname = "ns1.example.org"
+	config.Rname = "dns-admin@example.org"
+	config.Refresh = 2 * time.Hour
+	config.Retry = 30 * time.Minute
+	config.Expire = 28 * 24 * time.Hour
+	config.Minimum = 5 * time.Minute
+	s.editor = NewZoneEditorWithConfig(config)
+	zone := "example.com."
+
+	s.editor.AddRecord(zone, s.newARecord("www."+zone, "192.0.2.1"))
+	s.editor.UpdateSerials()
+	soa := s.editor.getSOA(zone)
+	s.Require().NotNil(soa)
+	s.Equal("ns1.example.org.", soa.Ns)
+	s.Equal("dns-admin.example.org.", soa.Mbox)
+	s.Equal(uint32(3600), soa.Hdr.Ttl)
+	s.Equal(uint32(7200), soa.Refresh)
+	s.Equal(uint32(1800), soa.Retry)
+	s.Equal(uint32(2419200), soa.Expire)
+	s.Equal(uint32(300), soa.Minttl)
+}
+
+func (s *ZoneEditorTestSuite) TestZoneConfig_DefaultSOA() {
+	zone := "example.com."
+	s.editor.AddRecord(zone, s.newARecord("www."+zone, "192.0.2.1"))
+	soa := s.editor.getSOA(zone)
+	s.Require().NotNil(soa)
+	s.Equal("ns.example.com.", soa.Ns)
+	s.Equal("hostmaster.example.com.", soa.Mbox)
+}
+
+func (s *ZoneEditorTestSuite) TestZoneConfig_Nameservers() {
+	config := DefaultZoneConfig()
+	config.Nameservers = []string{"ns1.example.org", "ns2.example.org."}
+	s.editor = NewZoneEditorWithConfig(config)
+	zone := "example.com."
+
+	// The apex NS records of a loaded zone should be replaced with the configured ones
+	err := s.editor.LoadZone(`$ORIGIN example.com.
+example.com.	3600	IN	SOA	ns.example.com. hostmaster.example.com. 1 3600 3600 3600 3600
+example.com.	3600	IN	NS	ns.example.com.
+sub.example.com.	3600	IN	NS	ns.sub.example.com.
+www.example.com.	3600	IN	A	192.0.2.1
+`)
+	s.Require().NoError(err)
+	s.editor.AddRecord(zone, s.newARecord("api."+zone, "192.0.2.2"))
+	s.editor.UpdateSerials()
+
+	records := s.editor.GetAllRecords(zone)
+	s.Require().Len(records, 6)
+	s.IsType(&dns.SOA{}, records[0], "The SOA record should be first")
+	s.Equal("ns1.example.org.", records[1].(*dns.NS).Ns)
+	s.Equal("ns2.example.org.", records[2].(*dns.NS).Ns)
+	s.Equal("sub.example.com.", records[3].Header().Name, "Delegations should be kept")
+
+	s.True(s.editor.IsGenerated(zone, records[0]))
+	s.True(s.editor.IsGenerated(zone, records[1]))
+	s.False(s.editor.IsGenerated(zone, records[3]))
+	s.False(s.editor.IsGenerated(zone, records[4]))
+}
+
+func (s *ZoneEditorTestSuite) TestDeleteRecord_OnlyGeneratedRecordsLeft() {
+	config := DefaultZoneConfig()
+	config.Nameservers = []string{"ns1.example.org"}
+	s.editor = NewZoneEditorWithConfig(config)
+	zone := "example.com."
+
+	s.editor.AddRecord(zone, s.newARecord("www."+zone, "192.0.2.1"))
+	s.Require().Len(s.editor.GetAllRecords(zone), 3)
+	s.editor.DeleteRecord(zone, s.newARecord("www."+zone, "192.0.2.1"))
+	s.NotContains(s.editor.GetZones(), zone, "The zone should be removed if only the SOA and NS records are left")
+}
+
 func TestZoneEditorTestSuite(t *testing.T) {
 	suite.Run(t, new(ZoneEditorTestSuite))
 }
@@ -3,21 +3,34 @@ package k8s
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strings"
 
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	apiv1 "k8s.io/client-go/kubernetes/typed/core/v1"
 )
 
 const (
 	CorefileKey = "Corefile"
-	ZoneKey     = "Zone"
+	// ImportKey is the key of the file imported in the server block from the ConfigMap owned by external-dns
+	ImportKey = "external-dns.override"
+	// ZoneKeyPrefix is the prefix of the keys holding the zone files, one key per zone (e.g. "db.example.com")
+	ZoneKeyPrefix = "db."
 )
 
 // CoreDNSConfigMap is a representation of a Kubernetes Coredns CoreDNSConfigMap
+//
+// The updates are based on the last version of the ConfigMap loaded or written, and carry its
+// resourceVersion: if the ConfigMap is modified meanwhile, the update is rejected with a conflict
+// instead of overwriting the concurrent changes.
 type CoreDNSConfigMap struct {
 	ns     string
 	name   string
 	client apiv1.CoreV1Interface
+	// cfgMap is the last version of the ConfigMap loaded or written
+	cfgMap *corev1.ConfigMap
 }
 
 // NewConfigMap creates a new CoreDNSConfigMap
@@ -29,48 +42,160 @@ func NewConfigMap(client apiv1.CoreV1Interface, ns, name string) *CoreDNSConfigM
 	}
 }
 
-// GetCoreDNSConfig returns the CoreDNSConfigMap string
-func (c *CoreDNSConfigMap) GetCoreDNSConfig(ctx context.Context) (string, error) {
+// Load reads the current version of the CoreDNSConfigMap, the following updates are based on it
+func (c *CoreDNSConfigMap) Load(ctx context.Context) error {
 	cfgMap, err := c.client.ConfigMaps(c.ns).Get(ctx, c.name, metav1.GetOptions{})
 	if err != nil {
-		return "", err
+		return err
+	}
+	c.cfgMap = cfgMap
+	return nil
+}
+
+// LoadOrCreate reads the current version of the CoreDNSConfigMap, creating it empty with the labels if
+// it does not exist
+func (c *CoreDNSConfigMap) LoadOrCreate(ctx context.Context, labels map[string]string) error {
+	err := c.Load(ctx)
+	if !apierrors.IsNotFound(err) {
+		return err
+	}
+	cfgMap, err := c.client.ConfigMaps(c.ns).Create(ctx, &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: c.name, Namespace: c.ns, Labels: labels},
+		Data:       map[string]string{},
+	}, metav1.CreateOptions{})
+	if apierrors.IsAlreadyExists(err) {
+		// Created concurrently
+		return c.Load(ctx)
+	}
+	if err != nil {
+		return err
 	}
-	if value, ok := cfgMap.Data[CorefileKey]; ok {
+	c.cfgMap = cfgMap
+	return nil
+}
+
+// Corefile returns the Corefile of the loaded CoreDNSConfigMap
+func (c *CoreDNSConfigMap) Corefile() (string, error) {
+	if c.cfgMap == nil {
+		return "", errors.New("ConfigMap not loaded")
+	}
+	if value, ok := c.cfgMap.Data[CorefileKey]; ok {
 		return value, nil
 	}
 	return "", errors.New("Corefile not found")
 }
 
+// Zones returns the zone files of the loaded CoreDNSConfigMap, by key
+func (c *CoreDNSConfigMap) Zones() map[string]string {
+	zones := make(map[string]string)
+	if c.cfgMap == nil {
+		return zones
+	}
+	for key, value := range c.cfgMap.Data {
+		if strings.HasPrefix(key, ZoneKeyPrefix) {
+			zones[key] = value
+		}
+	}
+	return zones
+}
+
+// GetCoreDNSConfig loads the CoreDNSConfigMap and returns the Corefile
+func (c *CoreDNSConfigMap) GetCoreDNSConfig(ctx context.Context) (string, error) {
+	if err := c.Load(ctx); err != nil {
+		return "", err
+	}
+	return c.Corefile()
+}
+
 // UpdateCoreDNSConfig updates the CoreDNSConfigMap with the new Corefile
 func (c *CoreDNSConfigMap) UpdateCoreDNSConfig(ctx context.Context, corefile string) error {
-	cfgMap, err := c.client.ConfigMaps(c.ns).Get(ctx, c.name, metav1.GetOptions{})
-	if err != nil {
-		return err
+	return c.update(ctx, func(data map[string]string) error {
+		data[CorefileKey] = corefile
+		return nil
+	})
+}
+
+// GetZones loads the CoreDNSConfigMap and returns the zone files, by key
+func (c *CoreDNSConfigMap) GetZones(ctx context.Context) (map[string]string, error) {
+	if err := c.Load(ctx); err != nil {
+		return nil, err
 	}
-	cfgMap.Data[CorefileKey] = corefile
-	_, err = c.client.ConfigMaps(c.ns).Update(ctx, cfgMap, metav1.UpdateOptions{})
-	return err
+	return c.Zones(), nil
 }
 
-// GetZone returns the Zone from the CoreDNSConfigMap
-func (c *CoreDNSConfigMap) GetZone(ctx context.Context) (string, error) {
-	cfgMap, err := c.client.ConfigMaps(c.ns).Get(ctx, c.name, metav1.GetOptions{})
-	if err != nil {
-		return "", err
+// UpdateZones updates the CoreDNSConfigMap with the new zone files, by key
+func (c *CoreDNSConfigMap) UpdateZones(ctx context.Context, zones map[string]string) error {
+	if len(zones) == 0 {
+		return nil
 	}
-	if value, ok := cfgMap.Data[ZoneKey]; ok {
-		return value, nil
+	return c.update(ctx, func(data map[string]string) error {
+		for key, zone := range zones {
+			if !strings.HasPrefix(key, ZoneKeyPrefix) {
+				return fmt.Errorf("invalid zone key %s", key)
+			}
+			data[key] = zone
+		}
+		return nil
+	})
+}
+
+// DeleteZones removes the zone files from the CoreDNSConfigMap
+func (c *CoreDNSConfigMap) DeleteZones(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
 	}
-	return "", errors.New("zone not found")
+	return c.update(ctx, func(data map[string]string) error {
+		for _, key := range keys {
+			if !strings.HasPrefix(key, ZoneKeyPrefix) {
+				return fmt.Errorf("invalid zone key %s", key)
+			}
+			delete(data, key)
+		}
+		return nil
+	})
 }
 
-// UpdateZone updates the CoreDNSConfigMap with the new Zone
-func (c *CoreDNSConfigMap) UpdateZone(ctx context.Context, zone string) error {
-	cfgMap, err := c.client.ConfigMaps(c.ns).Get(ctx, c.name, metav1.GetOptions{})
+// ReplaceData replaces the whole data of the CoreDNSConfigMap, in a single update
+//
+// It is meant for the ConfigMaps owned by external-dns, every key missing from the data is removed.
+func (c *CoreDNSConfigMap) ReplaceData(ctx context.Context, data map[string]string) error {
+	return c.update(ctx, func(current map[string]string) error {
+		for key := range current {
+			if _, ok := data[key]; !ok {
+				delete(current, key)
+			}
+		}
+		for key, value := range data {
+			current[key] = value
+		}
+		return nil
+	})
+}
+
+// update applies the changes to the data of the last known version of the CoreDNSConfigMap
+//
+// The update fails with a conflict error if the CoreDNSConfigMap has been modified since, in which
+// case it has to be loaded again before retrying.
+func (c *CoreDNSConfigMap) update(ctx context.Context, apply func(data map[string]string) error) error {
+	if c.cfgMap == nil {
+		if err := c.Load(ctx); err != nil {
+			return err
+		}
+	}
+	cfgMap := c.cfgMap.DeepCopy()
+	if cfgMap.Data == nil {
+		cfgMap.Data = make(map[string]string)
+	}
+	if err := apply(cfgMap.Data); err != nil {
+		return err
+	}
+	updated, err := c.client.ConfigMaps(c.ns).Update(ctx, cfgMap, metav1.UpdateOptions{})
 	if err != nil {
+		if apierrors.IsConflict(err) {
+			c.cfgMap = nil
+		}
 		return err
 	}
-	cfgMap.Data[ZoneKey] = zone
-	_, err = c.client.ConfigMaps(c.ns).Update(ctx, cfgMap, metav1.UpdateOptions{})
-	return err
+	c.cfgMap = updated
+	return nil
 }
@@ -2,17 +2,23 @@ package k8s
 
 import (
 	"context"
+	"errors"
+	"strconv"
 	"testing"
 
 	"github.com/stretchr/testify/suite"
 	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes/fake"
 	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	k8stesting "k8s.io/client-go/testing"
 )
 
 type CoreDNSConfigMapTestSuite struct {
 	suite.Suite
+	clientset *fake.Clientset
 	client    corev1.CoreV1Interface
 	configMap *CoreDNSConfigMap
 }
@@ -23,7 +29,8 @@ func (s *CoreDNSConfigMapTestSuite) SetupTest() {
 
 // BeforeTest is a function to be executed right before the test
 func (s *CoreDNSConfigMapTestSuite) BeforeTest(suiteName, testName string) {
-	s.client = fake.NewSimpleClientset().CoreV1()
+	s.clientset = fake.NewSimpleClientset()
+	s.client = s.clientset.CoreV1()
 	s.configMap = NewConfigMap(s.client, "kube-system", "coredns")
 }
 
@@ -82,56 +89,165 @@ func (s *CoreDNSConfigMapTestSuite) TestUpdateCoreDNSConfig_Failure_NotFound() {
 	s.Contains(err.Error(), "not found", "Should return an error for non-existent CoreDNSConfigMap")
 }
 
-func (s *CoreDNSConfigMapTestSuite) TestGetZone_Success() {
-	zoneContent := "example.com."
+func (s *CoreDNSConfigMapTestSuite) TestGetZones_Success() {
+	zoneContent := "$ORIGIN example.com."
 	_, err := s.client.ConfigMaps("kube-system").Create(context.TODO(), &v1.ConfigMap{
 		ObjectMeta: metav1.ObjectMeta{Name: "coredns", Namespace: "kube-system"},
-		Data:       map[string]string{ZoneKey: zoneContent},
+		Data:       map[string]string{CorefileKey: "CoreDNS config data", "db.example.com": zoneContent},
 	}, metav1.CreateOptions{})
 	s.Require().NoError(err)
 
-	result, err := s.configMap.GetZone(context.TODO())
+	result, err := s.configMap.GetZones(context.TODO())
 	s.Require().NoError(err)
-	s.Equal(zoneContent, result)
+	s.Equal(map[string]string{"db.example.com": zoneContent}, result, "Only the zone keys should be returned")
 }
 
-func (s *CoreDNSConfigMapTestSuite) TestGetZone_Failure_ZoneNotFound() {
+func (s *CoreDNSConfigMapTestSuite) TestGetZones_NoZones() {
 	_, err := s.client.ConfigMaps("kube-system").Create(context.TODO(), &v1.ConfigMap{
 		ObjectMeta: metav1.ObjectMeta{Name: "coredns", Namespace: "kube-system"},
-		Data:       map[string]string{"InvalidKey": "example.com."},
+		Data:       map[string]string{CorefileKey: "CoreDNS config data"},
 	}, metav1.CreateOptions{})
 	s.Require().NoError(err)
 
-	_, err = s.configMap.GetZone(context.TODO())
+	result, err := s.configMap.GetZones(context.TODO())
+	s.Require().NoError(err)
+	s.Empty(result)
+}
+
+func (s *CoreDNSConfigMapTestSuite) TestGetZones_Failure_ConfigMapNotFound() {
+	_, err := s.configMap.GetZones(context.TODO())
 	s.Require().Error(err)
-	s.Equal("zone not found", err.Error())
+	s.Contains(err.Error(), "not found")
 }
 
-func (s *CoreDNSConfigMapTestSuite) TestUpdateZone_Success() {
+func (s *CoreDNSConfigMapTestSuite) TestUpdateZones_Success() {
 	initialZoneContent := "initial.example.com."
 	updatedZoneContent := "updated.example.com."
 	_, err := s.client.ConfigMaps("kube-system").Create(context.TODO(), &v1.ConfigMap{
 		ObjectMeta: metav1.ObjectMeta{Name: "coredns", Namespace: "kube-system"},
-		Data:       map[string]string{ZoneKey: initialZoneContent},
+		Data:       map[string]string{CorefileKey: "CoreDNS config data", "db.example.com": initialZoneContent},
 	}, metav1.CreateOptions{})
 	s.Require().NoError(err)
 
-	err = s.configMap.UpdateZone(context.Background(), updatedZoneContent)
+	err = s.configMap.UpdateZones(context.Background(), map[string]string{
+		"db.example.com": updatedZoneContent,
+		"db.example.net": updatedZoneContent,
+	})
 	s.Require().NoError(err)
 
 	updatedCfgMap, err := s.client.ConfigMaps("kube-system").Get(context.Background(), "coredns", metav1.GetOptions{})
 	s.Require().NoError(err)
-	s.Equal(updatedZoneContent, updatedCfgMap.Data[ZoneKey], "Zone should be updated with new content")
+	s.Equal(updatedZoneContent, updatedCfgMap.Data["db.example.com"], "Zone should be updated with new content")
+	s.Equal(updatedZoneContent, updatedCfgMap.Data["db.example.net"], "Zone should be added")
+	s.Equal("CoreDNS config data", updatedCfgMap.Data[CorefileKey], "Corefile should be left untouched")
 }
 
-func (s *CoreDNSConfigMapTestSuite) TestUpdateZone_Failure_NotFound() {
-	updatedZoneContent := "updated.example.com."
+func (s *CoreDNSConfigMapTestSuite) TestUpdateZones_Failure_InvalidKey() {
+	_, err := s.client.ConfigMaps("kube-system").Create(context.TODO(), &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "coredns", Namespace: "kube-system"},
+		Data:       map[string]string{CorefileKey: "CoreDNS config data"},
+	}, metav1.CreateOptions{})
+	s.Require().NoError(err)
 
-	err := s.configMap.UpdateZone(context.Background(), updatedZoneContent)
+	err = s.configMap.UpdateZones(context.Background(), map[string]string{CorefileKey: "overwritten"})
+	s.Require().Error(err, "Only zone keys should be writable")
+}
+
+func (s *CoreDNSConfigMapTestSuite) TestUpdateZones_Failure_NotFound() {
+	err := s.configMap.UpdateZones(context.Background(), map[string]string{"db.example.com": "updated.example.com."})
 	s.Require().Error(err)
 	s.Contains(err.Error(), "not found", "Should return an error for non-existent CoreDNSConfigMap")
 }
 
+func (s *CoreDNSConfigMapTestSuite) TestDeleteZones_Success() {
+	_, err := s.client.ConfigMaps("kube-system").Create(context.TODO(), &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "coredns", Namespace: "kube-system"},
+		Data:       map[string]string{CorefileKey: "CoreDNS config data", "db.example.com": "zone", "db.example.net": "zone"},
+	}, metav1.CreateOptions{})
+	s.Require().NoError(err)
+
+	err = s.configMap.DeleteZones(context.Background(), "db.example.com")
+	s.Require().NoError(err)
+
+	zones, err := s.configMap.GetZones(context.Background())
+	s.Require().NoError(err)
+	s.Equal(map[string]string{"db.example.net": "zone"}, zones)
+}
+
+// checkResourceVersions makes the fake clientset reject the ConfigMap updates based on an outdated
+// resourceVersion, as done by the API server
+func (s *CoreDNSConfigMapTestSuite) checkResourceVersions() {
+	gvr := v1.SchemeGroupVersion.WithResource("configmaps")
+	s.clientset.PrependReactor("update", "configmaps", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		cfgMap := action.(k8stesting.UpdateAction).GetObject().(*v1.ConfigMap).DeepCopy()
+		current, err := s.clientset.Tracker().Get(gvr, cfgMap.Namespace, cfgMap.Name)
+		if err != nil {
+			return true, nil, err
+		}
+		if current.(*v1.ConfigMap).ResourceVersion != cfgMap.ResourceVersion {
+			return true, nil, apierrors.NewConflict(gvr.GroupResource(), cfgMap.Name, errors.New("the object has been modified"))
+		}
+		version, _ := strconv.Atoi(cfgMap.ResourceVersion)
+		cfgMap.ResourceVersion = strconv.Itoa(version + 1)
+		return true, cfgMap, s.clientset.Tracker().Update(gvr, cfgMap, cfgMap.Namespace)
+	})
+}
+
+func (s *CoreDNSConfigMapTestSuite) TestUpdate_ConcurrentModification() {
+	s.checkResourceVersions()
+	_, err := s.client.ConfigMaps("kube-system").Create(context.TODO(), &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "coredns", Namespace: "kube-system", ResourceVersion: "1"},
+		Data:       map[string]string{CorefileKey: "CoreDNS config data"},
+	}, metav1.CreateOptions{})
+	s.Require().NoError(err)
+	s.Require().NoError(s.configMap.Load(context.TODO()))
+
+	// Another client modifies the ConfigMap after it was loaded
+	cfgMap, err := s.client.ConfigMaps("kube-system").Get(context.TODO(), "coredns", metav1.GetOptions{})
+	s.Require().NoError(err)
+	cfgMap.Data[CorefileKey] = "Concurrent CoreDNS config data"
+	_, err = s.client.ConfigMaps("kube-system").Update(context.TODO(), cfgMap, metav1.UpdateOptions{})
+	s.Require().NoError(err)
+
+	err = s.configMap.UpdateCoreDNSConfig(context.Background(), "Updated CoreDNS config data")
+	s.Require().Error(err)
+	s.True(apierrors.IsConflict(err), "The update should be rejected with a conflict")
+
+	updatedCfgMap, err := s.client.ConfigMaps("kube-system").Get(context.Background(), "coredns", metav1.GetOptions{})
+	s.Require().NoError(err)
+	s.Equal("Concurrent CoreDNS config data", updatedCfgMap.Data[CorefileKey], "The concurrent changes should not be overwritten")
+
+	// The update should succeed once the ConfigMap is loaded again
+	s.Require().NoError(s.configMap.Load(context.TODO()))
+	s.Require().NoError(s.configMap.UpdateCoreDNSConfig(context.Background(), "Updated CoreDNS config data"))
+}
+
+func (s *CoreDNSConfigMapTestSuite) TestUpdate_SuccessiveUpdates() {
+	s.checkResourceVersions()
+	_, err := s.client.ConfigMaps("kube-system").Create(context.TODO(), &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "coredns", Namespace: "kube-system", ResourceVersion: "1"},
+		Data:       map[string]string{CorefileKey: "CoreDNS config data"},
+	}, metav1.CreateOptions{})
+	s.Require().NoError(err)
+	s.Require().NoError(s.configMap.Load(context.TODO()))
+
+	// The updates should be based on the version written by the previous update
+	s.Require().NoError(s.configMap.UpdateZones(context.Background(), map[string]string{"db.example.com": "zone"}))
+	s.Require().NoError(s.configMap.UpdateCoreDNSConfig(context.Background(), "Updated CoreDNS config data"))
+	s.Require().NoError(s.configMap.DeleteZones(context.Background(), "db.example.com"))
+
+	updatedCfgMap, err := s.client.ConfigMaps("kube-system").Get(context.Background(), "coredns", metav1.GetOptions{})
+	s.Require().NoError(err)
+	s.Equal("4", updatedCfgMap.ResourceVersion)
+	s.Equal(map[string]string{CorefileKey: "Updated CoreDNS config data"}, updatedCfgMap.Data)
+}
+
+func (s *CoreDNSConfigMapTestSuite) TestCorefile_NotLoaded() {
+	_, err := s.configMap.Corefile()
+	s.Require().Error(err)
+	s.Empty(s.configMap.Zones())
+}
+
 func TestConfigMapTestSuite(t *testing.T) {
 	suite.Run(t, new(CoreDNSConfigMapTestSuite))
 }
@@ -3,6 +3,8 @@ package k8s
 import (
 	"context"
 	"errors"
+	"fmt"
+	"path"
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
@@ -10,10 +12,6 @@ import (
 	apiv1 "k8s.io/client-go/kubernetes/typed/apps/v1"
 )
 
-const (
-	ZonePath = "/etc/coredns/zone.db"
-)
-
 type CoreDNSDeployment struct {
 	ns        string
 	name      string
@@ -31,19 +29,51 @@ func NewDeployment(client apiv1.AppsV1Interface, ns, name, configMap string) *Co
 	}
 }
 
-// MountZoneFile mounts the Zone file in the CoreDNS Deployment
-func (c *CoreDNSDeployment) MountZoneFile(ctx context.Context) error {
+// MountZoneFile mounts the zone file stored in the ConfigMap key in the CoreDNS Deployment
+//
+// Returns the path the zone file is mounted at in the CoreDNS container.
+func (c *CoreDNSDeployment) MountZoneFile(ctx context.Context, key string) (string, error) {
 	deployment, err := c.client.Deployments(c.ns).Get(ctx, c.name, metav1.GetOptions{})
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	// Add the volume
-	added, err := c.addVolume(deployment)
+	filePath, added, err := c.addVolume(deployment, key)
 	if err != nil {
-		return err
+		return "", err
 	}
 	if !added {
+		return filePath, nil
+	}
+
+	// Update the deployment
+	_, err = c.client.Deployments(c.ns).Update(ctx, deployment, metav1.UpdateOptions{})
+	return filePath, err
+}
+
+// GetMountPath returns the path the CoreDNS ConfigMap is mounted at in the CoreDNS container
+func (c *CoreDNSDeployment) GetMountPath(ctx context.Context) (string, error) {
+	deployment, err := c.client.Deployments(c.ns).Get(ctx, c.name, metav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+	volume := c.getConfigMapVolume(deployment)
+	if volume == nil {
+		return "", errors.New("coreDNS configMap not found in volumes")
+	}
+	return c.getMountPath(deployment, volume.Name)
+}
+
+// UnmountZoneFile removes the zone file stored in the ConfigMap key from the CoreDNS Deployment
+func (c *CoreDNSDeployment) UnmountZoneFile(ctx context.Context, key string) error {
+	deployment, err := c.client.Deployments(c.ns).Get(ctx, c.name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	// Remove the item from the volume
+	if !c.removeVolumeItem(deployment, key) {
 		return nil
 	}
 
@@ -52,27 +82,67 @@ func (c *CoreDNSDeployment) MountZoneFile(ctx context.Context) error {
 	return err
 }
 
-// addVolume adds volume for the Zone file in the CoreDNS Deployment
+// addVolume adds the ConfigMap key as an item of the CoreDNS ConfigMap volume in the CoreDNS Deployment
+//
+// Returns the path of the mounted file and true if the item was added
+func (c *CoreDNSDeployment) addVolume(deployment *appsv1.Deployment, key string) (string, bool, error) {
+	volume := c.getConfigMapVolume(deployment)
+	if volume == nil {
+		return "", false, errors.New("coreDNS configMap not found in volumes")
+	}
+	mountPath, err := c.getMountPath(deployment, volume.Name)
+	if err != nil {
+		return "", false, err
+	}
+	// If there is an existing item skip
+	for _, item := range volume.ConfigMap.Items {
+		if item.Key == key {
+			return path.Join(mountPath, item.Path), false, nil
+		}
+	}
+	// Append the new item
+	volume.ConfigMap.Items = append(volume.ConfigMap.Items, corev1.KeyToPath{
+		Key:  key,
+		Path: key,
+	})
+	return path.Join(mountPath, key), true, nil
+}
+
+// removeVolumeItem removes the ConfigMap key from the items of the CoreDNS ConfigMap volume
 //
-// Returns true  if the volume was added
-func (c *CoreDNSDeployment) addVolume(deployment *appsv1.Deployment) (bool, error) {
-	// Add the Zone file volume
+// Returns true if the item was removed
+func (c *CoreDNSDeployment) removeVolumeItem(deployment *appsv1.Deployment, key string) bool {
+	volume := c.getConfigMapVolume(deployment)
+	if volume == nil {
+		return false
+	}
+	for i, item := range volume.ConfigMap.Items {
+		if item.Key == key {
+			volume.ConfigMap.Items = append(volume.ConfigMap.Items[:i], volume.ConfigMap.Items[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// getConfigMapVolume returns the volume of the CoreDNS ConfigMap in the CoreDNS Deployment
+func (c *CoreDNSDeployment) getConfigMapVolume(deployment *appsv1.Deployment) *corev1.Volume {
 	for i, volume := range deployment.Spec.Template.Spec.Volumes {
 		if cfg := volume.ConfigMap; cfg != nil && cfg.Name == c.configMap {
-			// If there is an existing item skip
-			for _, item := range cfg.Items {
-				if item.Key == ZoneKey {
-					return false, nil
-				}
+			return &deployment.Spec.Template.Spec.Volumes[i]
+		}
+	}
+	return nil
+}
+
+// getMountPath returns the path the volume is mounted at in the CoreDNS containers
+func (c *CoreDNSDeployment) getMountPath(deployment *appsv1.Deployment, volume string) (string, error) {
+	for _, container := range deployment.Spec.Template.Spec.Containers {
+		for _, mount := range container.VolumeMounts {
+			if mount.Name == volume {
+				return mount.MountPath, nil
 			}
-			// Append the new item
-			added := append(deployment.Spec.Template.Spec.Volumes[i].VolumeSource.ConfigMap.Items, corev1.KeyToPath{
-				Key:  ZoneKey,
-				Path: ZoneKey,
-			})
-			deployment.Spec.Template.Spec.Volumes[i].VolumeSource.ConfigMap.Items = added
-			return true, nil
 		}
 	}
-	return false, errors.New("coreDNS configMap not found in volumes")
+	return "", fmt.Errorf("volume %s is not mounted in the CoreDNS containers", volume)
 }
@@ -62,8 +62,9 @@ func (s *CoreDNSDeploymentTestSuite) TestMountZoneFile_Success() {
 	}, v1.CreateOptions{})
 	s.Require().NoError(err)
 
-	err = s.deployment.MountZoneFile(context.TODO())
+	zonePath, err := s.deployment.MountZoneFile(context.TODO(), "db.example.com")
 	s.Require().NoError(err)
+	s.Equal("/etc/coredns/db.example.com", zonePath, "Zone file should be mounted next to the Corefile")
 
 	// Verify the deployment has been updated
 	deployment, err := s.client.Deployments("kube-system").Get(context.TODO(), "coredns", v1.GetOptions{})
@@ -73,12 +74,94 @@ func (s *CoreDNSDeploymentTestSuite) TestMountZoneFile_Success() {
 	s.Len(deployment.Spec.Template.Spec.Containers[0].VolumeMounts, 1, "Expected 1 volume mount in the CoreDNS container")
 }
 
+func (s *CoreDNSDeploymentTestSuite) TestMountZoneFile_AlreadyMounted() {
+	s.createDeployment(corev1.KeyToPath{Key: "Corefile", Path: "Corefile"}, corev1.KeyToPath{Key: "db.example.com", Path: "zones/db.example.com"})
+
+	zonePath, err := s.deployment.MountZoneFile(context.TODO(), "db.example.com")
+	s.Require().NoError(err)
+	s.Equal("/etc/coredns/zones/db.example.com", zonePath, "The existing item path should be used")
+
+	deployment, err := s.client.Deployments("kube-system").Get(context.TODO(), "coredns", v1.GetOptions{})
+	s.Require().NoError(err)
+	s.Len(deployment.Spec.Template.Spec.Volumes[0].ConfigMap.Items, 2, "No item should be added")
+}
+
+func (s *CoreDNSDeploymentTestSuite) TestUnmountZoneFile_Success() {
+	s.createDeployment(corev1.KeyToPath{Key: "Corefile", Path: "Corefile"}, corev1.KeyToPath{Key: "db.example.com", Path: "db.example.com"})
+
+	err := s.deployment.UnmountZoneFile(context.TODO(), "db.example.com")
+	s.Require().NoError(err)
+
+	deployment, err := s.client.Deployments("kube-system").Get(context.TODO(), "coredns", v1.GetOptions{})
+	s.Require().NoError(err)
+	s.Equal([]corev1.KeyToPath{{Key: "Corefile", Path: "Corefile"}}, deployment.Spec.Template.Spec.Volumes[0].ConfigMap.Items)
+}
+
+func (s *CoreDNSDeploymentTestSuite) TestMountZoneFile_Failure_VolumeNotMounted() {
+	_, err := s.client.Deployments("kube-system").Create(context.TODO(), &appsv1.Deployment{
+		ObjectMeta: v1.ObjectMeta{Name: "coredns", Namespace: "kube-system"},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: "coredns"}},
+					Volumes: []corev1.Volume{
+						{
+							Name: "config-volume",
+							VolumeSource: corev1.VolumeSource{
+								ConfigMap: &corev1.ConfigMapVolumeSource{
+									LocalObjectReference: corev1.LocalObjectReference{Name: "coredns"},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}, v1.CreateOptions{})
+	s.Require().NoError(err)
+
+	_, err = s.deployment.MountZoneFile(context.TODO(), "db.example.com")
+	s.Require().Error(err)
+	s.Contains(err.Error(), "is not mounted")
+}
+
 func (s *CoreDNSDeploymentTestSuite) TestMountZoneFile_Failure_DeploymentNotFound() {
-	err := s.deployment.MountZoneFile(context.TODO())
+	_, err := s.deployment.MountZoneFile(context.TODO(), "db.example.com")
 	s.Require().Error(err)
 	s.Contains(err.Error(), "not found")
 }
 
+// createDeployment creates a CoreDNS deployment mounting the ConfigMap items in /etc/coredns
+func (s *CoreDNSDeploymentTestSuite) createDeployment(items ...corev1.KeyToPath) {
+	_, err := s.client.Deployments("kube-system").Create(context.TODO(), &appsv1.Deployment{
+		ObjectMeta: v1.ObjectMeta{Name: "coredns", Namespace: "kube-system"},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name:         "coredns",
+							VolumeMounts: []corev1.VolumeMount{{Name: "config-volume", MountPath: "/etc/coredns", ReadOnly: true}},
+						},
+					},
+					Volumes: []corev1.Volume{
+						{
+							Name: "config-volume",
+							VolumeSource: corev1.VolumeSource{
+								ConfigMap: &corev1.ConfigMapVolumeSource{
+									LocalObjectReference: corev1.LocalObjectReference{Name: "coredns"},
+									Items:                items,
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}, v1.CreateOptions{})
+	s.Require().NoError(err)
+}
+
 func TestCoreDNSDeploymentTestSuite(t *testing.T) {
 	suite.Run(t, new(CoreDNSDeploymentTestSuite))
 }
@@ -3,28 +3,43 @@ package manager
 import (
 	"context"
 	"fmt"
+	"net"
 
 	"github.com/miekg/dns"
 	log "github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
 	"sigs.k8s.io/external-dns/endpoint"
 	"sigs.k8s.io/external-dns/plan"
+	"sigs.k8s.io/external-dns/provider"
 	"sigs.k8s.io/external-dns/provider/corednsk8s/editor"
 	"sigs.k8s.io/external-dns/provider/corednsk8s/k8s"
 )
 
+// HostsManager manages records through the hosts plugin of the Corefile.
+//
+// The hosts plugin can only serve A and AAAA records, every other record type is refused.
 type HostsManager struct {
 	client           kubernetes.Interface
 	coreDNSEditor    *editor.CoreDNSConfigEditor
 	coreDNSConfigMap *k8s.CoreDNSConfigMap
+	// backoff is the backoff between the attempts to apply changes when the Corefile is modified concurrently
+	backoff wait.Backoff
 }
 
-// NewHostsManager creates a new RFC1035 manager.
-func NewHostsManager(client kubernetes.Interface, configMap, ns string) (*HostsManager, error) {
+// NewHostsManager creates a new hosts manager.
+//
+// The hosts plugin is configured in the server block with the given key, defaults to ".:53".
+func NewHostsManager(client kubernetes.Interface, configMap, ns, serverBlock string) (*HostsManager, error) {
 	m := &HostsManager{
 		client:           client,
 		coreDNSEditor:    editor.NewCoreDNSConfigEditor(),
 		coreDNSConfigMap: k8s.NewConfigMap(client.CoreV1(), ns, configMap),
+		backoff:          retry.DefaultBackoff,
+	}
+	if serverBlock != "" {
+		m.coreDNSEditor.SetServerBlock(serverBlock)
 	}
 	err := m.reload(context.Background())
 	if err != nil {
@@ -34,15 +49,55 @@ func NewHostsManager(client kubernetes.Interface, configMap, ns string) (*HostsM
 }
 
 func (m *HostsManager) Records(ctx context.Context) ([]*endpoint.Endpoint, error) {
-	// Get all records
-	records := make([]*endpoint.Endpoint, 0)
-	for _, host := range m.coreDNSEditor.GetHosts() {
-		records = append(records, endpoint.NewEndpoint(host.Header().Name, endpoint.RecordTypeA, host.A.String()))
-	}
+	// Group the hosts into an endpoint per name and type
+	records := recordsToEndpoints(m.coreDNSEditor.GetHosts())
 	return records, nil
 }
 
+// AdjustEndpoints drops the endpoints that cannot be served by the hosts plugin
+//
+// The hosts plugin serves every host with the same TTL, so the endpoints with a configured TTL get the
+// lowest configured TTL.
+func (m *HostsManager) AdjustEndpoints(endpoints []*endpoint.Endpoint) ([]*endpoint.Endpoint, error) {
+	adjusted := make([]*endpoint.Endpoint, 0, len(endpoints))
+	var ttl endpoint.TTL
+	for _, endPt := range endpoints {
+		if !isHostsRecordType(endPt.RecordType) {
+			log.WithFields(log.Fields{
+				"record": endPt.DNSName,
+				"type":   endPt.RecordType,
+			}).Warn("Record type is not supported by the hosts plugin, skipping")
+			continue
+		}
+		if endPt.RecordTTL.IsConfigured() && (ttl == 0 || endPt.RecordTTL < ttl) {
+			ttl = endPt.RecordTTL
+		}
+		adjusted = append(adjusted, endPt)
+	}
+	for _, endPt := range adjusted {
+		if endPt.RecordTTL.IsConfigured() && endPt.RecordTTL != ttl {
+			log.WithFields(log.Fields{
+				"record": endPt.DNSName,
+				"type":   endPt.RecordType,
+				"ttl":    endPt.RecordTTL,
+			}).Warnf("The hosts plugin serves every record with the same TTL, using the lowest configured TTL %d", ttl)
+			endPt.RecordTTL = ttl
+		}
+	}
+	return adjusted, nil
+}
+
+// ApplyChanges applies the changes on the latest version of the CoreDNS configuration
+//
+// If the configuration is modified concurrently, it is reloaded and the changes are applied again.
 func (m *HostsManager) ApplyChanges(ctx context.Context, changes *plan.Changes) error {
+	return retryOnConflict(m.backoff, func() error {
+		return m.applyChanges(ctx, changes)
+	})
+}
+
+// applyChanges reloads the configuration, applies the changes and commits them
+func (m *HostsManager) applyChanges(ctx context.Context, changes *plan.Changes) error {
 	// Reload the Corefile and Zone file
 	if err := m.reload(ctx); err != nil {
 		return err
@@ -65,6 +120,12 @@ func (m *HostsManager) ApplyChanges(ctx context.Context, changes *plan.Changes)
 			return err
 		}
 	}
+	// Set the TTL of the hosts plugin from the written records
+	if ttl := changesTTL(changes); ttl > 0 && ttl != m.coreDNSEditor.GetHostsTTL() {
+		if err := m.coreDNSEditor.SetHostsTTL(ttl); err != nil {
+			return err
+		}
+	}
 	// Apply changes
 	return m.commit(ctx)
 }
@@ -77,15 +138,15 @@ func (m *HostsManager) applyCreate(endpoints []*endpoint.Endpoint) error {
 			"type":   endPt.RecordType,
 		}
 		log.WithFields(logFields).Debug("Creating record")
-		record, ok := endpointToRecord(endPt).(*dns.A)
-		if !ok {
-			log.WithFields(logFields).Warn("Could not map record to DNS entry")
-			continue
-		}
-		err := m.coreDNSEditor.AddHost(*record)
+		records, err := endpointToHosts(endPt)
 		if err != nil {
 			return err
 		}
+		for _, record := range records {
+			if err := m.coreDNSEditor.AddHost(record); err != nil {
+				return err
+			}
+		}
 	}
 	return nil
 }
@@ -102,20 +163,25 @@ func (m *HostsManager) applyUpdate(endpointsOld, endpointsNew []*endpoint.Endpoi
 			"new": map[string]interface{}{"record": endPtNew.DNSName, "type": endPtNew.RecordType},
 		}
 		log.WithFields(logFields).Debug("Updating record")
-		recordOld, ok := endpointToRecord(endPtOld).(*dns.A)
-		if !ok {
-			log.WithFields(logFields).Warn("Could not map record to DNS entry")
-			continue
-		}
-		recordNew, ok := endpointToRecord(endPtNew).(*dns.A)
-		if !ok {
-			log.WithFields(logFields).Warn("Could not map record to DNS entry")
-			continue
+		recordsOld, err := endpointToHosts(endPtOld)
+		if err != nil {
+			return err
 		}
-		err := m.coreDNSEditor.UpdateHost(*recordOld, *recordNew)
+		recordsNew, err := endpointToHosts(endPtNew)
 		if err != nil {
 			return err
 		}
+		// The new hosts replace every host of the old endpoint
+		for _, record := range recordsOld {
+			if err := m.coreDNSEditor.RemoveHost(record); err != nil {
+				return err
+			}
+		}
+		for _, record := range recordsNew {
+			if err := m.coreDNSEditor.AddHost(record); err != nil {
+				return err
+			}
+		}
 	}
 	return nil
 }
@@ -128,15 +194,15 @@ func (m *HostsManager) applyDelete(endpoints []*endpoint.Endpoint) error {
 			"type":   endPt.RecordType,
 		}
 		log.WithFields(logFields).Debug("Deleting record")
-		record, ok := endpointToRecord(endPt).(*dns.A)
-		if !ok {
-			log.WithFields(logFields).Warn("Could not map record to DNS entry")
-			continue
-		}
-		err := m.coreDNSEditor.RemoveHost(*record)
+		records, err := endpointToHosts(endPt)
 		if err != nil {
 			return err
 		}
+		for _, record := range records {
+			if err := m.coreDNSEditor.RemoveHost(record); err != nil {
+				return err
+			}
+		}
 	}
 	return nil
 }
@@ -144,7 +210,10 @@ func (m *HostsManager) applyDelete(endpoints []*endpoint.Endpoint) error {
 // reload reloads the Corefile
 func (m *HostsManager) reload(ctx context.Context) error {
 	// Reload the Corefile
-	if corefile, err := m.coreDNSConfigMap.GetCoreDNSConfig(ctx); err == nil {
+	if err := m.coreDNSConfigMap.Load(ctx); err != nil {
+		return err
+	}
+	if corefile, err := m.coreDNSConfigMap.Corefile(); err == nil {
 		if err = m.coreDNSEditor.LoadCorefile(corefile); err != nil {
 			return err
 		}
@@ -162,3 +231,34 @@ func (m *HostsManager) commit(ctx context.Context) error {
 	}
 	return nil
 }
+
+// isHostsRecordType returns true if the record type can be served by the hosts plugin
+func isHostsRecordType(recordType string) bool {
+	return recordType == endpoint.RecordTypeA || recordType == endpoint.RecordTypeAAAA
+}
+
+// endpointToHosts converts an endpoint to an A or AAAA record per target for the hosts plugin
+func endpointToHosts(endPt *endpoint.Endpoint) ([]dns.RR, error) {
+	if !isHostsRecordType(endPt.RecordType) {
+		return nil, provider.NewInvalidRecordError(fmt.Errorf("record type %s of %s is not supported by the hosts plugin", endPt.RecordType, endPt.DNSName))
+	}
+	if len(endPt.Targets) == 0 {
+		return nil, provider.NewInvalidRecordError(fmt.Errorf("record %s has no target", endPt.DNSName))
+	}
+	for _, target := range endPt.Targets {
+		if net.ParseIP(target) == nil {
+			return nil, provider.NewInvalidRecordError(fmt.Errorf("record %s has an invalid IP target %s", endPt.DNSName, target))
+		}
+	}
+	return endpointToRecords(endPt), nil
+}
+
+// changesTTL returns the first configured TTL of the created and updated endpoints, or 0 if there is none
+func changesTTL(changes *plan.Changes) uint32 {
+	for _, endPt := range append(append([]*endpoint.Endpoint{}, changes.Create...), changes.UpdateNew...) {
+		if endPt.RecordTTL.IsConfigured() {
+			return uint32(endPt.RecordTTL)
+		}
+	}
+	return 0
+}
@@ -0,0 +1,263 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/plan"
+	"sigs.k8s.io/external-dns/provider/corednsk8s/k8s"
+)
+
+const testCorefile = `.:53 {
+    errors
+    health {
+       lameduck 5s
+    }
+    ready
+    forward . /etc/resolv.conf
+    cache 30
+    reload
+}
+`
+
+// testBackoff is a short backoff to retry the conflicting updates in the tests
+var testBackoff = wait.Backoff{Steps: 3, Duration: time.Millisecond}
+
+// conflictingUpdates makes the selected update attempts of the ConfigMap fail with a conflict, after
+// running the concurrent modification on the stored ConfigMap. It returns the number of update attempts.
+func conflictingUpdates(client *fake.Clientset, conflict func(attempt int) bool, modify func(cfgMap *v1.ConfigMap)) *int {
+	gvr := v1.SchemeGroupVersion.WithResource("configmaps")
+	attempts := 0
+	client.PrependReactor("update", "configmaps", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		attempts++
+		if !conflict(attempts) {
+			return false, nil, nil
+		}
+		cfgMap := action.(k8stesting.UpdateAction).GetObject().(*v1.ConfigMap)
+		current, err := client.Tracker().Get(gvr, cfgMap.Namespace, cfgMap.Name)
+		if err != nil {
+			return true, nil, err
+		}
+		modified := current.(*v1.ConfigMap).DeepCopy()
+		modify(modified)
+		if err := client.Tracker().Update(gvr, modified, modified.Namespace); err != nil {
+			return true, nil, err
+		}
+		return true, nil, apierrors.NewConflict(gvr.GroupResource(), cfgMap.Name, errors.New("the object has been modified"))
+	})
+	return &attempts
+}
+
+// addLogPlugin adds the log plugin to the Corefile, as a cluster admin would do
+func addLogPlugin(cfgMap *v1.ConfigMap) {
+	cfgMap.Data[k8s.CorefileKey] = strings.Replace(cfgMap.Data[k8s.CorefileKey], "    errors\n", "    errors\n    log\n", 1)
+}
+
+type HostsManagerTestSuite struct {
+	suite.Suite
+	client  *fake.Clientset
+	manager *HostsManager
+}
+
+func (s *HostsManagerTestSuite) SetupTest() {
+	s.client = fake.NewSimpleClientset(&v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "coredns", Namespace: "kube-system"},
+		Data:       map[string]string{k8s.CorefileKey: testCorefile},
+	})
+	mgr, err := NewHostsManager(s.client, "coredns", "kube-system", "")
+	s.Require().NoError(err)
+	mgr.backoff = testBackoff
+	s.manager = mgr
+}
+
+func (s *HostsManagerTestSuite) corefile() string {
+	cfgMap, err := s.client.CoreV1().ConfigMaps("kube-system").Get(context.Background(), "coredns", metav1.GetOptions{})
+	s.Require().NoError(err)
+	return cfgMap.Data[k8s.CorefileKey]
+}
+
+func (s *HostsManagerTestSuite) TestApplyChanges_AAndAAAA() {
+	err := s.manager.ApplyChanges(context.Background(), &plan.Changes{
+		Create: []*endpoint.Endpoint{
+			endpoint.NewEndpoint("v4.example.com", endpoint.RecordTypeA, "192.0.2.1"),
+			endpoint.NewEndpoint("v6.example.com", endpoint.RecordTypeAAAA, "2001:db8::1"),
+		},
+	})
+	s.Require().NoError(err)
+
+	s.Contains(s.corefile(), "192.0.2.1 v4.example.com")
+	s.Contains(s.corefile(), "2001:db8::1 v6.example.com")
+
+	records, err := s.manager.Records(context.Background())
+	s.Require().NoError(err)
+	s.ElementsMatch([]*endpoint.Endpoint{
+		endpoint.NewEndpoint("v4.example.com.", endpoint.RecordTypeA, "192.0.2.1"),
+		endpoint.NewEndpoint("v6.example.com.", endpoint.RecordTypeAAAA, "2001:db8::1"),
+	}, records)
+}
+
+func (s *HostsManagerTestSuite) TestApplyChanges_UpdateAndDeleteAAAA() {
+	s.Require().NoError(s.manager.ApplyChanges(context.Background(), &plan.Changes{
+		Create: []*endpoint.Endpoint{endpoint.NewEndpoint("v6.example.com", endpoint.RecordTypeAAAA, "2001:db8::1")},
+	}))
+
+	s.Require().NoError(s.manager.ApplyChanges(context.Background(), &plan.Changes{
+		UpdateOld: []*endpoint.Endpoint{endpoint.NewEndpoint("v6.example.com", endpoint.RecordTypeAAAA, "2001:db8::1")},
+		UpdateNew: []*endpoint.Endpoint{endpoint.NewEndpoint("v6.example.com", endpoint.RecordTypeAAAA, "2001:db8::2")},
+	}))
+	s.Contains(s.corefile(), "2001:db8::2 v6.example.com")
+	s.NotContains(s.corefile(), "2001:db8::1 v6.example.com")
+
+	s.Require().NoError(s.manager.ApplyChanges(context.Background(), &plan.Changes{
+		Delete: []*endpoint.Endpoint{endpoint.NewEndpoint("v6.example.com", endpoint.RecordTypeAAAA, "2001:db8::2")},
+	}))
+	s.NotContains(s.corefile(), "v6.example.com")
+}
+
+func (s *HostsManagerTestSuite) TestApplyChanges_UnsupportedType() {
+	err := s.manager.ApplyChanges(context.Background(), &plan.Changes{
+		Create: []*endpoint.Endpoint{
+			endpoint.NewEndpoint("v4.example.com", endpoint.RecordTypeA, "192.0.2.1"),
+			endpoint.NewEndpoint("txt.example.com", endpoint.RecordTypeTXT, "\"heritage=external-dns\""),
+		},
+	})
+	s.Require().Error(err, "Unsupported record types should not be skipped silently")
+	s.Contains(err.Error(), "not supported by the hosts plugin")
+	s.Equal(testCorefile, s.corefile(), "The Corefile should not be updated when a change fails")
+}
+
+func (s *HostsManagerTestSuite) TestApplyChanges_InvalidIP() {
+	err := s.manager.ApplyChanges(context.Background(), &plan.Changes{
+		Create: []*endpoint.Endpoint{endpoint.NewEndpoint("v4.example.com", endpoint.RecordTypeA, "not-an-ip")},
+	})
+	s.Require().Error(err)
+	s.Contains(err.Error(), "invalid IP")
+}
+
+func (s *HostsManagerTestSuite) TestAdjustEndpoints() {
+	endpoints := []*endpoint.Endpoint{
+		endpoint.NewEndpoint("v4.example.com", endpoint.RecordTypeA, "192.0.2.1"),
+		endpoint.NewEndpoint("v6.example.com", endpoint.RecordTypeAAAA, "2001:db8::1"),
+		endpoint.NewEndpoint("alias.example.com", endpoint.RecordTypeCNAME, "v4.example.com"),
+		endpoint.NewEndpoint("txt.example.com", endpoint.RecordTypeTXT, "\"text\""),
+	}
+	adjusted, err := s.manager.AdjustEndpoints(endpoints)
+	s.Require().NoError(err)
+	s.Equal(endpoints[:2], adjusted, "Only A and AAAA endpoints should be kept")
+}
+
+func (s *HostsManagerTestSuite) TestApplyChanges_ServerBlock() {
+	corefile := testCorefile + `
+cluster.example:53 {
+    errors
+}
+`
+	cfgMap, err := s.client.CoreV1().ConfigMaps("kube-system").Get(context.Background(), "coredns", metav1.GetOptions{})
+	s.Require().NoError(err)
+	cfgMap.Data[k8s.CorefileKey] = corefile
+	_, err = s.client.CoreV1().ConfigMaps("kube-system").Update(context.Background(), cfgMap, metav1.UpdateOptions{})
+	s.Require().NoError(err)
+
+	mgr, err := NewHostsManager(s.client, "coredns", "kube-system", "cluster.example")
+	s.Require().NoError(err)
+	s.Require().NoError(mgr.ApplyChanges(context.Background(), &plan.Changes{
+		Create: []*endpoint.Endpoint{endpoint.NewEndpoint("v4.example.com", endpoint.RecordTypeA, "192.0.2.1")},
+	}))
+
+	s.True(strings.HasPrefix(s.corefile(), testCorefile), "The other server blocks should be left untouched")
+	s.Contains(s.corefile(), "cluster.example:53 {\n    errors\n    # BEGIN external-dns")
+}
+
+func (s *HostsManagerTestSuite) TestApplyChanges_ConcurrentModification() {
+	attempts := conflictingUpdates(s.client, func(attempt int) bool { return attempt == 1 }, addLogPlugin)
+
+	err := s.manager.ApplyChanges(context.Background(), &plan.Changes{
+		Create: []*endpoint.Endpoint{endpoint.NewEndpoint("v4.example.com", endpoint.RecordTypeA, "192.0.2.1")},
+	})
+	s.Require().NoError(err)
+	s.Equal(2, *attempts, "The changes should be applied again after the conflict")
+	s.Contains(s.corefile(), "    log\n", "The concurrent changes should be kept")
+	s.Contains(s.corefile(), "192.0.2.1 v4.example.com")
+}
+
+func (s *HostsManagerTestSuite) TestApplyChanges_PersistentConflict() {
+	attempts := conflictingUpdates(s.client, func(int) bool { return true }, func(*v1.ConfigMap) {})
+
+	err := s.manager.ApplyChanges(context.Background(), &plan.Changes{
+		Create: []*endpoint.Endpoint{endpoint.NewEndpoint("v4.example.com", endpoint.RecordTypeA, "192.0.2.1")},
+	})
+	s.Require().Error(err)
+	s.True(apierrors.IsConflict(err), "The conflict should be returned once the retries are exhausted")
+	s.Equal(testBackoff.Steps, *attempts)
+	s.NotContains(s.corefile(), "v4.example.com")
+}
+
+func (s *HostsManagerTestSuite) TestApplyChanges_MultipleTargetsAndTTL() {
+	desired := []*endpoint.Endpoint{
+		endpoint.NewEndpointWithTTL("www.example.com", endpoint.RecordTypeA, 300, "192.0.2.1", "192.0.2.2", "192.0.2.3"),
+		endpoint.NewEndpointWithTTL("www.example.com", endpoint.RecordTypeAAAA, 300, "2001:db8::1"),
+	}
+	s.Require().NoError(s.manager.ApplyChanges(context.Background(), &plan.Changes{Create: desired}))
+
+	corefile := s.corefile()
+	s.Contains(corefile, "192.0.2.1 www.example.com\n")
+	s.Contains(corefile, "192.0.2.2 www.example.com\n")
+	s.Contains(corefile, "192.0.2.3 www.example.com\n")
+	s.Contains(corefile, "        ttl 300\n        fallthrough\n")
+
+	records, err := s.manager.Records(context.Background())
+	s.Require().NoError(err)
+	s.Require().Len(records, 2, "The hosts should be grouped by name and type")
+	s.Equal(endpoint.Targets{"192.0.2.1", "192.0.2.2", "192.0.2.3"}, records[0].Targets)
+	s.Equal(endpoint.TTL(300), records[0].RecordTTL)
+
+	// The records read back should match the desired records
+	p := &plan.Plan{
+		Current:        records,
+		Desired:        desired,
+		ManagedRecords: []string{endpoint.RecordTypeA, endpoint.RecordTypeAAAA},
+	}
+	s.False(p.Calculate().Changes.HasChanges(), "The written records should not drift from the desired records")
+
+	// Updating the targets replaces every host of the endpoint
+	s.Require().NoError(s.manager.ApplyChanges(context.Background(), &plan.Changes{
+		UpdateOld: []*endpoint.Endpoint{desired[0]},
+		UpdateNew: []*endpoint.Endpoint{endpoint.NewEndpointWithTTL("www.example.com", endpoint.RecordTypeA, 60, "192.0.2.2", "192.0.2.4")},
+	}))
+	records, err = s.manager.Records(context.Background())
+	s.Require().NoError(err)
+	s.ElementsMatch([]*endpoint.Endpoint{
+		endpoint.NewEndpointWithTTL("www.example.com.", endpoint.RecordTypeA, 60, "192.0.2.2", "192.0.2.4"),
+		endpoint.NewEndpointWithTTL("www.example.com.", endpoint.RecordTypeAAAA, 60, "2001:db8::1"),
+	}, records)
+}
+
+func (s *HostsManagerTestSuite) TestAdjustEndpoints_TTL() {
+	adjusted, err := s.manager.AdjustEndpoints([]*endpoint.Endpoint{
+		endpoint.NewEndpointWithTTL("a.example.com", endpoint.RecordTypeA, 300, "192.0.2.1"),
+		endpoint.NewEndpointWithTTL("b.example.com", endpoint.RecordTypeA, 60, "192.0.2.2"),
+		endpoint.NewEndpoint("c.example.com", endpoint.RecordTypeA, "192.0.2.3"),
+		endpoint.NewEndpoint("c.example.com", endpoint.RecordTypeCNAME, "a.example.com"),
+	})
+	s.Require().NoError(err)
+	s.Require().Len(adjusted, 3)
+	s.Equal(endpoint.TTL(60), adjusted[0].RecordTTL, "The lowest configured TTL should be used")
+	s.Equal(endpoint.TTL(60), adjusted[1].RecordTTL)
+	s.False(adjusted[2].RecordTTL.IsConfigured(), "An unconfigured TTL should be left unconfigured")
+}
+
+func TestHostsManagerTestSuite(t *testing.T) {
+	suite.Run(t, new(HostsManagerTestSuite))
+}
@@ -2,101 +2,176 @@ package manager
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"path"
 	"strings"
 
 	"github.com/miekg/dns"
 	log "github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
 	"sigs.k8s.io/external-dns/endpoint"
 	"sigs.k8s.io/external-dns/plan"
+	"sigs.k8s.io/external-dns/provider"
 	"sigs.k8s.io/external-dns/provider/corednsk8s/editor"
 	"sigs.k8s.io/external-dns/provider/corednsk8s/k8s"
 )
 
+// importLabels are the labels of the ConfigMap created to be imported in the Corefile
+var importLabels = map[string]string{"app.kubernetes.io/managed-by": "external-dns"}
+
+// RFC1035Manager manages records through zone files served by the file plugin of the Corefile.
+//
+// Every zone is stored in its own key of the CoreDNS ConfigMap, which is mounted in the CoreDNS Deployment.
+// In import mode, the zone files and their file entries are stored in a ConfigMap owned by external-dns
+// instead, which is imported in the server block through the import plugin: neither the Corefile nor the
+// CoreDNS Deployment are modified.
 type RFC1035Manager struct {
 	client            kubernetes.Interface
 	zoneEditor        *editor.ZoneEditor
 	coreDNSEditor     *editor.CoreDNSConfigEditor
 	coreDNSConfigMap  *k8s.CoreDNSConfigMap
 	coreDNSDeployment *k8s.CoreDNSDeployment
+	// zones are the zones the manager is authoritative for
+	zones provider.ZoneIDName
+	// zoneKeys are the ConfigMap keys of the zone files loaded on the last reload
+	zoneKeys []string
+	// backoff is the backoff between the attempts to apply changes when the ConfigMap is modified concurrently
+	backoff wait.Backoff
+	// importConfigMap is the ConfigMap imported in the server block in import mode, nil otherwise
+	importConfigMap *k8s.CoreDNSConfigMap
+	// importDir is the directory the import ConfigMap is mounted at in the CoreDNS container
+	importDir string
 }
 
-// NewRFC1035Manager creates a new RFC1035 manager.
-func NewRFC1035Manager(client kubernetes.Interface, deployment, configMap, ns string) (*RFC1035Manager, error) {
-	m := &RFC1035Manager{
-		client:            client,
-		zoneEditor:        editor.NewZoneEditor(),
-		coreDNSEditor:     editor.NewCoreDNSConfigEditor(),
-		coreDNSConfigMap:  k8s.NewConfigMap(client.CoreV1(), ns, configMap),
-		coreDNSDeployment: k8s.NewDeployment(client.AppsV1(), ns, deployment, configMap),
-	}
-
-	// Verify that the Zone file is exist in ConfigMap
-	_, err := m.coreDNSConfigMap.GetZone(context.Background())
-	switch {
-	case err == nil:
-		// Zone file exists
-	case err.Error() == "zone not found":
-		// Create the Zone file in the ConfigMap
-		err = m.coreDNSConfigMap.UpdateZone(context.Background(), "")
-		if err != nil {
-			return nil, err
-		}
-	default:
+// NewRFC1035Manager creates a new RFC1035 manager, authoritative for the given zones.
+//
+// The zones are served from the server block with the given key, defaults to ".:53".
+func NewRFC1035Manager(client kubernetes.Interface, deployment, configMap, ns, serverBlock string, zones []string, zoneConfig editor.ZoneConfig) (*RFC1035Manager, error) {
+	m, err := newRFC1035Manager(client, configMap, ns, serverBlock, zones, zoneConfig)
+	if err != nil {
 		return nil, err
 	}
+	m.coreDNSDeployment = k8s.NewDeployment(client.AppsV1(), ns, deployment, configMap)
 
-	// Verify that the Zone file is mounted
-	err = m.coreDNSDeployment.MountZoneFile(context.Background())
-	if err != nil {
+	if err := m.reload(context.Background()); err != nil {
 		return nil, err
 	}
-
 	return m, nil
 }
 
-func (m *RFC1035Manager) Records(ctx context.Context) ([]*endpoint.Endpoint, error) {
-	// Get Zone from ConfigMap
-	zoneCfg, err := m.coreDNSConfigMap.GetZone(ctx)
+// NewRFC1035ImportManager creates a new RFC1035 manager in import mode, authoritative for the given zones.
+//
+// The zone files are stored in the import ConfigMap, created if it does not exist, which is expected to be
+// mounted in the import directory of the CoreDNS container and imported in the server block (e.g. with
+// "import /etc/coredns/custom/*.override").
+func NewRFC1035ImportManager(client kubernetes.Interface, configMap, importConfigMap, ns, serverBlock, importDir string, zones []string, zoneConfig editor.ZoneConfig) (*RFC1035Manager, error) {
+	m, err := newRFC1035Manager(client, configMap, ns, serverBlock, zones, zoneConfig)
 	if err != nil {
 		return nil, err
 	}
+	m.importConfigMap = k8s.NewConfigMap(client.CoreV1(), ns, importConfigMap)
+	m.importDir = importDir
+	m.coreDNSEditor.SetZoneFileDir(importDir)
 
-	// Parse it
-	if err = m.zoneEditor.LoadZone(zoneCfg); err != nil {
+	if err := m.reload(context.Background()); err != nil {
 		return nil, err
 	}
-	// Get all records for every zone
+	return m, nil
+}
+
+// newRFC1035Manager creates a new RFC1035 manager without loading the configuration
+func newRFC1035Manager(client kubernetes.Interface, configMap, ns, serverBlock string, zones []string, zoneConfig editor.ZoneConfig) (*RFC1035Manager, error) {
+	zoneNames := provider.ZoneIDName{}
+	for _, zone := range zones {
+		if zone := normalizeZone(zone); zone != "" {
+			zoneNames.Add(zone, zone)
+		}
+	}
+	if len(zoneNames) == 0 {
+		return nil, errors.New("at least one zone is required to manage zone files")
+	}
+	m := &RFC1035Manager{
+		client:           client,
+		zoneEditor:       editor.NewZoneEditorWithConfig(zoneConfig),
+		coreDNSEditor:    editor.NewCoreDNSConfigEditor(),
+		coreDNSConfigMap: k8s.NewConfigMap(client.CoreV1(), ns, configMap),
+		zones:            zoneNames,
+		backoff:          retry.DefaultBackoff,
+	}
+	if serverBlock != "" {
+		m.coreDNSEditor.SetServerBlock(serverBlock)
+	}
+	return m, nil
+}
+
+func (m *RFC1035Manager) Records(ctx context.Context) ([]*endpoint.Endpoint, error) {
+	// Reload the Corefile and Zone files
+	if err := m.reload(ctx); err != nil {
+		return nil, err
+	}
+	// Get all records for every zone, grouped into an endpoint per name and type
 	records := make([]*endpoint.Endpoint, 0)
 	for _, zone := range m.zoneEditor.GetZones() {
+		zoneRecords := make([]dns.RR, 0)
 		for _, record := range m.zoneEditor.GetAllRecords(zone) {
-			switch r := record.(type) {
-			case *dns.A:
-				records = append(records, endpoint.NewEndpointWithTTL(r.Header().Name, endpoint.RecordTypeA, endpoint.TTL(r.Hdr.Ttl), r.A.String()))
-			case *dns.CNAME:
-				records = append(records, endpoint.NewEndpointWithTTL(r.Header().Name, endpoint.RecordTypeCNAME, endpoint.TTL(r.Hdr.Ttl), r.Target))
-			case *dns.TXT:
-				records = append(records, endpoint.NewEndpointWithTTL(r.Header().Name, endpoint.RecordTypeTXT, endpoint.TTL(r.Hdr.Ttl), r.Txt...))
-			case *dns.SRV:
-				records = append(records, endpoint.NewEndpointWithTTL(r.Header().Name, endpoint.RecordTypeSRV, endpoint.TTL(r.Hdr.Ttl), r.Target))
-			case *dns.PTR:
-				records = append(records, endpoint.NewEndpointWithTTL(r.Header().Name, endpoint.RecordTypePTR, endpoint.TTL(r.Hdr.Ttl), r.Ptr))
-			case *dns.MX:
-				records = append(records, endpoint.NewEndpointWithTTL(r.Header().Name, endpoint.RecordTypeMX, endpoint.TTL(r.Hdr.Ttl), r.Mx))
-			case *dns.AAAA:
-				records = append(records, endpoint.NewEndpointWithTTL(r.Header().Name, endpoint.RecordTypeAAAA, endpoint.TTL(r.Hdr.Ttl), r.AAAA.String()))
-			case *dns.NS:
-				records = append(records, endpoint.NewEndpointWithTTL(r.Header().Name, endpoint.RecordTypeNS, endpoint.TTL(r.Hdr.Ttl), r.Ns))
-			default:
-				//TODO implement me
+			// The SOA and apex NS records are managed from the configuration
+			if !m.zoneEditor.IsGenerated(zone, record) {
+				zoneRecords = append(zoneRecords, record)
 			}
 		}
+		records = append(records, recordsToEndpoints(zoneRecords)...)
 	}
 	return records, nil
 }
 
+// AdjustEndpoints drops the endpoints that cannot be written in a zone file or are outside the managed zones
+//
+// The SRV, MX and NAPTR targets are rewritten in the form they are read back from the zone files, so they
+// are compared with the records of the zone files without drift.
+func (m *RFC1035Manager) AdjustEndpoints(endpoints []*endpoint.Endpoint) ([]*endpoint.Endpoint, error) {
+	adjusted := make([]*endpoint.Endpoint, 0, len(endpoints))
+	for _, endPt := range endpoints {
+		if !isZoneRecordType(endPt.RecordType) {
+			log.WithFields(log.Fields{
+				"record": endPt.DNSName,
+				"type":   endPt.RecordType,
+			}).Warn("Record type is not supported in zone files, skipping")
+			continue
+		}
+		if _, err := m.findZone(endPt.DNSName); err != nil {
+			log.WithFields(log.Fields{
+				"record": endPt.DNSName,
+				"type":   endPt.RecordType,
+			}).Warn("Record is not part of any managed zone, skipping")
+			continue
+		}
+		if !canonicalizeTargets(endPt) {
+			log.WithFields(log.Fields{
+				"record":  endPt.DNSName,
+				"type":    endPt.RecordType,
+				"targets": endPt.Targets,
+			}).Warn("Record has an invalid target, skipping")
+			continue
+		}
+		adjusted = append(adjusted, endPt)
+	}
+	return adjusted, nil
+}
+
+// ApplyChanges applies the changes on the latest version of the CoreDNS configuration
+//
+// If the configuration is modified concurrently, it is reloaded and the changes are applied again.
 func (m *RFC1035Manager) ApplyChanges(ctx context.Context, changes *plan.Changes) error {
+	return retryOnConflict(m.backoff, func() error {
+		return m.applyChanges(ctx, changes)
+	})
+}
+
+// applyChanges reloads the configuration, applies the changes and commits them
+func (m *RFC1035Manager) applyChanges(ctx context.Context, changes *plan.Changes) error {
 	// Reload the Corefile and Zone file
 	if err := m.reload(ctx); err != nil {
 		return err
@@ -131,13 +206,19 @@ func (m *RFC1035Manager) applyCreate(endpoints []*endpoint.Endpoint) error {
 			"type":   endPt.RecordType,
 		}
 		log.WithFields(logFields).Debug("Creating record")
-		zone := getZone(endPt.DNSName)
-		record := endpointToRecord(endPt)
-		if record == nil {
+		zone, err := m.findZone(endPt.DNSName)
+		if err != nil {
+			log.WithFields(logFields).Warn(err)
+			continue
+		}
+		records := endpointToRecords(endPt)
+		if len(records) == 0 {
 			log.WithFields(logFields).Warn("Could not map record to DNS entry")
 			continue
 		}
-		m.zoneEditor.AddRecord(zone, record)
+		for _, record := range records {
+			m.zoneEditor.AddRecord(zone, record)
+		}
 	}
 	return nil
 }
@@ -156,14 +237,22 @@ func (m *RFC1035Manager) applyUpdate(endpointsOld, endpointsNew []*endpoint.Endp
 			"type":   endPtOld.RecordType,
 		}
 		log.WithFields(logFields).Debug("Updating record")
-		zone := getZone(endPtOld.DNSName)
-		recordOld := endpointToRecord(endPtOld)
-		recordNew := endpointToRecord(endPtNew)
-		if recordOld == nil || recordNew == nil {
+		zone, err := m.findZone(endPtOld.DNSName)
+		if err != nil {
+			log.WithFields(logFields).Warn(err)
+			continue
+		}
+		recordsOld := endpointToRecords(endPtOld)
+		recordsNew := endpointToRecords(endPtNew)
+		if len(recordsOld) == 0 || len(recordsNew) == 0 {
 			log.WithFields(logFields).Warn("Could not map record to DNS entry")
 			continue
 		}
-		m.zoneEditor.UpdateRecord(zone, recordOld, recordNew)
+		// The new records replace the whole RRset of the old endpoint
+		if !strings.EqualFold(endPtOld.DNSName, endPtNew.DNSName) || endPtOld.RecordType != endPtNew.RecordType {
+			m.zoneEditor.DeleteRecord(zone, recordsOld[0])
+		}
+		m.zoneEditor.SetRecords(zone, recordsNew)
 	}
 	return nil
 }
@@ -176,21 +265,31 @@ func (m *RFC1035Manager) applyDelete(endpoints []*endpoint.Endpoint) error {
 			"type":   endPt.RecordType,
 		}
 		log.WithFields(logFields).Debug("Deleting record")
-		zone := getZone(endPt.DNSName)
-		record := endpointToRecord(endPt)
-		if record == nil {
+		zone, err := m.findZone(endPt.DNSName)
+		if err != nil {
+			log.WithFields(logFields).Warn(err)
+			continue
+		}
+		records := endpointToRecords(endPt)
+		if len(records) == 0 {
 			log.WithFields(logFields).Warn("Could not map record to DNS entry")
 			continue
 		}
-		m.zoneEditor.DeleteRecord(zone, record)
+		// Deleting a record deletes the whole RRset of the endpoint
+		m.zoneEditor.DeleteRecord(zone, records[0])
 	}
 	return nil
 }
 
-// reload reloads the Corefile and Zone file
+// reload reloads the Corefile and Zone files
 func (m *RFC1035Manager) reload(ctx context.Context) error {
+	// Load the Corefile and the Zone files from the same version of the ConfigMap
+	if err := m.coreDNSConfigMap.Load(ctx); err != nil {
+		return err
+	}
+
 	// Reload the Corefile
-	if corefile, err := m.coreDNSConfigMap.GetCoreDNSConfig(ctx); err == nil {
+	if corefile, err := m.coreDNSConfigMap.Corefile(); err == nil {
 		if err = m.coreDNSEditor.LoadCorefile(corefile); err != nil {
 			return err
 		}
@@ -198,44 +297,121 @@ func (m *RFC1035Manager) reload(ctx context.Context) error {
 		return err
 	}
 
-	// Reload the Zone file
-	if zone, err := m.coreDNSConfigMap.GetZone(ctx); err == nil {
-		if err = m.zoneEditor.LoadZone(zone); err != nil {
+	// Reload the Zone files
+	zones := m.coreDNSConfigMap.Zones()
+	if m.importConfigMap != nil {
+		if err := m.importConfigMap.LoadOrCreate(ctx, importLabels); err != nil {
 			return err
 		}
-	} else {
-		return err
+		// The Corefile is not modified in import mode, it has to import the file entries
+		if importPath := path.Join(m.importDir, k8s.ImportKey); !m.coreDNSEditor.Imports(importPath, editor.ZoneFileDir) {
+			return fmt.Errorf("the server block of the Corefile does not import the zones, add \"import %s\" to it", importPath)
+		}
+		zones = m.importConfigMap.Zones()
 	}
-	return nil
+	m.zoneKeys = make([]string, 0, len(zones))
+	zoneFiles := make([]string, 0, len(zones))
+	for key, zone := range zones {
+		m.zoneKeys = append(m.zoneKeys, key)
+		zoneFiles = append(zoneFiles, zone)
+	}
+	return m.zoneEditor.LoadZones(zoneFiles)
 }
 
-// commit saves the changes to the Corefile and Zone file
+// commit saves the changes to the Corefile and Zone files
+//
+// The zone files are written and mounted before the Corefile references them, and are only
+// removed after the Corefile stopped referencing them. In import mode, the import ConfigMap is
+// updated at once, since a ConfigMap volume is updated atomically in the CoreDNS container.
 func (m *RFC1035Manager) commit(ctx context.Context) error {
-	// Update zones in corefile
+	m.zoneEditor.UpdateSerials()
 	zones := m.zoneEditor.GetZones()
-	if err := m.coreDNSEditor.SetZones(zones); err != nil {
+	zoneFiles := make(map[string]string, len(zones))
+	for _, zone := range zones {
+		zoneFiles[editor.ZoneFileName(zone)] = m.zoneEditor.RenderZone(zone)
+	}
+
+	// Store the zone files and their file entries in the import ConfigMap, in a single update
+	if m.importConfigMap != nil {
+		zoneFiles[k8s.ImportKey] = m.coreDNSEditor.RenderZones(zones)
+		return m.importConfigMap.ReplaceData(ctx, zoneFiles)
+	}
+
+	// Update zones in zone files
+	if err := m.coreDNSConfigMap.UpdateZones(ctx, zoneFiles); err != nil {
 		return err
 	}
 
-	// Update records in zone file
-	if err := m.coreDNSConfigMap.UpdateCoreDNSConfig(ctx, m.coreDNSEditor.GetConfig()); err != nil {
+	// Mount the zone files in the deployment
+	zoneFileDir, err := m.coreDNSDeployment.GetMountPath(ctx)
+	if err != nil {
+		return err
+	}
+	m.coreDNSEditor.SetZoneFileDir(zoneFileDir)
+	for _, zone := range zones {
+		zoneFile := editor.ZoneFileName(zone)
+		zonePath, err := m.coreDNSDeployment.MountZoneFile(ctx, zoneFile)
+		if err != nil {
+			return err
+		}
+		if zonePath != path.Join(zoneFileDir, zoneFile) {
+			return fmt.Errorf("zone file %s is mounted at %s instead of %s", zoneFile, zonePath, zoneFileDir)
+		}
+	}
+
+	// Update zones in corefile
+	if err := m.coreDNSEditor.SetZones(zones); err != nil {
 		return err
 	}
-	// Update zones in zone file
-	if err := m.coreDNSConfigMap.UpdateZone(ctx, m.zoneEditor.RenderZone()); err != nil {
+	if err := m.coreDNSConfigMap.UpdateCoreDNSConfig(ctx, m.coreDNSEditor.GetConfig()); err != nil {
 		return err
 	}
-	return nil
+
+	// Remove the zone files which are not used anymore
+	staleKeys := make([]string, 0)
+	for _, key := range m.zoneKeys {
+		if _, ok := zoneFiles[key]; ok {
+			continue
+		}
+		if err := m.coreDNSDeployment.UnmountZoneFile(ctx, key); err != nil {
+			return err
+		}
+		staleKeys = append(staleKeys, key)
+	}
+	return m.coreDNSConfigMap.DeleteZones(ctx, staleKeys...)
 }
 
-// getZone returns the n-1 parts of the TLD (e.g. "test.example.com" -> "example.com.")
-func getZone(domain string) string {
-	parts := dns.SplitDomainName(domain)
-	if len(parts) < 3 {
-		panic("invalid domain")
+// canonicalizeTargets rewrites the SRV, MX and NAPTR targets of the endpoint in their canonical
+// presentation form, and returns false if a target is invalid
+func canonicalizeTargets(endPt *endpoint.Endpoint) bool {
+	switch endPt.RecordType {
+	case endpoint.RecordTypeSRV, endpoint.RecordTypeMX, endpoint.RecordTypeNAPTR:
+	default:
+		return true
 	}
+	targets := make(endpoint.Targets, 0, len(endPt.Targets))
+	for _, target := range endPt.Targets {
+		canonical, ok := canonicalTarget(endPt, target)
+		if !ok {
+			return false
+		}
+		targets = append(targets, canonical)
+	}
+	endPt.Targets = targets
+	return true
+}
+
+// findZone returns the authoritative zone of the domain, by the longest matching suffix
+// (e.g. "a.b.example.com" -> "example.com." when managing "example.com")
+func (m *RFC1035Manager) findZone(domain string) (string, error) {
+	_, zone := m.zones.FindZone(normalizeZone(domain))
+	if zone == "" {
+		return "", provider.NewInvalidRecordError(fmt.Errorf("%s is not part of any managed zone", domain))
+	}
+	return dns.Fqdn(zone), nil
+}
 
-	zone := strings.Join(parts[1:], ".")
-	zone = fmt.Sprintf("%s.", zone)
-	return zone
+// normalizeZone returns the lower case zone name without leading or trailing dots
+func normalizeZone(zone string) string {
+	return strings.Trim(strings.ToLower(strings.TrimSpace(zone)), ".")
 }
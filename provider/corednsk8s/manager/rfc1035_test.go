@@ -0,0 +1,407 @@
+package manager
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/suite"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/plan"
+	"sigs.k8s.io/external-dns/provider/corednsk8s/editor"
+	"sigs.k8s.io/external-dns/provider/corednsk8s/k8s"
+)
+
+type RFC1035ManagerTestSuite struct {
+	suite.Suite
+	client  *fake.Clientset
+	manager *RFC1035Manager
+}
+
+func (s *RFC1035ManagerTestSuite) SetupTest() {
+	s.client = fake.NewSimpleClientset(
+		&v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "coredns", Namespace: "kube-system"},
+			Data:       map[string]string{k8s.CorefileKey: testCorefile},
+		},
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "coredns", Namespace: "kube-system"},
+			Spec: appsv1.DeploymentSpec{
+				Template: v1.PodTemplateSpec{
+					Spec: v1.PodSpec{
+						Containers: []v1.Container{
+							{
+								Name:         "coredns",
+								VolumeMounts: []v1.VolumeMount{{Name: "config-volume", MountPath: "/etc/coredns", ReadOnly: true}},
+							},
+						},
+						Volumes: []v1.Volume{
+							{
+								Name: "config-volume",
+								VolumeSource: v1.VolumeSource{
+									ConfigMap: &v1.ConfigMapVolumeSource{
+										LocalObjectReference: v1.LocalObjectReference{Name: "coredns"},
+										Items:                []v1.KeyToPath{{Key: k8s.CorefileKey, Path: k8s.CorefileKey}},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	)
+	mgr, err := NewRFC1035Manager(s.client, "coredns", "coredns", "kube-system", "", []string{"example.com", "example.net.", "sub.example.net"}, editor.DefaultZoneConfig())
+	s.Require().NoError(err)
+	mgr.backoff = testBackoff
+	s.manager = mgr
+}
+
+func (s *RFC1035ManagerTestSuite) configMapData() map[string]string {
+	cfgMap, err := s.client.CoreV1().ConfigMaps("kube-system").Get(context.Background(), "coredns", metav1.GetOptions{})
+	s.Require().NoError(err)
+	return cfgMap.Data
+}
+
+func (s *RFC1035ManagerTestSuite) volumeItems() []v1.KeyToPath {
+	deployment, err := s.client.AppsV1().Deployments("kube-system").Get(context.Background(), "coredns", metav1.GetOptions{})
+	s.Require().NoError(err)
+	return deployment.Spec.Template.Spec.Volumes[0].ConfigMap.Items
+}
+
+func (s *RFC1035ManagerTestSuite) TestApplyChanges_ServesZoneFile() {
+	err := s.manager.ApplyChanges(context.Background(), &plan.Changes{
+		Create: []*endpoint.Endpoint{
+			endpoint.NewEndpointWithTTL("www.example.com", endpoint.RecordTypeA, 300, "192.0.2.1"),
+			endpoint.NewEndpointWithTTL("txt.example.com", endpoint.RecordTypeTXT, 300, "\"heritage=external-dns\""),
+			endpoint.NewEndpointWithTTL("www.example.net", endpoint.RecordTypeCNAME, 300, "www.example.com"),
+		},
+	})
+	s.Require().NoError(err)
+
+	// Every zone should be stored in its own key
+	data := s.configMapData()
+	s.Contains(data, "db.example.com")
+	s.Contains(data, "db.example.net")
+
+	// Every zone file should be parseable on its own, as done by the file plugin
+	for _, zone := range []string{"example.com.", "example.net."} {
+		parser := dns.NewZoneParser(strings.NewReader(data[editor.ZoneFileName(zone)]), zone, "")
+		soaFound := false
+		for rr, ok := parser.Next(); ok; rr, ok = parser.Next() {
+			s.True(dns.IsSubDomain(zone, rr.Header().Name), "Record %s should be in zone %s", rr.Header().Name, zone)
+			if _, ok := rr.(*dns.SOA); ok {
+				soaFound = true
+			}
+		}
+		s.Require().NoError(parser.Err())
+		s.True(soaFound, "Zone %s should have an SOA record", zone)
+	}
+
+	// The Corefile should serve every zone from its mounted file
+	s.Contains(data[k8s.CorefileKey], "file /etc/coredns/db.example.com example.com\n")
+	s.Contains(data[k8s.CorefileKey], "file /etc/coredns/db.example.net example.net\n")
+	s.ElementsMatch([]v1.KeyToPath{
+		{Key: k8s.CorefileKey, Path: k8s.CorefileKey},
+		{Key: "db.example.com", Path: "db.example.com"},
+		{Key: "db.example.net", Path: "db.example.net"},
+	}, s.volumeItems())
+
+	// The records should be read back from the zone files
+	records, err := s.manager.Records(context.Background())
+	s.Require().NoError(err)
+	s.ElementsMatch([]*endpoint.Endpoint{
+		endpoint.NewEndpointWithTTL("www.example.com.", endpoint.RecordTypeA, 300, "192.0.2.1"),
+		endpoint.NewEndpointWithTTL("txt.example.com.", endpoint.RecordTypeTXT, 300, "heritage=external-dns"),
+		endpoint.NewEndpointWithTTL("www.example.net.", endpoint.RecordTypeCNAME, 300, "www.example.com"),
+	}, records)
+}
+
+func (s *RFC1035ManagerTestSuite) TestApplyChanges_RemovesEmptyZone() {
+	s.Require().NoError(s.manager.ApplyChanges(context.Background(), &plan.Changes{
+		Create: []*endpoint.Endpoint{
+			endpoint.NewEndpointWithTTL("www.example.com", endpoint.RecordTypeA, 300, "192.0.2.1"),
+			endpoint.NewEndpointWithTTL("www.example.net", endpoint.RecordTypeA, 300, "192.0.2.2"),
+		},
+	}))
+
+	s.Require().NoError(s.manager.ApplyChanges(context.Background(), &plan.Changes{
+		Delete: []*endpoint.Endpoint{endpoint.NewEndpointWithTTL("www.example.net", endpoint.RecordTypeA, 300, "192.0.2.2")},
+	}))
+
+	data := s.configMapData()
+	s.Contains(data, "db.example.com")
+	s.NotContains(data, "db.example.net", "The zone file of an empty zone should be removed")
+	s.NotContains(data[k8s.CorefileKey], "example.net", "The empty zone should not be served anymore")
+	s.ElementsMatch([]v1.KeyToPath{
+		{Key: k8s.CorefileKey, Path: k8s.CorefileKey},
+		{Key: "db.example.com", Path: "db.example.com"},
+	}, s.volumeItems())
+}
+
+func (s *RFC1035ManagerTestSuite) TestApplyChanges_NestedNames() {
+	err := s.manager.ApplyChanges(context.Background(), &plan.Changes{
+		Create: []*endpoint.Endpoint{
+			endpoint.NewEndpointWithTTL("example.com", endpoint.RecordTypeA, 300, "192.0.2.1"),
+			endpoint.NewEndpointWithTTL("a.b.example.com", endpoint.RecordTypeA, 300, "192.0.2.2"),
+			endpoint.NewEndpointWithTTL("www.sub.example.net", endpoint.RecordTypeA, 300, "192.0.2.3"),
+		},
+	})
+	s.Require().NoError(err)
+
+	data := s.configMapData()
+	s.Contains(data, "db.example.com")
+	s.Contains(data, "db.sub.example.net", "The longest matching zone should be used")
+	s.NotContains(data, "db.b.example.com", "Subdomains should not get their own zone")
+	s.NotContains(data, "db.example.net")
+	s.Contains(data["db.example.com"], "a.b.example.com.")
+}
+
+func (s *RFC1035ManagerTestSuite) TestApplyChanges_OutsideZones() {
+	err := s.manager.ApplyChanges(context.Background(), &plan.Changes{
+		Create: []*endpoint.Endpoint{
+			endpoint.NewEndpointWithTTL("com", endpoint.RecordTypeA, 300, "192.0.2.1"),
+			endpoint.NewEndpointWithTTL("www.example.org", endpoint.RecordTypeA, 300, "192.0.2.2"),
+			endpoint.NewEndpointWithTTL("www.example.com", endpoint.RecordTypeA, 300, "192.0.2.3"),
+		},
+	})
+	s.Require().NoError(err, "Names outside the zones should not fail the sync")
+
+	records, err := s.manager.Records(context.Background())
+	s.Require().NoError(err)
+	s.Equal([]*endpoint.Endpoint{
+		endpoint.NewEndpointWithTTL("www.example.com.", endpoint.RecordTypeA, 300, "192.0.2.3"),
+	}, records)
+}
+
+func (s *RFC1035ManagerTestSuite) TestApplyChanges_ConfiguredNameservers() {
+	zoneConfig := editor.DefaultZoneConfig()
+	zoneConfig.Nameservers = []string{"ns1.example.org"}
+	mgr, err := NewRFC1035Manager(s.client, "coredns", "coredns", "kube-system", "", []string{"example.com"}, zoneConfig)
+	s.Require().NoError(err)
+
+	s.Require().NoError(mgr.ApplyChanges(context.Background(), &plan.Changes{
+		Create: []*endpoint.Endpoint{endpoint.NewEndpointWithTTL("www.example.com", endpoint.RecordTypeA, 300, "192.0.2.1")},
+	}))
+	s.Contains(s.configMapData()["db.example.com"], "example.com.\t3600\tIN\tNS\tns1.example.org.")
+
+	// The generated apex NS records should not be reported as managed records
+	records, err := mgr.Records(context.Background())
+	s.Require().NoError(err)
+	s.Equal([]*endpoint.Endpoint{
+		endpoint.NewEndpointWithTTL("www.example.com.", endpoint.RecordTypeA, 300, "192.0.2.1"),
+	}, records)
+}
+
+func (s *RFC1035ManagerTestSuite) TestApplyChanges_ConcurrentModification() {
+	s.Require().NoError(s.manager.ApplyChanges(context.Background(), &plan.Changes{
+		Create: []*endpoint.Endpoint{endpoint.NewEndpointWithTTL("www.example.com", endpoint.RecordTypeA, 300, "192.0.2.1")},
+	}))
+
+	// The Corefile is modified concurrently after the zone files were written
+	attempts := conflictingUpdates(s.client, func(attempt int) bool { return attempt == 2 }, addLogPlugin)
+	err := s.manager.ApplyChanges(context.Background(), &plan.Changes{
+		Create: []*endpoint.Endpoint{endpoint.NewEndpointWithTTL("www.example.net", endpoint.RecordTypeA, 300, "192.0.2.2")},
+	})
+	s.Require().NoError(err)
+	s.Equal(4, *attempts, "The zone files and the Corefile should be written again after the conflict")
+
+	data := s.configMapData()
+	s.Contains(data[k8s.CorefileKey], "    log\n", "The concurrent changes should be kept")
+	s.Contains(data[k8s.CorefileKey], "file /etc/coredns/db.example.net example.net\n")
+	s.Equal(1, strings.Count(data["db.example.net"], "192.0.2.2"), "The changes should not be applied twice")
+}
+
+func (s *RFC1035ManagerTestSuite) TestFindZone() {
+	for _, tc := range []struct {
+		domain   string
+		expected string
+	}{
+		{domain: "example.com", expected: "example.com."},
+		{domain: "example.com.", expected: "example.com."},
+		{domain: "WWW.Example.Com", expected: "example.com."},
+		{domain: "a.b.example.com", expected: "example.com."},
+		{domain: "www.example.net", expected: "example.net."},
+		{domain: "www.sub.example.net", expected: "sub.example.net."},
+		{domain: "www.notexample.com", expected: ""},
+		{domain: "com", expected: ""},
+		{domain: "", expected: ""},
+	} {
+		zone, err := s.manager.findZone(tc.domain)
+		if tc.expected == "" {
+			s.Error(err, tc.domain)
+			continue
+		}
+		s.NoError(err, tc.domain)
+		s.Equal(tc.expected, zone, tc.domain)
+	}
+}
+
+func (s *RFC1035ManagerTestSuite) TestNewRFC1035Manager_NoZones() {
+	_, err := NewRFC1035Manager(s.client, "coredns", "coredns", "kube-system", "", []string{""}, editor.DefaultZoneConfig())
+	s.Require().Error(err)
+}
+
+func (s *RFC1035ManagerTestSuite) TestAdjustEndpoints() {
+	endpoints := []*endpoint.Endpoint{
+		endpoint.NewEndpoint("www.example.com", endpoint.RecordTypeA, "192.0.2.1"),
+		endpoint.NewEndpoint("txt.example.com", endpoint.RecordTypeTXT, "\"text\""),
+		endpoint.NewEndpoint("caa.example.com", "CAA", "0 issue \"ca.example.net\""),
+		endpoint.NewEndpoint("www.example.org", endpoint.RecordTypeA, "192.0.2.1"),
+	}
+	adjusted, err := s.manager.AdjustEndpoints(endpoints)
+	s.Require().NoError(err)
+	s.Equal(endpoints[:2], adjusted)
+}
+
+func (s *RFC1035ManagerTestSuite) TestAdjustEndpoints_RDATA() {
+	adjusted, err := s.manager.AdjustEndpoints([]*endpoint.Endpoint{
+		endpoint.NewEndpoint("_sip._tcp.example.com", endpoint.RecordTypeSRV, "10  5 5060 sip.example.com.", "20 0 5060 SIP2.example.com"),
+		endpoint.NewEndpoint("example.com", endpoint.RecordTypeMX, "10 mail.example.com."),
+		endpoint.NewEndpoint("naptr.example.com", endpoint.RecordTypeNAPTR, "100 10 \"u\" \"E2U+sip\" \"!^.*$!sip:info@example.com!\" ."),
+		endpoint.NewEndpoint("invalid.example.com", endpoint.RecordTypeSRV, "sip.example.com"),
+		endpoint.NewEndpoint("invalid.example.com", endpoint.RecordTypeMX, "10"),
+	})
+	s.Require().NoError(err)
+	s.Require().Len(adjusted, 3, "The records with an invalid target should be dropped")
+	s.Equal(endpoint.Targets{"10 5 5060 sip.example.com", "20 0 5060 SIP2.example.com"}, adjusted[0].Targets)
+	s.Equal(endpoint.Targets{"10 mail.example.com"}, adjusted[1].Targets)
+	s.Equal(endpoint.Targets{"100 10 \"u\" \"E2U+sip\" \"!^.*$!sip:info@example.com!\" ."}, adjusted[2].Targets)
+}
+
+func (s *RFC1035ManagerTestSuite) TestApplyChanges_RDATA() {
+	desired := []*endpoint.Endpoint{
+		endpoint.NewEndpointWithTTL("_sip._tcp.example.com", endpoint.RecordTypeSRV, 300, "10 5 5060 sip.example.com", "20 0 5060 sip2.example.com"),
+		endpoint.NewEndpointWithTTL("example.com", endpoint.RecordTypeMX, 300, "10 mail.example.com"),
+		endpoint.NewEndpointWithTTL("naptr.example.com", endpoint.RecordTypeNAPTR, 300, "100 10 \"s\" \"SIP+D2U\" \"\" _sip._udp.example.com"),
+	}
+	adjusted, err := s.manager.AdjustEndpoints(desired)
+	s.Require().NoError(err)
+	s.Require().NoError(s.manager.ApplyChanges(context.Background(), &plan.Changes{Create: adjusted}))
+
+	zoneFile := s.configMapData()["db.example.com"]
+	s.Contains(zoneFile, "_sip._tcp.example.com.\t300\tIN\tSRV\t10 5 5060 sip.example.com.\n")
+	s.Contains(zoneFile, "_sip._tcp.example.com.\t300\tIN\tSRV\t20 0 5060 sip2.example.com.\n")
+	s.Contains(zoneFile, "example.com.\t300\tIN\tMX\t10 mail.example.com.\n")
+	s.Contains(zoneFile, "naptr.example.com.\t300\tIN\tNAPTR\t100 10 \"s\" \"SIP+D2U\" \"\" _sip._udp.example.com.\n")
+
+	records, err := s.manager.Records(context.Background())
+	s.Require().NoError(err)
+	s.ElementsMatch(adjusted, records)
+
+	// The records read back should match the desired records
+	p := &plan.Plan{
+		Current:        records,
+		Desired:        adjusted,
+		ManagedRecords: []string{endpoint.RecordTypeSRV, endpoint.RecordTypeMX, endpoint.RecordTypeNAPTR},
+	}
+	s.False(p.Calculate().Changes.HasChanges(), "The written records should not drift from the desired records")
+}
+
+func (s *RFC1035ManagerTestSuite) TestApplyChanges_MultipleTargetsAndTTL() {
+	longTXT := "\"" + strings.Repeat("x", 300) + "\""
+	desired := []*endpoint.Endpoint{
+		endpoint.NewEndpointWithTTL("www.example.com", endpoint.RecordTypeA, 300, "192.0.2.1", "192.0.2.2", "192.0.2.3"),
+		endpoint.NewEndpointWithTTL("txt.example.com", endpoint.RecordTypeTXT, 60, "\"first\"", longTXT),
+		endpoint.NewEndpoint("ns.example.com", endpoint.RecordTypeNS, "ns1.example.net", "ns2.example.net"),
+	}
+	s.Require().NoError(s.manager.ApplyChanges(context.Background(), &plan.Changes{Create: desired}))
+
+	zoneFile := s.configMapData()["db.example.com"]
+	s.Contains(zoneFile, "www.example.com.\t300\tIN\tA\t192.0.2.1\n")
+	s.Contains(zoneFile, "www.example.com.\t300\tIN\tA\t192.0.2.2\n")
+	s.Contains(zoneFile, "www.example.com.\t300\tIN\tA\t192.0.2.3\n")
+	s.Contains(zoneFile, "txt.example.com.\t60\tIN\tTXT\t\"first\"\n")
+
+	records, err := s.manager.Records(context.Background())
+	s.Require().NoError(err)
+	s.ElementsMatch([]*endpoint.Endpoint{
+		endpoint.NewEndpointWithTTL("www.example.com.", endpoint.RecordTypeA, 300, "192.0.2.1", "192.0.2.2", "192.0.2.3"),
+		endpoint.NewEndpointWithTTL("txt.example.com.", endpoint.RecordTypeTXT, 60, "first", strings.Trim(longTXT, "\"")),
+		endpoint.NewEndpoint("ns.example.com.", endpoint.RecordTypeNS, "ns1.example.net", "ns2.example.net"),
+	}, records)
+
+	// The records read back should match the desired records
+	p := &plan.Plan{
+		Current:        records,
+		Desired:        desired[:1],
+		ManagedRecords: []string{endpoint.RecordTypeA},
+	}
+	s.False(p.Calculate().Changes.HasChanges(), "The written records should not drift from the desired records")
+
+	// Updating the targets replaces the whole RRset
+	s.Require().NoError(s.manager.ApplyChanges(context.Background(), &plan.Changes{
+		UpdateOld: []*endpoint.Endpoint{desired[0]},
+		UpdateNew: []*endpoint.Endpoint{endpoint.NewEndpointWithTTL("www.example.com", endpoint.RecordTypeA, 600, "192.0.2.4")},
+	}))
+	records, err = s.manager.Records(context.Background())
+	s.Require().NoError(err)
+	s.Contains(records, endpoint.NewEndpointWithTTL("www.example.com.", endpoint.RecordTypeA, 600, "192.0.2.4"))
+	s.NotContains(s.configMapData()["db.example.com"], "192.0.2.1")
+
+	// Deleting the endpoint deletes the whole RRset
+	s.Require().NoError(s.manager.ApplyChanges(context.Background(), &plan.Changes{
+		Delete: []*endpoint.Endpoint{desired[1]},
+	}))
+	s.NotContains(s.configMapData()["db.example.com"], "txt.example.com")
+}
+
+func (s *RFC1035ManagerTestSuite) TestImportManager() {
+	// The Corefile imports the files of the coredns-custom ConfigMap, as done on managed clusters
+	corefile := strings.Replace(testCorefile, "    errors\n", "    errors\n    import custom/*.override\n", 1)
+	cfgMap, err := s.client.CoreV1().ConfigMaps("kube-system").Get(context.Background(), "coredns", metav1.GetOptions{})
+	s.Require().NoError(err)
+	cfgMap.Data[k8s.CorefileKey] = corefile
+	_, err = s.client.CoreV1().ConfigMaps("kube-system").Update(context.Background(), cfgMap, metav1.UpdateOptions{})
+	s.Require().NoError(err)
+	volumeItems := s.volumeItems()
+
+	mgr, err := NewRFC1035ImportManager(s.client, "coredns", "coredns-custom", "kube-system", "", "/etc/coredns/custom", []string{"example.com", "example.net"}, editor.DefaultZoneConfig())
+	s.Require().NoError(err)
+	mgr.backoff = testBackoff
+	importCfgMap, err := s.client.CoreV1().ConfigMaps("kube-system").Get(context.Background(), "coredns-custom", metav1.GetOptions{})
+	s.Require().NoError(err, "The import ConfigMap should be created")
+	s.Equal(importLabels, importCfgMap.Labels)
+
+	s.Require().NoError(mgr.ApplyChanges(context.Background(), &plan.Changes{
+		Create: []*endpoint.Endpoint{
+			endpoint.NewEndpoint("www.example.com", endpoint.RecordTypeA, "192.0.2.1"),
+			endpoint.NewEndpoint("www.example.net", endpoint.RecordTypeA, "192.0.2.2"),
+		},
+	}))
+	importCfgMap, err = s.client.CoreV1().ConfigMaps("kube-system").Get(context.Background(), "coredns-custom", metav1.GetOptions{})
+	s.Require().NoError(err)
+	s.Equal("file /etc/coredns/custom/db.example.com example.com\nfile /etc/coredns/custom/db.example.net example.net\n", importCfgMap.Data[k8s.ImportKey])
+	s.Contains(importCfgMap.Data["db.example.com"], "www.example.com.")
+	s.Contains(importCfgMap.Data["db.example.net"], "www.example.net.")
+	s.Equal(map[string]string{k8s.CorefileKey: corefile}, s.configMapData(), "The CoreDNS ConfigMap should not be modified")
+	s.Equal(volumeItems, s.volumeItems(), "The CoreDNS Deployment should not be modified")
+
+	records, err := mgr.Records(context.Background())
+	s.Require().NoError(err)
+	s.Len(records, 2)
+
+	// Removing the last record of a zone removes its zone file and file entry
+	s.Require().NoError(mgr.ApplyChanges(context.Background(), &plan.Changes{
+		Delete: []*endpoint.Endpoint{endpoint.NewEndpoint("www.example.net", endpoint.RecordTypeA, "192.0.2.2")},
+	}))
+	importCfgMap, err = s.client.CoreV1().ConfigMaps("kube-system").Get(context.Background(), "coredns-custom", metav1.GetOptions{})
+	s.Require().NoError(err)
+	s.NotContains(importCfgMap.Data, "db.example.net")
+	s.Equal("file /etc/coredns/custom/db.example.com example.com\n", importCfgMap.Data[k8s.ImportKey])
+}
+
+func (s *RFC1035ManagerTestSuite) TestImportManager_NotImported() {
+	_, err := NewRFC1035ImportManager(s.client, "coredns", "coredns-custom", "kube-system", "", "/etc/coredns/custom", []string{"example.com"}, editor.DefaultZoneConfig())
+	s.Require().Error(err)
+	s.Contains(err.Error(), "import /etc/coredns/custom/external-dns.override")
+}
+
+func TestRFC1035ManagerTestSuite(t *testing.T) {
+	suite.Run(t, new(RFC1035ManagerTestSuite))
+}
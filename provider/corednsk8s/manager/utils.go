@@ -1,15 +1,36 @@
 package manager
 
 import (
+	"fmt"
 	"net"
 	"strings"
 
 	"github.com/miekg/dns"
+	log "github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/retry"
 	"sigs.k8s.io/external-dns/endpoint"
 )
 
-// EndpointToRecord converts an endpoint to a DNS record.
-func endpointToRecord(endpt *endpoint.Endpoint) dns.RR {
+// maxTXTStringLength is the maximum length of a character string of a TXT record
+const maxTXTStringLength = 255
+
+// endpointToRecords converts an endpoint to a DNS record per target
+//
+// The records of an endpoint are an RRset: they share the name, type and TTL of the endpoint.
+func endpointToRecords(endpt *endpoint.Endpoint) []dns.RR {
+	records := make([]dns.RR, 0, len(endpt.Targets))
+	for _, target := range endpt.Targets {
+		if record := targetToRecord(endpt, target); record != nil {
+			records = append(records, record)
+		}
+	}
+	return records
+}
+
+// targetToRecord converts a target of an endpoint to a DNS record, or returns nil if the record type is not supported
+func targetToRecord(endpt *endpoint.Endpoint, target string) dns.RR {
 	switch endpt.RecordType {
 	case endpoint.RecordTypeA:
 		return &dns.A{
@@ -19,7 +40,7 @@ func endpointToRecord(endpt *endpoint.Endpoint) dns.RR {
 				Class:  dns.ClassINET,
 				Ttl:    uint32(endpt.RecordTTL),
 			},
-			A: net.ParseIP(endpt.Targets[0]),
+			A: net.ParseIP(target),
 		}
 	case endpoint.RecordTypeCNAME:
 		return &dns.CNAME{
@@ -29,14 +50,9 @@ func endpointToRecord(endpt *endpoint.Endpoint) dns.RR {
 				Class:  dns.ClassINET,
 				Ttl:    uint32(endpt.RecordTTL),
 			},
-			Target: endpt.Targets[0],
+			Target: dns.Fqdn(target),
 		}
 	case endpoint.RecordTypeTXT:
-		// Remove quotes from targets
-		trimmedTargets := make([]string, len(endpt.Targets))
-		for i, target := range endpt.Targets {
-			trimmedTargets[i] = strings.Trim(target, "\"")
-		}
 		return &dns.TXT{
 			Hdr: dns.RR_Header{
 				Name:   dns.Fqdn(endpt.DNSName),
@@ -44,18 +60,10 @@ func endpointToRecord(endpt *endpoint.Endpoint) dns.RR {
 				Class:  dns.ClassINET,
 				Ttl:    uint32(endpt.RecordTTL),
 			},
-			Txt: trimmedTargets,
-		}
-	case endpoint.RecordTypeSRV:
-		return &dns.SRV{
-			Hdr: dns.RR_Header{
-				Name:   dns.Fqdn(endpt.DNSName),
-				Rrtype: dns.TypeSRV,
-				Class:  dns.ClassINET,
-				Ttl:    uint32(endpt.RecordTTL),
-			},
-			Target: endpt.Targets[0],
+			Txt: splitTXT(strings.Trim(target, "\"")),
 		}
+	case endpoint.RecordTypeSRV, endpoint.RecordTypeMX, endpoint.RecordTypeNAPTR:
+		return parseRDATA(endpt, target)
 	case endpoint.RecordTypePTR:
 		return &dns.PTR{
 			Hdr: dns.RR_Header{
@@ -64,17 +72,7 @@ func endpointToRecord(endpt *endpoint.Endpoint) dns.RR {
 				Class:  dns.ClassINET,
 				Ttl:    uint32(endpt.RecordTTL),
 			},
-			Ptr: endpt.Targets[0],
-		}
-	case endpoint.RecordTypeMX:
-		return &dns.MX{
-			Hdr: dns.RR_Header{
-				Name:   dns.Fqdn(endpt.DNSName),
-				Rrtype: dns.TypeMX,
-				Class:  dns.ClassINET,
-				Ttl:    uint32(endpt.RecordTTL),
-			},
-			Mx: endpt.Targets[0],
+			Ptr: dns.Fqdn(target),
 		}
 	case endpoint.RecordTypeAAAA:
 		return &dns.AAAA{
@@ -84,7 +82,7 @@ func endpointToRecord(endpt *endpoint.Endpoint) dns.RR {
 				Class:  dns.ClassINET,
 				Ttl:    uint32(endpt.RecordTTL),
 			},
-			AAAA: net.ParseIP(endpt.Targets[0]),
+			AAAA: net.ParseIP(target),
 		}
 	case endpoint.RecordTypeNS:
 		return &dns.NS{
@@ -94,8 +92,133 @@ func endpointToRecord(endpt *endpoint.Endpoint) dns.RR {
 				Class:  dns.ClassINET,
 				Ttl:    uint32(endpt.RecordTTL),
 			},
-			Ns: endpt.Targets[0],
+			Ns: dns.Fqdn(target),
 		}
 	}
 	return nil
 }
+
+// recordsToEndpoints groups the records with the same name and type into an endpoint
+//
+// The endpoints are returned in the order of their first record, with the TTL of their first record.
+// Records of unsupported types are ignored.
+func recordsToEndpoints(records []dns.RR) []*endpoint.Endpoint {
+	type key struct {
+		name       string
+		recordType string
+	}
+	endpoints := make([]*endpoint.Endpoint, 0)
+	byKey := make(map[key]*endpoint.Endpoint)
+	for _, record := range records {
+		target, ok := recordTarget(record)
+		if !ok {
+			continue
+		}
+		recordType := dns.TypeToString[record.Header().Rrtype]
+		k := key{name: strings.ToLower(dns.Fqdn(record.Header().Name)), recordType: recordType}
+		endPt, ok := byKey[k]
+		if !ok {
+			endPt = endpoint.NewEndpointWithTTL(record.Header().Name, recordType, endpoint.TTL(record.Header().Ttl))
+			byKey[k] = endPt
+			endpoints = append(endpoints, endPt)
+		}
+		endPt.Targets = append(endPt.Targets, target)
+	}
+	return endpoints
+}
+
+// recordTarget returns the target of a DNS record, as written by targetToRecord
+//
+// Domain names are returned without their trailing dot, and SRV, MX and NAPTR records in their
+// presentation form (e.g. "10 5 443 host.example.com").
+func recordTarget(record dns.RR) (string, bool) {
+	switch r := record.(type) {
+	case *dns.A:
+		return r.A.String(), true
+	case *dns.AAAA:
+		return r.AAAA.String(), true
+	case *dns.CNAME:
+		return trimDomain(r.Target), true
+	case *dns.TXT:
+		return strings.Join(r.Txt, ""), true
+	case *dns.SRV:
+		return fmt.Sprintf("%d %d %d %s", r.Priority, r.Weight, r.Port, trimDomain(r.Target)), true
+	case *dns.PTR:
+		return trimDomain(r.Ptr), true
+	case *dns.MX:
+		return fmt.Sprintf("%d %s", r.Preference, trimDomain(r.Mx)), true
+	case *dns.NS:
+		return trimDomain(r.Ns), true
+	case *dns.NAPTR:
+		rdata := strings.TrimPrefix(r.String(), r.Hdr.String())
+		return strings.TrimSuffix(rdata, r.Replacement) + trimDomain(r.Replacement), true
+	}
+	return "", false
+}
+
+// parseRDATA parses a target in presentation form (e.g. "10 5 443 host.example.com") into a DNS record
+//
+// Domain names without a trailing dot are fully qualified. It returns nil if the target is invalid.
+func parseRDATA(endpt *endpoint.Endpoint, target string) dns.RR {
+	target = strings.TrimSpace(target)
+	// endpoint.NewEndpoint trims the trailing dot of the targets, which is the whole "." replacement of a NAPTR record
+	if endpt.RecordType == endpoint.RecordTypeNAPTR && strings.HasSuffix(target, "\"") {
+		target += " ."
+	}
+	record, err := dns.NewRR(fmt.Sprintf("%s %d IN %s %s", dns.Fqdn(endpt.DNSName), endpt.RecordTTL, endpt.RecordType, target))
+	if err != nil || record == nil {
+		return nil
+	}
+	return record
+}
+
+// canonicalTarget returns the target as it is read back from its DNS record, or false if it is invalid
+func canonicalTarget(endpt *endpoint.Endpoint, target string) (string, bool) {
+	record := targetToRecord(endpt, target)
+	if record == nil {
+		return "", false
+	}
+	return recordTarget(record)
+}
+
+// trimDomain removes the trailing dot of a domain name, except for the root domain
+func trimDomain(name string) string {
+	if name == "." {
+		return name
+	}
+	return strings.TrimSuffix(name, ".")
+}
+
+// splitTXT splits a TXT target into character strings of at most 255 bytes
+func splitTXT(target string) []string {
+	chunks := make([]string, 0, len(target)/maxTXTStringLength+1)
+	for len(target) > maxTXTStringLength {
+		chunks = append(chunks, target[:maxTXTStringLength])
+		target = target[maxTXTStringLength:]
+	}
+	return append(chunks, target)
+}
+
+// isZoneRecordType returns true if the record type can be converted by endpointToRecords
+func isZoneRecordType(recordType string) bool {
+	switch recordType {
+	case endpoint.RecordTypeA, endpoint.RecordTypeAAAA, endpoint.RecordTypeCNAME, endpoint.RecordTypeTXT,
+		endpoint.RecordTypeSRV, endpoint.RecordTypePTR, endpoint.RecordTypeMX, endpoint.RecordTypeNS, endpoint.RecordTypeNAPTR:
+		return true
+	}
+	return false
+}
+
+// retryOnConflict runs the apply function again with backoff when it fails because the ConfigMap was
+// modified concurrently, the apply function is expected to reload the ConfigMap before editing it
+func retryOnConflict(backoff wait.Backoff, apply func() error) error {
+	attempt := 0
+	return retry.RetryOnConflict(backoff, func() error {
+		attempt++
+		err := apply()
+		if apierrors.IsConflict(err) {
+			log.WithField("attempt", attempt).Warn("CoreDNS configuration modified concurrently, reloading it to apply the changes again")
+		}
+		return err
+	})
+}
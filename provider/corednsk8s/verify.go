@@ -0,0 +1,127 @@
+package corednsk8s
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/external-dns/plan"
+	"sigs.k8s.io/external-dns/provider/corednsk8s/editor"
+	"sigs.k8s.io/external-dns/provider/corednsk8s/k8s"
+	"sigs.k8s.io/external-dns/provider/corednsk8s/verify"
+)
+
+const (
+	// kubeDNSService is the Service of the CoreDNS Deployment queried by default to verify the changes
+	kubeDNSService = "kube-dns"
+
+	verifyResultSuccess        = "success"
+	verifyResultTimeout        = "timeout"
+	verifyResultNoReloadPlugin = "no_reload_plugin"
+	verifyResultError          = "error"
+)
+
+var (
+	reloadVerificationsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "external_dns",
+			Subsystem: "coredns_k8s",
+			Name:      "reload_verifications_total",
+			Help:      "Number of verifications that CoreDNS serves the applied changes, by result.",
+		},
+		[]string{"result"},
+	)
+	reloadVerificationDuration = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Namespace: "external_dns",
+			Subsystem: "coredns_k8s",
+			Name:      "reload_verification_duration_seconds",
+			Help:      "Time for CoreDNS to serve the applied changes.",
+			Buckets:   []float64{1, 5, 10, 30, 60, 90, 120, 180, 300},
+		},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(reloadVerificationsTotal)
+	prometheus.MustRegister(reloadVerificationDuration)
+}
+
+// newVerifier creates the verifier of the applied changes, querying the server or the kube-dns Service by default
+func newVerifier(ctx context.Context, client kubernetes.Interface, cfg CoreDNSConfig) (*verify.Verifier, error) {
+	server := cfg.VerifyServer
+	if server == "" {
+		svc, err := client.CoreV1().Services(cfg.CoreDNSNamespace).Get(ctx, kubeDNSService, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to find the CoreDNS server to verify the changes: %w", err)
+		}
+		if svc.Spec.ClusterIP == "" || svc.Spec.ClusterIP == "None" {
+			return nil, fmt.Errorf("service %s/%s has no cluster IP to verify the changes", svc.Namespace, svc.Name)
+		}
+		server = net.JoinHostPort(svc.Spec.ClusterIP, "53")
+	}
+	return verify.NewVerifier(server, cfg.VerifyTimeout, cfg.VerifyInterval), nil
+}
+
+// startVerification verifies in the background that CoreDNS serves the applied changes, so that the run
+// does not wait for the reload. The result is reported in the metrics and the logs. A verification still
+// running is canceled, since the new changes supersede the ones it waits for.
+func (c *coreDNSk8sProvider) startVerification(changes *plan.Changes) {
+	ctx, cancel := context.WithCancel(context.Background())
+	c.verifyMu.Lock()
+	if c.cancelVerify != nil {
+		c.cancelVerify()
+	}
+	c.cancelVerify = cancel
+	c.verifyMu.Unlock()
+
+	c.verifications.Add(1)
+	go func() {
+		defer c.verifications.Done()
+		defer cancel()
+		start := time.Now()
+		result, err := c.verifyReload(ctx, changes)
+		if errors.Is(ctx.Err(), context.Canceled) {
+			log.Debug("The verification of the CoreDNS reload was superseded by new changes")
+			return
+		}
+		reloadVerificationsTotal.WithLabelValues(result).Inc()
+		if err != nil {
+			log.Warnf("The changes are applied, but may not be served by CoreDNS yet: %v", err)
+			return
+		}
+		reloadVerificationDuration.Observe(time.Since(start).Seconds())
+	}()
+}
+
+// verifyReload checks that CoreDNS serves the applied changes, and returns the result of the verification
+//
+// A Corefile without the reload plugin, or changes not served before the timeout, are reported as errors:
+// the changes are applied, but may not be served yet.
+func (c *coreDNSk8sProvider) verifyReload(ctx context.Context, changes *plan.Changes) (string, error) {
+	corefile, err := k8s.NewConfigMap(c.client.CoreV1(), c.namespace, c.configMap).GetCoreDNSConfig(ctx)
+	if err != nil {
+		return verifyResultError, fmt.Errorf("failed to verify the CoreDNS reload: %w", err)
+	}
+	corefileEditor := editor.NewCoreDNSConfigEditor()
+	if c.serverBlock != "" {
+		corefileEditor.SetServerBlock(c.serverBlock)
+	}
+	if err := corefileEditor.LoadCorefile(corefile); err != nil {
+		return verifyResultError, fmt.Errorf("failed to verify the CoreDNS reload: %w", err)
+	}
+	if !corefileEditor.HasPlugin("reload") {
+		return verifyResultNoReloadPlugin, errors.New("the reload plugin is not configured in the Corefile, CoreDNS will not serve the changes until it is restarted")
+	}
+
+	if err := c.verifier.Verify(ctx, changes); err != nil {
+		return verifyResultTimeout, err
+	}
+	return verifyResultSuccess, nil
+}
@@ -0,0 +1,230 @@
+package verify
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+	log "github.com/sirupsen/logrus"
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/plan"
+)
+
+const (
+	// DefaultInterval is the default interval between the queries to the CoreDNS server
+	DefaultInterval = 5 * time.Second
+	// queryTimeout is the timeout of a single query to the CoreDNS server
+	queryTimeout = 2 * time.Second
+)
+
+// Verifier queries the CoreDNS server until it serves the applied changes
+type Verifier struct {
+	// server is the address of the CoreDNS server (e.g. "10.96.0.10:53")
+	server   string
+	timeout  time.Duration
+	interval time.Duration
+	client   *dns.Client
+}
+
+// NewVerifier creates a new Verifier querying the server, giving up after the timeout
+func NewVerifier(server string, timeout, interval time.Duration) *Verifier {
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		server = net.JoinHostPort(server, "53")
+	}
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	return &Verifier{
+		server:   server,
+		timeout:  timeout,
+		interval: interval,
+		client:   &dns.Client{Timeout: queryTimeout},
+	}
+}
+
+// expectation is the state of a record set expected after the changes are applied
+type expectation struct {
+	name  string
+	qtype uint16
+	// present are the targets which should be served
+	present []string
+	// absent are the targets which should not be served anymore
+	absent []string
+}
+
+// Verify waits until the CoreDNS server serves the changes, or returns an error once the timeout is reached
+//
+// Created and updated targets should be served, while deleted targets should not be served anymore.
+// Deleted names are not expected to be missing, since the hosts plugin may fall through to an upstream
+// server serving them.
+func (v *Verifier) Verify(ctx context.Context, changes *plan.Changes) error {
+	pending := expectations(changes)
+	if len(pending) == 0 {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, v.timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(v.interval)
+	defer ticker.Stop()
+	for {
+		remaining := make([]expectation, 0, len(pending))
+		for _, exp := range pending {
+			ok, err := v.check(ctx, exp)
+			if err != nil {
+				log.WithFields(log.Fields{
+					"record": exp.name,
+					"type":   dns.TypeToString[exp.qtype],
+				}).Debugf("Failed to query CoreDNS: %v", err)
+			}
+			if !ok {
+				remaining = append(remaining, exp)
+			}
+		}
+		pending = remaining
+		if len(pending) == 0 {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			names := make([]string, len(pending))
+			for i, exp := range pending {
+				names[i] = fmt.Sprintf("%s %s", exp.name, dns.TypeToString[exp.qtype])
+			}
+			return fmt.Errorf("CoreDNS at %s did not serve the changes of %s after %s", v.server, strings.Join(names, ", "), v.timeout)
+		case <-ticker.C:
+		}
+	}
+}
+
+// check queries the record set and returns true if it matches the expectation
+func (v *Verifier) check(ctx context.Context, exp expectation) (bool, error) {
+	msg := new(dns.Msg)
+	msg.SetQuestion(exp.name, exp.qtype)
+	resp, _, err := v.client.ExchangeContext(ctx, msg, v.server)
+	if err != nil {
+		return false, err
+	}
+	served := make(map[string]bool)
+	for _, rr := range resp.Answer {
+		if rr.Header().Rrtype == exp.qtype && strings.EqualFold(rr.Header().Name, exp.name) {
+			for _, target := range recordTargets(rr) {
+				served[target] = true
+			}
+		}
+	}
+	for _, target := range exp.present {
+		if !served[target] {
+			return false, nil
+		}
+	}
+	for _, target := range exp.absent {
+		if served[target] {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// expectations returns the expected state of every record set touched by the changes
+func expectations(changes *plan.Changes) []expectation {
+	type key struct {
+		name  string
+		qtype uint16
+	}
+	present := make(map[key]map[string]bool)
+	absent := make(map[key]map[string]bool)
+	add := func(sets map[key]map[string]bool, endpoints []*endpoint.Endpoint) {
+		for _, ep := range endpoints {
+			qtype, ok := dns.StringToType[ep.RecordType]
+			if !ok {
+				continue
+			}
+			k := key{name: strings.ToLower(dns.Fqdn(ep.DNSName)), qtype: qtype}
+			if sets[k] == nil {
+				sets[k] = make(map[string]bool)
+			}
+			for _, target := range ep.Targets {
+				sets[k][endpointTarget(ep.RecordType, target)] = true
+			}
+		}
+	}
+	add(absent, changes.Delete)
+	add(absent, changes.UpdateOld)
+	add(present, changes.Create)
+	add(present, changes.UpdateNew)
+
+	keys := make([]key, 0)
+	for k := range present {
+		keys = append(keys, k)
+	}
+	for k := range absent {
+		if _, ok := present[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].name != keys[j].name {
+			return keys[i].name < keys[j].name
+		}
+		return keys[i].qtype < keys[j].qtype
+	})
+
+	result := make([]expectation, 0, len(keys))
+	for _, k := range keys {
+		exp := expectation{name: k.name, qtype: k.qtype}
+		for target := range present[k] {
+			exp.present = append(exp.present, target)
+		}
+		for target := range absent[k] {
+			// A target which is deleted and created again should be served
+			if !present[k][target] {
+				exp.absent = append(exp.absent, target)
+			}
+		}
+		result = append(result, exp)
+	}
+	return result
+}
+
+// endpointTarget returns the target of an endpoint in the form returned by recordTargets
+func endpointTarget(recordType, target string) string {
+	switch recordType {
+	case endpoint.RecordTypeA, endpoint.RecordTypeAAAA:
+		if ip := net.ParseIP(target); ip != nil {
+			return ip.String()
+		}
+	case endpoint.RecordTypeTXT:
+		return strings.Trim(target, "\"")
+	}
+	// Parse the target as the RDATA of a record, so "10 5 443 host" matches "10 5 443 host."
+	if rr, err := dns.NewRR(fmt.Sprintf(". IN %s %s", recordType, target)); err == nil && rr != nil {
+		return rdata(rr)
+	}
+	return strings.ToLower(dns.Fqdn(target))
+}
+
+// recordTargets returns the targets of a served record
+//
+// The strings of a TXT record are joined, since a long target is split into several strings.
+func recordTargets(rr dns.RR) []string {
+	switch r := rr.(type) {
+	case *dns.A:
+		return []string{r.A.String()}
+	case *dns.AAAA:
+		return []string{r.AAAA.String()}
+	case *dns.TXT:
+		return []string{strings.Join(r.Txt, "")}
+	}
+	return []string{rdata(rr)}
+}
+
+// rdata returns the lower case presentation form of the RDATA of a record (e.g. "10 5 443 host.")
+func rdata(rr dns.RR) string {
+	return strings.ToLower(strings.TrimPrefix(rr.String(), rr.Header().String()))
+}
@@ -0,0 +1,174 @@
+package verify
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/suite"
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/plan"
+)
+
+// testServer is an in-process DNS server serving the records it is given
+type testServer struct {
+	server  *dns.Server
+	addr    string
+	mu      sync.Mutex
+	records []dns.RR
+	queries int
+}
+
+func newTestServer(t *testing.T) *testServer {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &testServer{addr: conn.LocalAddr().String()}
+	started := make(chan struct{})
+	s.server = &dns.Server{PacketConn: conn, Handler: s, NotifyStartedFunc: func() { close(started) }}
+	go func() {
+		_ = s.server.ActivateAndServe()
+	}()
+	<-started
+	t.Cleanup(func() {
+		_ = s.server.Shutdown()
+	})
+	return s
+}
+
+// ServeDNS answers with the records matching the question
+func (s *testServer) ServeDNS(w dns.ResponseWriter, r *dns.Msg) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.queries++
+	msg := new(dns.Msg)
+	msg.SetReply(r)
+	for _, rr := range s.records {
+		if rr.Header().Name == r.Question[0].Name && rr.Header().Rrtype == r.Question[0].Qtype {
+			msg.Answer = append(msg.Answer, rr)
+		}
+	}
+	_ = w.WriteMsg(msg)
+}
+
+// serve replaces the served records
+func (s *testServer) serve(records ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records = nil
+	for _, record := range records {
+		rr, err := dns.NewRR(record)
+		if err != nil {
+			panic(err)
+		}
+		s.records = append(s.records, rr)
+	}
+}
+
+type VerifierTestSuite struct {
+	suite.Suite
+	server *testServer
+}
+
+func (s *VerifierTestSuite) SetupTest() {
+	s.server = newTestServer(s.T())
+}
+
+func (s *VerifierTestSuite) TestVerify_Served() {
+	s.server.serve(
+		"www.example.com. 300 IN A 192.0.2.1",
+		"www.example.com. 300 IN A 192.0.2.2",
+		"txt.example.com. 300 IN TXT \"heritage=external-dns\"",
+		"alias.example.com. 300 IN CNAME www.example.com.",
+		"_sip._tcp.example.com. 300 IN SRV 10 5 5060 SIP.example.com.",
+	)
+	verifier := NewVerifier(s.server.addr, time.Second, 10*time.Millisecond)
+
+	err := verifier.Verify(context.Background(), &plan.Changes{
+		Create: []*endpoint.Endpoint{
+			endpoint.NewEndpoint("www.example.com", endpoint.RecordTypeA, "192.0.2.1", "192.0.2.2"),
+			endpoint.NewEndpoint("txt.example.com", endpoint.RecordTypeTXT, "\"heritage=external-dns\""),
+			endpoint.NewEndpoint("Alias.example.com", endpoint.RecordTypeCNAME, "www.example.com"),
+			endpoint.NewEndpoint("_sip._tcp.example.com", endpoint.RecordTypeSRV, "10 5 5060 sip.example.com"),
+		},
+	})
+	s.Require().NoError(err)
+}
+
+func (s *VerifierTestSuite) TestVerify_ServedLater() {
+	s.server.serve("www.example.com. 300 IN A 192.0.2.1")
+	verifier := NewVerifier(s.server.addr, 5*time.Second, 10*time.Millisecond)
+
+	// The change is picked up by CoreDNS after a few queries
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		s.server.serve("www.example.com. 300 IN A 192.0.2.2")
+	}()
+	err := verifier.Verify(context.Background(), &plan.Changes{
+		UpdateOld: []*endpoint.Endpoint{endpoint.NewEndpoint("www.example.com", endpoint.RecordTypeA, "192.0.2.1")},
+		UpdateNew: []*endpoint.Endpoint{endpoint.NewEndpoint("www.example.com", endpoint.RecordTypeA, "192.0.2.2")},
+	})
+	s.Require().NoError(err)
+	s.Greater(s.server.queries, 1, "The server should be queried until it serves the change")
+}
+
+func (s *VerifierTestSuite) TestVerify_Deleted() {
+	s.server.serve("www.example.com. 300 IN A 192.0.2.1")
+	verifier := NewVerifier(s.server.addr, 100*time.Millisecond, 10*time.Millisecond)
+	changes := &plan.Changes{
+		Delete: []*endpoint.Endpoint{endpoint.NewEndpoint("www.example.com", endpoint.RecordTypeA, "192.0.2.1")},
+	}
+
+	s.Require().Error(verifier.Verify(context.Background(), changes), "The deleted record is still served")
+
+	// A record served by an upstream server after the deletion should not fail the verification
+	s.server.serve("www.example.com. 300 IN A 203.0.113.1")
+	s.Require().NoError(verifier.Verify(context.Background(), changes))
+}
+
+func (s *VerifierTestSuite) TestVerify_Timeout() {
+	verifier := NewVerifier(s.server.addr, 100*time.Millisecond, 10*time.Millisecond)
+
+	err := verifier.Verify(context.Background(), &plan.Changes{
+		Create: []*endpoint.Endpoint{
+			endpoint.NewEndpoint("www.example.com", endpoint.RecordTypeA, "192.0.2.1"),
+			endpoint.NewEndpoint("v6.example.com", endpoint.RecordTypeAAAA, "2001:db8::1"),
+		},
+	})
+	s.Require().Error(err)
+	s.Contains(err.Error(), "www.example.com. A")
+	s.Contains(err.Error(), "v6.example.com. AAAA")
+}
+
+func (s *VerifierTestSuite) TestVerify_NoChanges() {
+	verifier := NewVerifier("192.0.2.1", time.Second, 10*time.Millisecond)
+	s.Require().NoError(verifier.Verify(context.Background(), &plan.Changes{}))
+	s.Equal("192.0.2.1:53", verifier.server, "The port should default to 53")
+}
+
+func (s *VerifierTestSuite) TestExpectations() {
+	exps := expectations(&plan.Changes{
+		Create: []*endpoint.Endpoint{endpoint.NewEndpoint("a.example.com", endpoint.RecordTypeA, "192.0.2.1")},
+		UpdateOld: []*endpoint.Endpoint{
+			endpoint.NewEndpoint("b.example.com", endpoint.RecordTypeA, "192.0.2.1", "192.0.2.2"),
+		},
+		UpdateNew: []*endpoint.Endpoint{
+			endpoint.NewEndpoint("b.example.com", endpoint.RecordTypeA, "192.0.2.2", "192.0.2.3"),
+		},
+		Delete: []*endpoint.Endpoint{endpoint.NewEndpoint("c.example.com", endpoint.RecordTypeCNAME, "a.example.com")},
+	})
+	s.Require().Len(exps, 3)
+	s.Equal(expectation{name: "a.example.com.", qtype: dns.TypeA, present: []string{"192.0.2.1"}}, exps[0])
+	s.Equal("b.example.com.", exps[1].name)
+	s.ElementsMatch([]string{"192.0.2.2", "192.0.2.3"}, exps[1].present)
+	s.Equal([]string{"192.0.2.1"}, exps[1].absent, "Targets kept by the update should not be expected absent")
+	s.Equal(expectation{name: "c.example.com.", qtype: dns.TypeCNAME, absent: []string{"a.example.com."}}, exps[2])
+}
+
+func TestVerifierTestSuite(t *testing.T) {
+	suite.Run(t, new(VerifierTestSuite))
+}
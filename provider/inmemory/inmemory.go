@@ -199,6 +199,39 @@ func (im *InMemoryProvider) ApplyChanges(ctx context.Context, changes *plan.Chan
 	return nil
 }
 
+// ApplyChangesWithResults modifies records in memory one change at a time,
+// so that an invalid change fails alone instead of the whole batch.
+// An update is applied together with the old endpoint at the same index.
+func (im *InMemoryProvider) ApplyChangesWithResults(ctx context.Context, changes *plan.Changes) ([]provider.ChangeResult, error) {
+	defer im.OnApplyChanges(ctx, changes)
+
+	zones := im.Zones()
+	results := make([]provider.ChangeResult, 0, len(changes.Create)+len(changes.UpdateNew)+len(changes.Delete))
+	apply := func(ep *endpoint.Endpoint, change *plan.Changes) {
+		var err error
+		if zoneID := im.filter.EndpointZoneID(ep, zones); zoneID != "" {
+			err = im.client.ApplyChanges(ctx, zoneID, change)
+		}
+		results = append(results, provider.ChangeResult{Endpoint: ep, Err: err})
+	}
+
+	for _, ep := range changes.Create {
+		apply(ep, &plan.Changes{Create: []*endpoint.Endpoint{ep}})
+	}
+	for i, ep := range changes.UpdateNew {
+		change := &plan.Changes{UpdateNew: []*endpoint.Endpoint{ep}}
+		if i < len(changes.UpdateOld) {
+			change.UpdateOld = []*endpoint.Endpoint{changes.UpdateOld[i]}
+		}
+		apply(ep, change)
+	}
+	for _, ep := range changes.Delete {
+		apply(ep, &plan.Changes{Delete: []*endpoint.Endpoint{ep}})
+	}
+
+	return results, nil
+}
+
 func copyEndpoints(endpoints []*endpoint.Endpoint) []*endpoint.Endpoint {
 	records := make([]*endpoint.Endpoint, 0, len(endpoints))
 	for _, ep := range endpoints {
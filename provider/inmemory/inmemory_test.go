@@ -28,12 +28,16 @@ import (
 	"sigs.k8s.io/external-dns/provider"
 )
 
-var _ provider.Provider = &InMemoryProvider{}
+var (
+	_ provider.Provider       = &InMemoryProvider{}
+	_ provider.ResultsApplier = &InMemoryProvider{}
+)
 
 func TestInMemoryProvider(t *testing.T) {
 	t.Run("Records", testInMemoryRecords)
 	t.Run("validateChangeBatch", testInMemoryValidateChangeBatch)
 	t.Run("ApplyChanges", testInMemoryApplyChanges)
+	t.Run("ApplyChangesWithResults", testInMemoryApplyChangesWithResults)
 	t.Run("NewInMemoryProvider", testNewInMemoryProvider)
 	t.Run("CreateZone", testInMemoryCreateZone)
 }
@@ -560,6 +564,41 @@ func testInMemoryApplyChanges(t *testing.T) {
 	}
 }
 
+func testInMemoryApplyChangesWithResults(t *testing.T) {
+	im := NewInMemoryProvider()
+	c := &inMemoryClient{}
+	c.zones = getInitData()
+	im.client = c
+
+	created := endpoint.NewEndpoint("new.org", endpoint.RecordTypeA, "1.1.1.1")
+	existing := endpoint.NewEndpoint("example.org", endpoint.RecordTypeA, "1.1.1.1")
+	updateOld := endpoint.NewEndpoint("foo.org", endpoint.RecordTypeCNAME, "4.4.4.4")
+	updateNew := endpoint.NewEndpoint("foo.org", endpoint.RecordTypeCNAME, "5.5.5.5")
+	missing := endpoint.NewEndpoint("missing.org", endpoint.RecordTypeA, "1.1.1.1")
+	deleted := endpoint.NewEndpoint("foo.bar.org", endpoint.RecordTypeA, "5.5.5.5")
+
+	results, err := im.ApplyChangesWithResults(context.Background(), &plan.Changes{
+		Create:    []*endpoint.Endpoint{created, existing},
+		UpdateOld: []*endpoint.Endpoint{updateOld},
+		UpdateNew: []*endpoint.Endpoint{updateNew},
+		Delete:    []*endpoint.Endpoint{missing, deleted},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []provider.ChangeResult{
+		{Endpoint: created},
+		{Endpoint: existing, Err: ErrRecordAlreadyExists},
+		{Endpoint: updateNew},
+		{Endpoint: missing, Err: ErrRecordNotFound},
+		{Endpoint: deleted},
+	}, results)
+
+	expected := getInitData()
+	expected["org"][created.Key()] = created
+	expected["org"][updateNew.Key()] = updateNew
+	delete(expected["org"], deleted.Key())
+	assert.Equal(t, expected, c.zones)
+}
+
 func testNewInMemoryProvider(t *testing.T) {
 	cfg := NewInMemoryProvider()
 	assert.NotNil(t, cfg.client)
@@ -21,6 +21,7 @@ import (
 	"errors"
 	"net"
 	"strings"
+	"time"
 
 	"sigs.k8s.io/external-dns/endpoint"
 	"sigs.k8s.io/external-dns/plan"
@@ -36,6 +37,54 @@ func NewSoftError(err error) error {
 	return errors.Join(SoftError, err)
 }
 
+// RateLimited is an error, that provider returns when the DNS API throttled
+// the requests. The controller backs off before retrying, at least for the
+// delay given with NewRateLimitedError.
+var RateLimited error = errors.New("rate limited")
+
+// AuthFailed is an error, that provider returns when the DNS API refused the
+// credentials. Retrying does not help, so the controller exits on it.
+var AuthFailed error = errors.New("authentication failed")
+
+// InvalidRecord is an error, that provider returns when the DNS API refused a
+// record of the changes. The controller retries at the next interval without
+// backing off, since the failure tells nothing about the DNS API.
+var InvalidRecord error = errors.New("invalid record")
+
+type rateLimitedError struct {
+	error
+	retryAfter time.Duration
+}
+
+func (e *rateLimitedError) Unwrap() error {
+	return e.error
+}
+
+// NewRateLimitedError creates a RateLimited error from the given error, with
+// the delay after which the DNS API accepts the requests again, 0 if unknown
+func NewRateLimitedError(err error, retryAfter time.Duration) error {
+	return &rateLimitedError{error: errors.Join(RateLimited, err), retryAfter: retryAfter}
+}
+
+// RetryAfter returns the delay given with NewRateLimitedError, if any
+func RetryAfter(err error) (time.Duration, bool) {
+	var rateLimited *rateLimitedError
+	if errors.As(err, &rateLimited) && rateLimited.retryAfter > 0 {
+		return rateLimited.retryAfter, true
+	}
+	return 0, false
+}
+
+// NewAuthFailedError creates an AuthFailed error from the given error
+func NewAuthFailedError(err error) error {
+	return errors.Join(AuthFailed, err)
+}
+
+// NewInvalidRecordError creates an InvalidRecord error from the given error
+func NewInvalidRecordError(err error) error {
+	return errors.Join(InvalidRecord, err)
+}
+
 // Provider defines the interface DNS providers should implement.
 type Provider interface {
 	Records(ctx context.Context) ([]*endpoint.Endpoint, error)
@@ -51,6 +100,21 @@ type Provider interface {
 	GetDomainFilter() endpoint.DomainFilter
 }
 
+// ChangeResult is the outcome of the change of one endpoint.
+type ChangeResult struct {
+	Endpoint *endpoint.Endpoint
+	Err      error
+}
+
+// ResultsApplier is implemented by the providers and registries that can apply
+// a part of the changes. ApplyChangesWithResults returns one result per endpoint of
+// Create, UpdateNew and Delete, so that a failing endpoint does not block the others.
+// The error is returned when none of the changes could be applied, and the results
+// are nil when the outcome of each change is unknown.
+type ResultsApplier interface {
+	ApplyChangesWithResults(ctx context.Context, changes *plan.Changes) ([]ChangeResult, error)
+}
+
 type BaseProvider struct{}
 
 func (b BaseProvider) AdjustEndpoints(endpoints []*endpoint.Endpoint) ([]*endpoint.Endpoint, error) {
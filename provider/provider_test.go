@@ -17,9 +17,12 @@ limitations under the License.
 package provider
 
 import (
+	"errors"
+	"fmt"
 	"io"
 	"os"
 	"testing"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
@@ -54,3 +57,25 @@ func TestDifference(t *testing.T) {
 	assert.Equal(t, remove, []string{"foo"})
 	assert.Equal(t, leave, []string{"bar"})
 }
+
+func TestErrors(t *testing.T) {
+	err := errors.New("test")
+
+	rateLimited := fmt.Errorf("applying changes: %w", NewRateLimitedError(err, time.Minute))
+	assert.ErrorIs(t, rateLimited, RateLimited)
+	assert.ErrorIs(t, rateLimited, err)
+	assert.NotErrorIs(t, rateLimited, SoftError)
+	retryAfter, ok := RetryAfter(rateLimited)
+	assert.True(t, ok)
+	assert.Equal(t, time.Minute, retryAfter)
+
+	_, ok = RetryAfter(NewRateLimitedError(err, 0))
+	assert.False(t, ok)
+	_, ok = RetryAfter(NewSoftError(err))
+	assert.False(t, ok)
+
+	assert.ErrorIs(t, NewAuthFailedError(err), AuthFailed)
+	assert.ErrorIs(t, NewAuthFailedError(err), err)
+	assert.ErrorIs(t, NewInvalidRecordError(err), InvalidRecord)
+	assert.NotErrorIs(t, NewInvalidRecordError(err), AuthFailed)
+}
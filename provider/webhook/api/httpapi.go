@@ -19,8 +19,11 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"math"
 	"net"
 	"net/http"
+	"strconv"
 	"time"
 
 	"sigs.k8s.io/external-dns/endpoint"
@@ -45,7 +48,7 @@ func (p *WebhookServer) RecordsHandler(w http.ResponseWriter, req *http.Request)
 		records, err := p.Provider.Records(context.Background())
 		if err != nil {
 			log.Errorf("Failed to get Records: %v", err)
-			w.WriteHeader(http.StatusInternalServerError)
+			writeError(w, err)
 			return
 		}
 		w.Header().Set(ContentTypeHeader, MediaTypeFormatAndVersion)
@@ -64,7 +67,7 @@ func (p *WebhookServer) RecordsHandler(w http.ResponseWriter, req *http.Request)
 		err := p.Provider.ApplyChanges(context.Background(), &changes)
 		if err != nil {
 			log.Errorf("Failed to apply changes: %v", err)
-			w.WriteHeader(http.StatusInternalServerError)
+			writeError(w, err)
 			return
 		}
 		w.WriteHeader(http.StatusNoContent)
@@ -75,6 +78,24 @@ func (p *WebhookServer) RecordsHandler(w http.ResponseWriter, req *http.Request)
 	}
 }
 
+// writeError writes the status code of the error of the provider, so that the webhook provider tells
+// the controller how to retry it.
+func writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, provider.RateLimited):
+		if retryAfter, ok := provider.RetryAfter(err); ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		}
+		w.WriteHeader(http.StatusTooManyRequests)
+	case errors.Is(err, provider.AuthFailed):
+		w.WriteHeader(http.StatusUnauthorized)
+	case errors.Is(err, provider.InvalidRecord):
+		w.WriteHeader(http.StatusUnprocessableEntity)
+	default:
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
 func (p *WebhookServer) AdjustEndpointsHandler(w http.ResponseWriter, req *http.Request) {
 	if req.Method != http.MethodPost {
 		log.Errorf("Unsupported method %s", req.Method)
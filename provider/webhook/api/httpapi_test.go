@@ -30,6 +30,7 @@ import (
 	"github.com/stretchr/testify/require"
 	"sigs.k8s.io/external-dns/endpoint"
 	"sigs.k8s.io/external-dns/plan"
+	"sigs.k8s.io/external-dns/provider"
 )
 
 var records []*endpoint.Endpoint
@@ -112,6 +113,33 @@ func TestRecordsHandlerRecordsWithErrors(t *testing.T) {
 	require.Equal(t, http.StatusInternalServerError, res.StatusCode)
 }
 
+func TestRecordsHandlerRecordsWithTypedErrors(t *testing.T) {
+	for _, tt := range []struct {
+		err        error
+		statusCode int
+		retryAfter string
+	}{
+		{provider.NewRateLimitedError(fmt.Errorf("error"), 1500*time.Millisecond), http.StatusTooManyRequests, "2"},
+		{provider.NewRateLimitedError(fmt.Errorf("error"), 0), http.StatusTooManyRequests, ""},
+		{provider.NewAuthFailedError(fmt.Errorf("error")), http.StatusUnauthorized, ""},
+		{provider.NewInvalidRecordError(fmt.Errorf("error")), http.StatusUnprocessableEntity, ""},
+		{provider.NewSoftError(fmt.Errorf("error")), http.StatusInternalServerError, ""},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/records", nil)
+		w := httptest.NewRecorder()
+
+		providerAPIServer := &WebhookServer{
+			Provider: &FakeWebhookProvider{
+				err: tt.err,
+			},
+		}
+		providerAPIServer.RecordsHandler(w, req)
+		res := w.Result()
+		require.Equal(t, tt.statusCode, res.StatusCode)
+		require.Equal(t, tt.retryAfter, res.Header.Get("Retry-After"))
+	}
+}
+
 func TestRecordsHandlerApplyChangesWithBadRequest(t *testing.T) {
 	req := httptest.NewRequest(http.MethodPost, "/applychanges", nil)
 	w := httptest.NewRecorder()
@@ -23,6 +23,8 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"strconv"
+	"time"
 
 	"sigs.k8s.io/external-dns/endpoint"
 	"sigs.k8s.io/external-dns/plan"
@@ -181,11 +183,7 @@ func (p WebhookProvider) Records(ctx context.Context) ([]*endpoint.Endpoint, err
 	if resp.StatusCode != http.StatusOK {
 		recordsErrorsGauge.Inc()
 		log.Debugf("Failed to get records with code %d", resp.StatusCode)
-		err := fmt.Errorf("failed to get records with code %d", resp.StatusCode)
-		if isRetryableError(resp.StatusCode) {
-			return nil, provider.NewSoftError(err)
-		}
-		return nil, err
+		return nil, statusError(resp, fmt.Errorf("failed to get records with code %d", resp.StatusCode))
 	}
 
 	endpoints := []*endpoint.Endpoint{}
@@ -229,11 +227,7 @@ func (p WebhookProvider) ApplyChanges(ctx context.Context, changes *plan.Changes
 	if resp.StatusCode != http.StatusNoContent {
 		applyChangesErrorsGauge.Inc()
 		log.Debugf("Failed to apply changes with code %d", resp.StatusCode)
-		err := fmt.Errorf("failed to apply changes with code %d", resp.StatusCode)
-		if isRetryableError(resp.StatusCode) {
-			return provider.NewSoftError(err)
-		}
-		return err
+		return statusError(resp, fmt.Errorf("failed to apply changes with code %d", resp.StatusCode))
 	}
 	return nil
 }
@@ -300,6 +294,33 @@ func (p WebhookProvider) GetDomainFilter() endpoint.DomainFilter {
 	return p.DomainFilter
 }
 
+// statusError classifies the error of a response by its status code, so that the controller retries
+// it accordingly.
+func statusError(resp *http.Response, err error) error {
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return provider.NewRateLimitedError(err, retryAfter(resp.Header.Get("Retry-After")))
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return provider.NewAuthFailedError(err)
+	case resp.StatusCode == http.StatusUnprocessableEntity:
+		return provider.NewInvalidRecordError(err)
+	case isRetryableError(resp.StatusCode):
+		return provider.NewSoftError(err)
+	}
+	return err
+}
+
+// retryAfter parses a Retry-After header, either a number of seconds or an HTTP date, 0 if unset or invalid.
+func retryAfter(header string) time.Duration {
+	if seconds, err := strconv.Atoi(header); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(header); err == nil {
+		return time.Until(date)
+	}
+	return 0
+}
+
 // isRetryableError returns true for HTTP status codes between 500 and 510 (inclusive)
 func isRetryableError(statusCode int) bool {
 	return statusCode >= http.StatusInternalServerError && statusCode <= http.StatusNotExtended
@@ -23,6 +23,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 	"sigs.k8s.io/external-dns/endpoint"
@@ -108,6 +109,51 @@ func TestRecordsWithErrors(t *testing.T) {
 	require.ErrorIs(t, err, provider.SoftError)
 }
 
+func TestApplyChangesWithTypedErrors(t *testing.T) {
+	var statusCode int
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/" {
+			w.Header().Set(webhookapi.ContentTypeHeader, webhookapi.MediaTypeFormatAndVersion)
+			w.Write([]byte(`{}`))
+			return
+		}
+		require.Equal(t, "/records", r.URL.Path)
+		if statusCode == http.StatusTooManyRequests {
+			w.Header().Set("Retry-After", "30")
+		}
+		w.WriteHeader(statusCode)
+	}))
+	defer svr.Close()
+
+	p, err := NewWebhookProvider(svr.URL)
+	require.NoError(t, err)
+
+	statusCode = http.StatusTooManyRequests
+	err = p.ApplyChanges(context.TODO(), nil)
+	require.ErrorIs(t, err, provider.RateLimited)
+	retryAfter, ok := provider.RetryAfter(err)
+	require.True(t, ok)
+	require.Equal(t, 30*time.Second, retryAfter)
+
+	statusCode = http.StatusForbidden
+	require.ErrorIs(t, p.ApplyChanges(context.TODO(), nil), provider.AuthFailed)
+
+	statusCode = http.StatusUnprocessableEntity
+	require.ErrorIs(t, p.ApplyChanges(context.TODO(), nil), provider.InvalidRecord)
+
+	statusCode = http.StatusBadRequest
+	err = p.ApplyChanges(context.TODO(), nil)
+	require.Error(t, err)
+	require.NotErrorIs(t, err, provider.SoftError)
+}
+
+func TestRetryAfter(t *testing.T) {
+	require.Equal(t, 2*time.Minute, retryAfter("120"))
+	require.Equal(t, time.Duration(0), retryAfter(""))
+	require.Equal(t, time.Duration(0), retryAfter("soon"))
+	require.InDelta(t, float64(time.Hour), float64(retryAfter(time.Now().Add(time.Hour).UTC().Format(http.TimeFormat))), float64(2*time.Second))
+}
+
 func TestApplyChanges(t *testing.T) {
 	successfulApplyChanges := true
 	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
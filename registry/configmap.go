@@ -0,0 +1,539 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/plan"
+	"sigs.k8s.io/external-dns/provider"
+)
+
+// ConfigMapRegistry implements registry interface with ownership implemented via Kubernetes ConfigMaps,
+// for the providers which cannot store TXT records. The ownership of the records is spread across a
+// fixed number of ConfigMaps, the shards, which may be shared by several owners.
+type ConfigMapRegistry struct {
+	provider provider.Provider
+	ownerID  string // refers to the owner id of the current instance
+
+	client    kubernetes.Interface
+	namespace string
+	name      string
+	shards    int
+
+	// For migration from TXT registry
+	mapper              nameMapper
+	wildcardReplacement string
+	managedRecordTypes  []string
+	excludeRecordTypes  []string
+	txtEncryptAESKey    []byte
+
+	// cache the ownership records owned by us, and the ConfigMap holding each of them.
+	labels         map[endpoint.EndpointKey]endpoint.Labels
+	locations      map[endpoint.EndpointKey]string
+	orphanedLabels sets.Set[endpoint.EndpointKey]
+
+	// cache the records in memory and update on an interval instead.
+	recordsCache            []*endpoint.Endpoint
+	recordsCacheRefreshTime time.Time
+	cacheInterval           time.Duration
+}
+
+const (
+	configMapAttributeMigrate = "configmap/needs-migration"
+	// configMapRegistryLabelKey labels the ConfigMaps of a registry with its name
+	configMapRegistryLabelKey = "external-dns.alpha.kubernetes.io/ownership-registry"
+)
+
+// configMapOwnership is the ownership of a record, stored as JSON in a ConfigMap
+type configMapOwnership struct {
+	DNSName       string            `json:"dnsName"`
+	RecordType    string            `json:"recordType,omitempty"`
+	SetIdentifier string            `json:"setIdentifier,omitempty"`
+	Owner         string            `json:"owner"`
+	Labels        map[string]string `json:"labels,omitempty"`
+}
+
+func (o *configMapOwnership) key() endpoint.EndpointKey {
+	return endpoint.EndpointKey{
+		DNSName:       o.DNSName,
+		RecordType:    o.RecordType,
+		SetIdentifier: o.SetIdentifier,
+	}
+}
+
+// configMapWrite inserts, updates or deletes, when labels is nil, the ownership record of key
+type configMapWrite struct {
+	key    endpoint.EndpointKey
+	labels endpoint.Labels
+	insert bool
+}
+
+// NewConfigMapRegistry returns a new ConfigMapRegistry object.
+func NewConfigMapRegistry(provider provider.Provider, ownerID string, client kubernetes.Interface, namespace, name string, shards int, txtPrefix, txtSuffix, txtWildcardReplacement string, managedRecordTypes, excludeRecordTypes []string, txtEncryptAESKey []byte, cacheInterval time.Duration) (*ConfigMapRegistry, error) {
+	if ownerID == "" {
+		return nil, errors.New("owner id cannot be empty")
+	}
+	if name == "" {
+		return nil, errors.New("configmap name cannot be empty")
+	}
+	if shards <= 0 {
+		return nil, errors.New("the number of configmap shards must be positive")
+	}
+
+	if len(txtEncryptAESKey) == 0 {
+		txtEncryptAESKey = nil
+	} else if len(txtEncryptAESKey) != 32 {
+		return nil, errors.New("the AES Encryption key must have a length of 32 bytes")
+	}
+	if len(txtPrefix) > 0 && len(txtSuffix) > 0 {
+		return nil, errors.New("txt-prefix and txt-suffix are mutually exclusive")
+	}
+
+	mapper := newaffixNameMapper(txtPrefix, txtSuffix, txtWildcardReplacement)
+
+	return &ConfigMapRegistry{
+		provider:            provider,
+		ownerID:             ownerID,
+		client:              client,
+		namespace:           namespace,
+		name:                name,
+		shards:              shards,
+		mapper:              mapper,
+		wildcardReplacement: txtWildcardReplacement,
+		managedRecordTypes:  managedRecordTypes,
+		excludeRecordTypes:  excludeRecordTypes,
+		txtEncryptAESKey:    txtEncryptAESKey,
+		cacheInterval:       cacheInterval,
+	}, nil
+}
+
+func (im *ConfigMapRegistry) GetDomainFilter() endpoint.DomainFilter {
+	return im.provider.GetDomainFilter()
+}
+
+func (im *ConfigMapRegistry) OwnerID() string {
+	return im.ownerID
+}
+
+// Records returns the current records from the registry.
+func (im *ConfigMapRegistry) Records(ctx context.Context) ([]*endpoint.Endpoint, error) {
+	// If we have the zones cached AND we have refreshed the cache since the
+	// last given interval, then just use the cached results.
+	if im.recordsCache != nil && time.Since(im.recordsCacheRefreshTime) < im.cacheInterval {
+		log.Debug("Using cached records.")
+		return im.recordsCache, nil
+	}
+
+	if im.labels == nil {
+		if err := im.readLabels(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	records, err := im.provider.Records(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoints, orphanedLabels := labelRecords(records, im.labels, labelRecordsOptions{
+		mapper:              im.mapper,
+		wildcardReplacement: im.wildcardReplacement,
+		txtEncryptAESKey:    im.txtEncryptAESKey,
+		migrateProperty:     configMapAttributeMigrate,
+		ownerID:             im.ownerID,
+		managedRecordTypes:  im.managedRecordTypes,
+		excludeRecordTypes:  im.excludeRecordTypes,
+	})
+	im.orphanedLabels = orphanedLabels
+
+	// Update the cache.
+	if im.cacheInterval > 0 {
+		im.recordsCache = endpoints
+		im.recordsCacheRefreshTime = time.Now()
+	}
+
+	return endpoints, nil
+}
+
+// ApplyChanges updates the DNS provider and the ConfigMaps with the changes.
+func (im *ConfigMapRegistry) ApplyChanges(ctx context.Context, changes *plan.Changes) error {
+	filteredChanges := &plan.Changes{
+		Create:    changes.Create,
+		UpdateNew: endpoint.FilterEndpointsByOwnerID(im.ownerID, changes.UpdateNew),
+		UpdateOld: endpoint.FilterEndpointsByOwnerID(im.ownerID, changes.UpdateOld),
+		Delete:    endpoint.FilterEndpointsByOwnerID(im.ownerID, changes.Delete),
+	}
+
+	writes := make([]configMapWrite, 0, len(filteredChanges.Create)+len(filteredChanges.UpdateNew))
+	for _, r := range filteredChanges.Create {
+		if r.Labels == nil {
+			r.Labels = make(map[string]string)
+		}
+		r.Labels[endpoint.OwnerLabelKey] = im.ownerID
+
+		key := r.Key()
+		oldLabels := im.labels[key]
+		if oldLabels == nil {
+			writes = append(writes, configMapWrite{key: key, labels: r.Labels, insert: true})
+		} else {
+			im.orphanedLabels.Delete(key)
+			if !labelsEqual(oldLabels, r.Labels) {
+				writes = append(writes, configMapWrite{key: key, labels: r.Labels})
+			}
+		}
+
+		im.labels[key] = r.Labels
+		if im.cacheInterval > 0 {
+			im.addToCache(r)
+		}
+	}
+
+	for _, r := range filteredChanges.Delete {
+		delete(im.labels, r.Key())
+		if im.cacheInterval > 0 {
+			im.removeFromCache(r)
+		}
+	}
+
+	oldLabels := make(map[endpoint.EndpointKey]endpoint.Labels, len(filteredChanges.UpdateOld))
+	needMigration := map[endpoint.EndpointKey]bool{}
+	for _, r := range filteredChanges.UpdateOld {
+		oldLabels[r.Key()] = r.Labels
+
+		if _, ok := r.GetProviderSpecificProperty(configMapAttributeMigrate); ok {
+			needMigration[r.Key()] = true
+		}
+
+		// remove old version of record from cache
+		if im.cacheInterval > 0 {
+			im.removeFromCache(r)
+		}
+	}
+
+	for _, r := range filteredChanges.UpdateNew {
+		key := r.Key()
+		if needMigration[key] {
+			writes = append(writes, configMapWrite{key: key, labels: r.Labels, insert: true})
+			// Invalidate the records cache so the next sync deletes the TXT ownership record
+			im.recordsCache = nil
+		} else if !labelsEqual(oldLabels[key], r.Labels) {
+			writes = append(writes, configMapWrite{key: key, labels: r.Labels})
+		}
+
+		// add new version of record to caches
+		im.labels[key] = r.Labels
+		if im.cacheInterval > 0 {
+			im.addToCache(r)
+		}
+	}
+
+	conflicts, err := im.write(ctx, writes)
+	if err == nil {
+		// We lost a race with a different owner or another owner has an orphaned ownership record.
+		for key, owner := range conflicts {
+			skipped := false
+			for i, ep := range filteredChanges.Create {
+				if ep.Key() == key {
+					log.Infof("Skipping endpoint %v because owner does not match", ep)
+					filteredChanges.Create = append(filteredChanges.Create[:i], filteredChanges.Create[i+1:]...)
+					im.removeFromCache(ep)
+					delete(im.labels, key)
+					skipped = true
+					break
+				}
+			}
+			if !skipped {
+				err = fmt.Errorf("ownership record %q is owned by %q", key.DNSName, owner)
+				break
+			}
+		}
+	}
+	if err != nil {
+		im.recordsCache = nil
+		im.labels = nil
+		return err
+	}
+
+	// When caching is enabled, disable the provider from using the cache.
+	if im.cacheInterval > 0 {
+		ctx = context.WithValue(ctx, provider.RecordsContextKey, nil)
+	}
+	err = im.provider.ApplyChanges(ctx, filteredChanges)
+	if err != nil {
+		im.recordsCache = nil
+		im.labels = nil
+		return err
+	}
+
+	writes = make([]configMapWrite, 0, len(filteredChanges.Delete)+len(im.orphanedLabels))
+	for _, r := range filteredChanges.Delete {
+		writes = append(writes, configMapWrite{key: r.Key()})
+	}
+	for r := range im.orphanedLabels {
+		writes = append(writes, configMapWrite{key: r})
+		delete(im.labels, r)
+	}
+	im.orphanedLabels = nil
+	if _, err := im.write(ctx, writes); err != nil {
+		im.recordsCache = nil
+		im.labels = nil
+		return err
+	}
+	return nil
+}
+
+// AdjustEndpoints modifies the endpoints as needed by the specific provider.
+func (im *ConfigMapRegistry) AdjustEndpoints(endpoints []*endpoint.Endpoint) ([]*endpoint.Endpoint, error) {
+	return im.provider.AdjustEndpoints(endpoints)
+}
+
+// readLabels reads the ownership records of every ConfigMap of the registry, whatever its shard count
+func (im *ConfigMapRegistry) readLabels(ctx context.Context) error {
+	list, err := im.client.CoreV1().ConfigMaps(im.namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: configMapRegistryLabelKey + "=" + im.name,
+	})
+	if err != nil {
+		return fmt.Errorf("listing configmaps: %w", err)
+	}
+
+	labels := map[endpoint.EndpointKey]endpoint.Labels{}
+	locations := map[endpoint.EndpointKey]string{}
+	for _, cm := range list.Items {
+		for dataKey, value := range cm.Data {
+			ownership := &configMapOwnership{}
+			if err := json.Unmarshal([]byte(value), ownership); err != nil {
+				log.Warnf("Ignoring the invalid ownership record %q of configmap %s/%s: %v", dataKey, cm.Namespace, cm.Name, err)
+				continue
+			}
+			if ownership.Owner != im.ownerID {
+				continue
+			}
+			key := ownership.key()
+			labels[key] = toConfigMapLabels(ownership)
+			locations[key] = cm.Name
+		}
+	}
+
+	im.labels = labels
+	im.locations = locations
+	return nil
+}
+
+// write applies the writes to the ConfigMaps, creating the missing ones, and returns the owners of
+// the ownership records which could not be inserted because another owner has them. Only our own
+// ownership records are updated or deleted.
+func (im *ConfigMapRegistry) write(ctx context.Context, writes []configMapWrite) (map[endpoint.EndpointKey]string, error) {
+	byName := map[string][]configMapWrite{}
+	for _, w := range writes {
+		name, ok := im.locations[w.key]
+		if !ok {
+			name = im.shardName(w.key)
+		}
+		byName[name] = append(byName[name], w)
+	}
+	names := make([]string, 0, len(byName))
+	for name := range byName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	conflicts := map[endpoint.EndpointKey]string{}
+	for _, name := range names {
+		var nameConflicts map[endpoint.EndpointKey]string
+		err := retry.OnError(retry.DefaultRetry, func(err error) bool {
+			return apierrors.IsConflict(err) || apierrors.IsAlreadyExists(err)
+		}, func() error {
+			var err error
+			nameConflicts, err = im.writeConfigMap(ctx, name, byName[name])
+			return err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("updating configmap %s/%s: %w", im.namespace, name, err)
+		}
+		for key, owner := range nameConflicts {
+			conflicts[key] = owner
+		}
+	}
+	return conflicts, nil
+}
+
+// writeConfigMap applies the writes to the latest version of the ConfigMap
+func (im *ConfigMapRegistry) writeConfigMap(ctx context.Context, name string, writes []configMapWrite) (map[endpoint.EndpointKey]string, error) {
+	configMaps := im.client.CoreV1().ConfigMaps(im.namespace)
+	create := false
+	cm, err := configMaps.Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		create = true
+		cm = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: im.namespace,
+				Labels:    map[string]string{configMapRegistryLabelKey: im.name},
+			},
+		}
+	} else if err != nil {
+		return nil, err
+	}
+	if cm.Data == nil {
+		cm.Data = map[string]string{}
+	}
+
+	conflicts := map[endpoint.EndpointKey]string{}
+	changed := false
+	for _, w := range writes {
+		dataKey := configMapDataKey(w.key)
+		existing := &configMapOwnership{}
+		if value, ok := cm.Data[dataKey]; ok {
+			if err := json.Unmarshal([]byte(value), existing); err != nil {
+				log.Warnf("Replacing the invalid ownership record %q of configmap %s/%s: %v", dataKey, im.namespace, name, err)
+				existing = &configMapOwnership{}
+			}
+		}
+		if existing.Owner != "" && existing.Owner != im.ownerID {
+			if w.labels != nil {
+				conflicts[w.key] = existing.Owner
+			}
+			continue
+		}
+		if w.labels == nil {
+			if _, ok := cm.Data[dataKey]; ok {
+				delete(cm.Data, dataKey)
+				changed = true
+				log.Infof("DELETE configmap record %q", w.key.DNSName)
+			}
+			continue
+		}
+		value, err := json.Marshal(im.toConfigMapOwnership(w.key, w.labels))
+		if err != nil {
+			return nil, err
+		}
+		cm.Data[dataKey] = string(value)
+		changed = true
+		if w.insert {
+			log.Infof("INSERT configmap record %q", w.key.DNSName)
+		} else {
+			log.Infof("UPDATE configmap record %q", w.key.DNSName)
+		}
+	}
+
+	switch {
+	case !changed:
+		return conflicts, nil
+	case create:
+		_, err = configMaps.Create(ctx, cm, metav1.CreateOptions{})
+	default:
+		_, err = configMaps.Update(ctx, cm, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, w := range writes {
+		if _, conflict := conflicts[w.key]; conflict {
+			continue
+		}
+		if w.labels == nil {
+			delete(im.locations, w.key)
+		} else if im.locations != nil {
+			im.locations[w.key] = name
+		}
+	}
+	return conflicts, nil
+}
+
+// shardName returns the name of the ConfigMap holding the ownership record of key
+func (im *ConfigMapRegistry) shardName(key endpoint.EndpointKey) string {
+	h := fnv.New32a()
+	h.Write([]byte(configMapKeyString(key)))
+	return fmt.Sprintf("%s-%d", im.name, h.Sum32()%uint32(im.shards))
+}
+
+func (im *ConfigMapRegistry) toConfigMapOwnership(key endpoint.EndpointKey, labels endpoint.Labels) *configMapOwnership {
+	ownership := &configMapOwnership{
+		DNSName:       key.DNSName,
+		RecordType:    key.RecordType,
+		SetIdentifier: key.SetIdentifier,
+		Owner:         im.ownerID,
+	}
+	for k, v := range labels {
+		if k == endpoint.OwnerLabelKey || !endpoint.IsStoredLabel(k) {
+			continue
+		}
+		if ownership.Labels == nil {
+			ownership.Labels = map[string]string{}
+		}
+		ownership.Labels[k] = v
+	}
+	return ownership
+}
+
+func toConfigMapLabels(ownership *configMapOwnership) endpoint.Labels {
+	labels := endpoint.NewLabels()
+	for k, v := range ownership.Labels {
+		labels[k] = v
+	}
+	labels[endpoint.OwnerLabelKey] = ownership.Owner
+	return labels
+}
+
+func configMapKeyString(key endpoint.EndpointKey) string {
+	return fmt.Sprintf("%s#%s#%s", key.DNSName, key.RecordType, key.SetIdentifier)
+}
+
+// configMapDataKey returns the key of the ownership record of key in the data of a ConfigMap, a hash
+// since the DNS names and set identifiers may contain characters which are not allowed in the keys
+func configMapDataKey(key endpoint.EndpointKey) string {
+	sum := sha256.Sum256([]byte(configMapKeyString(key)))
+	return hex.EncodeToString(sum[:16])
+}
+
+func (im *ConfigMapRegistry) addToCache(ep *endpoint.Endpoint) {
+	if im.recordsCache != nil {
+		im.recordsCache = append(im.recordsCache, ep)
+	}
+}
+
+func (im *ConfigMapRegistry) removeFromCache(ep *endpoint.Endpoint) {
+	if im.recordsCache == nil || ep == nil {
+		return
+	}
+
+	for i, e := range im.recordsCache {
+		if e.DNSName == ep.DNSName && e.RecordType == ep.RecordType && e.SetIdentifier == ep.SetIdentifier && e.Targets.Same(ep.Targets) {
+			// We found a match; delete the endpoint from the cache.
+			im.recordsCache = append(im.recordsCache[:i], im.recordsCache[i+1:]...)
+			return
+		}
+	}
+}
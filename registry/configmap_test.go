@@ -0,0 +1,339 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/internal/testutils"
+	"sigs.k8s.io/external-dns/plan"
+	"sigs.k8s.io/external-dns/provider/inmemory"
+)
+
+// newConfigMapOwnership returns the ConfigMap of the registry holding the ownership records
+func newConfigMapOwnership(t *testing.T, name string, ownerships ...*configMapOwnership) *corev1.ConfigMap {
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "external-dns",
+			Labels:    map[string]string{configMapRegistryLabelKey: "external-dns-ownership"},
+		},
+		Data: map[string]string{},
+	}
+	for _, ownership := range ownerships {
+		value, err := json.Marshal(ownership)
+		require.NoError(t, err)
+		cm.Data[configMapDataKey(ownership.key())] = string(value)
+	}
+	return cm
+}
+
+// readConfigMapOwnerships returns the ownership records of the registry by ConfigMap and key
+func readConfigMapOwnerships(t *testing.T, client kubernetes.Interface) map[string]map[string]configMapOwnership {
+	list, err := client.CoreV1().ConfigMaps("external-dns").List(context.Background(), metav1.ListOptions{})
+	require.NoError(t, err)
+	ownerships := map[string]map[string]configMapOwnership{}
+	for _, cm := range list.Items {
+		assert.Equal(t, "external-dns-ownership", cm.Labels[configMapRegistryLabelKey])
+		ownerships[cm.Name] = map[string]configMapOwnership{}
+		for dataKey, value := range cm.Data {
+			ownership := configMapOwnership{}
+			require.NoError(t, json.Unmarshal([]byte(value), &ownership))
+			assert.Equal(t, configMapDataKey(ownership.key()), dataKey)
+			ownerships[cm.Name][configMapKeyString(ownership.key())] = ownership
+		}
+	}
+	return ownerships
+}
+
+func newConfigMapTestRegistry(t *testing.T) (*ConfigMapRegistry, kubernetes.Interface, *inmemory.InMemoryProvider) {
+	p := inmemory.NewInMemoryProvider()
+	require.NoError(t, p.CreateZone(testZone))
+	require.NoError(t, p.ApplyChanges(context.Background(), &plan.Changes{
+		Create: []*endpoint.Endpoint{
+			endpoint.NewEndpoint("foo.test-zone.example.org", endpoint.RecordTypeCNAME, "foo.loadbalancer.com"),
+			endpoint.NewEndpoint("bar.test-zone.example.org", endpoint.RecordTypeCNAME, "my-domain.com"),
+			endpoint.NewEndpoint("baz.test-zone.example.org", endpoint.RecordTypeA, "1.1.1.1").WithSetIdentifier("set-1"),
+			endpoint.NewEndpoint("baz.test-zone.example.org", endpoint.RecordTypeA, "2.2.2.2").WithSetIdentifier("set-2"),
+			endpoint.NewEndpoint("migrate.test-zone.example.org", endpoint.RecordTypeA, "3.3.3.3"),
+			endpoint.NewEndpoint("txt.migrate.test-zone.example.org", endpoint.RecordTypeTXT, "\"heritage=external-dns,external-dns/owner=test-owner,external-dns/resource=ingress/default/other-ingress\""),
+		},
+	}))
+
+	client := fake.NewSimpleClientset(
+		// the ownership records of a former shard count are still read and updated in place
+		newConfigMapOwnership(t, "external-dns-ownership-7",
+			&configMapOwnership{DNSName: "bar.test-zone.example.org", RecordType: endpoint.RecordTypeCNAME, Owner: "test-owner", Labels: map[string]string{endpoint.ResourceLabelKey: "ingress/default/my-ingress"}},
+			&configMapOwnership{DNSName: "foo.test-zone.example.org", RecordType: endpoint.RecordTypeCNAME, Owner: "other-owner"},
+		),
+		newConfigMapOwnership(t, "external-dns-ownership-8",
+			&configMapOwnership{DNSName: "baz.test-zone.example.org", RecordType: endpoint.RecordTypeA, SetIdentifier: "set-1", Owner: "test-owner", Labels: map[string]string{endpoint.ResourceLabelKey: "ingress/default/my-ingress"}},
+			&configMapOwnership{DNSName: "baz.test-zone.example.org", RecordType: endpoint.RecordTypeA, SetIdentifier: "set-2", Owner: "test-owner", Labels: map[string]string{endpoint.ResourceLabelKey: "ingress/default/other-ingress"}},
+			&configMapOwnership{DNSName: "quux.test-zone.example.org", RecordType: endpoint.RecordTypeA, Owner: "test-owner"},
+		),
+	)
+	r, err := NewConfigMapRegistry(p, "test-owner", client, "external-dns", "external-dns-ownership", 3, "txt.", "", "", []string{}, []string{}, nil, 0)
+	require.NoError(t, err)
+	return r, client, p
+}
+
+func TestConfigMapRegistryNew(t *testing.T) {
+	p := inmemory.NewInMemoryProvider()
+	client := fake.NewSimpleClientset()
+
+	_, err := NewConfigMapRegistry(p, "test-owner", client, "external-dns", "external-dns-ownership", 3, "", "", "", []string{}, []string{}, nil, 0)
+	require.NoError(t, err)
+
+	_, err = NewConfigMapRegistry(p, "", client, "external-dns", "external-dns-ownership", 3, "", "", "", []string{}, []string{}, nil, 0)
+	require.EqualError(t, err, "owner id cannot be empty")
+
+	_, err = NewConfigMapRegistry(p, "test-owner", client, "external-dns", "", 3, "", "", "", []string{}, []string{}, nil, 0)
+	require.EqualError(t, err, "configmap name cannot be empty")
+
+	_, err = NewConfigMapRegistry(p, "test-owner", client, "external-dns", "external-dns-ownership", 0, "", "", "", []string{}, []string{}, nil, 0)
+	require.EqualError(t, err, "the number of configmap shards must be positive")
+
+	_, err = NewConfigMapRegistry(p, "test-owner", client, "external-dns", "external-dns-ownership", 3, "", "", "", []string{}, []string{}, []byte(";k&l)nUC/33:{?d{3)54+,AD?]SX%yh^"), 0)
+	require.NoError(t, err)
+
+	_, err = NewConfigMapRegistry(p, "test-owner", client, "external-dns", "external-dns-ownership", 3, "", "", "", []string{}, []string{}, []byte("abc"), 0)
+	require.EqualError(t, err, "the AES Encryption key must have a length of 32 bytes")
+
+	_, err = NewConfigMapRegistry(p, "test-owner", client, "external-dns", "external-dns-ownership", 3, "testPrefix", "testSuffix", "", []string{}, []string{}, nil, 0)
+	require.EqualError(t, err, "txt-prefix and txt-suffix are mutually exclusive")
+}
+
+func TestConfigMapRegistryRecords(t *testing.T) {
+	r, _, _ := newConfigMapTestRegistry(t)
+
+	records, err := r.Records(context.Background())
+	require.NoError(t, err)
+
+	assert.True(t, testutils.SameEndpoints(records, []*endpoint.Endpoint{
+		{
+			DNSName:    "foo.test-zone.example.org",
+			Targets:    endpoint.Targets{"foo.loadbalancer.com"},
+			RecordType: endpoint.RecordTypeCNAME,
+			Labels: map[string]string{
+				endpoint.OwnerLabelKey: "",
+			},
+		},
+		{
+			DNSName:    "bar.test-zone.example.org",
+			Targets:    endpoint.Targets{"my-domain.com"},
+			RecordType: endpoint.RecordTypeCNAME,
+			Labels: map[string]string{
+				endpoint.OwnerLabelKey:    "test-owner",
+				endpoint.ResourceLabelKey: "ingress/default/my-ingress",
+			},
+		},
+		{
+			DNSName:       "baz.test-zone.example.org",
+			Targets:       endpoint.Targets{"1.1.1.1"},
+			RecordType:    endpoint.RecordTypeA,
+			SetIdentifier: "set-1",
+			Labels: map[string]string{
+				endpoint.OwnerLabelKey:    "test-owner",
+				endpoint.ResourceLabelKey: "ingress/default/my-ingress",
+			},
+		},
+		{
+			DNSName:       "baz.test-zone.example.org",
+			Targets:       endpoint.Targets{"2.2.2.2"},
+			RecordType:    endpoint.RecordTypeA,
+			SetIdentifier: "set-2",
+			Labels: map[string]string{
+				endpoint.OwnerLabelKey:    "test-owner",
+				endpoint.ResourceLabelKey: "ingress/default/other-ingress",
+			},
+		},
+		{
+			DNSName:    "migrate.test-zone.example.org",
+			Targets:    endpoint.Targets{"3.3.3.3"},
+			RecordType: endpoint.RecordTypeA,
+			Labels: map[string]string{
+				endpoint.OwnerLabelKey:    "test-owner",
+				endpoint.ResourceLabelKey: "ingress/default/other-ingress",
+			},
+			ProviderSpecific: endpoint.ProviderSpecific{
+				{
+					Name:  configMapAttributeMigrate,
+					Value: "true",
+				},
+			},
+		},
+	}))
+	assert.Equal(t, []endpoint.EndpointKey{{DNSName: "quux.test-zone.example.org", RecordType: endpoint.RecordTypeA}}, r.orphanedLabels.UnsortedList())
+}
+
+func TestConfigMapRegistryApplyChanges(t *testing.T) {
+	r, client, p := newConfigMapTestRegistry(t)
+	ctx := context.Background()
+	records, err := r.Records(ctx)
+	require.NoError(t, err)
+	byName := map[string]*endpoint.Endpoint{}
+	for _, record := range records {
+		byName[record.DNSName+record.SetIdentifier] = record
+	}
+
+	bar := byName["bar.test-zone.example.org"].DeepCopy()
+	bar.Targets = endpoint.Targets{"new-domain.com"}
+	bar.Labels[endpoint.ResourceLabelKey] = "ingress/default/new-ingress"
+	migrate := byName["migrate.test-zone.example.org"].DeepCopy()
+	migrate.Targets = endpoint.Targets{"4.4.4.4"}
+	created := endpoint.NewEndpoint("new.test-zone.example.org", endpoint.RecordTypeCNAME, "new.loadbalancer.com").WithSetIdentifier("set-new")
+	created.Labels[endpoint.ResourceLabelKey] = "ingress/default/new-ingress"
+	// the labels ranking the candidates of the DNS name are not stored
+	created.Labels[endpoint.ResourceCreatedLabelKey] = "2024-01-01T00:00:00Z"
+	created.Labels[endpoint.ResourcePriorityLabelKey] = "10"
+
+	require.NoError(t, r.ApplyChanges(ctx, &plan.Changes{
+		Create:    []*endpoint.Endpoint{created},
+		UpdateOld: []*endpoint.Endpoint{byName["bar.test-zone.example.org"], byName["migrate.test-zone.example.org"]},
+		UpdateNew: []*endpoint.Endpoint{bar, migrate},
+		Delete:    []*endpoint.Endpoint{byName["baz.test-zone.example.orgset-1"]},
+	}))
+
+	newShard := r.shardName(created.Key())
+	migrateShard := r.shardName(migrate.Key())
+	expected := map[string]map[string]configMapOwnership{
+		"external-dns-ownership-7": {
+			"bar.test-zone.example.org#CNAME#": {DNSName: "bar.test-zone.example.org", RecordType: endpoint.RecordTypeCNAME, Owner: "test-owner", Labels: map[string]string{endpoint.ResourceLabelKey: "ingress/default/new-ingress"}},
+			"foo.test-zone.example.org#CNAME#": {DNSName: "foo.test-zone.example.org", RecordType: endpoint.RecordTypeCNAME, Owner: "other-owner"},
+		},
+		"external-dns-ownership-8": {
+			"baz.test-zone.example.org#A#set-2": {DNSName: "baz.test-zone.example.org", RecordType: endpoint.RecordTypeA, SetIdentifier: "set-2", Owner: "test-owner", Labels: map[string]string{endpoint.ResourceLabelKey: "ingress/default/other-ingress"}},
+		},
+	}
+	for _, shard := range []string{newShard, migrateShard} {
+		if expected[shard] == nil {
+			expected[shard] = map[string]configMapOwnership{}
+		}
+	}
+	expected[newShard]["new.test-zone.example.org#CNAME#set-new"] = configMapOwnership{DNSName: "new.test-zone.example.org", RecordType: endpoint.RecordTypeCNAME, SetIdentifier: "set-new", Owner: "test-owner", Labels: map[string]string{endpoint.ResourceLabelKey: "ingress/default/new-ingress"}}
+	expected[migrateShard]["migrate.test-zone.example.org#A#"] = configMapOwnership{DNSName: "migrate.test-zone.example.org", RecordType: endpoint.RecordTypeA, Owner: "test-owner", Labels: map[string]string{endpoint.ResourceLabelKey: "ingress/default/other-ingress"}}
+	assert.Equal(t, expected, readConfigMapOwnerships(t, client))
+
+	// the records are applied, and the TXT ownership record is deleted on the next synchronization
+	providerRecords, err := p.Records(ctx)
+	require.NoError(t, err)
+	targets := map[string]endpoint.Targets{}
+	for _, record := range providerRecords {
+		targets[record.DNSName+record.SetIdentifier] = record.Targets
+	}
+	assert.Equal(t, endpoint.Targets{"new-domain.com"}, targets["bar.test-zone.example.org"])
+	assert.Equal(t, endpoint.Targets{"new.loadbalancer.com"}, targets["new.test-zone.example.orgset-new"])
+	assert.NotContains(t, targets, "baz.test-zone.example.orgset-1")
+
+	records, err = r.Records(ctx)
+	require.NoError(t, err)
+	for _, record := range records {
+		if record.DNSName == "txt.migrate.test-zone.example.org" {
+			assert.Equal(t, "test-owner", record.Labels[endpoint.OwnerLabelKey])
+		}
+		if record.DNSName == "migrate.test-zone.example.org" {
+			assert.Equal(t, "ingress/default/other-ingress", record.Labels[endpoint.ResourceLabelKey])
+		}
+	}
+}
+
+func TestConfigMapRegistryApplyChangesOwnedByOther(t *testing.T) {
+	r, client, p := newConfigMapTestRegistry(t)
+	ctx := context.Background()
+	_, err := r.Records(ctx)
+	require.NoError(t, err)
+
+	// another owner created the ownership record of the new record meanwhile
+	created := endpoint.NewEndpoint("new.test-zone.example.org", endpoint.RecordTypeCNAME, "new.loadbalancer.com")
+	_, err = client.CoreV1().ConfigMaps("external-dns").Create(ctx, newConfigMapOwnership(t, r.shardName(created.Key()),
+		&configMapOwnership{DNSName: "new.test-zone.example.org", RecordType: endpoint.RecordTypeCNAME, Owner: "other-owner"},
+	), metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	require.NoError(t, r.ApplyChanges(ctx, &plan.Changes{Create: []*endpoint.Endpoint{created}}))
+
+	providerRecords, err := p.Records(ctx)
+	require.NoError(t, err)
+	for _, record := range providerRecords {
+		assert.NotEqual(t, "new.test-zone.example.org", record.DNSName)
+	}
+	assert.Equal(t, "other-owner", readConfigMapOwnerships(t, client)[r.shardName(created.Key())]["new.test-zone.example.org#CNAME#"].Owner)
+}
+
+func TestConfigMapRegistryCache(t *testing.T) {
+	r, client, _ := newConfigMapTestRegistry(t)
+	r.cacheInterval = time.Hour
+	ctx := context.Background()
+	records, err := r.Records(ctx)
+	require.NoError(t, err)
+
+	// the cached records are returned without reading the ConfigMaps
+	require.NoError(t, client.CoreV1().ConfigMaps("external-dns").Delete(ctx, "external-dns-ownership-7", metav1.DeleteOptions{}))
+	cached, err := r.Records(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, records, cached)
+}
+
+func TestConfigMapRegistryCacheWriteFailure(t *testing.T) {
+	r, client, _ := newConfigMapTestRegistry(t)
+	r.cacheInterval = time.Hour
+	ctx := context.Background()
+	records, err := r.Records(ctx)
+	require.NoError(t, err)
+
+	// the ownership record of the deleted record fails to be removed
+	failing := true
+	client.(*fake.Clientset).PrependReactor("update", "configmaps", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if failing {
+			return true, nil, errors.New("failed to update")
+		}
+		return false, nil, nil
+	})
+	var deleted *endpoint.Endpoint
+	for _, record := range records {
+		if record.DNSName == "bar.test-zone.example.org" {
+			deleted = record
+		}
+	}
+	require.NotNil(t, deleted)
+	require.Error(t, r.ApplyChanges(ctx, &plan.Changes{Delete: []*endpoint.Endpoint{deleted}}))
+	failing = false
+
+	// the records and the ownership records are read again, instead of the cache
+	records, err = r.Records(ctx)
+	require.NoError(t, err)
+	for _, record := range records {
+		assert.NotEqual(t, "bar.test-zone.example.org", record.DNSName)
+	}
+	created := endpoint.NewEndpoint("new.test-zone.example.org", endpoint.RecordTypeCNAME, "new.loadbalancer.com")
+	require.NoError(t, r.ApplyChanges(ctx, &plan.Changes{Create: []*endpoint.Endpoint{created}}))
+	assert.Equal(t, "test-owner", readConfigMapOwnerships(t, client)[r.shardName(created.Key())]["new.test-zone.example.org#CNAME#"].Owner)
+}
@@ -133,80 +133,17 @@ func (im *DynamoDBRegistry) Records(ctx context.Context) ([]*endpoint.Endpoint,
 		return nil, err
 	}
 
-	orphanedLabels := sets.KeySet(im.labels)
-	endpoints := make([]*endpoint.Endpoint, 0, len(records))
-	labelMap := map[endpoint.EndpointKey]endpoint.Labels{}
-	txtRecordsMap := map[endpoint.EndpointKey]*endpoint.Endpoint{}
-	for _, record := range records {
-		key := record.Key()
-		if labels := im.labels[key]; labels != nil {
-			record.Labels = labels
-			orphanedLabels.Delete(key)
-		} else {
-			record.Labels = endpoint.NewLabels()
-
-			if record.RecordType == endpoint.RecordTypeTXT {
-				// We simply assume that TXT records for the TXT registry will always have only one target.
-				if labels, err := endpoint.NewLabelsFromString(record.Targets[0], im.txtEncryptAESKey); err == nil {
-					endpointName, recordType := im.mapper.toEndpointName(record.DNSName)
-					key := endpoint.EndpointKey{
-						DNSName:       endpointName,
-						SetIdentifier: record.SetIdentifier,
-					}
-					if recordType == endpoint.RecordTypeAAAA {
-						key.RecordType = recordType
-					}
-					labelMap[key] = labels
-					txtRecordsMap[key] = record
-					continue
-				}
-			}
-		}
-
-		endpoints = append(endpoints, record)
-	}
-
+	endpoints, orphanedLabels := labelRecords(records, im.labels, labelRecordsOptions{
+		mapper:              im.mapper,
+		wildcardReplacement: im.wildcardReplacement,
+		txtEncryptAESKey:    im.txtEncryptAESKey,
+		migrateProperty:     dynamodbAttributeMigrate,
+		ownerID:             im.ownerID,
+		managedRecordTypes:  im.managedRecordTypes,
+		excludeRecordTypes:  im.excludeRecordTypes,
+	})
 	im.orphanedLabels = orphanedLabels
 
-	// Migrate label data from TXT registry.
-	if len(labelMap) > 0 {
-		for _, ep := range endpoints {
-			if _, ok := im.labels[ep.Key()]; ok {
-				continue
-			}
-
-			dnsNameSplit := strings.Split(ep.DNSName, ".")
-			// If specified, replace a leading asterisk in the generated txt record name with some other string
-			if im.wildcardReplacement != "" && dnsNameSplit[0] == "*" {
-				dnsNameSplit[0] = im.wildcardReplacement
-			}
-			dnsName := strings.Join(dnsNameSplit, ".")
-			key := endpoint.EndpointKey{
-				DNSName:       dnsName,
-				SetIdentifier: ep.SetIdentifier,
-			}
-			if ep.RecordType == endpoint.RecordTypeAAAA {
-				key.RecordType = ep.RecordType
-			}
-			if labels, ok := labelMap[key]; ok {
-				for k, v := range labels {
-					ep.Labels[k] = v
-				}
-				ep.SetProviderSpecificProperty(dynamodbAttributeMigrate, "true")
-				delete(txtRecordsMap, key)
-			}
-		}
-	}
-
-	// Remove any unused TXT ownership records owned by us
-	if len(txtRecordsMap) > 0 && !plan.IsManagedRecord(endpoint.RecordTypeTXT, im.managedRecordTypes, im.excludeRecordTypes) {
-		log.Infof("Old TXT ownership records will not be deleted because \"TXT\" is not in the set of managed record types.")
-	}
-	for _, record := range txtRecordsMap {
-		record.Labels[endpoint.OwnerLabelKey] = im.ownerID
-		endpoints = append(endpoints, record)
-	}
-
 	// Update the cache.
 	if im.cacheInterval > 0 {
 		im.recordsCache = endpoints
@@ -424,7 +361,7 @@ func fromDynamoLabels(label *dynamodb.AttributeValue, owner string) endpoint.Lab
 func toDynamoLabels(labels endpoint.Labels) *dynamodb.AttributeValue {
 	labelMap := make(map[string]*dynamodb.AttributeValue, len(labels))
 	for k, v := range labels {
-		if k == endpoint.OwnerLabelKey {
+		if k == endpoint.OwnerLabelKey || !endpoint.IsStoredLabel(k) {
 			continue
 		}
 		labelMap[k] = &dynamodb.AttributeValue{S: aws.String(v)}
@@ -445,17 +382,8 @@ func (im *DynamoDBRegistry) appendInsert(statements []*dynamodb.BatchStatementRe
 }
 
 func (im *DynamoDBRegistry) appendUpdate(statements []*dynamodb.BatchStatementRequest, key endpoint.EndpointKey, old endpoint.Labels, new endpoint.Labels) []*dynamodb.BatchStatementRequest {
-	if len(old) == len(new) {
-		equal := true
-		for k, v := range old {
-			if newV, exists := new[k]; !exists || v != newV {
-				equal = false
-				break
-			}
-		}
-		if equal {
-			return statements
-		}
+	if labelsEqual(old, new) {
+		return statements
 	}
 
 	return append(statements, &dynamodb.BatchStatementRequest{
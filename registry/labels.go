@@ -0,0 +1,137 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/plan"
+)
+
+// labelRecordsOptions are the settings of labelRecords, shared by the registries storing the labels
+// outside of the DNS zone
+type labelRecordsOptions struct {
+	// mapper, wildcardReplacement and txtEncryptAESKey read the TXT ownership records to migrate
+	mapper              nameMapper
+	wildcardReplacement string
+	txtEncryptAESKey    []byte
+	// migrateProperty is the provider specific property of the records whose labels are migrated
+	migrateProperty string
+	ownerID         string
+	// managedRecordTypes and excludeRecordTypes tell whether the TXT ownership records can be deleted
+	managedRecordTypes []string
+	excludeRecordTypes []string
+}
+
+// labelRecords labels the records of the provider with the labels stored outside of the DNS zone, e.g.
+// in a DynamoDB table. A record without stored labels gets the ones of its TXT ownership record, if
+// any, and the migrate property, so that they get stored on its next update. The TXT ownership records
+// are returned with the endpoints, owned by ownerID to be deleted, as well as the keys of the stored
+// labels without record.
+func labelRecords(records []*endpoint.Endpoint, storedLabels map[endpoint.EndpointKey]endpoint.Labels, opts labelRecordsOptions) ([]*endpoint.Endpoint, sets.Set[endpoint.EndpointKey]) {
+	orphanedLabels := sets.KeySet(storedLabels)
+	endpoints := make([]*endpoint.Endpoint, 0, len(records))
+	labelMap := map[endpoint.EndpointKey]endpoint.Labels{}
+	txtRecordsMap := map[endpoint.EndpointKey]*endpoint.Endpoint{}
+	for _, record := range records {
+		key := record.Key()
+		if labels := storedLabels[key]; labels != nil {
+			record.Labels = labels
+			orphanedLabels.Delete(key)
+		} else {
+			record.Labels = endpoint.NewLabels()
+
+			if record.RecordType == endpoint.RecordTypeTXT {
+				// We simply assume that TXT records for the TXT registry will always have only one target.
+				if labels, err := endpoint.NewLabelsFromString(record.Targets[0], opts.txtEncryptAESKey); err == nil {
+					endpointName, recordType := opts.mapper.toEndpointName(record.DNSName)
+					key := endpoint.EndpointKey{
+						DNSName:       endpointName,
+						SetIdentifier: record.SetIdentifier,
+					}
+					if recordType == endpoint.RecordTypeAAAA {
+						key.RecordType = recordType
+					}
+					labelMap[key] = labels
+					txtRecordsMap[key] = record
+					continue
+				}
+			}
+		}
+
+		endpoints = append(endpoints, record)
+	}
+
+	// Migrate label data from TXT registry.
+	if len(labelMap) > 0 {
+		for _, ep := range endpoints {
+			if _, ok := storedLabels[ep.Key()]; ok {
+				continue
+			}
+
+			dnsNameSplit := strings.Split(ep.DNSName, ".")
+			// If specified, replace a leading asterisk in the generated txt record name with some other string
+			if opts.wildcardReplacement != "" && dnsNameSplit[0] == "*" {
+				dnsNameSplit[0] = opts.wildcardReplacement
+			}
+			dnsName := strings.Join(dnsNameSplit, ".")
+			key := endpoint.EndpointKey{
+				DNSName:       dnsName,
+				SetIdentifier: ep.SetIdentifier,
+			}
+			if ep.RecordType == endpoint.RecordTypeAAAA {
+				key.RecordType = ep.RecordType
+			}
+			if labels, ok := labelMap[key]; ok {
+				for k, v := range labels {
+					ep.Labels[k] = v
+				}
+				ep.SetProviderSpecificProperty(opts.migrateProperty, "true")
+				delete(txtRecordsMap, key)
+			}
+		}
+	}
+
+	// Remove any unused TXT ownership records owned by us
+	if len(txtRecordsMap) > 0 && !plan.IsManagedRecord(endpoint.RecordTypeTXT, opts.managedRecordTypes, opts.excludeRecordTypes) {
+		log.Infof("Old TXT ownership records will not be deleted because \"TXT\" is not in the set of managed record types.")
+	}
+	for _, record := range txtRecordsMap {
+		record.Labels[endpoint.OwnerLabelKey] = opts.ownerID
+		endpoints = append(endpoints, record)
+	}
+	return endpoints, orphanedLabels
+}
+
+// labelsEqual tells whether the stored labels are the same, the labels which are not stored are ignored
+func labelsEqual(old, new endpoint.Labels) bool {
+	for k, v := range old {
+		if newV, exists := new[k]; endpoint.IsStoredLabel(k) && (!exists || v != newV) {
+			return false
+		}
+	}
+	for k := range new {
+		if _, exists := old[k]; endpoint.IsStoredLabel(k) && !exists {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,38 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+func TestLabelsEqual(t *testing.T) {
+	labels := endpoint.Labels{endpoint.OwnerLabelKey: "owner", endpoint.ResourceLabelKey: "ingress/default/foo"}
+	ranked := endpoint.Labels{endpoint.OwnerLabelKey: "owner", endpoint.ResourceLabelKey: "ingress/default/foo", endpoint.ResourceCreatedLabelKey: "2024-01-01T00:00:00Z"}
+
+	assert.True(t, labelsEqual(labels, labels))
+	// the labels which are not stored are ignored
+	assert.True(t, labelsEqual(labels, ranked))
+	assert.True(t, labelsEqual(ranked, labels))
+	assert.False(t, labelsEqual(labels, endpoint.Labels{endpoint.OwnerLabelKey: "owner"}))
+	assert.False(t, labelsEqual(endpoint.Labels{endpoint.OwnerLabelKey: "owner"}, labels))
+	assert.False(t, labelsEqual(labels, endpoint.Labels{endpoint.OwnerLabelKey: "owner", endpoint.ResourceLabelKey: "ingress/default/bar"}))
+}
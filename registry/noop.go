@@ -54,6 +54,14 @@ func (im *NoopRegistry) ApplyChanges(ctx context.Context, changes *plan.Changes)
 	return im.provider.ApplyChanges(ctx, changes)
 }
 
+// ApplyChangesWithResults propagates changes to the dns provider and returns the result of each change when the provider reports them
+func (im *NoopRegistry) ApplyChangesWithResults(ctx context.Context, changes *plan.Changes) ([]provider.ChangeResult, error) {
+	if applier, ok := im.provider.(provider.ResultsApplier); ok {
+		return applier.ApplyChangesWithResults(ctx, changes)
+	}
+	return nil, im.provider.ApplyChanges(ctx, changes)
+}
+
 // AdjustEndpoints modifies the endpoints as needed by the specific provider
 func (im *NoopRegistry) AdjustEndpoints(endpoints []*endpoint.Endpoint) ([]*endpoint.Endpoint, error) {
 	return im.provider.AdjustEndpoints(endpoints)
@@ -26,15 +26,20 @@ import (
 	"sigs.k8s.io/external-dns/endpoint"
 	"sigs.k8s.io/external-dns/internal/testutils"
 	"sigs.k8s.io/external-dns/plan"
+	"sigs.k8s.io/external-dns/provider"
 	"sigs.k8s.io/external-dns/provider/inmemory"
 )
 
-var _ Registry = &NoopRegistry{}
+var (
+	_ Registry                = &NoopRegistry{}
+	_ provider.ResultsApplier = &NoopRegistry{}
+)
 
 func TestNoopRegistry(t *testing.T) {
 	t.Run("NewNoopRegistry", testNoopInit)
 	t.Run("Records", testNoopRecords)
 	t.Run("ApplyChanges", testNoopApplyChanges)
+	t.Run("ApplyChangesWithResults", testNoopApplyChangesWithResults)
 }
 
 func testNoopInit(t *testing.T) {
@@ -135,3 +140,27 @@ func testNoopApplyChanges(t *testing.T) {
 	res, _ := p.Records(ctx)
 	assert.True(t, testutils.SameEndpoints(res, expectedUpdate))
 }
+
+func testNoopApplyChangesWithResults(t *testing.T) {
+	ctx := context.Background()
+	p := inmemory.NewInMemoryProvider()
+	p.CreateZone("org")
+	existing := endpoint.NewEndpoint("example.org", endpoint.RecordTypeCNAME, "old-lb.com")
+	require.NoError(t, p.ApplyChanges(ctx, &plan.Changes{Create: []*endpoint.Endpoint{existing}}))
+
+	r, _ := NewNoopRegistry(p)
+	duplicate := endpoint.NewEndpoint("example.org", endpoint.RecordTypeCNAME, "lb.com")
+	created := endpoint.NewEndpoint("new-record.org", endpoint.RecordTypeCNAME, "new-lb.org")
+	results, err := r.ApplyChangesWithResults(ctx, &plan.Changes{
+		Create: []*endpoint.Endpoint{duplicate, created},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []provider.ChangeResult{
+		{Endpoint: duplicate, Err: inmemory.ErrRecordAlreadyExists},
+		{Endpoint: created},
+	}, results)
+
+	records, err := r.Records(ctx)
+	require.NoError(t, err)
+	assert.True(t, testutils.SameEndpoints(records, []*endpoint.Endpoint{existing, created}))
+}
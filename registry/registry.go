@@ -34,3 +34,11 @@ type Registry interface {
 	GetDomainFilter() endpoint.DomainFilter
 	OwnerID() string
 }
+
+// OrphanCollector is implemented by the registries which delete the ownership records left without
+// their DNS record, e.g. after the record was deleted by hand
+type OrphanCollector interface {
+	// Orphans returns the orphaned ownership records found by the last read of the records, which are
+	// deleted by the plan along with the changes
+	Orphans() []*endpoint.Endpoint
+}
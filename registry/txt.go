@@ -22,6 +22,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
 	log "github.com/sirupsen/logrus"
 
 	"sigs.k8s.io/external-dns/endpoint"
@@ -32,8 +33,54 @@ import (
 const (
 	recordTemplate              = "%{record_type}"
 	providerSpecificForceUpdate = "txt/force-update"
+	// providerSpecificMigrateOwner holds the former owner of a record taken over from it
+	providerSpecificMigrateOwner = "txt/migrate-owner-from"
 )
 
+var (
+	ownerMigrationPendingRecords = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "external_dns",
+			Subsystem: "registry",
+			Name:      "owner_migration_pending_records",
+			Help:      "Number of records still owned by the owner migrated from, at the last read of the records.",
+		},
+		[]string{"from"},
+	)
+	ownerMigratedRecordsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "external_dns",
+			Subsystem: "registry",
+			Name:      "owner_migrated_records_total",
+			Help:      "Number of records whose ownership was rewritten from the owner migrated from.",
+		},
+		[]string{"from"},
+	)
+	orphanedTXTRecords = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: "external_dns",
+			Subsystem: "registry",
+			Name:      "orphaned_txt_records",
+			Help:      "Number of TXT records of this instance without their record, at the last read of the records.",
+		},
+	)
+	orphanedTXTRecordsDeletedTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: "external_dns",
+			Subsystem: "registry",
+			Name:      "orphaned_txt_records_deleted_total",
+			Help:      "Number of TXT records of this instance deleted because their record did not exist.",
+		},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(ownerMigrationPendingRecords)
+	prometheus.MustRegister(ownerMigratedRecordsTotal)
+	prometheus.MustRegister(orphanedTXTRecords)
+	prometheus.MustRegister(orphanedTXTRecordsDeletedTotal)
+}
+
 // TXTRegistry implements registry interface with ownership implemented via associated TXT records
 type TXTRegistry struct {
 	provider provider.Provider
@@ -56,6 +103,20 @@ type TXTRegistry struct {
 	// encrypt text records
 	txtEncryptEnabled bool
 	txtEncryptAESKey  []byte
+
+	// the owner whose records are taken over, see MigrateOwnerFrom
+	migrateOwnerFrom string
+
+	// the records shared with other owners are changed as well, see ShareOwnership
+	sharedOwnership bool
+
+	// the codec the labels are written with, see UseLabelsFormat
+	labelsCodec endpoint.LabelsCodec
+
+	// the orphaned TXT records are deleted, see CollectOrphans
+	collectOrphans bool
+	// the TXT records of this instance without their record, found by the last read from the provider
+	orphans []*endpoint.Endpoint
 }
 
 // NewTXTRegistry returns new TXTRegistry object
@@ -77,6 +138,10 @@ func NewTXTRegistry(provider provider.Provider, txtPrefix, txtSuffix, ownerID st
 	}
 
 	mapper := newaffixNameMapper(txtPrefix, txtSuffix, txtWildcardReplacement)
+	labelsCodec, err := endpoint.NewLabelsCodec(endpoint.LabelsFormatV1)
+	if err != nil {
+		return nil, err
+	}
 
 	return &TXTRegistry{
 		provider:            provider,
@@ -88,6 +153,7 @@ func NewTXTRegistry(provider provider.Provider, txtPrefix, txtSuffix, ownerID st
 		excludeRecordTypes:  excludeRecordTypes,
 		txtEncryptEnabled:   txtEncryptEnabled,
 		txtEncryptAESKey:    txtEncryptAESKey,
+		labelsCodec:         labelsCodec,
 	}, nil
 }
 
@@ -103,6 +169,36 @@ func (im *TXTRegistry) OwnerID() string {
 	return im.ownerID
 }
 
+// MigrateOwnerFrom makes the registry take over the records of the given former owner, e.g. after a
+// rename of the owner ID: they are reported as owned by this instance, and updated so that their TXT
+// records are rewritten with the current owner.
+func (im *TXTRegistry) MigrateOwnerFrom(ownerID string) {
+	im.migrateOwnerFrom = ownerID
+}
+
+// ShareOwnership makes the registry change the records shared with other owners as well, which the plan
+// joins or leaves when the owners share the records, see plan.Plan.
+func (im *TXTRegistry) ShareOwnership() {
+	im.sharedOwnership = true
+}
+
+// UseLabelsFormat makes the registry write the labels in the given format, see endpoint.LabelsCodec. The
+// labels are read in every format, and the TXT records of this instance in another format are rewritten.
+func (im *TXTRegistry) UseLabelsFormat(format string) error {
+	codec, err := endpoint.NewLabelsCodec(format)
+	if err != nil {
+		return err
+	}
+	im.labelsCodec = codec
+	return nil
+}
+
+// CollectOrphans makes the registry delete the TXT records of this instance whose record does not exist,
+// e.g. because it was deleted by hand or rejected by the provider, see Orphans.
+func (im *TXTRegistry) CollectOrphans() {
+	im.collectOrphans = true
+}
+
 // Records returns the current records from the registry excluding TXT Records
 // If TXT records was created previously to indicate ownership its corresponding value
 // will be added to the endpoints Labels map
@@ -120,9 +216,12 @@ func (im *TXTRegistry) Records(ctx context.Context) ([]*endpoint.Endpoint, error
 	}
 
 	endpoints := []*endpoint.Endpoint{}
+	pendingMigrations := 0
 
 	labelMap := map[endpoint.EndpointKey]endpoint.Labels{}
 	txtRecordsMap := map[string]struct{}{}
+	// the TXT records of this instance by the key of their record, until the record is found
+	ownedTXTRecords := map[endpoint.EndpointKey][]*endpoint.Endpoint{}
 
 	for _, record := range records {
 		if record.RecordType != endpoint.RecordTypeTXT {
@@ -150,6 +249,9 @@ func (im *TXTRegistry) Records(ctx context.Context) ([]*endpoint.Endpoint, error
 		}
 		labelMap[key] = labels
 		txtRecordsMap[record.DNSName] = struct{}{}
+		if labels[endpoint.OwnerLabelKey] == im.ownerID {
+			ownedTXTRecords[key] = append(ownedTXTRecords[key], record)
+		}
 	}
 
 	for _, ep := range endpoints {
@@ -173,6 +275,10 @@ func (im *TXTRegistry) Records(ctx context.Context) ([]*endpoint.Endpoint, error
 			key.RecordType = endpoint.RecordTypeCNAME
 		}
 
+		// The TXT records of the record in both formats are not orphaned
+		delete(ownedTXTRecords, key)
+		delete(ownedTXTRecords, endpoint.EndpointKey{DNSName: key.DNSName, SetIdentifier: key.SetIdentifier})
+
 		// Handle both new and old registry format with the preference for the new one
 		labels, labelsExist := labelMap[key]
 		if !labelsExist && ep.RecordType != endpoint.RecordTypeAAAA {
@@ -185,12 +291,20 @@ func (im *TXTRegistry) Records(ctx context.Context) ([]*endpoint.Endpoint, error
 			}
 		}
 
+		// Take over the records of the former owner, the TXT records are rewritten by the update
+		if im.migrateOwnerFrom != "" && ep.Labels[endpoint.OwnerLabelKey] == im.migrateOwnerFrom {
+			log.Infof("Migrating the ownership of %s record %s from %q to %q", ep.RecordType, ep.DNSName, im.migrateOwnerFrom, im.ownerID)
+			ep.Labels[endpoint.OwnerLabelKey] = im.ownerID
+			ep.WithProviderSpecific(providerSpecificMigrateOwner, im.migrateOwnerFrom)
+			pendingMigrations++
+		}
+
 		// Handle the migration of TXT records created before the new format (introduced in v0.12.0).
 		// The migration is done for the TXT records owned by this instance only.
 		if len(txtRecordsMap) > 0 && ep.Labels[endpoint.OwnerLabelKey] == im.ownerID {
 			if plan.IsManagedRecord(ep.RecordType, im.managedRecordTypes, im.excludeRecordTypes) {
 				// Get desired TXT records and detect the missing ones
-				desiredTXTs := im.generateTXTRecord(ep)
+				desiredTXTs := im.generateTXTRecord(ep, im.labelsCodec)
 				for _, desiredTXT := range desiredTXTs {
 					if _, exists := txtRecordsMap[desiredTXT.DNSName]; !exists {
 						ep.WithProviderSpecific(providerSpecificForceUpdate, "true")
@@ -198,7 +312,45 @@ func (im *TXTRegistry) Records(ctx context.Context) ([]*endpoint.Endpoint, error
 				}
 			}
 		}
+
+		// Rewrite the TXT records of this instance in the format of the labels written
+		if labelsExist && ep.Labels[endpoint.OwnerLabelKey] == im.ownerID && ep.Labels.Format() != im.labelsCodec.Format() {
+			if plan.IsManagedRecord(ep.RecordType, im.managedRecordTypes, im.excludeRecordTypes) {
+				ep.WithProviderSpecific(providerSpecificForceUpdate, "true")
+			}
+		}
+	}
+
+	if im.migrateOwnerFrom != "" {
+		ownerMigrationPendingRecords.WithLabelValues(im.migrateOwnerFrom).Set(float64(pendingMigrations))
+	}
+
+	im.orphans = nil
+	// the records of the types not managed may not be read from the provider, so the TXT records without
+	// record type are only orphaned along with the TXT records of a managed type
+	orphanedNames := map[endpoint.EndpointKey]bool{}
+	for key := range ownedTXTRecords {
+		if key.RecordType != "" && plan.IsManagedRecord(key.RecordType, im.managedRecordTypes, im.excludeRecordTypes) {
+			orphanedNames[endpoint.EndpointKey{DNSName: key.DNSName, SetIdentifier: key.SetIdentifier}] = true
+		}
+	}
+	for key, txtRecords := range ownedTXTRecords {
+		if !orphanedNames[endpoint.EndpointKey{DNSName: key.DNSName, SetIdentifier: key.SetIdentifier}] {
+			continue
+		}
+		if key.RecordType != "" && !plan.IsManagedRecord(key.RecordType, im.managedRecordTypes, im.excludeRecordTypes) {
+			continue
+		}
+		for _, txt := range txtRecords {
+			if txt.Labels == nil {
+				txt.Labels = endpoint.NewLabels()
+			}
+			// owned like the records, so that the plan deletes them
+			txt.Labels[endpoint.OwnerLabelKey] = im.ownerID
+		}
+		im.orphans = append(im.orphans, txtRecords...)
 	}
+	orphanedTXTRecords.Set(float64(len(im.orphans)))
 
 	// Update the cache.
 	if im.cacheInterval > 0 {
@@ -209,14 +361,100 @@ func (im *TXTRegistry) Records(ctx context.Context) ([]*endpoint.Endpoint, error
 	return endpoints, nil
 }
 
-// generateTXTRecord generates both "old" and "new" TXT records.
+// Orphans returns the orphaned TXT records found by the last read of the records, when enabled by
+// CollectOrphans
+func (im *TXTRegistry) Orphans() []*endpoint.Endpoint {
+	if !im.collectOrphans {
+		return nil
+	}
+	return im.orphans
+}
+
+// isOrphan returns true if the endpoint is one of the orphaned TXT records
+func (im *TXTRegistry) isOrphan(ep *endpoint.Endpoint) bool {
+	for _, orphan := range im.orphans {
+		if orphan == ep {
+			return true
+		}
+	}
+	return false
+}
+
+// countOrphans counts the orphaned TXT records deleted by the applied changes, and forgets them
+func (im *TXTRegistry) countOrphans(changes *plan.Changes, owners map[*endpoint.Endpoint]*endpoint.Endpoint, failed map[*endpoint.Endpoint]error) {
+	deleted := map[*endpoint.Endpoint]bool{}
+	for _, txt := range changes.Delete {
+		if !im.isOrphan(txt) {
+			continue
+		}
+		// the orphan replaced by the TXT record of a created record fails with it
+		r := txt
+		if owner, ok := owners[txt]; ok {
+			r = owner
+		}
+		if failed[r] == nil {
+			deleted[txt] = true
+		}
+	}
+	if len(deleted) == 0 {
+		return
+	}
+	orphanedTXTRecordsDeletedTotal.Add(float64(len(deleted)))
+	orphans := make([]*endpoint.Endpoint, 0, len(im.orphans))
+	for _, orphan := range im.orphans {
+		if !deleted[orphan] {
+			orphans = append(orphans, orphan)
+		}
+	}
+	im.orphans = orphans
+}
+
+// previousOwnership returns the record as it was before its migration from its former owner, if any, so
+// that its TXT records are generated with the former owner
+func previousOwnership(r *endpoint.Endpoint) *endpoint.Endpoint {
+	from, ok := r.GetProviderSpecificProperty(providerSpecificMigrateOwner)
+	if !ok {
+		return r
+	}
+	previous := r.DeepCopy()
+	previous.Labels[endpoint.OwnerLabelKey] = from
+	return previous
+}
+
+// countMigrations counts the migrated records of the applied changes
+func (im *TXTRegistry) countMigrations(changes *plan.Changes, failed map[*endpoint.Endpoint]error) {
+	if im.migrateOwnerFrom == "" {
+		return
+	}
+	for i, r := range changes.UpdateOld {
+		if _, ok := r.GetProviderSpecificProperty(providerSpecificMigrateOwner); !ok {
+			continue
+		}
+		if i < len(changes.UpdateNew) && failed[changes.UpdateNew[i]] != nil {
+			continue
+		}
+		ownerMigratedRecordsTotal.WithLabelValues(im.migrateOwnerFrom).Inc()
+	}
+}
+
+// currentLabelsCodec returns the codec of the format the labels of the record were read in, so that its
+// TXT records are generated as they are
+func (im *TXTRegistry) currentLabelsCodec(r *endpoint.Endpoint) endpoint.LabelsCodec {
+	codec, err := endpoint.NewLabelsCodec(r.Labels.Format())
+	if err != nil {
+		return im.labelsCodec
+	}
+	return codec
+}
+
+// generateTXTRecord generates both "old" and "new" TXT records, with the labels encoded with the codec.
 // Once we decide to drop old format we need to drop toTXTName() and rename toNewTXTName
-func (im *TXTRegistry) generateTXTRecord(r *endpoint.Endpoint) []*endpoint.Endpoint {
+func (im *TXTRegistry) generateTXTRecord(r *endpoint.Endpoint, codec endpoint.LabelsCodec) []*endpoint.Endpoint {
 	endpoints := make([]*endpoint.Endpoint, 0)
 
 	if !im.txtEncryptEnabled && !im.mapper.recordTypeInAffix() && r.RecordType != endpoint.RecordTypeAAAA {
 		// old TXT record format
-		txt := endpoint.NewEndpoint(im.mapper.toTXTName(r.DNSName), endpoint.RecordTypeTXT, r.Labels.Serialize(true, im.txtEncryptEnabled, im.txtEncryptAESKey))
+		txt := endpoint.NewEndpoint(im.mapper.toTXTName(r.DNSName), endpoint.RecordTypeTXT, r.Labels.SerializeWith(codec, im.txtEncryptEnabled, im.txtEncryptAESKey))
 		if txt != nil {
 			txt.WithSetIdentifier(r.SetIdentifier)
 			txt.Labels[endpoint.OwnedRecordLabelKey] = r.DNSName
@@ -230,7 +468,7 @@ func (im *TXTRegistry) generateTXTRecord(r *endpoint.Endpoint) []*endpoint.Endpo
 	if isAlias, found := r.GetProviderSpecificProperty("alias"); found && isAlias == "true" && recordType == endpoint.RecordTypeA {
 		recordType = endpoint.RecordTypeCNAME
 	}
-	txtNew := endpoint.NewEndpoint(im.mapper.toNewTXTName(r.DNSName, recordType), endpoint.RecordTypeTXT, r.Labels.Serialize(true, im.txtEncryptEnabled, im.txtEncryptAESKey))
+	txtNew := endpoint.NewEndpoint(im.mapper.toNewTXTName(r.DNSName, recordType), endpoint.RecordTypeTXT, r.Labels.SerializeWith(codec, im.txtEncryptEnabled, im.txtEncryptAESKey))
 	if txtNew != nil {
 		txtNew.WithSetIdentifier(r.SetIdentifier)
 		txtNew.Labels[endpoint.OwnedRecordLabelKey] = r.DNSName
@@ -244,11 +482,102 @@ func (im *TXTRegistry) generateTXTRecord(r *endpoint.Endpoint) []*endpoint.Endpo
 // ApplyChanges updates dns provider with the changes
 // for each created/deleted record it will also take into account TXT records for creation/deletion
 func (im *TXTRegistry) ApplyChanges(ctx context.Context, changes *plan.Changes) error {
+	ctx, filteredChanges, _ := im.registryChanges(ctx, changes)
+	if err := im.provider.ApplyChanges(ctx, filteredChanges); err != nil {
+		return err
+	}
+	im.countMigrations(changes, nil)
+	im.countOrphans(changes, nil, nil)
+	return nil
+}
+
+// ApplyChangesWithResults updates dns provider with the changes like ApplyChanges and returns the
+// result of each change when the provider reports them. A failed TXT record fails the record it belongs to.
+func (im *TXTRegistry) ApplyChangesWithResults(ctx context.Context, changes *plan.Changes) ([]provider.ChangeResult, error) {
+	applier, ok := im.provider.(provider.ResultsApplier)
+	if !ok {
+		return nil, im.ApplyChanges(ctx, changes)
+	}
+
+	ctx, filteredChanges, owners := im.registryChanges(ctx, changes)
+	providerResults, err := applier.ApplyChangesWithResults(ctx, filteredChanges)
+	if err != nil || providerResults == nil {
+		return nil, err
+	}
+
+	failed := map[*endpoint.Endpoint]error{}
+	for _, result := range providerResults {
+		if result.Err == nil {
+			continue
+		}
+		r := result.Endpoint
+		if owner, ok := owners[r]; ok {
+			r = owner
+		}
+		if failed[r] == nil {
+			failed[r] = result.Err
+		}
+	}
+
+	im.countMigrations(changes, failed)
+	im.countOrphans(changes, owners, failed)
+
+	results := make([]provider.ChangeResult, 0, len(providerResults))
+	for _, result := range providerResults {
+		if _, ok := owners[result.Endpoint]; ok {
+			continue
+		}
+		results = append(results, provider.ChangeResult{Endpoint: result.Endpoint, Err: failed[result.Endpoint]})
+	}
+
+	// revert the cache for the records which were not changed
+	if im.cacheInterval > 0 {
+		for _, r := range filteredChanges.Create {
+			if failed[r] != nil {
+				im.removeFromCache(r)
+			}
+		}
+		for _, r := range filteredChanges.Delete {
+			if failed[r] != nil && !im.isOrphan(r) {
+				im.addToCache(r)
+			}
+		}
+		for _, r := range filteredChanges.UpdateNew {
+			if failed[r] == nil {
+				continue
+			}
+			im.removeFromCache(r)
+			for _, old := range filteredChanges.UpdateOld {
+				if old.Key() == r.Key() {
+					im.addToCache(old)
+				}
+			}
+		}
+	}
+
+	return results, nil
+}
+
+// registryChanges returns the changes with the TXT records of their records, and the record each TXT record belongs to
+func (im *TXTRegistry) registryChanges(ctx context.Context, changes *plan.Changes) (context.Context, *plan.Changes, map[*endpoint.Endpoint]*endpoint.Endpoint) {
+	owners := map[*endpoint.Endpoint]*endpoint.Endpoint{}
+	withTXT := func(endpoints []*endpoint.Endpoint, r *endpoint.Endpoint, codec endpoint.LabelsCodec) []*endpoint.Endpoint {
+		for _, txt := range im.generateTXTRecord(previousOwnership(r), codec) {
+			owners[txt] = r
+			endpoints = append(endpoints, txt)
+		}
+		return endpoints
+	}
+
+	filter := endpoint.FilterEndpointsByOwnerID
+	if im.sharedOwnership {
+		filter = endpoint.FilterEndpointsBySharedOwnerID
+	}
 	filteredChanges := &plan.Changes{
 		Create:    changes.Create,
-		UpdateNew: endpoint.FilterEndpointsByOwnerID(im.ownerID, changes.UpdateNew),
-		UpdateOld: endpoint.FilterEndpointsByOwnerID(im.ownerID, changes.UpdateOld),
-		Delete:    endpoint.FilterEndpointsByOwnerID(im.ownerID, changes.Delete),
+		UpdateNew: filter(im.ownerID, changes.UpdateNew),
+		UpdateOld: filter(im.ownerID, changes.UpdateOld),
+		Delete:    filter(im.ownerID, changes.Delete),
 	}
 	for _, r := range filteredChanges.Create {
 		if r.Labels == nil {
@@ -256,7 +585,7 @@ func (im *TXTRegistry) ApplyChanges(ctx context.Context, changes *plan.Changes)
 		}
 		r.Labels[endpoint.OwnerLabelKey] = im.ownerID
 
-		filteredChanges.Create = append(filteredChanges.Create, im.generateTXTRecord(r)...)
+		filteredChanges.Create = withTXT(filteredChanges.Create, r, im.labelsCodec)
 
 		if im.cacheInterval > 0 {
 			im.addToCache(r)
@@ -264,10 +593,14 @@ func (im *TXTRegistry) ApplyChanges(ctx context.Context, changes *plan.Changes)
 	}
 
 	for _, r := range filteredChanges.Delete {
+		// the orphaned TXT records are deleted as they are
+		if im.isOrphan(r) {
+			continue
+		}
 		// when we delete TXT records for which value has changed (due to new label) this would still work because
 		// !!! TXT record value is uniquely generated from the Labels of the endpoint. Hence old TXT record can be uniquely reconstructed
 		// !!! After migration to the new TXT registry format we can drop records in old format here!!!
-		filteredChanges.Delete = append(filteredChanges.Delete, im.generateTXTRecord(r)...)
+		filteredChanges.Delete = withTXT(filteredChanges.Delete, r, im.currentLabelsCodec(r))
 
 		if im.cacheInterval > 0 {
 			im.removeFromCache(r)
@@ -278,7 +611,7 @@ func (im *TXTRegistry) ApplyChanges(ctx context.Context, changes *plan.Changes)
 	for _, r := range filteredChanges.UpdateOld {
 		// when we updateOld TXT records for which value has changed (due to new label) this would still work because
 		// !!! TXT record value is uniquely generated from the Labels of the endpoint. Hence old TXT record can be uniquely reconstructed
-		filteredChanges.UpdateOld = append(filteredChanges.UpdateOld, im.generateTXTRecord(r)...)
+		filteredChanges.UpdateOld = withTXT(filteredChanges.UpdateOld, r, im.currentLabelsCodec(r))
 		// remove old version of record from cache
 		if im.cacheInterval > 0 {
 			im.removeFromCache(r)
@@ -287,18 +620,46 @@ func (im *TXTRegistry) ApplyChanges(ctx context.Context, changes *plan.Changes)
 
 	// make sure TXT records are consistently updated as well
 	for _, r := range filteredChanges.UpdateNew {
-		filteredChanges.UpdateNew = append(filteredChanges.UpdateNew, im.generateTXTRecord(r)...)
+		filteredChanges.UpdateNew = withTXT(filteredChanges.UpdateNew, r, im.labelsCodec)
 		// add new version of record to cache
 		if im.cacheInterval > 0 {
 			im.addToCache(r)
 		}
 	}
 
+	im.replaceOrphans(filteredChanges, owners)
+
 	// when caching is enabled, disable the provider from using the cache
 	if im.cacheInterval > 0 {
 		ctx = context.WithValue(ctx, provider.RecordsContextKey, nil)
 	}
-	return im.provider.ApplyChanges(ctx, filteredChanges)
+	return ctx, filteredChanges, owners
+}
+
+// replaceOrphans updates the orphaned TXT records with the TXT records of the created records, instead of
+// deleting and creating them again in the same changes
+func (im *TXTRegistry) replaceOrphans(changes *plan.Changes, owners map[*endpoint.Endpoint]*endpoint.Endpoint) {
+	deletes := make([]*endpoint.Endpoint, 0, len(changes.Delete))
+	for _, orphan := range changes.Delete {
+		replaced := false
+		if im.isOrphan(orphan) {
+			for i, txt := range changes.Create {
+				if _, ok := owners[txt]; !ok || txt.Key() != orphan.Key() {
+					continue
+				}
+				changes.Create = append(changes.Create[:i], changes.Create[i+1:]...)
+				changes.UpdateOld = append(changes.UpdateOld, orphan)
+				changes.UpdateNew = append(changes.UpdateNew, txt)
+				owners[orphan] = owners[txt]
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			deletes = append(deletes, orphan)
+		}
+	}
+	changes.Delete = deletes
 }
 
 // AdjustEndpoints modifies the endpoints as needed by the specific provider
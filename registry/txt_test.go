@@ -23,6 +23,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -1144,6 +1145,43 @@ func testTXTRegistryMissingRecordsWithPrefix(t *testing.T) {
 	assert.True(t, testutils.SameEndpoints(records, expectedRecords))
 }
 
+func TestTXTRegistryApplyChangesWithResults(t *testing.T) {
+	ctx := context.Background()
+	p := inmemory.NewInMemoryProvider()
+	p.CreateZone(testZone)
+	require.NoError(t, p.ApplyChanges(ctx, &plan.Changes{
+		Create: []*endpoint.Endpoint{
+			newEndpointWithOwner("exists.test-zone.example.org", "exists.loadbalancer.com", endpoint.RecordTypeCNAME, ""),
+			newEndpointWithOwner("cname-txt-exists.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=other\"", endpoint.RecordTypeTXT, ""),
+		},
+	}))
+	r, _ := NewTXTRegistry(p, "", "", "owner", time.Hour, "", []string{}, []string{}, false, nil)
+	_, err := r.Records(ctx)
+	require.NoError(t, err)
+
+	created := newEndpointWithOwner("new.test-zone.example.org", "new.loadbalancer.com", endpoint.RecordTypeCNAME, "")
+	exists := newEndpointWithOwner("exists.test-zone.example.org", "other.loadbalancer.com", endpoint.RecordTypeCNAME, "")
+	txtExists := newEndpointWithOwner("txt-exists.test-zone.example.org", "txt.loadbalancer.com", endpoint.RecordTypeCNAME, "")
+	results, err := r.ApplyChangesWithResults(ctx, &plan.Changes{
+		Create: []*endpoint.Endpoint{created, exists, txtExists},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []provider.ChangeResult{
+		{Endpoint: created},
+		{Endpoint: exists, Err: inmemory.ErrRecordAlreadyExists},
+		{Endpoint: txtExists, Err: inmemory.ErrRecordAlreadyExists},
+	}, results)
+
+	// only the created record is cached
+	cached := map[string]bool{}
+	for _, ep := range r.recordsCache {
+		if ep.Labels[endpoint.OwnerLabelKey] == "owner" {
+			cached[ep.DNSName] = true
+		}
+	}
+	assert.Equal(t, map[string]bool{"new.test-zone.example.org": true}, cached)
+}
+
 func TestCacheMethods(t *testing.T) {
 	cache := []*endpoint.Endpoint{
 		newEndpointWithOwner("thing.com", "1.2.3.4", "A", "owner"),
@@ -1503,7 +1541,7 @@ func TestGenerateTXT(t *testing.T) {
 	p := inmemory.NewInMemoryProvider()
 	p.CreateZone(testZone)
 	r, _ := NewTXTRegistry(p, "", "", "owner", time.Hour, "", []string{}, []string{}, false, nil)
-	gotTXT := r.generateTXTRecord(record)
+	gotTXT := r.generateTXTRecord(record, r.labelsCodec)
 	assert.Equal(t, expectedTXT, gotTXT)
 }
 
@@ -1522,7 +1560,7 @@ func TestGenerateTXTForAAAA(t *testing.T) {
 	p := inmemory.NewInMemoryProvider()
 	p.CreateZone(testZone)
 	r, _ := NewTXTRegistry(p, "", "", "owner", time.Hour, "", []string{}, []string{}, false, nil)
-	gotTXT := r.generateTXTRecord(record)
+	gotTXT := r.generateTXTRecord(record, r.labelsCodec)
 	assert.Equal(t, expectedTXT, gotTXT)
 }
 
@@ -1539,7 +1577,7 @@ func TestFailGenerateTXT(t *testing.T) {
 	p := inmemory.NewInMemoryProvider()
 	p.CreateZone(testZone)
 	r, _ := NewTXTRegistry(p, "", "", "owner", time.Hour, "", []string{}, []string{}, false, nil)
-	gotTXT := r.generateTXTRecord(cnameRecord)
+	gotTXT := r.generateTXTRecord(cnameRecord, r.labelsCodec)
 	assert.Equal(t, expectedTXT, gotTXT)
 }
 
@@ -1590,6 +1628,314 @@ func TestTXTRegistryApplyChangesEncrypt(t *testing.T) {
 // if the same ingress host is deployed to the other. With the introduction of Dual Record support each record type
 // was treated independently and would cause each cluster to fight over ownership. This tests ensure that the default
 // Dual Stack record support only treats AAAA records independently and while keeping A and CNAME record ownership intact.
+func TestTXTRegistryMigrateOwner(t *testing.T) {
+	ctx := context.Background()
+	p := inmemory.NewInMemoryProvider()
+	p.CreateZone(testZone)
+	require.NoError(t, p.ApplyChanges(ctx, &plan.Changes{
+		Create: []*endpoint.Endpoint{
+			newEndpointWithOwner("foo.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, ""),
+			newEndpointWithOwner("foo.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=old,external-dns/resource=ingress/default/foo\"", endpoint.RecordTypeTXT, ""),
+			newEndpointWithOwner("a-foo.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=old,external-dns/resource=ingress/default/foo\"", endpoint.RecordTypeTXT, ""),
+			newEndpointWithOwner("gone.test-zone.example.org", "gone.loadbalancer.com", endpoint.RecordTypeCNAME, ""),
+			newEndpointWithOwner("gone.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=old,external-dns/resource=ingress/default/gone\"", endpoint.RecordTypeTXT, ""),
+			newEndpointWithOwner("cname-gone.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=old,external-dns/resource=ingress/default/gone\"", endpoint.RecordTypeTXT, ""),
+			newEndpointWithOwner("other.test-zone.example.org", "other.loadbalancer.com", endpoint.RecordTypeCNAME, ""),
+			newEndpointWithOwner("cname-other.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=other\"", endpoint.RecordTypeTXT, ""),
+		},
+	}))
+	r, err := NewTXTRegistry(p, "", "", "new", 0, "", []string{}, []string{}, false, nil)
+	require.NoError(t, err)
+	r.MigrateOwnerFrom("old")
+
+	records, err := r.Records(ctx)
+	require.NoError(t, err)
+	owners := map[string]string{}
+	for _, record := range records {
+		owners[record.DNSName] = record.Labels[endpoint.OwnerLabelKey]
+	}
+	assert.Equal(t, map[string]string{
+		"foo.test-zone.example.org":   "new",
+		"gone.test-zone.example.org":  "new",
+		"other.test-zone.example.org": "other",
+	}, owners)
+	assert.Equal(t, 2.0, testutil.ToFloat64(ownerMigrationPendingRecords.WithLabelValues("old")))
+
+	// the records of the former owner are updated or deleted, not the ones of other owners
+	desired := endpoint.NewEndpoint("foo.test-zone.example.org", endpoint.RecordTypeA, "1.2.3.4")
+	desired.Labels[endpoint.ResourceLabelKey] = "ingress/default/foo"
+	changes := (&plan.Plan{
+		Policies:       []plan.Policy{&plan.SyncPolicy{}},
+		Current:        records,
+		Desired:        []*endpoint.Endpoint{desired},
+		ManagedRecords: []string{endpoint.RecordTypeA, endpoint.RecordTypeCNAME},
+		OwnerID:        "new",
+	}).Calculate().Changes
+	require.Len(t, changes.UpdateNew, 1)
+	require.Len(t, changes.Delete, 1)
+	assert.Equal(t, "gone.test-zone.example.org", changes.Delete[0].DNSName)
+
+	// the former TXT records are identified by their former owner
+	p.OnApplyChanges = func(ctx context.Context, changes *plan.Changes) {
+		for _, txt := range append(changes.UpdateOld, changes.Delete...) {
+			if txt.RecordType == endpoint.RecordTypeTXT {
+				assert.Contains(t, txt.Targets[0], "external-dns/owner=old,", txt.DNSName)
+			}
+		}
+	}
+	migrated := testutil.ToFloat64(ownerMigratedRecordsTotal.WithLabelValues("old"))
+	require.NoError(t, r.ApplyChanges(ctx, changes))
+	assert.Equal(t, migrated+1, testutil.ToFloat64(ownerMigratedRecordsTotal.WithLabelValues("old")))
+
+	// the TXT records of the former owner are rewritten or deleted
+	records, err = p.Records(ctx)
+	require.NoError(t, err)
+	txtRecords := map[string]string{}
+	for _, record := range records {
+		if record.RecordType == endpoint.RecordTypeTXT {
+			txtRecords[record.DNSName] = record.Targets[0]
+		}
+	}
+	assert.Equal(t, map[string]string{
+		"foo.test-zone.example.org":         "\"heritage=external-dns,external-dns/owner=new,external-dns/resource=ingress/default/foo\"",
+		"a-foo.test-zone.example.org":       "\"heritage=external-dns,external-dns/owner=new,external-dns/resource=ingress/default/foo\"",
+		"cname-other.test-zone.example.org": "\"heritage=external-dns,external-dns/owner=other\"",
+	}, txtRecords)
+
+	// nothing is left to migrate
+	records, err = r.Records(ctx)
+	require.NoError(t, err)
+	for _, record := range records {
+		_, ok := record.GetProviderSpecificProperty(providerSpecificMigrateOwner)
+		assert.False(t, ok, record.DNSName)
+	}
+	assert.Equal(t, 0.0, testutil.ToFloat64(ownerMigrationPendingRecords.WithLabelValues("old")))
+}
+
+func TestTXTRegistrySharedOwnership(t *testing.T) {
+	ctx := context.Background()
+	p := inmemory.NewInMemoryProvider()
+	p.CreateZone(testZone)
+	newRegistry := func(ownerID string) *TXTRegistry {
+		r, err := NewTXTRegistry(p, "", "", ownerID, 0, "", []string{}, []string{}, false, nil)
+		require.NoError(t, err)
+		r.ShareOwnership()
+		return r
+	}
+	clusterA, clusterB := newRegistry("cluster-a"), newRegistry("cluster-b")
+	sync := func(r *TXTRegistry, targets ...string) {
+		records, err := r.Records(ctx)
+		require.NoError(t, err)
+		var desired []*endpoint.Endpoint
+		if len(targets) > 0 {
+			desired = append(desired, endpoint.NewEndpoint("shared.test-zone.example.org", endpoint.RecordTypeA, targets...))
+		}
+		changes := (&plan.Plan{
+			Policies:        []plan.Policy{&plan.SyncPolicy{}},
+			Current:         records,
+			Desired:         desired,
+			ManagedRecords:  []string{endpoint.RecordTypeA},
+			OwnerID:         r.OwnerID(),
+			SharedOwnership: true,
+		}).Calculate().Changes
+		require.NoError(t, r.ApplyChanges(ctx, changes))
+	}
+	// sharedRecord returns the shared record as read by the registry, nil if it does not exist
+	sharedRecord := func() *endpoint.Endpoint {
+		records, err := clusterA.Records(ctx)
+		require.NoError(t, err)
+		for _, record := range records {
+			if record.RecordType == endpoint.RecordTypeA {
+				return record
+			}
+		}
+		return nil
+	}
+
+	sync(clusterA, "1.1.1.1")
+	sync(clusterB, "2.2.2.2")
+	record := sharedRecord()
+	require.NotNil(t, record)
+	assert.True(t, record.Targets.Same(endpoint.Targets{"1.1.1.1", "2.2.2.2"}), record.Targets)
+	assert.Equal(t, "cluster-a", record.Labels[endpoint.OwnerLabelKey])
+	assert.Equal(t, endpoint.SharedOwners{"cluster-a": {"1.1.1.1"}, "cluster-b": {"2.2.2.2"}}, record.SharedOwners())
+
+	// each cluster only removes its own targets, the last one deletes the record and its TXT records
+	sync(clusterA)
+	record = sharedRecord()
+	require.NotNil(t, record)
+	assert.Equal(t, endpoint.Targets{"2.2.2.2"}, record.Targets)
+	assert.Equal(t, "cluster-b", record.Labels[endpoint.OwnerLabelKey])
+	assert.Equal(t, endpoint.SharedOwners{"cluster-b": {"2.2.2.2"}}, record.SharedOwners())
+
+	sync(clusterB)
+	records, err := p.Records(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, records)
+}
+
+func TestTXTRegistryLabelsFormat(t *testing.T) {
+	ctx := context.Background()
+	p := inmemory.NewInMemoryProvider()
+	p.CreateZone(testZone)
+	require.NoError(t, p.ApplyChanges(ctx, &plan.Changes{
+		Create: []*endpoint.Endpoint{
+			newEndpointWithOwner("foo.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, ""),
+			newEndpointWithOwner("foo.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=owner,external-dns/resource=ingress/default/foo\"", endpoint.RecordTypeTXT, ""),
+			newEndpointWithOwner("a-foo.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=owner,external-dns/resource=ingress/default/foo\"", endpoint.RecordTypeTXT, ""),
+			newEndpointWithOwner("other.test-zone.example.org", "other.loadbalancer.com", endpoint.RecordTypeCNAME, ""),
+			newEndpointWithOwner("cname-other.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=other\"", endpoint.RecordTypeTXT, ""),
+		},
+	}))
+	r, err := NewTXTRegistry(p, "", "", "owner", 0, "", []string{endpoint.RecordTypeA, endpoint.RecordTypeCNAME}, []string{}, false, nil)
+	require.NoError(t, err)
+	require.NoError(t, r.UseLabelsFormat(endpoint.LabelsFormatV2))
+	assert.Error(t, r.UseLabelsFormat("v3"))
+
+	// the records of this instance in the former format are updated
+	records, err := r.Records(ctx)
+	require.NoError(t, err)
+	desired := endpoint.NewEndpoint("foo.test-zone.example.org", endpoint.RecordTypeA, "1.2.3.4")
+	desired.Labels[endpoint.ResourceLabelKey] = "ingress/default/foo"
+	created := endpoint.NewEndpoint("bar.test-zone.example.org", endpoint.RecordTypeA, "1.2.3.5")
+	created.Labels[endpoint.ResourceLabelKey] = "ingress/default/bar,with=separators"
+	changes := (&plan.Plan{
+		Policies:       []plan.Policy{&plan.SyncPolicy{}},
+		Current:        records,
+		Desired:        []*endpoint.Endpoint{desired, created},
+		ManagedRecords: []string{endpoint.RecordTypeA, endpoint.RecordTypeCNAME},
+		OwnerID:        "owner",
+	}).Calculate().Changes
+	require.Len(t, changes.UpdateNew, 1)
+	require.NoError(t, r.ApplyChanges(ctx, changes))
+
+	records, err = p.Records(ctx)
+	require.NoError(t, err)
+	txtRecords := map[string]string{}
+	for _, record := range records {
+		if record.RecordType == endpoint.RecordTypeTXT {
+			txtRecords[record.DNSName] = record.Targets[0]
+		}
+	}
+	assert.Equal(t, "\"heritage=external-dns,external-dns/owner=other\"", txtRecords["cname-other.test-zone.example.org"])
+	for _, name := range []string{"foo", "a-foo", "bar", "a-bar"} {
+		assert.True(t, strings.HasPrefix(txtRecords[name+".test-zone.example.org"], "\"external-dns/v2:"), name)
+	}
+
+	// the labels are read back, and nothing is left to rewrite
+	records, err = r.Records(ctx)
+	require.NoError(t, err)
+	resources := map[string]string{}
+	for _, record := range records {
+		resources[record.DNSName] = record.Labels[endpoint.ResourceLabelKey]
+		_, ok := record.GetProviderSpecificProperty(providerSpecificForceUpdate)
+		assert.False(t, ok, record.DNSName)
+	}
+	assert.Equal(t, map[string]string{
+		"foo.test-zone.example.org":   "ingress/default/foo",
+		"bar.test-zone.example.org":   "ingress/default/bar,with=separators",
+		"other.test-zone.example.org": "",
+	}, resources)
+
+	// the records in the new format are deleted with their TXT records
+	changes = (&plan.Plan{
+		Policies:       []plan.Policy{&plan.SyncPolicy{}},
+		Current:        records,
+		ManagedRecords: []string{endpoint.RecordTypeA, endpoint.RecordTypeCNAME},
+		OwnerID:        "owner",
+	}).Calculate().Changes
+	require.Len(t, changes.Delete, 2)
+	require.NoError(t, r.ApplyChanges(ctx, changes))
+	records, err = p.Records(ctx)
+	require.NoError(t, err)
+	assert.Len(t, records, 2)
+}
+
+func TestTXTRegistryOrphans(t *testing.T) {
+	ctx := context.Background()
+	p := inmemory.NewInMemoryProvider()
+	p.CreateZone(testZone)
+	require.NoError(t, p.ApplyChanges(ctx, &plan.Changes{
+		Create: []*endpoint.Endpoint{
+			newEndpointWithOwner("foo.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, ""),
+			newEndpointWithOwner("foo.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=owner\"", endpoint.RecordTypeTXT, ""),
+			newEndpointWithOwner("a-foo.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=owner\"", endpoint.RecordTypeTXT, ""),
+			// the records were deleted
+			newEndpointWithOwner("gone.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=owner\"", endpoint.RecordTypeTXT, ""),
+			newEndpointWithOwner("cname-gone.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=owner\"", endpoint.RecordTypeTXT, ""),
+			newEndpointWithOwner("aaaa-gone.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=owner\"", endpoint.RecordTypeTXT, ""),
+			// the orphaned TXT records of other owners, and of the record types not managed, are kept
+			newEndpointWithOwner("cname-other.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=other\"", endpoint.RecordTypeTXT, ""),
+			newEndpointWithOwner("ns-sub.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=owner\"", endpoint.RecordTypeTXT, ""),
+			// the TXT record without record type of a record type not managed is kept as well
+			newEndpointWithOwner("sub.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=owner\"", endpoint.RecordTypeTXT, ""),
+		},
+	}))
+	r, err := NewTXTRegistry(p, "", "", "owner", 0, "", []string{endpoint.RecordTypeA, endpoint.RecordTypeAAAA, endpoint.RecordTypeCNAME}, []string{}, false, nil)
+	require.NoError(t, err)
+	txtRecords := func() []string {
+		records, err := p.Records(ctx)
+		require.NoError(t, err)
+		var names []string
+		for _, record := range records {
+			if record.RecordType == endpoint.RecordTypeTXT {
+				names = append(names, record.DNSName)
+			}
+		}
+		return names
+	}
+
+	records, err := r.Records(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 3.0, testutil.ToFloat64(orphanedTXTRecords))
+
+	// the orphaned TXT records are only deleted when enabled
+	assert.Empty(t, r.Orphans())
+	r.CollectOrphans()
+	assert.Len(t, r.Orphans(), 3)
+
+	calculate := func(policy plan.Policy, desired []*endpoint.Endpoint) *plan.Changes {
+		p := &plan.Plan{
+			Policies:       []plan.Policy{policy},
+			Current:        records,
+			Desired:        desired,
+			ManagedRecords: []string{endpoint.RecordTypeA, endpoint.RecordTypeAAAA, endpoint.RecordTypeCNAME},
+			OwnerID:        "owner",
+			Orphans:        r.Orphans(),
+		}
+		return p.Calculate().Changes
+	}
+
+	// the orphaned TXT records are deleted under the policy
+	assert.Empty(t, calculate(&plan.UpsertOnlyPolicy{}, records).Delete)
+
+	// the TXT records of a record created again replace its orphaned TXT records
+	desired := append([]*endpoint.Endpoint{
+		newEndpointWithOwner("gone.test-zone.example.org", "bar.example.org", endpoint.RecordTypeCNAME, ""),
+	}, records...)
+	changes := calculate(&plan.SyncPolicy{}, desired)
+	assert.Len(t, changes.Delete, 3)
+	deleted := testutil.ToFloat64(orphanedTXTRecordsDeletedTotal)
+	require.NoError(t, r.ApplyChanges(ctx, changes))
+	assert.ElementsMatch(t, []string{
+		"foo.test-zone.example.org",
+		"a-foo.test-zone.example.org",
+		"gone.test-zone.example.org",
+		"cname-gone.test-zone.example.org",
+		"cname-other.test-zone.example.org",
+		"ns-sub.test-zone.example.org",
+		"sub.test-zone.example.org",
+	}, txtRecords())
+	assert.Equal(t, deleted+3, testutil.ToFloat64(orphanedTXTRecordsDeletedTotal))
+
+	// nothing is left to delete
+	assert.Empty(t, r.Orphans())
+	_, err = r.Records(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0.0, testutil.ToFloat64(orphanedTXTRecords))
+	assert.Empty(t, r.Orphans())
+}
+
 func TestMultiClusterDifferentRecordTypeOwnership(t *testing.T) {
 	ctx := context.Background()
 	p := inmemory.NewInMemoryProvider()
@@ -0,0 +1,78 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"context"
+	"sync"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+// EndpointCounts holds the number of endpoints listed the last time by each source type.
+type EndpointCounts struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+// Counts returns the number of endpoints listed the last time by each source type
+func (c *EndpointCounts) Counts() map[string]int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	counts := make(map[string]int, len(c.counts))
+	for sourceType, count := range c.counts {
+		counts[sourceType] = count
+	}
+	return counts
+}
+
+func (c *EndpointCounts) set(sourceType string, count int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.counts == nil {
+		c.counts = map[string]int{}
+	}
+	c.counts[sourceType] = count
+}
+
+// countingSource is a Source that counts the endpoints of its wrapped source.
+type countingSource struct {
+	Source
+	sourceType string
+	counts     *EndpointCounts
+}
+
+// NewCountingSource creates a new countingSource wrapping the provided Source, counting its endpoints
+// as the ones of the given source type.
+func NewCountingSource(sourceType string, source Source, counts *EndpointCounts) Source {
+	return &countingSource{Source: source, sourceType: sourceType, counts: counts}
+}
+
+// AddObservedEventHandler adds the observed event handler to its wrapped source.
+func (cs *countingSource) AddObservedEventHandler(ctx context.Context, handler func(), observer ChangeObserver) {
+	AddObservedEventHandler(ctx, cs.Source, handler, observer)
+}
+
+// Endpoints returns the endpoints of its wrapped source, after counting them.
+func (cs *countingSource) Endpoints(ctx context.Context) ([]*endpoint.Endpoint, error) {
+	endpoints, err := cs.Source.Endpoints(ctx)
+	if err != nil {
+		return nil, err
+	}
+	cs.counts.set(cs.sourceType, len(endpoints))
+	return endpoints, nil
+}
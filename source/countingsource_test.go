@@ -0,0 +1,60 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/internal/testutils"
+)
+
+// Validates that countingSource is a Source
+var _ Source = &countingSource{}
+
+func TestCountingSource(t *testing.T) {
+	services := new(testutils.MockSource)
+	services.On("Endpoints").Return([]*endpoint.Endpoint{
+		endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeA, "1.2.3.4"),
+		endpoint.NewEndpoint("bar.example.org", endpoint.RecordTypeA, "1.2.3.4"),
+	}, nil)
+	ingresses := new(testutils.MockSource)
+	ingresses.On("Endpoints").Return([]*endpoint.Endpoint{}, nil)
+	failing := new(testutils.MockSource)
+	failing.On("Endpoints").Return(nil, errors.New("failed"))
+
+	counts := &EndpointCounts{}
+	assert.Empty(t, counts.Counts())
+
+	endpoints, err := NewMultiSource([]Source{
+		NewCountingSource("service", services, counts),
+		NewCountingSource("ingress", ingresses, counts),
+	}, nil).Endpoints(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, endpoints, 2)
+	assert.Equal(t, map[string]int{"service": 2, "ingress": 0}, counts.Counts())
+
+	// the count of a failed listing is kept
+	_, err = NewCountingSource("service", failing, counts).Endpoints(context.Background())
+	assert.Error(t, err)
+	assert.Equal(t, map[string]int{"service": 2, "ingress": 0}, counts.Counts())
+}
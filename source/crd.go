@@ -138,24 +138,17 @@ func NewCRDSource(crdClient rest.Interface, namespace, kind string, annotationFi
 }
 
 func (cs *crdSource) AddEventHandler(ctx context.Context, handler func()) {
+	cs.AddObservedEventHandler(ctx, handler, nil)
+}
+
+// AddObservedEventHandler adds an event handler telling the observer which DNSEndpoint changed
+func (cs *crdSource) AddObservedEventHandler(ctx context.Context, handler func(), observer ChangeObserver) {
 	if cs.informer != nil {
 		log.Debug("Adding event handler for CRD")
 		// Right now there is no way to remove event handler from informer, see:
 		// https://github.com/kubernetes/kubernetes/issues/79610
 		informer := *cs.informer
-		informer.AddEventHandler(
-			cache.ResourceEventHandlerFuncs{
-				AddFunc: func(obj interface{}) {
-					handler()
-				},
-				UpdateFunc: func(old interface{}, new interface{}) {
-					handler()
-				},
-				DeleteFunc: func(obj interface{}) {
-					handler()
-				},
-			},
-		)
+		informer.AddEventHandler(newResourceEventHandler("crd", handler, observer))
 	}
 }
 
@@ -233,6 +226,7 @@ func (cs *crdSource) setResourceLabel(crd *endpoint.DNSEndpoint, endpoints []*en
 	for _, ep := range endpoints {
 		ep.Labels[endpoint.ResourceLabelKey] = fmt.Sprintf("crd/%s/%s", crd.ObjectMeta.Namespace, crd.ObjectMeta.Name)
 	}
+	setRankLabels(&crd.ObjectMeta, endpoints)
 }
 
 func (cs *crdSource) watch(ctx context.Context, opts *metav1.ListOptions) (watch.Interface, error) {
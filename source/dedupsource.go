@@ -62,3 +62,8 @@ func (ms *dedupSource) Endpoints(ctx context.Context) ([]*endpoint.Endpoint, err
 func (ms *dedupSource) AddEventHandler(ctx context.Context, handler func()) {
 	ms.source.AddEventHandler(ctx, handler)
 }
+
+// AddObservedEventHandler adds the observed event handler to the wrapped source
+func (ms *dedupSource) AddObservedEventHandler(ctx context.Context, handler func(), observer ChangeObserver) {
+	AddObservedEventHandler(ctx, ms.source, handler, observer)
+}
@@ -180,10 +180,15 @@ func newGatewayRouteSource(clients ClientGenerator, config *Config, kind string,
 }
 
 func (src *gatewayRouteSource) AddEventHandler(ctx context.Context, handler func()) {
+	src.AddObservedEventHandler(ctx, handler, nil)
+}
+
+// AddObservedEventHandler adds the event handlers, the one of the routes telling the observer which route changed
+func (src *gatewayRouteSource) AddObservedEventHandler(ctx context.Context, handler func(), observer ChangeObserver) {
 	log.Debugf("Adding event handlers for %s", src.rtKind)
 	eventHandler := eventHandlerFunc(handler)
 	src.gwInformer.Informer().AddEventHandler(eventHandler)
-	src.rtInformer.Informer().AddEventHandler(eventHandler)
+	src.rtInformer.Informer().AddEventHandler(newResourceEventHandler(strings.ToLower(src.rtKind), handler, observer))
 	src.nsInformer.Informer().AddEventHandler(eventHandler)
 }
 
@@ -232,9 +237,12 @@ func (src *gatewayRouteSource) Endpoints(ctx context.Context) ([]*endpoint.Endpo
 		resource := fmt.Sprintf("%s/%s/%s", kind, meta.Namespace, meta.Name)
 		providerSpecific, setIdentifier := getProviderSpecificAnnotations(annots)
 		ttl := getTTLFromAnnotations(annots, resource)
+		var rtEndpoints []*endpoint.Endpoint
 		for host, targets := range hostTargets {
-			endpoints = append(endpoints, endpointsForHostname(host, targets, ttl, providerSpecific, setIdentifier, resource)...)
+			rtEndpoints = append(rtEndpoints, endpointsForHostname(host, targets, ttl, providerSpecific, setIdentifier, resource)...)
 		}
+		setRankLabels(meta, rtEndpoints)
+		endpoints = append(endpoints, rtEndpoints...)
 		log.Debugf("Endpoints generated from %s %s/%s: %v", src.rtKind, meta.Namespace, meta.Name, endpoints)
 	}
 	return endpoints, nil
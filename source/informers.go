@@ -0,0 +1,65 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"sync"
+
+	kubeinformers "k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+)
+
+// KubeInformerFactories holds the informer factories of the sources, so that the sources created with
+// the same client and namespace share their informers, e.g. the sources of several pipelines running
+// in the same process watching the same objects. The informers are started with the context of the
+// first source, so the sources sharing them are created with the same context.
+type KubeInformerFactories struct {
+	mu        sync.Mutex
+	factories map[kubeInformerFactoryKey]kubeinformers.SharedInformerFactory
+}
+
+type kubeInformerFactoryKey struct {
+	client    kubernetes.Interface
+	namespace string
+}
+
+// NewKubeInformerFactories returns the informer factories shared by the sources created with them
+func NewKubeInformerFactories() *KubeInformerFactories {
+	return &KubeInformerFactories{factories: map[kubeInformerFactoryKey]kubeinformers.SharedInformerFactory{}}
+}
+
+// factory returns the informer factory of the client and namespace, a new one which is not shared when
+// the factories are nil
+func (f *KubeInformerFactories) factory(kubeClient kubernetes.Interface, namespace string) kubeinformers.SharedInformerFactory {
+	if f == nil {
+		return newKubeInformerFactory(kubeClient, namespace)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	key := kubeInformerFactoryKey{client: kubeClient, namespace: namespace}
+	factory, ok := f.factories[key]
+	if !ok {
+		factory = newKubeInformerFactory(kubeClient, namespace)
+		f.factories[key] = factory
+	}
+	return factory
+}
+
+func newKubeInformerFactory(kubeClient kubernetes.Interface, namespace string) kubeinformers.SharedInformerFactory {
+	// Set resync period to 0, to prevent processing when nothing has changed
+	return kubeinformers.NewSharedInformerFactoryWithOptions(kubeClient, 0, kubeinformers.WithNamespace(namespace))
+}
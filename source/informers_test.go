@@ -0,0 +1,71 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestKubeInformerFactories(t *testing.T) {
+	kubeClient := fake.NewSimpleClientset()
+	factories := NewKubeInformerFactories()
+
+	factory := factories.factory(kubeClient, "default")
+	assert.Same(t, factory, factories.factory(kubeClient, "default"))
+	assert.NotSame(t, factory, factories.factory(kubeClient, "kube-system"))
+	assert.NotSame(t, factory, factories.factory(fake.NewSimpleClientset(), "default"))
+	assert.NotSame(t, factory, NewKubeInformerFactories().factory(kubeClient, "default"))
+
+	// the factories are not shared without factories
+	var none *KubeInformerFactories
+	assert.NotSame(t, none.factory(kubeClient, "default"), none.factory(kubeClient, "default"))
+}
+
+func TestKubeInformerFactoriesSources(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	kubeClient := fake.NewSimpleClientset()
+	_, err := kubeClient.CoreV1().Nodes().Create(ctx, &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node1"},
+		Status: v1.NodeStatus{
+			Addresses: []v1.NodeAddress{{Type: v1.NodeExternalIP, Address: "1.2.3.4"}},
+		},
+	}, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	// the pod and node sources watch the nodes through the same informer
+	factories := NewKubeInformerFactories()
+	_, err = NewPodSource(ctx, kubeClient, factories, "", "")
+	require.NoError(t, err)
+	nodes, err := NewNodeSource(ctx, kubeClient, factories, "", "{{.Name}}.example.org", labels.Everything())
+	require.NoError(t, err)
+
+	informers := factories.factory(kubeClient, "").WaitForCacheSync(ctx.Done())
+	assert.Len(t, informers, 2)
+
+	endpoints, err := nodes.Endpoints(ctx)
+	require.NoError(t, err)
+	assert.Len(t, endpoints, 1)
+}
@@ -28,7 +28,6 @@ import (
 	networkv1 "k8s.io/api/networking/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/selection"
-	kubeinformers "k8s.io/client-go/informers"
 	netinformers "k8s.io/client-go/informers/networking/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/cache"
@@ -68,7 +67,7 @@ type ingressSource struct {
 }
 
 // NewIngressSource creates a new ingressSource with the given config.
-func NewIngressSource(ctx context.Context, kubeClient kubernetes.Interface, namespace, annotationFilter string, fqdnTemplate string, combineFqdnAnnotation bool, ignoreHostnameAnnotation bool, ignoreIngressTLSSpec bool, ignoreIngressRulesSpec bool, labelSelector labels.Selector, ingressClassNames []string) (Source, error) {
+func NewIngressSource(ctx context.Context, kubeClient kubernetes.Interface, informerFactories *KubeInformerFactories, namespace, annotationFilter string, fqdnTemplate string, combineFqdnAnnotation bool, ignoreHostnameAnnotation bool, ignoreIngressTLSSpec bool, ignoreIngressRulesSpec bool, labelSelector labels.Selector, ingressClassNames []string) (Source, error) {
 	tmpl, err := parseTemplate(fqdnTemplate)
 	if err != nil {
 		return nil, err
@@ -90,8 +89,7 @@ func NewIngressSource(ctx context.Context, kubeClient kubernetes.Interface, name
 		}
 	}
 	// Use shared informer to listen for add/update/delete of ingresses in the specified namespace.
-	// Set resync period to 0, to prevent processing when nothing has changed.
-	informerFactory := kubeinformers.NewSharedInformerFactoryWithOptions(kubeClient, 0, kubeinformers.WithNamespace(namespace))
+	informerFactory := informerFactories.factory(kubeClient, namespace)
 	ingressInformer := informerFactory.Networking().V1().Ingresses()
 
 	// Add default resource event handlers to properly initialize informer.
@@ -172,6 +170,7 @@ func (sc *ingressSource) Endpoints(ctx context.Context) ([]*endpoint.Endpoint, e
 
 		log.Debugf("Endpoints generated from ingress: %s/%s: %v", ing.Namespace, ing.Name, ingEndpoints)
 		sc.setDualstackLabel(ing, ingEndpoints)
+		setRankLabels(&ing.ObjectMeta, ingEndpoints)
 		endpoints = append(endpoints, ingEndpoints...)
 	}
 
@@ -363,9 +362,14 @@ func targetsFromIngressStatus(status networkv1.IngressStatus) endpoint.Targets {
 }
 
 func (sc *ingressSource) AddEventHandler(ctx context.Context, handler func()) {
+	sc.AddObservedEventHandler(ctx, handler, nil)
+}
+
+// AddObservedEventHandler adds an event handler telling the observer which ingress changed
+func (sc *ingressSource) AddObservedEventHandler(ctx context.Context, handler func(), observer ChangeObserver) {
 	log.Debug("Adding event handler for ingress")
 
 	// Right now there is no way to remove event handler from informer, see:
 	// https://github.com/kubernetes/kubernetes/issues/79610
-	sc.ingressInformer.Informer().AddEventHandler(eventHandlerFunc(handler))
+	sc.ingressInformer.Informer().AddEventHandler(newResourceEventHandler("ingress", handler, observer))
 }
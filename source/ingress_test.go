@@ -57,6 +57,7 @@ func (suite *IngressSuite) SetupTest() {
 	suite.sc, err = NewIngressSource(
 		context.TODO(),
 		fakeClient,
+		nil,
 		"",
 		"",
 		"{{.Name}}",
@@ -153,6 +154,7 @@ func TestNewIngressSource(t *testing.T) {
 			_, err := NewIngressSource(
 				context.TODO(),
 				fake.NewSimpleClientset(),
+				nil,
 				"",
 				ti.annotationFilter,
 				ti.fqdnTemplate,
@@ -1407,6 +1409,7 @@ func testIngressEndpoints(t *testing.T) {
 			source, _ := NewIngressSource(
 				context.TODO(),
 				fakeClient,
+				nil,
 				ti.targetNamespace,
 				ti.annotationFilter,
 				ti.fqdnTemplate,
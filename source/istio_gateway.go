@@ -181,6 +181,7 @@ func (sc *gatewaySource) Endpoints(ctx context.Context) ([]*endpoint.Endpoint, e
 		}
 
 		log.Debugf("Endpoints generated from gateway: %s/%s: %v", gateway.Namespace, gateway.Name, gwEndpoints)
+		setRankLabels(&gateway.ObjectMeta, gwEndpoints)
 		endpoints = append(endpoints, gwEndpoints...)
 	}
 
@@ -193,9 +194,14 @@ func (sc *gatewaySource) Endpoints(ctx context.Context) ([]*endpoint.Endpoint, e
 
 // AddEventHandler adds an event handler that should be triggered if the watched Istio Gateway changes.
 func (sc *gatewaySource) AddEventHandler(ctx context.Context, handler func()) {
+	sc.AddObservedEventHandler(ctx, handler, nil)
+}
+
+// AddObservedEventHandler adds an event handler telling the observer which Istio Gateway changed.
+func (sc *gatewaySource) AddObservedEventHandler(ctx context.Context, handler func(), observer ChangeObserver) {
 	log.Debug("Adding event handler for Istio Gateway")
 
-	sc.gatewayInformer.Informer().AddEventHandler(eventHandlerFunc(handler))
+	sc.gatewayInformer.Informer().AddEventHandler(newResourceEventHandler("gateway", handler, observer))
 }
 
 // filterByAnnotations filters a list of configs by a given annotation selector.
@@ -169,6 +169,7 @@ func (sc *virtualServiceSource) Endpoints(ctx context.Context) ([]*endpoint.Endp
 		}
 
 		log.Debugf("Endpoints generated from VirtualService: %s/%s: %v", virtualService.Namespace, virtualService.Name, gwEndpoints)
+		setRankLabels(&virtualService.ObjectMeta, gwEndpoints)
 		endpoints = append(endpoints, gwEndpoints...)
 	}
 
@@ -181,9 +182,14 @@ func (sc *virtualServiceSource) Endpoints(ctx context.Context) ([]*endpoint.Endp
 
 // AddEventHandler adds an event handler that should be triggered if the watched Istio VirtualService changes.
 func (sc *virtualServiceSource) AddEventHandler(ctx context.Context, handler func()) {
+	sc.AddObservedEventHandler(ctx, handler, nil)
+}
+
+// AddObservedEventHandler adds an event handler telling the observer which Istio VirtualService changed.
+func (sc *virtualServiceSource) AddObservedEventHandler(ctx context.Context, handler func(), observer ChangeObserver) {
 	log.Debug("Adding event handler for Istio VirtualService")
 
-	sc.virtualserviceInformer.Informer().AddEventHandler(eventHandlerFunc(handler))
+	sc.virtualserviceInformer.Informer().AddEventHandler(newResourceEventHandler("virtualservice", handler, observer))
 }
 
 func (sc *virtualServiceSource) getGateway(ctx context.Context, gatewayStr string, virtualService *networkingv1alpha3.VirtualService) (*networkingv1alpha3.Gateway, error) {
@@ -59,6 +59,13 @@ func (ms *multiSource) AddEventHandler(ctx context.Context, handler func()) {
 	}
 }
 
+// AddObservedEventHandler adds the observed event handler to every child source
+func (ms *multiSource) AddObservedEventHandler(ctx context.Context, handler func(), observer ChangeObserver) {
+	for _, s := range ms.children {
+		AddObservedEventHandler(ctx, s, handler, observer)
+	}
+}
+
 // NewMultiSource creates a new multiSource.
 func NewMultiSource(children []Source, defaultTargets []string) Source {
 	return &multiSource{children: children, defaultTargets: defaultTargets}
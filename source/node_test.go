@@ -74,6 +74,7 @@ func testNodeSourceNewNodeSource(t *testing.T) {
 			_, err := NewNodeSource(
 				context.TODO(),
 				fake.NewSimpleClientset(),
+				nil,
 				ti.annotationFilter,
 				ti.fqdnTemplate,
 				labels.Everything(),
@@ -354,6 +355,7 @@ func testNodeSourceEndpoints(t *testing.T) {
 			client, err := NewNodeSource(
 				context.TODO(),
 				kubernetes,
+				nil,
 				tc.annotationFilter,
 				tc.fqdnTemplate,
 				labelSelector,
@@ -67,6 +67,7 @@ func (suite *ServiceSuite) SetupTest() {
 	suite.sc, err = NewServiceSource(
 		context.TODO(),
 		fakeClient,
+		nil,
 		"",
 		"",
 		"{{.Name}}",
@@ -148,6 +149,7 @@ func testServiceSourceNewServiceSource(t *testing.T) {
 			_, err := NewServiceSource(
 				context.TODO(),
 				fake.NewSimpleClientset(),
+				nil,
 				"",
 				ti.annotationFilter,
 				ti.fqdnTemplate,
@@ -1119,6 +1121,7 @@ func testServiceSourceEndpoints(t *testing.T) {
 			client, err := NewServiceSource(
 				context.TODO(),
 				kubernetes,
+				nil,
 				tc.targetNamespace,
 				tc.annotationFilter,
 				tc.fqdnTemplate,
@@ -1309,6 +1312,7 @@ func testMultipleServicesEndpoints(t *testing.T) {
 			client, err := NewServiceSource(
 				context.TODO(),
 				kubernetes,
+				nil,
 				tc.targetNamespace,
 				tc.annotationFilter,
 				tc.fqdnTemplate,
@@ -1612,6 +1616,7 @@ func TestClusterIpServices(t *testing.T) {
 			client, _ := NewServiceSource(
 				context.TODO(),
 				kubernetes,
+				nil,
 				tc.targetNamespace,
 				tc.annotationFilter,
 				tc.fqdnTemplate,
@@ -2330,6 +2335,7 @@ func TestServiceSourceNodePortServices(t *testing.T) {
 			client, _ := NewServiceSource(
 				context.TODO(),
 				kubernetes,
+				nil,
 				tc.targetNamespace,
 				tc.annotationFilter,
 				tc.fqdnTemplate,
@@ -3058,6 +3064,7 @@ func TestHeadlessServices(t *testing.T) {
 			client, _ := NewServiceSource(
 				context.TODO(),
 				kubernetes,
+				nil,
 				tc.targetNamespace,
 				"",
 				tc.fqdnTemplate,
@@ -3517,6 +3524,7 @@ func TestHeadlessServicesHostIP(t *testing.T) {
 			client, _ := NewServiceSource(
 				context.TODO(),
 				kubernetes,
+				nil,
 				tc.targetNamespace,
 				"",
 				tc.fqdnTemplate,
@@ -3695,6 +3703,7 @@ func TestExternalServices(t *testing.T) {
 			client, _ := NewServiceSource(
 				context.TODO(),
 				kubernetes,
+				nil,
 				tc.targetNamespace,
 				"",
 				tc.fqdnTemplate,
@@ -3750,6 +3759,7 @@ func BenchmarkServiceEndpoints(b *testing.B) {
 	client, err := NewServiceSource(
 		context.TODO(),
 		kubernetes,
+		nil,
 		v1.NamespaceAll,
 		"",
 		"",
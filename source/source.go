@@ -30,10 +30,13 @@ import (
 	"unicode"
 
 	log "github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/cache"
 
 	"sigs.k8s.io/external-dns/endpoint"
 )
@@ -60,8 +63,13 @@ const (
 	controllerAnnotationValue = "dns-controller"
 	// The annotation used for defining the desired hostname
 	internalHostnameAnnotationKey = "external-dns.alpha.kubernetes.io/internal-hostname"
+	// The annotation used for ranking the resources claiming the same hostname, the highest wins
+	priorityAnnotationKey = "external-dns.alpha.kubernetes.io/priority"
 )
 
+// SyncedAtAnnotationKey is the annotation of the time the records of a resource were applied, set by the controller
+const SyncedAtAnnotationKey = "external-dns.alpha.kubernetes.io/synced-at"
+
 const (
 	EndpointsTypeNodeExternalIP = "NodeExternalIP"
 	EndpointsTypeHostIP         = "HostIP"
@@ -87,6 +95,29 @@ type Source interface {
 	AddEventHandler(context.Context, func())
 }
 
+// setRankLabels labels the endpoints with the creation timestamp and the priority of their resource,
+// which the conflict resolvers rank the resources claiming the same hostname by.
+func setRankLabels(meta *metav1.ObjectMeta, endpoints []*endpoint.Endpoint) {
+	priority, hasPriority := meta.Annotations[priorityAnnotationKey]
+	if hasPriority {
+		if _, err := strconv.Atoi(priority); err != nil {
+			log.Warnf("%s/%s: %q is not a valid priority: %v", meta.Namespace, meta.Name, priority, err)
+			hasPriority = false
+		}
+	}
+	for _, ep := range endpoints {
+		if ep.Labels == nil {
+			ep.Labels = endpoint.NewLabels()
+		}
+		if !meta.CreationTimestamp.IsZero() {
+			ep.Labels[endpoint.ResourceCreatedLabelKey] = meta.CreationTimestamp.UTC().Format(time.RFC3339)
+		}
+		if hasPriority {
+			ep.Labels[endpoint.ResourcePriorityLabelKey] = priority
+		}
+	}
+}
+
 func getTTLFromAnnotations(annotations map[string]string, resource string) endpoint.TTL {
 	ttlNotConfigured := endpoint.TTL(0)
 	ttlAnnotation, exists := annotations[ttlAnnotationKey]
@@ -348,6 +379,108 @@ func (fn eventHandlerFunc) OnAdd(obj interface{}, isInInitialList bool) { fn() }
 func (fn eventHandlerFunc) OnUpdate(oldObj, newObj interface{})         { fn() }
 func (fn eventHandlerFunc) OnDelete(obj interface{})                    { fn() }
 
+// ChangeObserver is told of the resource, by its resource label, whose change triggered an event handler
+type ChangeObserver func(resource string)
+
+// ObservedSource is implemented by the sources whose event handlers can tell an observer which resource
+// changed. Only the sources labelling their endpoints with their resource tell it.
+type ObservedSource interface {
+	AddObservedEventHandler(ctx context.Context, handler func(), observer ChangeObserver)
+}
+
+// AddObservedEventHandler adds the event handler to the source, telling the observer which resources
+// changed when the source supports it
+func AddObservedEventHandler(ctx context.Context, src Source, handler func(), observer ChangeObserver) {
+	if observed, ok := src.(ObservedSource); ok {
+		observed.AddObservedEventHandler(ctx, handler, observer)
+		return
+	}
+	src.AddEventHandler(ctx, handler)
+}
+
+// resourceEventHandler is an event handler which tells the observer, if any, which resource of the
+// given kind changed, before calling the handler. The updates only setting the synced-at annotation
+// are skipped, since the records of the resource were just applied.
+type resourceEventHandler struct {
+	kind     string
+	handler  func()
+	observer ChangeObserver
+}
+
+// newResourceEventHandler returns the event handler of the resources of the given kind, as in their resource label
+func newResourceEventHandler(kind string, handler func(), observer ChangeObserver) cache.ResourceEventHandler {
+	return &resourceEventHandler{kind: kind, handler: handler, observer: observer}
+}
+
+func (h *resourceEventHandler) OnAdd(obj interface{}, isInInitialList bool) {
+	if !isInInitialList {
+		h.observe(obj)
+	}
+	h.handler()
+}
+
+func (h *resourceEventHandler) OnUpdate(oldObj, newObj interface{}) {
+	if syncedAtUpdate(oldObj, newObj) {
+		return
+	}
+	h.observe(newObj)
+	h.handler()
+}
+
+func (h *resourceEventHandler) OnDelete(obj interface{}) {
+	h.observe(obj)
+	h.handler()
+}
+
+func (h *resourceEventHandler) observe(obj interface{}) {
+	if h.observer == nil {
+		return
+	}
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	object, err := meta.Accessor(obj)
+	if err != nil {
+		return
+	}
+	h.observer(fmt.Sprintf("%s/%s/%s", h.kind, object.GetNamespace(), object.GetName()))
+}
+
+// syncedAtUpdate tells whether the update only changed the synced-at annotation of the object
+func syncedAtUpdate(oldObj, newObj interface{}) bool {
+	oldObject, ok := oldObj.(runtime.Object)
+	if !ok {
+		return false
+	}
+	newObject, ok := newObj.(runtime.Object)
+	if !ok {
+		return false
+	}
+	oldObject, newObject = oldObject.DeepCopyObject(), newObject.DeepCopyObject()
+	oldMeta, err := meta.Accessor(oldObject)
+	if err != nil {
+		return false
+	}
+	newMeta, err := meta.Accessor(newObject)
+	if err != nil {
+		return false
+	}
+	if oldMeta.GetAnnotations()[SyncedAtAnnotationKey] == newMeta.GetAnnotations()[SyncedAtAnnotationKey] {
+		return false
+	}
+	for _, m := range []metav1.Object{oldMeta, newMeta} {
+		annotations := m.GetAnnotations()
+		delete(annotations, SyncedAtAnnotationKey)
+		if len(annotations) == 0 {
+			annotations = nil
+		}
+		m.SetAnnotations(annotations)
+		m.SetResourceVersion("")
+		m.SetManagedFields(nil)
+	}
+	return equality.Semantic.DeepEqual(oldObject, newObject)
+}
+
 type informerFactory interface {
 	WaitForCacheSync(stopCh <-chan struct{}) map[reflect.Type]bool
 }
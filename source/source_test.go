@@ -17,10 +17,16 @@ limitations under the License.
 package source
 
 import (
+	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	networkv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
 
 	"sigs.k8s.io/external-dns/endpoint"
 )
@@ -96,3 +102,123 @@ func TestSuitableType(t *testing.T) {
 		}
 	}
 }
+
+func TestSetRankLabels(t *testing.T) {
+	created := metav1.NewTime(time.Date(2024, 1, 2, 3, 4, 5, 0, time.FixedZone("CET", 3600)))
+	for _, tc := range []struct {
+		title    string
+		meta     metav1.ObjectMeta
+		expected endpoint.Labels
+	}{
+		{
+			title:    "no creation timestamp nor priority",
+			meta:     metav1.ObjectMeta{},
+			expected: endpoint.Labels{},
+		},
+		{
+			title: "creation timestamp and priority",
+			meta: metav1.ObjectMeta{
+				CreationTimestamp: created,
+				Annotations:       map[string]string{priorityAnnotationKey: "-10"},
+			},
+			expected: endpoint.Labels{
+				endpoint.ResourceCreatedLabelKey:  "2024-01-02T02:04:05Z",
+				endpoint.ResourcePriorityLabelKey: "-10",
+			},
+		},
+		{
+			title: "invalid priority",
+			meta: metav1.ObjectMeta{
+				Annotations: map[string]string{priorityAnnotationKey: "high"},
+			},
+			expected: endpoint.Labels{},
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			ep := endpoint.NewEndpoint("example.org", endpoint.RecordTypeA, "1.2.3.4")
+			setRankLabels(&tc.meta, []*endpoint.Endpoint{ep})
+			assert.Equal(t, tc.expected, ep.Labels)
+		})
+	}
+}
+
+func TestResourceEventHandler(t *testing.T) {
+	calls := 0
+	handler := func() { calls++ }
+	ing := &networkv1.Ingress{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "foo"}}
+
+	// without observer, the handler is only called
+	newResourceEventHandler("ingress", handler, nil).OnUpdate(ing, ing)
+	assert.Equal(t, 1, calls)
+
+	var observed []string
+	eventHandler := newResourceEventHandler("ingress", handler, func(resource string) { observed = append(observed, resource) })
+
+	// the objects of the initial list did not change
+	calls = 0
+	eventHandler.OnAdd(ing, true)
+	eventHandler.OnAdd(ing, false)
+	eventHandler.OnUpdate(ing, ing)
+	eventHandler.OnDelete(cache.DeletedFinalStateUnknown{Key: "default/foo", Obj: ing})
+
+	assert.Equal(t, 4, calls)
+	assert.Equal(t, []string{"ingress/default/foo", "ingress/default/foo", "ingress/default/foo"}, observed)
+}
+
+func TestResourceEventHandlerSyncedAt(t *testing.T) {
+	calls := 0
+	eventHandler := newResourceEventHandler("ingress", func() { calls++ }, nil)
+	ing := &networkv1.Ingress{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "foo", ResourceVersion: "1"}}
+
+	// the update only setting the synced-at annotation is skipped
+	synced := ing.DeepCopy()
+	synced.ResourceVersion = "2"
+	synced.Annotations = map[string]string{SyncedAtAnnotationKey: "2024-01-01T00:00:00Z"}
+	eventHandler.OnUpdate(ing, synced)
+	assert.Equal(t, 0, calls)
+
+	// as well as the next one
+	resynced := synced.DeepCopy()
+	resynced.ResourceVersion = "3"
+	resynced.Annotations[SyncedAtAnnotationKey] = "2024-01-01T00:01:00Z"
+	eventHandler.OnUpdate(synced, resynced)
+	assert.Equal(t, 0, calls)
+
+	// but not an update changing another annotation along with it
+	changed := resynced.DeepCopy()
+	changed.ResourceVersion = "4"
+	changed.Annotations[SyncedAtAnnotationKey] = "2024-01-01T00:02:00Z"
+	changed.Annotations[hostnameAnnotationKey] = "foo.example.org"
+	eventHandler.OnUpdate(resynced, changed)
+	assert.Equal(t, 1, calls)
+
+	// nor an update of the spec
+	updated := changed.DeepCopy()
+	updated.ResourceVersion = "5"
+	updated.Annotations[SyncedAtAnnotationKey] = "2024-01-01T00:03:00Z"
+	updated.Spec.Rules = []networkv1.IngressRule{{Host: "bar.example.org"}}
+	eventHandler.OnUpdate(changed, updated)
+	assert.Equal(t, 2, calls)
+}
+
+// observedSource records the observer of its event handler
+type observedSource struct {
+	emptySource
+	observer ChangeObserver
+}
+
+func (s *observedSource) AddObservedEventHandler(ctx context.Context, handler func(), observer ChangeObserver) {
+	s.observer = observer
+}
+
+func TestAddObservedEventHandler(t *testing.T) {
+	observed := &observedSource{}
+	src := NewTargetFilterSource(NewDedupSource(NewMultiSource([]Source{NewCountingSource("fake", observed, &EndpointCounts{})}, nil)), endpoint.NewTargetNetFilterWithExclusions(nil, nil))
+
+	// the observer is passed down the wrapping sources
+	var resources []string
+	AddObservedEventHandler(context.Background(), src, func() {}, func(resource string) { resources = append(resources, resource) })
+	require.NotNil(t, observed.observer)
+	observed.observer("ingress/default/foo")
+	assert.Equal(t, []string{"ingress/default/foo"}, resources)
+}
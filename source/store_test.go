@@ -100,6 +100,10 @@ func (m *MockClientGenerator) OpenShiftClient() (openshift.Interface, error) {
 	return nil, args.Error(1)
 }
 
+func (m *MockClientGenerator) KubeInformerFactories() *KubeInformerFactories {
+	return nil
+}
+
 type ByNamesTestSuite struct {
 	suite.Suite
 }
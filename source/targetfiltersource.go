@@ -71,3 +71,8 @@ func (ms *targetFilterSource) Endpoints(ctx context.Context) ([]*endpoint.Endpoi
 func (ms *targetFilterSource) AddEventHandler(ctx context.Context, handler func()) {
 	ms.source.AddEventHandler(ctx, handler)
 }
+
+// AddObservedEventHandler adds the observed event handler to the wrapped source
+func (ms *targetFilterSource) AddObservedEventHandler(ctx context.Context, handler func(), observer ChangeObserver) {
+	AddObservedEventHandler(ctx, ms.source, handler, observer)
+}